@@ -190,7 +190,7 @@ func (a *Accessibility) SetValueMap(valueMap string) error {
 func (a *Accessibility) accSetPropertyInt(hwnd handle.HWND, idProp *oleacc.MSAAPROPID, event uint32, value int32) error {
 	accPropServices := a.wb.group.accessibilityServices()
 	if accPropServices == nil {
-		return errs.NewError("Dynamic Annotation not available")
+		return errs.NewNotSupportedError("Dynamic Annotation not available")
 	}
 	var v oleaut32.VARIANT
 	v.SetLong(value)
@@ -208,7 +208,7 @@ func (a *Accessibility) accSetPropertyInt(hwnd handle.HWND, idProp *oleacc.MSAAP
 func (a *Accessibility) accSetPropertyStr(hwnd handle.HWND, idProp *oleacc.MSAAPROPID, event uint32, value string) error {
 	accPropServices := a.wb.group.accessibilityServices()
 	if accPropServices == nil {
-		return errs.NewError("Dynamic Annotation not available")
+		return errs.NewNotSupportedError("Dynamic Annotation not available")
 	}
 	hr := accPropServices.SetHwndPropStr(hwnd, user32.OBJID_CLIENT, user32.CHILDID_SELF, idProp, value)
 	if win.FAILED(hr) {
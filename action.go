@@ -395,7 +395,7 @@ func (a *Action) Visible() bool {
 
 func (a *Action) SetVisible(value bool) (err error) {
 	if a.visibleCondition != nil {
-		return errs.NewError("VisibleCondition != nil")
+		return errs.NewInvalidArgumentError("VisibleCondition != nil")
 	}
 
 	if value != a.visible {
@@ -442,6 +442,16 @@ func (a *Action) Triggered() *Event {
 	return a.triggeredPublisher.Event()
 }
 
+// Trigger triggers the action as if the user had clicked it, toggling it
+// first if it is checkable. Nothing happens if the action is disabled.
+func (a *Action) Trigger() {
+	if !a.Enabled() {
+		return
+	}
+
+	a.raiseTriggered()
+}
+
 func (a *Action) raiseTriggered() {
 	if a.Checkable() {
 		a.SetChecked(!a.Checked())
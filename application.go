@@ -7,12 +7,14 @@
 package winapi
 
 import (
+	"fmt"
 	"runtime"
 	"sync"
 	"time"
 
 	"github.com/Gipcomp/win32/kernel32"
 	"github.com/Gipcomp/win32/user32"
+	"github.com/Gipcomp/winapi/errs"
 )
 
 type Settings interface {
@@ -38,7 +40,10 @@ type Application struct {
 	mutex              sync.RWMutex
 	organizationName   string
 	productName        string
+	productVersion     string
 	settings           Settings
+	crashReporter      *CrashReporter
+	inspectorShortcut  Shortcut
 	exiting            bool
 	exitCode           int
 	panickingPublisher ErrorEventPublisher
@@ -74,6 +79,18 @@ func (app *Application) SetProductName(value string) {
 	app.productName = value
 }
 
+func (app *Application) ProductVersion() string {
+	app.mutex.RLock()
+	defer app.mutex.RUnlock()
+	return app.productVersion
+}
+
+func (app *Application) SetProductVersion(value string) {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	app.productVersion = value
+}
+
 func (app *Application) Settings() Settings {
 	app.mutex.RLock()
 	defer app.mutex.RUnlock()
@@ -86,6 +103,34 @@ func (app *Application) SetSettings(value Settings) {
 	app.settings = value
 }
 
+// CrashReporter returns the CrashReporter that handles panics recovered from
+// window procedures and Synchronize callbacks, or nil.
+func (app *Application) CrashReporter() *CrashReporter {
+	app.mutex.RLock()
+	defer app.mutex.RUnlock()
+	return app.crashReporter
+}
+
+func (app *Application) SetCrashReporter(value *CrashReporter) {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	app.crashReporter = value
+}
+
+// InspectorShortcut returns the shortcut that opens an Inspector for the
+// Form it is pressed in. The zero Shortcut, the default, disables it.
+func (app *Application) InspectorShortcut() Shortcut {
+	app.mutex.RLock()
+	defer app.mutex.RUnlock()
+	return app.inspectorShortcut
+}
+
+func (app *Application) SetInspectorShortcut(value Shortcut) {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	app.inspectorShortcut = value
+}
+
 func (app *Application) Exit(exitCode int) {
 	app.mutex.Lock()
 	defer app.mutex.Unlock()
@@ -120,3 +165,27 @@ func (app *Application) ActiveForm() Form {
 	}
 	return group.ActiveForm()
 }
+
+// recoversPanics returns whether panics on the UI thread should be recovered,
+// which is the case if anybody is interested in them.
+func (app *Application) recoversPanics() bool {
+	return len(app.panickingPublisher.event.handlers) > 0 || app.CrashReporter() != nil
+}
+
+// handlePanic publishes the recovered panic value x through Panicking and
+// passes it on to the CrashReporter, if any. It must be called from the
+// deferred function that recovered x.
+func (app *Application) handlePanic(x interface{}) {
+	var err error
+	if e, ok := x.(error); ok {
+		err = errs.WrapErrorNoPanic(e)
+	} else {
+		err = errs.NewErrorNoPanic(fmt.Sprint(x))
+	}
+
+	app.panickingPublisher.Publish(err)
+
+	if cr := app.CrashReporter(); cr != nil {
+		cr.Report(x)
+	}
+}
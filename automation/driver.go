@@ -0,0 +1,319 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package automation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Gipcomp/win32/user32"
+	"github.com/Gipcomp/winapi"
+)
+
+type clicker interface {
+	Clicked() *winapi.Event
+}
+
+type menuer interface {
+	Menu() *winapi.Menu
+}
+
+type toolBarer interface {
+	ToolBar() *winapi.ToolBar
+}
+
+// FormDriver is a Driver that performs steps on the widgets and actions of
+// a Form. Its methods must be called from the goroutine of the Form, e.g.
+// from a function passed to Synchronize.
+type FormDriver struct {
+	form winapi.Form
+}
+
+// NewFormDriver returns a FormDriver for form.
+func NewFormDriver(form winapi.Form) *FormDriver {
+	return &FormDriver{form: form}
+}
+
+func (d *FormDriver) Form() winapi.Form {
+	return d.form
+}
+
+// Widget returns the widget target refers to, see Step.
+func (d *FormDriver) Widget(target string) (winapi.Widget, error) {
+	if w := winapi.FindWidget(d.form, target); w != nil {
+		return w, nil
+	}
+
+	if w, err := winapi.QueryWidget(d.form, target); err == nil && w != nil {
+		return w, nil
+	}
+
+	return nil, fmt.Errorf("no widget %q", target)
+}
+
+func (d *FormDriver) enabledWidget(target string) (winapi.Widget, error) {
+	w, err := d.Widget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if !w.Enabled() {
+		return nil, fmt.Errorf("widget %q is disabled", target)
+	}
+
+	return w, nil
+}
+
+// Click clicks a button like a PushButton, CheckBox or RadioButton.
+func (d *FormDriver) Click(target string) error {
+	w, err := d.enabledWidget(target)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := w.(clicker); !ok {
+		return fmt.Errorf("widget %q of type %s cannot be clicked", target, winapi.WindowTypeName(w))
+	}
+
+	w.SendMessage(user32.BM_CLICK, 0, 0)
+
+	return nil
+}
+
+// Type replaces the text of a LineEdit, TextEdit or editable ComboBox.
+func (d *FormDriver) Type(target, text string) error {
+	w, err := d.enabledWidget(target)
+	if err != nil {
+		return err
+	}
+
+	switch w := w.(type) {
+	case *winapi.LineEdit:
+		if w.ReadOnly() {
+			return fmt.Errorf("widget %q is read-only", target)
+		}
+		return w.SetText(text)
+
+	case *winapi.TextEdit:
+		if w.ReadOnly() {
+			return fmt.Errorf("widget %q is read-only", target)
+		}
+		return w.SetText(text)
+
+	case *winapi.ComboBox:
+		if !w.Editable() {
+			return fmt.Errorf("widget %q is not editable", target)
+		}
+		return w.SetText(text)
+	}
+
+	return fmt.Errorf("cannot type into widget %q of type %s", target, winapi.WindowTypeName(w))
+}
+
+// SelectRow sets the current row of a TableView, ComboBox or ListBox.
+func (d *FormDriver) SelectRow(target string, row int) error {
+	w, err := d.enabledWidget(target)
+	if err != nil {
+		return err
+	}
+
+	switch w := w.(type) {
+	case *winapi.TableView:
+		return w.SetCurrentIndex(row)
+
+	case *winapi.ComboBox:
+		return w.SetCurrentIndex(row)
+
+	case *winapi.ListBox:
+		return w.SetCurrentIndex(row)
+	}
+
+	return fmt.Errorf("cannot select a row of widget %q of type %s", target, winapi.WindowTypeName(w))
+}
+
+// Trigger triggers the action with the path action in the menu, tool bar or
+// context menu of the Form.
+func (d *FormDriver) Trigger(action string) error {
+	var found *winapi.Action
+
+	forEachAction(d.form, func(a *winapi.Action, path string) bool {
+		if path == action {
+			found = a
+			return false
+		}
+		return true
+	})
+
+	if found == nil {
+		return fmt.Errorf("no action %q", action)
+	}
+	if !found.Enabled() {
+		return fmt.Errorf("action %q is disabled", action)
+	}
+
+	found.Trigger()
+
+	return nil
+}
+
+// Property returns the value of a property registered with the widget.
+func (d *FormDriver) Property(target, name string) (interface{}, error) {
+	w, err := d.Widget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	p := w.AsWindowBase().Property(name)
+	if p == nil {
+		return nil, fmt.Errorf("widget %q has no property %q", target, name)
+	}
+
+	return p.Get(), nil
+}
+
+// Record records the interactions with the named widgets and the actions of
+// form into r until the returned function is called:
+//
+//   - clicks of buttons,
+//   - text typed into a focused LineEdit or TextEdit,
+//   - current row changes of TableView, ComboBox and ListBox widgets,
+//   - triggered actions of the menu, tool bar and context menu.
+//
+// Widgets and actions created after calling Record are not recorded.
+func Record(form winapi.Form, r *Recorder) (stop func()) {
+	var detachers []func()
+
+	attach := func(event *winapi.Event, handler func()) {
+		handle := event.Attach(handler)
+		detachers = append(detachers, func() {
+			event.Detach(handle)
+		})
+	}
+
+	widgets, _ := winapi.QueryWidgets(form, "*")
+	for _, w := range widgets {
+		if w.Name() == "" {
+			continue
+		}
+
+		w := w
+		target := winapi.WidgetNamePath(w)
+
+		switch w := w.(type) {
+		case *winapi.LineEdit:
+			attach(w.TextChanged(), func() {
+				if w.Focused() {
+					r.Record(Step{Kind: StepType, Target: target, Text: w.Text()})
+				}
+			})
+
+		case *winapi.TextEdit:
+			attach(w.TextChanged(), func() {
+				if w.Focused() {
+					r.Record(Step{Kind: StepType, Target: target, Text: w.Text()})
+				}
+			})
+
+		case *winapi.TableView:
+			attach(w.CurrentIndexChanged(), func() {
+				if row := w.CurrentIndex(); row >= 0 {
+					r.Record(Step{Kind: StepSelectRow, Target: target, Row: row})
+				}
+			})
+
+		case *winapi.ComboBox:
+			attach(w.CurrentIndexChanged(), func() {
+				if row := w.CurrentIndex(); row >= 0 {
+					r.Record(Step{Kind: StepSelectRow, Target: target, Row: row})
+				}
+			})
+
+		case *winapi.ListBox:
+			attach(w.CurrentIndexChanged(), func() {
+				if row := w.CurrentIndex(); row >= 0 {
+					r.Record(Step{Kind: StepSelectRow, Target: target, Row: row})
+				}
+			})
+
+		case clicker:
+			attach(w.Clicked(), func() {
+				r.Record(Step{Kind: StepClick, Target: target})
+			})
+		}
+	}
+
+	forEachAction(form, func(a *winapi.Action, path string) bool {
+		if a.Menu() == nil && !a.IsSeparator() {
+			attach(a.Triggered(), func() {
+				r.Record(Step{Kind: StepTrigger, Target: path})
+			})
+		}
+		return true
+	})
+
+	return func() {
+		for _, detach := range detachers {
+			detach()
+		}
+		detachers = nil
+	}
+}
+
+// ActionText returns the text of a, without mnemonic markers and shortcut
+// text, as used in action paths.
+func ActionText(a *winapi.Action) string {
+	text := a.Text()
+
+	if i := strings.IndexByte(text, '\t'); i >= 0 {
+		text = text[:i]
+	}
+
+	text = strings.ReplaceAll(text, "&&", "\x00")
+	text = strings.ReplaceAll(text, "&", "")
+
+	return strings.ReplaceAll(text, "\x00", "&")
+}
+
+// forEachAction calls f with each action of the menu, tool bar and context
+// menu of form and its path until f returns false.
+func forEachAction(form winapi.Form, f func(a *winapi.Action, path string) bool) {
+	var walk func(actions *winapi.ActionList, prefix string) bool
+	walk = func(actions *winapi.ActionList, prefix string) bool {
+		for i := 0; i < actions.Len(); i++ {
+			a := actions.At(i)
+			path := prefix + ActionText(a)
+
+			if !f(a, path) {
+				return false
+			}
+
+			if menu := a.Menu(); menu != nil {
+				if !walk(menu.Actions(), path+"/") {
+					return false
+				}
+			}
+		}
+
+		return true
+	}
+
+	if m, ok := form.(menuer); ok && m.Menu() != nil {
+		if !walk(m.Menu().Actions(), "") {
+			return
+		}
+	}
+
+	if tb, ok := form.(toolBarer); ok && tb.ToolBar() != nil {
+		if !walk(tb.ToolBar().Actions(), "") {
+			return
+		}
+	}
+
+	if cm := form.ContextMenu(); cm != nil {
+		walk(cm.Actions(), "")
+	}
+}
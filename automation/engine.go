@@ -0,0 +1,186 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Driver performs the steps of a script against an application.
+type Driver interface {
+	Click(target string) error
+	Type(target, text string) error
+	SelectRow(target string, row int) error
+	Trigger(action string) error
+
+	// Property returns the value of the property name of the widget target.
+	Property(target, name string) (interface{}, error)
+}
+
+// Engine runs scripts using a Driver.
+type Engine struct {
+	Driver Driver
+
+	// StepDelay is waited for after each step, e.g. to watch a replay.
+	StepDelay time.Duration
+
+	// BeforeStep is called before each step, if not nil.
+	BeforeStep func(index int, step Step)
+}
+
+// NewEngine returns an Engine for driver.
+func NewEngine(driver Driver) *Engine {
+	return &Engine{Driver: driver}
+}
+
+// Run runs the steps of script in order and stops at the first one that
+// fails, returning a *StepError.
+func (e *Engine) Run(script *Script) error {
+	for i, step := range script.Steps {
+		if e.BeforeStep != nil {
+			e.BeforeStep(i, step)
+		}
+
+		if err := e.RunStep(step); err != nil {
+			return &StepError{Index: i, Step: step, Err: err}
+		}
+
+		if e.StepDelay > 0 {
+			time.Sleep(e.StepDelay)
+		}
+	}
+
+	return nil
+}
+
+// RunStep runs a single step.
+func (e *Engine) RunStep(step Step) error {
+	if err := step.Validate(); err != nil {
+		return err
+	}
+
+	switch step.Kind {
+	case StepClick:
+		return e.Driver.Click(step.Target)
+
+	case StepType:
+		return e.Driver.Type(step.Target, step.Text)
+
+	case StepSelectRow:
+		return e.Driver.SelectRow(step.Target, step.Row)
+
+	case StepTrigger:
+		return e.Driver.Trigger(step.Target)
+
+	case StepAssert:
+		got, err := e.Driver.Property(step.Target, step.Property)
+		if err != nil {
+			return err
+		}
+
+		if !ValuesEqual(got, step.Value) {
+			return &AssertionError{Target: step.Target, Property: step.Property, Got: got, Want: step.Value}
+		}
+	}
+
+	return nil
+}
+
+// StepError is returned by Engine.Run if a step fails.
+type StepError struct {
+	Index int // zero-based
+	Step  Step
+	Err   error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("automation: step %d (%s): %s", e.Index+1, e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// AssertionError is returned for assert steps whose value does not match.
+type AssertionError struct {
+	Target   string
+	Property string
+	Got      interface{}
+	Want     interface{}
+}
+
+func (e *AssertionError) Error() string {
+	return fmt.Sprintf("%s of %q is %#v, want %#v", e.Property, e.Target, e.Got, e.Want)
+}
+
+// ValuesEqual reports whether the property value got equals the script
+// value want.
+//
+// Since script values come from JSON, numbers of any type are compared by
+// their float64 value, and a string want matches any got whose fmt.Sprint
+// representation equals it, e.g. a time.Time or a custom string type.
+func ValuesEqual(got, want interface{}) bool {
+	if got == nil || want == nil {
+		return isNil(got) && isNil(want)
+	}
+
+	if g, ok := toFloat(got); ok {
+		if w, ok := toFloat(want); ok {
+			return g == w
+		}
+	}
+
+	if w, ok := want.(string); ok {
+		return fmt.Sprint(got) == w
+	}
+
+	if ws, ok := want.([]interface{}); ok {
+		gv := reflect.ValueOf(got)
+		if gv.Kind() != reflect.Slice && gv.Kind() != reflect.Array || gv.Len() != len(ws) {
+			return false
+		}
+		for i, w := range ws {
+			if !ValuesEqual(gv.Index(i).Interface(), w) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(got, want)
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,199 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDriver records the calls it receives and serves properties from a map
+// keyed by "target.name".
+type fakeDriver struct {
+	calls      []string
+	properties map[string]interface{}
+	failOn     string
+}
+
+func (d *fakeDriver) call(format string, args ...interface{}) error {
+	call := fmt.Sprintf(format, args...)
+	d.calls = append(d.calls, call)
+
+	if d.failOn != "" && call == d.failOn {
+		return errors.New("driver failure")
+	}
+
+	return nil
+}
+
+func (d *fakeDriver) Click(target string) error {
+	return d.call("click %s", target)
+}
+
+func (d *fakeDriver) Type(target, text string) error {
+	return d.call("type %s %s", target, text)
+}
+
+func (d *fakeDriver) SelectRow(target string, row int) error {
+	return d.call("selectRow %s %d", target, row)
+}
+
+func (d *fakeDriver) Trigger(action string) error {
+	return d.call("trigger %s", action)
+}
+
+func (d *fakeDriver) Property(target, name string) (interface{}, error) {
+	if err := d.call("property %s.%s", target, name); err != nil {
+		return nil, err
+	}
+
+	value, ok := d.properties[target+"."+name]
+	if !ok {
+		return nil, fmt.Errorf("no property %s of %s", name, target)
+	}
+
+	return value, nil
+}
+
+func TestEngineRun(t *testing.T) {
+	d := &fakeDriver{properties: map[string]interface{}{"nameLE.Text": "Alice"}}
+
+	script := &Script{Steps: []Step{
+		{Kind: StepClick, Target: "okPB"},
+		{Kind: StepType, Target: "nameLE", Text: "Alice"},
+		{Kind: StepSelectRow, Target: "peopleTV", Row: 2},
+		{Kind: StepTrigger, Target: "File/Open..."},
+		{Kind: StepAssert, Target: "nameLE", Property: "Text", Value: "Alice"},
+	}}
+
+	var before []int
+	e := NewEngine(d)
+	e.BeforeStep = func(index int, step Step) {
+		before = append(before, index)
+	}
+
+	if err := e.Run(script); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"click okPB",
+		"type nameLE Alice",
+		"selectRow peopleTV 2",
+		"trigger File/Open...",
+		"property nameLE.Text",
+	}
+	if !reflect.DeepEqual(d.calls, want) {
+		t.Errorf("calls = %q; want %q", d.calls, want)
+	}
+	if !reflect.DeepEqual(before, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("BeforeStep indices = %v", before)
+	}
+}
+
+func TestEngineRunStopsAtFailure(t *testing.T) {
+	d := &fakeDriver{failOn: "click cancelPB"}
+
+	script := &Script{Steps: []Step{
+		{Kind: StepClick, Target: "okPB"},
+		{Kind: StepClick, Target: "cancelPB"},
+		{Kind: StepClick, Target: "closePB"},
+	}}
+
+	err := NewEngine(d).Run(script)
+
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) {
+		t.Fatalf("Run() error = %v; want a *StepError", err)
+	}
+	if stepErr.Index != 1 || stepErr.Step.Target != "cancelPB" {
+		t.Errorf("StepError.Index = %d, Step = %s; want 1, the cancelPB click", stepErr.Index, stepErr.Step)
+	}
+	if !strings.HasPrefix(err.Error(), "automation: step 2 ") {
+		t.Errorf("Error() = %q; want it to name step 2", err)
+	}
+	if len(d.calls) != 2 {
+		t.Errorf("calls = %q; want the run to stop after the failing step", d.calls)
+	}
+}
+
+func TestEngineAssertion(t *testing.T) {
+	d := &fakeDriver{properties: map[string]interface{}{"countNE.Value": 3.0}}
+
+	err := NewEngine(d).RunStep(Step{Kind: StepAssert, Target: "countNE", Property: "Value", Value: 4})
+
+	var assertErr *AssertionError
+	if !errors.As(err, &assertErr) {
+		t.Fatalf("RunStep() error = %v; want an *AssertionError", err)
+	}
+	if assertErr.Got != 3.0 || assertErr.Want != 4 {
+		t.Errorf("Got = %v, Want = %v", assertErr.Got, assertErr.Want)
+	}
+
+	if err := NewEngine(d).RunStep(Step{Kind: StepAssert, Target: "missing", Property: "Value"}); err == nil || errors.As(err, &assertErr) {
+		t.Errorf("RunStep() error = %v; want the driver error", err)
+	}
+}
+
+func TestEngineRunStepValidates(t *testing.T) {
+	d := new(fakeDriver)
+
+	if err := NewEngine(d).RunStep(Step{Kind: StepClick}); err == nil {
+		t.Error("RunStep() of a step without target succeeded")
+	}
+	if len(d.calls) != 0 {
+		t.Errorf("calls = %q; want an invalid step not to reach the driver", d.calls)
+	}
+}
+
+func TestEngineStepDelay(t *testing.T) {
+	e := NewEngine(new(fakeDriver))
+	e.StepDelay = 10 * time.Millisecond
+
+	start := time.Now()
+	if err := e.Run(&Script{Steps: []Step{{Kind: StepClick, Target: "a"}, {Kind: StepClick, Target: "b"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 2*e.StepDelay {
+		t.Errorf("Run() took %v; want at least %v", elapsed, 2*e.StepDelay)
+	}
+}
+
+type customString string
+
+func TestValuesEqual(t *testing.T) {
+	for _, test := range []struct {
+		got, want interface{}
+		equal     bool
+	}{
+		{nil, nil, true},
+		{(*int)(nil), nil, true},
+		{[]string(nil), nil, true},
+		{0, nil, false},
+		{nil, "", false},
+		{3, 3.0, true},
+		{uint8(7), 7.0, true},
+		{float32(0.5), 0.5, true},
+		{3, 4.0, false},
+		{"abc", "abc", true},
+		{customString("abc"), "abc", true},
+		{time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), "2021-01-02 00:00:00 +0000 UTC", true},
+		{true, true, true},
+		{true, "true", true},
+		{[]int{1, 2}, []interface{}{1.0, 2.0}, true},
+		{[2]string{"a", "b"}, []interface{}{"a", "b"}, true},
+		{[]int{1, 2}, []interface{}{1.0}, false},
+		{1, []interface{}{1.0}, false},
+		{map[string]interface{}{"a": 1.0}, map[string]interface{}{"a": 1.0}, true},
+	} {
+		if equal := ValuesEqual(test.got, test.want); equal != test.equal {
+			t.Errorf("ValuesEqual(%#v, %#v) = %t; want %t", test.got, test.want, equal, test.equal)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"sync"
+)
+
+// Recorder collects steps into a Script.
+//
+// Consecutive type steps for the same target are coalesced into one, so
+// typing a word records only the final text.
+type Recorder struct {
+	mutex sync.Mutex
+	name  string
+	steps []Step
+}
+
+// NewRecorder returns a Recorder for a script called name.
+func NewRecorder(name string) *Recorder {
+	return &Recorder{name: name}
+}
+
+// Record appends step to the script.
+func (r *Recorder) Record(step Step) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if step.Kind == StepType && len(r.steps) > 0 {
+		if last := &r.steps[len(r.steps)-1]; last.Kind == StepType && last.Target == step.Target {
+			last.Text = step.Text
+			return
+		}
+	}
+
+	r.steps = append(r.steps, step)
+}
+
+// Len returns the number of recorded steps.
+func (r *Recorder) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return len(r.steps)
+}
+
+// Reset discards all recorded steps.
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.steps = nil
+}
+
+// Script returns a script containing a copy of the recorded steps.
+func (r *Recorder) Script() *Script {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return &Script{
+		Version: ScriptVersion,
+		Name:    r.name,
+		Steps:   append([]Step(nil), r.steps...),
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package automation records user interactions with forms into JSON scripts
+// and replays them as regression tests.
+//
+// Scripts consist of high-level steps like clicking a named widget or typing
+// text into it, which are replayed through the widget APIs rather than by
+// simulating input at screen coordinates, so they survive layout changes.
+// Assert steps compare Property values against expected values.
+//
+// The script format, the Recorder and the Engine that runs scripts have no
+// dependencies on the Windows API, so they can be used and tested on any
+// platform with a custom Driver. FormDriver and Record, which connect them
+// to actual forms, are only available on Windows.
+package automation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// ScriptVersion is the version of the script format written by this package.
+const ScriptVersion = 1
+
+// StepKind identifies what a Step does.
+type StepKind string
+
+const (
+	// StepClick clicks the widget Target.
+	StepClick StepKind = "click"
+
+	// StepType replaces the text of the widget Target with Text.
+	StepType StepKind = "type"
+
+	// StepSelectRow makes Row the current row of the widget Target.
+	StepSelectRow StepKind = "selectRow"
+
+	// StepTrigger triggers the action Target. Actions are identified by the
+	// texts of their menus and themselves, separated by '/', e.g.
+	// "File/Open...".
+	StepTrigger StepKind = "trigger"
+
+	// StepAssert checks that the Property of the widget Target equals Value.
+	StepAssert StepKind = "assert"
+)
+
+// Step is a single interaction or assertion of a Script.
+//
+// Widgets are identified by their name path, see winapi.WidgetNamePath, or,
+// if no widget has that path, by a selector, see winapi.QueryWidget.
+type Step struct {
+	Kind     StepKind    `json:"kind"`
+	Target   string      `json:"target"`
+	Text     string      `json:"text,omitempty"`
+	Row      int         `json:"row,omitempty"`
+	Property string      `json:"property,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+	Comment  string      `json:"comment,omitempty"`
+}
+
+// Validate checks that the step has the fields its Kind requires.
+func (s Step) Validate() error {
+	switch s.Kind {
+	case StepClick, StepType, StepTrigger:
+
+	case StepSelectRow:
+		if s.Row < 0 {
+			return fmt.Errorf("%s: row must not be negative", s)
+		}
+
+	case StepAssert:
+		if s.Property == "" {
+			return fmt.Errorf("%s: property missing", s)
+		}
+
+	default:
+		return fmt.Errorf("unknown step kind %q", s.Kind)
+	}
+
+	if s.Target == "" {
+		return fmt.Errorf("%s: target missing", s)
+	}
+
+	return nil
+}
+
+func (s Step) String() string {
+	switch s.Kind {
+	case StepType:
+		return fmt.Sprintf("%s %q into %q", s.Kind, s.Text, s.Target)
+
+	case StepSelectRow:
+		return fmt.Sprintf("%s %d of %q", s.Kind, s.Row, s.Target)
+
+	case StepAssert:
+		return fmt.Sprintf("%s %q.%s == %v", s.Kind, s.Target, s.Property, s.Value)
+	}
+
+	return fmt.Sprintf("%s %q", s.Kind, s.Target)
+}
+
+// Script is a sequence of steps.
+type Script struct {
+	Version int    `json:"version"`
+	Name    string `json:"name,omitempty"`
+	Steps   []Step `json:"steps"`
+}
+
+// ParseScript parses a script in JSON format and validates its steps.
+func ParseScript(data []byte) (*Script, error) {
+	s := new(Script)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(s); err != nil {
+		return nil, fmt.Errorf("automation: parsing script: %w", err)
+	}
+
+	if s.Version != ScriptVersion {
+		return nil, fmt.Errorf("automation: unsupported script version %d", s.Version)
+	}
+
+	for i, step := range s.Steps {
+		if err := step.Validate(); err != nil {
+			return nil, fmt.Errorf("automation: step %d: %w", i+1, err)
+		}
+	}
+
+	return s, nil
+}
+
+// ReadScript reads and parses a script from r.
+func ReadScript(r io.Reader) (*Script, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseScript(data)
+}
+
+// LoadScript reads and parses the script at filePath.
+func LoadScript(filePath string) (*Script, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseScript(data)
+}
+
+// Marshal returns the script in indented JSON format.
+func (s *Script) Marshal() ([]byte, error) {
+	if s.Version == 0 {
+		s.Version = ScriptVersion
+	}
+
+	return json.MarshalIndent(s, "", "\t")
+}
+
+// Save writes the script to filePath.
+func (s *Script) Save(filePath string) error {
+	data, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, append(data, '\n'), 0644)
+}
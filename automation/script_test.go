@@ -0,0 +1,140 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStepValidate(t *testing.T) {
+	for _, test := range []struct {
+		step  Step
+		valid bool
+	}{
+		{Step{Kind: StepClick, Target: "okPB"}, true},
+		{Step{Kind: StepClick}, false},
+		{Step{Kind: StepType, Target: "nameLE"}, true},
+		{Step{Kind: StepSelectRow, Target: "tv", Row: 0}, true},
+		{Step{Kind: StepSelectRow, Target: "tv", Row: -1}, false},
+		{Step{Kind: StepTrigger, Target: "File/Exit"}, true},
+		{Step{Kind: StepAssert, Target: "nameLE", Property: "Text"}, true},
+		{Step{Kind: StepAssert, Target: "nameLE"}, false},
+		{Step{Kind: "drag", Target: "nameLE"}, false},
+	} {
+		if err := test.step.Validate(); (err == nil) != test.valid {
+			t.Errorf("%s: Validate() = %v; want valid %t", test.step, err, test.valid)
+		}
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	s, err := ParseScript([]byte(`{
+		"version": 1,
+		"name": "login",
+		"steps": [
+			{"kind": "type", "target": "nameLE", "text": "Alice"},
+			{"kind": "assert", "target": "countNE", "property": "Value", "value": 3}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Script{
+		Version: 1,
+		Name:    "login",
+		Steps: []Step{
+			{Kind: StepType, Target: "nameLE", Text: "Alice"},
+			{Kind: StepAssert, Target: "countNE", Property: "Value", Value: 3.0},
+		},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("ParseScript() = %+v; want %+v", s, want)
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	for _, test := range []struct {
+		data string
+		want string
+	}{
+		{`{`, "parsing script"},
+		{`{"version": 1, "steps": [], "extra": true}`, "parsing script"},
+		{`{"version": 2, "steps": []}`, "unsupported script version 2"},
+		{`{"version": 1, "steps": [{"kind": "click", "target": "a"}, {"kind": "click"}]}`, "step 2"},
+	} {
+		_, err := ParseScript([]byte(test.data))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ParseScript(%s) error = %v; want one containing %q", test.data, err, test.want)
+		}
+	}
+}
+
+func TestScriptSaveLoad(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "script.json")
+
+	saved := &Script{
+		Name: "round trip",
+		Steps: []Step{
+			{Kind: StepClick, Target: "okPB", Comment: "confirm"},
+			{Kind: StepSelectRow, Target: "peopleTV", Row: 4},
+			{Kind: StepAssert, Target: "tagsLB", Property: "Items", Value: []interface{}{"a", "b"}},
+		},
+	}
+	if err := saved.Save(filePath); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Version != ScriptVersion {
+		t.Errorf("Version = %d after Save; want %d", saved.Version, ScriptVersion)
+	}
+
+	loaded, err := LoadScript(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, saved) {
+		t.Errorf("LoadScript() = %+v; want %+v", loaded, saved)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder("typing")
+
+	r.Record(Step{Kind: StepType, Target: "nameLE", Text: "A"})
+	r.Record(Step{Kind: StepType, Target: "nameLE", Text: "Al"})
+	r.Record(Step{Kind: StepType, Target: "nameLE", Text: "Alice"})
+	r.Record(Step{Kind: StepType, Target: "cityLE", Text: "B"})
+	r.Record(Step{Kind: StepClick, Target: "okPB"})
+	r.Record(Step{Kind: StepType, Target: "cityLE", Text: "Berlin"})
+
+	if r.Len() != 4 {
+		t.Errorf("Len() = %d; want 4", r.Len())
+	}
+
+	s := r.Script()
+	want := []Step{
+		{Kind: StepType, Target: "nameLE", Text: "Alice"},
+		{Kind: StepType, Target: "cityLE", Text: "B"},
+		{Kind: StepClick, Target: "okPB"},
+		{Kind: StepType, Target: "cityLE", Text: "Berlin"},
+	}
+	if s.Name != "typing" || s.Version != ScriptVersion || !reflect.DeepEqual(s.Steps, want) {
+		t.Errorf("Script() = %+v; want steps %+v", s, want)
+	}
+
+	// The script must not share its steps with the recorder.
+	s.Steps[0].Text = "changed"
+	if r.Script().Steps[0].Text != "Alice" {
+		t.Error("modifying a script changed the recorder")
+	}
+
+	r.Reset()
+	if r.Len() != 0 {
+		t.Errorf("Len() after Reset = %d", r.Len())
+	}
+}
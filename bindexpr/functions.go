@@ -0,0 +1,430 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bindexpr implements the parts of the expression language of
+// declarative Bind that do not depend on the Windows API: the functions
+// available in expressions, the syntax of binding paths including null-safe
+// member access and the validation of paths against data source types.
+//
+// Paths consist of member names separated by '.' or "?.". Accessing a member
+// of a nil pointer, interface or map through "?." yields nil instead of an
+// error, e.g. "Customer?.Address?.City".
+//
+// The builtin functions are:
+//
+//	format(layout, args...)   formats args like fmt.Sprintf
+//	len(x)                    length of a string, slice, array or map, 0 for nil
+//	if(cond, then, else)      then if cond is true, else otherwise
+//	coalesce(args...)         the first argument that is not nil
+//	now()                     the current time
+//	date(x)                   converts a string or Unix seconds to a time
+//	formatDate(t, layout)     formats t, layout may be 'date', 'datetime', 'time' or 'rfc3339'
+//	addDays(t, n)             t plus n days, likewise addMonths and addYears
+//	addDuration(t, d)         t plus a duration like '1h30m' or a number of seconds
+//	daysBetween(a, b)         the number of calendar days from a to b
+//
+// Further functions can be added with Register.
+package bindexpr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Function is a function that can be called in expressions. Numbers are
+// passed as float64.
+type Function func(args ...interface{}) (interface{}, error)
+
+var (
+	mutex          sync.RWMutex
+	name2Function  = make(map[string]Function)
+	name2Builtin   map[string]Function
+	dateLayouts    = []string{time.RFC3339Nano, time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+	errorInterface = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+func init() {
+	name2Builtin = map[string]Function{
+		"format":      format,
+		"len":         length,
+		"if":          ifFunc,
+		"coalesce":    coalesce,
+		"now":         now,
+		"date":        date,
+		"formatDate":  formatDate,
+		"addDays":     addDays,
+		"addMonths":   addMonths,
+		"addYears":    addYears,
+		"addDuration": addDuration,
+		"daysBetween": daysBetween,
+	}
+}
+
+// Register makes fn available in expressions as name. Registering a name
+// again replaces the function, which may also replace a builtin one.
+func Register(name string, fn Function) error {
+	if name == "" {
+		return fmt.Errorf("bindexpr: function name must not be empty")
+	}
+	if fn == nil {
+		return fmt.Errorf("bindexpr: function %s must not be nil", name)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	name2Function[name] = fn
+
+	return nil
+}
+
+// MustRegister is like Register, but panics on error.
+func MustRegister(name string, fn Function) {
+	if err := Register(name, fn); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the registered or builtin function called name, or nil.
+func Lookup(name string) Function {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if fn, ok := name2Function[name]; ok {
+		return fn
+	}
+
+	return name2Builtin[name]
+}
+
+// Functions returns the builtin and registered functions by name.
+func Functions() map[string]Function {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	functions := make(map[string]Function, len(name2Builtin)+len(name2Function))
+	for name, fn := range name2Builtin {
+		functions[name] = fn
+	}
+	for name, fn := range name2Function {
+		functions[name] = fn
+	}
+
+	return functions
+}
+
+// FunctionNames returns the sorted names of the builtin and registered
+// functions.
+func FunctionNames() []string {
+	functions := Functions()
+
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func checkArgCount(name string, args []interface{}, min, max int) error {
+	if len(args) < min || max >= 0 && len(args) > max {
+		switch {
+		case min == max:
+			return fmt.Errorf("%s: want %d arguments, got %d", name, min, len(args))
+
+		case max < 0:
+			return fmt.Errorf("%s: want at least %d arguments, got %d", name, min, len(args))
+
+		default:
+			return fmt.Errorf("%s: want %d to %d arguments, got %d", name, min, max, len(args))
+		}
+	}
+
+	return nil
+}
+
+// format(layout, args...) formats args like fmt.Sprintf. Numbers that are
+// integral are passed as int, so %d works as expected.
+func format(args ...interface{}) (interface{}, error) {
+	if err := checkArgCount("format", args, 1, -1); err != nil {
+		return nil, err
+	}
+
+	layout, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("format: layout must be a string, got %T", args[0])
+	}
+
+	values := make([]interface{}, len(args)-1)
+	for i, arg := range args[1:] {
+		if f, ok := arg.(float64); ok && f == float64(int64(f)) {
+			values[i] = int64(f)
+		} else {
+			values[i] = arg
+		}
+	}
+
+	return fmt.Sprintf(layout, values...), nil
+}
+
+// len(x) returns the length of a string, slice, array or map, 0 for nil.
+func length(args ...interface{}) (interface{}, error) {
+	if err := checkArgCount("len", args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	if IsNil(args[0]) {
+		return 0.0, nil
+	}
+
+	v := reflect.ValueOf(args[0])
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return float64(len([]rune(v.String()))), nil
+
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return float64(v.Len()), nil
+	}
+
+	return nil, fmt.Errorf("len: unsupported argument type %T", args[0])
+}
+
+// if(cond, then, else) returns then if cond is true and else otherwise.
+func ifFunc(args ...interface{}) (interface{}, error) {
+	if err := checkArgCount("if", args, 3, 3); err != nil {
+		return nil, err
+	}
+
+	cond, ok := args[0].(bool)
+	if !ok && args[0] != nil {
+		return nil, fmt.Errorf("if: condition must be a bool, got %T", args[0])
+	}
+
+	if cond {
+		return args[1], nil
+	}
+
+	return args[2], nil
+}
+
+// coalesce(args...) returns the first argument that is not nil.
+func coalesce(args ...interface{}) (interface{}, error) {
+	for _, arg := range args {
+		if !IsNil(arg) {
+			return arg, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// now() returns the current time.
+func now(args ...interface{}) (interface{}, error) {
+	if err := checkArgCount("now", args, 0, 0); err != nil {
+		return nil, err
+	}
+
+	return time.Now(), nil
+}
+
+// date(x) converts a time.Time, a string in one of the layouts of
+// dateLayouts or a number of seconds since the Unix epoch to a time.Time.
+func date(args ...interface{}) (interface{}, error) {
+	if err := checkArgCount("date", args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	return toTime("date", args[0])
+}
+
+var name2DateLayout = map[string]string{
+	"date":     "2006-01-02",
+	"datetime": "2006-01-02 15:04:05",
+	"time":     "15:04:05",
+	"rfc3339":  time.RFC3339,
+}
+
+// formatDate(t, layout) formats t like time.Time.Format. layout may also be
+// one of the names in name2DateLayout.
+func formatDate(args ...interface{}) (interface{}, error) {
+	if err := checkArgCount("formatDate", args, 2, 2); err != nil {
+		return nil, err
+	}
+
+	if IsNil(args[0]) {
+		return "", nil
+	}
+
+	t, err := toTime("formatDate", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var layout string
+	switch v := args[1].(type) {
+	case string:
+		layout = v
+		if named, ok := name2DateLayout[v]; ok {
+			layout = named
+		}
+
+	case float64:
+		// The expression parser turns string literals that look like dates
+		// into numbers, so Go layouts like '2006-01-02' arrive this way.
+		return nil, fmt.Errorf("formatDate: layout was parsed as a date, use one of 'date', 'datetime', 'time' or 'rfc3339' instead")
+
+	default:
+		return nil, fmt.Errorf("formatDate: layout must be a string, got %T", args[1])
+	}
+
+	return t.Format(layout), nil
+}
+
+func addDays(args ...interface{}) (interface{}, error) {
+	return addDate("addDays", args, 0, 0, 1)
+}
+
+func addMonths(args ...interface{}) (interface{}, error) {
+	return addDate("addMonths", args, 0, 1, 0)
+}
+
+func addYears(args ...interface{}) (interface{}, error) {
+	return addDate("addYears", args, 1, 0, 0)
+}
+
+// addDate implements addDays(t, n), addMonths(t, n) and addYears(t, n).
+func addDate(name string, args []interface{}, years, months, days int) (interface{}, error) {
+	if err := checkArgCount(name, args, 2, 2); err != nil {
+		return nil, err
+	}
+
+	t, err := toTime(name, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := toInt(name, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return t.AddDate(years*n, months*n, days*n), nil
+}
+
+// addDuration(t, d) adds d, a string like "1h30m" or a number of seconds,
+// to t.
+func addDuration(args ...interface{}) (interface{}, error) {
+	if err := checkArgCount("addDuration", args, 2, 2); err != nil {
+		return nil, err
+	}
+
+	t, err := toTime("addDuration", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var d time.Duration
+	switch v := args[1].(type) {
+	case string:
+		if d, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("addDuration: %s", err)
+		}
+
+	case time.Duration:
+		d = v
+
+	case float64:
+		d = time.Duration(v * float64(time.Second))
+
+	default:
+		return nil, fmt.Errorf("addDuration: unsupported duration type %T", args[1])
+	}
+
+	return t.Add(d), nil
+}
+
+// daysBetween(a, b) returns the number of calendar days from a to b.
+func daysBetween(args ...interface{}) (interface{}, error) {
+	if err := checkArgCount("daysBetween", args, 2, 2); err != nil {
+		return nil, err
+	}
+
+	a, err := toTime("daysBetween", args[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := toTime("daysBetween", args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+
+	return b.Sub(a).Hours() / 24, nil
+}
+
+func toTime(name string, v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+
+	case *time.Time:
+		if v != nil {
+			return *v, nil
+		}
+
+	case float64:
+		// String literals in date format are turned into Unix seconds by the
+		// expression parser.
+		return time.Unix(int64(v), 0), nil
+
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("%s: cannot parse %q as date", name, v)
+	}
+
+	return time.Time{}, fmt.Errorf("%s: unsupported date type %T", name, v)
+}
+
+func toInt(name string, v interface{}) (int, error) {
+	switch v := v.(type) {
+	case float64:
+		return int(v), nil
+
+	case int:
+		return v, nil
+	}
+
+	return 0, fmt.Errorf("%s: want a number, got %T", name, v)
+}
+
+// IsNil reports whether v is nil or a nil pointer, interface, map, slice,
+// func or chan.
+func IsNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+
+	return false
+}
@@ -0,0 +1,224 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bindexpr
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func call(t *testing.T, name string, args ...interface{}) interface{} {
+	t.Helper()
+
+	fn := Lookup(name)
+	if fn == nil {
+		t.Fatalf("Lookup(%q) = nil", name)
+	}
+
+	v, err := fn(args...)
+	if err != nil {
+		t.Fatalf("%s(%v): %v", name, args, err)
+	}
+
+	return v
+}
+
+func callError(t *testing.T, name string, args ...interface{}) error {
+	t.Helper()
+
+	_, err := Lookup(name)(args...)
+	if err == nil {
+		t.Errorf("%s(%v) succeeded", name, args)
+	}
+
+	return err
+}
+
+func TestFormat(t *testing.T) {
+	if got := call(t, "format", "%d items, %.1f%%, %s", 3.0, 12.5, "ok"); got != "3 items, 12.5%, ok" {
+		t.Errorf("format() = %q", got)
+	}
+
+	callError(t, "format")
+	callError(t, "format", 1.0)
+}
+
+func TestLen(t *testing.T) {
+	for _, test := range []struct {
+		arg  interface{}
+		want float64
+	}{
+		{nil, 0},
+		{"äöü", 3},
+		{[]int{1, 2}, 2},
+		{[3]string{}, 3},
+		{map[string]int{"a": 1}, 1},
+		{(*[]int)(nil), 0},
+		{&[]int{1}, 1},
+	} {
+		if got := call(t, "len", test.arg); got != test.want {
+			t.Errorf("len(%#v) = %v; want %v", test.arg, got, test.want)
+		}
+	}
+
+	callError(t, "len", 1.0)
+	callError(t, "len")
+}
+
+func TestIf(t *testing.T) {
+	if got := call(t, "if", true, "yes", "no"); got != "yes" {
+		t.Errorf("if(true) = %v", got)
+	}
+	if got := call(t, "if", false, "yes", "no"); got != "no" {
+		t.Errorf("if(false) = %v", got)
+	}
+	if got := call(t, "if", nil, "yes", "no"); got != "no" {
+		t.Errorf("if(nil) = %v", got)
+	}
+
+	callError(t, "if", "true", 1.0, 2.0)
+	callError(t, "if", true, 1.0)
+}
+
+func TestCoalesce(t *testing.T) {
+	if got := call(t, "coalesce", nil, (*int)(nil), "first", "second"); got != "first" {
+		t.Errorf("coalesce() = %v", got)
+	}
+	if got := call(t, "coalesce", nil); got != nil {
+		t.Errorf("coalesce(nil) = %v", got)
+	}
+}
+
+func TestDateFunctions(t *testing.T) {
+	base := time.Date(2021, 1, 31, 10, 30, 0, 0, time.Local)
+
+	if got := call(t, "date", "2021-01-31 10:30"); !got.(time.Time).Equal(base) {
+		t.Errorf("date(string) = %v; want %v", got, base)
+	}
+	if got := call(t, "date", float64(base.Unix())); !got.(time.Time).Equal(base) {
+		t.Errorf("date(seconds) = %v; want %v", got, base)
+	}
+	callError(t, "date", "31.01.2021")
+	callError(t, "date", true)
+
+	for layout, want := range map[string]string{
+		"date":     "2021-01-31",
+		"datetime": "2021-01-31 10:30:00",
+		"time":     "10:30:00",
+		"Jan 2":    "Jan 31",
+	} {
+		if got := call(t, "formatDate", base, layout); got != want {
+			t.Errorf("formatDate(%q) = %q; want %q", layout, got, want)
+		}
+	}
+	if got := call(t, "formatDate", nil, "date"); got != "" {
+		t.Errorf("formatDate(nil) = %q", got)
+	}
+	if err := callError(t, "formatDate", base, 1612051200.0); err != nil && !strings.Contains(err.Error(), "parsed as a date") {
+		t.Errorf("formatDate with a numeric layout: %v", err)
+	}
+
+	for _, test := range []struct {
+		name string
+		n    float64
+		want time.Time
+	}{
+		{"addDays", 1, time.Date(2021, 2, 1, 10, 30, 0, 0, time.Local)},
+		{"addDays", -31, time.Date(2020, 12, 31, 10, 30, 0, 0, time.Local)},
+		{"addMonths", 2, time.Date(2021, 3, 31, 10, 30, 0, 0, time.Local)},
+		{"addYears", 1, time.Date(2022, 1, 31, 10, 30, 0, 0, time.Local)},
+	} {
+		if got := call(t, test.name, base, test.n); !got.(time.Time).Equal(test.want) {
+			t.Errorf("%s(%v) = %v; want %v", test.name, test.n, got, test.want)
+		}
+	}
+	callError(t, "addDays", base, "1")
+
+	for _, d := range []interface{}{"1h30m", 5400.0, 90 * time.Minute} {
+		if got := call(t, "addDuration", base, d); !got.(time.Time).Equal(base.Add(90 * time.Minute)) {
+			t.Errorf("addDuration(%v) = %v", d, got)
+		}
+	}
+	callError(t, "addDuration", base, "soon")
+
+	if got := call(t, "daysBetween", base, time.Date(2021, 2, 2, 1, 0, 0, 0, time.Local)); got != 2.0 {
+		t.Errorf("daysBetween() = %v; want 2", got)
+	}
+	if got := call(t, "daysBetween", "2021-03-01", "2021-02-01"); got != -28.0 {
+		t.Errorf("daysBetween() = %v; want -28", got)
+	}
+}
+
+func TestNow(t *testing.T) {
+	before := time.Now()
+	got := call(t, "now").(time.Time)
+
+	if got.Before(before) || got.After(time.Now()) {
+		t.Errorf("now() = %v", got)
+	}
+
+	callError(t, "now", 1.0)
+}
+
+func TestRegister(t *testing.T) {
+	if err := Register("", func(...interface{}) (interface{}, error) { return nil, nil }); err == nil {
+		t.Error("Register() with an empty name succeeded")
+	}
+	if err := Register("nilFunc", nil); err == nil {
+		t.Error("Register() of a nil function succeeded")
+	}
+
+	errTest := errors.New("test")
+	MustRegister("bindexprTestFunc", func(args ...interface{}) (interface{}, error) {
+		return nil, errTest
+	})
+	if _, err := Lookup("bindexprTestFunc")(); err != errTest {
+		t.Errorf("registered function returned %v", err)
+	}
+
+	names := FunctionNames()
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("FunctionNames() = %v; want them sorted", names)
+	}
+	if _, ok := Functions()["bindexprTestFunc"]; !ok {
+		t.Error("Functions() lacks the registered function")
+	}
+	if _, ok := Functions()["daysBetween"]; !ok {
+		t.Error("Functions() lacks the builtin functions")
+	}
+
+	// Registering a builtin name replaces the builtin.
+	MustRegister("len", func(args ...interface{}) (interface{}, error) {
+		return -1.0, nil
+	})
+	defer func() {
+		mutex.Lock()
+		delete(name2Function, "len")
+		delete(name2Function, "bindexprTestFunc")
+		mutex.Unlock()
+	}()
+	if got := call(t, "len", "abc"); got != -1.0 {
+		t.Errorf("len() = %v after replacing it", got)
+	}
+}
+
+func TestIsNil(t *testing.T) {
+	var nilMap map[string]int
+	var nilFunc func()
+
+	for _, v := range []interface{}{nil, (*int)(nil), nilMap, []int(nil), nilFunc, (chan int)(nil)} {
+		if !IsNil(v) {
+			t.Errorf("IsNil(%#v) = false", v)
+		}
+	}
+	for _, v := range []interface{}{0, "", false, []int{}, new(int)} {
+		if IsNil(v) {
+			t.Errorf("IsNil(%#v) = true", v)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bindexpr
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// PathPart is a member name of a path.
+type PathPart struct {
+	Name string
+
+	// NullSafe reports whether the member is accessed with "?.", so a nil
+	// value before it yields nil instead of an error.
+	NullSafe bool
+
+	// Offset is the byte offset of Name in the path.
+	Offset int
+}
+
+// ParsePath splits path into its parts and checks its syntax.
+func ParsePath(path string) ([]PathPart, error) {
+	if path == "" {
+		return nil, fmt.Errorf("bindexpr: empty path")
+	}
+
+	var parts []PathPart
+
+	nullSafe := false
+	offset := 0
+	for s := path; ; {
+		i := strings.IndexByte(s, '.')
+
+		name := s
+		if i >= 0 {
+			name = s[:i]
+		}
+
+		next := false
+		if strings.HasSuffix(name, "?") && i >= 0 {
+			name = name[:len(name)-1]
+			next = true
+		}
+
+		if !isIdentifier(name) {
+			return nil, fmt.Errorf("bindexpr: invalid member %q in path %q", name, path)
+		}
+
+		parts = append(parts, PathPart{Name: name, NullSafe: nullSafe, Offset: offset})
+
+		if i < 0 {
+			break
+		}
+
+		s = s[i+1:]
+		offset += i + 1
+		nullSafe = next
+	}
+
+	return parts, nil
+}
+
+// IsPath reports whether s is a syntactically valid path.
+func IsPath(s string) bool {
+	_, err := ParsePath(s)
+	return err == nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidatePath checks that path can be resolved on values of type t and
+// returns the type of the value it refers to.
+//
+// Members are resolved like a DataBinder does: map elements, struct fields,
+// func fields and methods without arguments that return a value and
+// optionally an error. Beyond interface values and maps of interface values,
+// types are only known at runtime, so nil and no error is returned for
+// paths reaching into them.
+func ValidatePath(t reflect.Type, path string) (reflect.Type, error) {
+	parts, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, part := range parts {
+		if t == nil {
+			return nil, nil
+		}
+
+		var methodOwner reflect.Type
+		for t.Kind() == reflect.Ptr {
+			methodOwner = t
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Interface:
+			return nil, nil
+
+		case reflect.Map:
+			if t.Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("bindexpr: %s in path %q: map key type %s is not string", part.Name, path, t.Key())
+			}
+			t = t.Elem()
+
+		case reflect.Struct:
+			if f, ok := t.FieldByName(part.Name); ok && f.PkgPath == "" {
+				switch f.Type.Kind() {
+				case reflect.Func:
+					if t, err = funcResultType(f.Type, part.Name, path); err != nil {
+						return nil, err
+					}
+
+				case reflect.Interface:
+					t = nil
+
+				default:
+					t = f.Type
+				}
+				continue
+			}
+
+			var m reflect.Method
+			var ok bool
+			if methodOwner != nil {
+				m, ok = methodOwner.MethodByName(part.Name)
+			}
+			if !ok {
+				m, ok = t.MethodByName(part.Name)
+			}
+			if !ok {
+				return nil, fmt.Errorf("bindexpr: %s has no field or method %s (path %q)", t, part.Name, path)
+			}
+
+			// Method types include the receiver as first argument.
+			if m.Type.NumIn() != 1 {
+				return nil, fmt.Errorf("bindexpr: method %s of %s must not take arguments (path %q)", part.Name, t, path)
+			}
+			if t, err = funcResultType(m.Type, part.Name, path); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("bindexpr: cannot access member %s of type %s (path %q)", part.Name, t, path)
+		}
+	}
+
+	return t, nil
+}
+
+func funcResultType(ft reflect.Type, name, path string) (reflect.Type, error) {
+	switch ft.NumOut() {
+	case 1:
+		return ft.Out(0), nil
+
+	case 2:
+		if ft.Out(1) == errorInterface {
+			return ft.Out(0), nil
+		}
+	}
+
+	return nil, fmt.Errorf("bindexpr: %s must return a value plus optionally an error (path %q)", name, path)
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bindexpr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	parts, err := ParsePath("Customer?.Address.City?.Name2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []PathPart{
+		{Name: "Customer", Offset: 0},
+		{Name: "Address", NullSafe: true, Offset: 10},
+		{Name: "City", Offset: 18},
+		{Name: "Name2", NullSafe: true, Offset: 24},
+	}
+	if !reflect.DeepEqual(parts, want) {
+		t.Errorf("ParsePath() = %+v; want %+v", parts, want)
+	}
+}
+
+func TestParsePathInvalid(t *testing.T) {
+	for _, path := range []string{
+		"",
+		".Name",
+		"Name.",
+		"Name?",
+		"Name?.",
+		"a..b",
+		"a?.?.b",
+		"1st",
+		"a.b-c",
+		"a.b c",
+	} {
+		if IsPath(path) {
+			t.Errorf("IsPath(%q) = true", path)
+		}
+	}
+
+	for _, path := range []string{"a", "_a.b1", "Größe?.Wert"} {
+		if !IsPath(path) {
+			t.Errorf("IsPath(%q) = false", path)
+		}
+	}
+}
+
+type address struct {
+	City string
+}
+
+type customer struct {
+	Name       string
+	Address    *address
+	Tags       map[string]int
+	Extra      interface{}
+	Fetch      func() (*address, error)
+	Broken     func() (string, string)
+	unexported string
+}
+
+func (c *customer) Label() string {
+	return c.Name
+}
+
+func (c customer) Lookup() (address, error) {
+	return address{}, nil
+}
+
+func (c *customer) WithArg(s string) string {
+	return s
+}
+
+func TestValidatePath(t *testing.T) {
+	ct := reflect.TypeOf(&customer{})
+
+	for _, test := range []struct {
+		path string
+		want reflect.Type
+	}{
+		{"Name", reflect.TypeOf("")},
+		{"Address?.City", reflect.TypeOf("")},
+		{"Tags.anything", reflect.TypeOf(0)},
+		{"Fetch.City", reflect.TypeOf("")},
+		{"Label", reflect.TypeOf("")},
+		{"Lookup.City", reflect.TypeOf("")},
+		{"Extra.Whatever.Goes", nil},
+	} {
+		got, err := ValidatePath(ct, test.path)
+		if err != nil {
+			t.Errorf("ValidatePath(%q): %v", test.path, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ValidatePath(%q) = %v; want %v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestValidatePathErrors(t *testing.T) {
+	ct := reflect.TypeOf(customer{})
+
+	for _, test := range []struct {
+		path string
+		want string
+	}{
+		{"Missing", "no field or method Missing"},
+		{"unexported", "no field or method unexported"},
+		{"Name.Length", "cannot access member Length"},
+		{"Broken", "must return a value plus optionally an error"},
+		{"Address.Street", "no field or method Street"},
+		{"Address?.", "invalid member"},
+	} {
+		_, err := ValidatePath(ct, test.path)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ValidatePath(%q) error = %v; want one containing %q", test.path, err, test.want)
+		}
+	}
+
+	if _, err := ValidatePath(reflect.TypeOf(&customer{}), "WithArg"); err == nil || !strings.Contains(err.Error(), "must not take arguments") {
+		t.Errorf("ValidatePath(WithArg) error = %v", err)
+	}
+
+	if _, err := ValidatePath(reflect.TypeOf(map[int]string{}), "Key"); err == nil || !strings.Contains(err.Error(), "not string") {
+		t.Errorf("ValidatePath() on a map with int keys: error = %v", err)
+	}
+}
+
+func TestValidatePathUnknownType(t *testing.T) {
+	if typ, err := ValidatePath(nil, "Anything.Goes"); typ != nil || err != nil {
+		t.Errorf("ValidatePath(nil) = %v, %v; want nil, nil", typ, err)
+	}
+
+	var e error = errors.New("x")
+	if typ, err := ValidatePath(reflect.TypeOf(&e), "Error"); typ != nil || err != nil {
+		t.Errorf("ValidatePath(*error) = %v, %v; want nil, nil", typ, err)
+	}
+}
@@ -101,7 +101,7 @@ func newBitmap(size Size, transparent bool, dpi int) (bmp *Bitmap, err error) {
 		hBmp := gdi32.CreateDIBSection(hdc, &hdr, gdi32.DIB_RGB_COLORS, &bitsPtr, 0, 0)
 		switch hBmp {
 		case 0, kernel32.ERROR_INVALID_PARAMETER:
-			return errs.NewError("CreateDIBSection failed")
+			return errs.NewWin32Error("CreateDIBSection")
 		}
 
 		if transparent {
@@ -289,7 +289,7 @@ func (bmp *Bitmap) ToImage() (*image.RGBA, error) {
 	buf := make([]byte, bi.BmiHeader.BiSizeImage)
 	bi.BmiHeader.BiCompression = gdi32.BI_RGB
 	if ret := gdi32.GetDIBits(hdc, bmp.hBmp, 0, uint32(bi.BmiHeader.BiHeight), &buf[0], &bi, gdi32.DIB_RGB_COLORS); ret == 0 {
-		return nil, errs.NewError("GetDIBits failed")
+		return nil, errs.NewWin32Error("GetDIBits")
 	}
 
 	width := int(bi.BmiHeader.BiWidth)
@@ -457,7 +457,7 @@ func (bmp *Bitmap) alphaBlendPart(hdc gdi32.HDC, dst, src Rectangle, opacity byt
 					int32(src.Height),
 					gdi32.SRCCOPY,
 				) {
-					return errs.NewError("StretchBlt failed")
+					return errs.NewWin32Error("StretchBlt")
 				}
 
 				return nil
@@ -477,7 +477,7 @@ func (bmp *Bitmap) alphaBlendPart(hdc gdi32.HDC, dst, src Rectangle, opacity byt
 			int32(src.Height),
 			gdi32.BLENDFUNCTION{AlphaFormat: gdi32.AC_SRC_ALPHA, SourceConstantAlpha: opacity},
 		) {
-			return errs.NewError("AlphaBlend failed")
+			return errs.NewWin32Error("AlphaBlend")
 		}
 
 		return nil
@@ -488,7 +488,7 @@ func (bmp *Bitmap) withSelectedIntoMemDC(f func(hdcMem gdi32.HDC) error) error {
 	return withCompatibleDC(func(hdcMem gdi32.HDC) error {
 		hBmpOld := gdi32.SelectObject(hdcMem, gdi32.HGDIOBJ(bmp.hBmp))
 		if hBmpOld == 0 {
-			return errs.NewError("SelectObject failed")
+			return errs.NewWin32Error("SelectObject")
 		}
 		defer gdi32.SelectObject(hdcMem, hBmpOld)
 
@@ -503,7 +503,7 @@ func (bmp *Bitmap) withSelectedIntoMemDC(f func(hdcMem gdi32.HDC) error) error {
 func newBitmapFromHBITMAP(hBmp gdi32.HBITMAP, dpi int) (bmp *Bitmap, err error) {
 	var dib gdi32.DIBSECTION
 	if gdi32.GetObject(gdi32.HGDIOBJ(hBmp), unsafe.Sizeof(dib), unsafe.Pointer(&dib)) == 0 {
-		return nil, errs.NewError("GetObject failed")
+		return nil, errs.NewWin32Error("GetObject")
 	}
 
 	bmih := &dib.DsBmih
@@ -564,7 +564,7 @@ func hBitmapFromImage(im image.Image, dpi int) (gdi32.HBITMAP, error) {
 	hBitmap := gdi32.CreateDIBSection(hdc, &bi.BITMAPINFOHEADER, gdi32.DIB_RGB_COLORS, &lpBits, 0, 0)
 	switch hBitmap {
 	case 0, kernel32.ERROR_INVALID_PARAMETER:
-		return 0, errs.NewError("CreateDIBSection failed")
+		return 0, errs.NewWin32Error("CreateDIBSection")
 	}
 
 	// Fill the image
@@ -587,13 +587,13 @@ func hBitmapFromImage(im image.Image, dpi int) (gdi32.HBITMAP, error) {
 func hBitmapFromWindow(window Window) (gdi32.HBITMAP, error) {
 	hdcMem := gdi32.CreateCompatibleDC(0)
 	if hdcMem == 0 {
-		return 0, errs.NewError("CreateCompatibleDC failed")
+		return 0, errs.NewWin32Error("CreateCompatibleDC")
 	}
 	defer gdi32.DeleteDC(hdcMem)
 
 	var r gdi32.RECT
 	if !user32.GetWindowRect(window.Handle(), &r) {
-		return 0, errs.NewError("GetWindowRect failed")
+		return 0, errs.LastError("GetWindowRect")
 	}
 
 	hdc := user32.GetDC(window.Handle())
@@ -618,7 +618,7 @@ func hBitmapFromIcon(icon *Icon, size Size, dpi int) (gdi32.HBITMAP, error) {
 
 	hdcMem := gdi32.CreateCompatibleDC(hdc)
 	if hdcMem == 0 {
-		return 0, errs.NewError("CreateCompatibleDC failed")
+		return 0, errs.NewWin32Error("CreateCompatibleDC")
 	}
 	defer gdi32.DeleteDC(hdcMem)
 
@@ -642,7 +642,7 @@ func hBitmapFromIcon(icon *Icon, size Size, dpi int) (gdi32.HBITMAP, error) {
 	hBmp := gdi32.CreateDIBSection(hdcMem, &bi.BITMAPINFOHEADER, gdi32.DIB_RGB_COLORS, nil, 0, 0)
 	switch hBmp {
 	case 0, kernel32.ERROR_INVALID_PARAMETER:
-		return 0, errs.NewError("CreateDIBSection failed")
+		return 0, errs.NewWin32Error("CreateDIBSection")
 	}
 
 	hOld := gdi32.SelectObject(hdcMem, gdi32.HGDIOBJ(hBmp))
@@ -659,7 +659,7 @@ func hBitmapFromIcon(icon *Icon, size Size, dpi int) (gdi32.HBITMAP, error) {
 func withCompatibleDC(f func(hdc gdi32.HDC) error) error {
 	hdc := gdi32.CreateCompatibleDC(0)
 	if hdc == 0 {
-		return errs.NewError("CreateCompatibleDC failed")
+		return errs.NewWin32Error("CreateCompatibleDC")
 	}
 	defer gdi32.DeleteDC(hdc)
 
@@ -61,7 +61,7 @@ func (l *BoxLayout) SetOrientation(value Orientation) error {
 		case Horizontal, Vertical:
 
 		default:
-			return errs.NewError("invalid Orientation value")
+			return errs.NewInvalidArgumentError("invalid Orientation value")
 		}
 
 		l.orientation = value
@@ -83,16 +83,16 @@ func (l *BoxLayout) StretchFactor(widget Widget) int {
 func (l *BoxLayout) SetStretchFactor(widget Widget, factor int) error {
 	if factor != l.StretchFactor(widget) {
 		if l.container == nil {
-			return errs.NewError("container required")
+			return errs.NewInvalidArgumentError("container required")
 		}
 
 		handle := widget.Handle()
 
 		if !l.container.Children().containsHandle(handle) {
-			return errs.NewError("unknown widget")
+			return errs.NewInvalidArgumentError("unknown widget")
 		}
 		if factor < 1 {
-			return errs.NewError("factor must be >= 1")
+			return errs.NewInvalidArgumentError("factor must be >= 1")
 		}
 
 		l.hwnd2StretchFactor[handle] = factor
@@ -0,0 +1,62 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/comdlg32"
+	"github.com/Gipcomp/win32/gdi32"
+	"github.com/Gipcomp/winapi/errs"
+)
+
+// ColorDialog wraps the common color dialog.
+type ColorDialog struct {
+	Color Color
+
+	// CustomColors are the 16 custom colors shown by the dialog. They are
+	// updated with the changes the user made when Show returns.
+	CustomColors [16]Color
+}
+
+// Show shows the dialog and returns whether the user accepted a color, which
+// is then stored in Color.
+func (dlg *ColorDialog) Show(owner Form) (accepted bool, err error) {
+	var custColors [16]gdi32.COLORREF
+	for i, c := range dlg.CustomColors {
+		custColors[i] = gdi32.COLORREF(c)
+	}
+
+	cc := comdlg32.CHOOSECOLOR{
+		RgbResult:    gdi32.COLORREF(dlg.Color),
+		LpCustColors: &custColors,
+		Flags:        comdlg32.CC_ANYCOLOR | comdlg32.CC_FULLOPEN | comdlg32.CC_RGBINIT,
+	}
+	cc.LStructSize = uint32(unsafe.Sizeof(cc))
+	if owner != nil {
+		cc.HwndOwner = owner.Handle()
+	}
+
+	accepted = comdlg32.ChooseColor(&cc)
+
+	for i, c := range custColors {
+		dlg.CustomColors[i] = Color(c)
+	}
+
+	if !accepted {
+		if errCode := comdlg32.CommDlgExtendedError(); errCode != 0 {
+			return false, errs.NewErrorWithCode(errs.CodeWin32, fmt.Sprintf("ChooseColor: Error %d", errCode))
+		}
+
+		return false, nil
+	}
+
+	dlg.Color = Color(cc.RgbResult)
+
+	return true, nil
+}
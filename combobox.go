@@ -217,7 +217,7 @@ func newComboBoxWithStyle(parent Container, style uint32) (*ComboBox, error) {
 				if cb.model == nil {
 					return nil
 				} else {
-					return errs.NewError("Data binding is only supported using a model that implements BindingValueProvider.")
+					return errs.NewNotSupportedError("Data binding is only supported using a model that implements BindingValueProvider.")
 				}
 			}
 
@@ -448,7 +448,7 @@ func (cb *ComboBox) BindingMember() string {
 func (cb *ComboBox) SetBindingMember(bindingMember string) error {
 	if bindingMember != "" {
 		if _, ok := cb.providedModel.([]string); ok {
-			return errs.NewError("invalid for []string model")
+			return errs.NewInvalidArgumentError("invalid for []string model")
 		}
 	}
 
@@ -489,7 +489,7 @@ func (cb *ComboBox) DisplayMember() string {
 func (cb *ComboBox) SetDisplayMember(displayMember string) error {
 	if displayMember != "" {
 		if _, ok := cb.providedModel.([]string); ok {
-			return errs.NewError("invalid for []string model")
+			return errs.NewInvalidArgumentError("invalid for []string model")
 		}
 	}
 
@@ -532,7 +532,7 @@ func (cb *ComboBox) SetMaxLength(value int) {
 func (cb *ComboBox) calculateMaxItemTextWidth() int {
 	hdc := user32.GetDC(cb.hWnd)
 	if hdc == 0 {
-		errs.NewError("GetDC failed")
+		errs.NewWin32Error("GetDC")
 		return -1
 	}
 	defer user32.ReleaseDC(cb.hWnd, hdc)
@@ -553,7 +553,7 @@ func (cb *ComboBox) calculateMaxItemTextWidth() int {
 
 		// if !gdi32.GetTextExtentPoint32(hdc, str, int32(len(helpers.UINT16PtrToString(str))), &s) {
 		if !gdi32.GetTextExtentPoint32(hdc, str, int32(len(cb.itemString(i))), &s) {
-			errs.NewError("GetTextExtentPoint32 failed")
+			errs.NewWin32Error("GetTextExtentPoint32")
 			return -1
 		}
 
@@ -571,7 +571,7 @@ func (cb *ComboBox) SetCurrentIndex(value int) error {
 	index := int(int32(cb.SendMessage(commctrl.CB_SETCURSEL, uintptr(value), 0)))
 
 	if index != value {
-		return errs.NewError("invalid index")
+		return errs.NewInvalidArgumentError("invalid index")
 	}
 
 	if value != cb.prevCurIndex {
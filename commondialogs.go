@@ -22,6 +22,8 @@ import (
 	"github.com/Gipcomp/winapi/errs"
 )
 
+// FileDialog shows the legacy GetOpenFileName/SHBrowseForFolder dialogs.
+// New code should prefer CommonItemDialog.
 type FileDialog struct {
 	Title          string
 	FilePath       string
@@ -149,7 +151,7 @@ func (dlg *FileDialog) ShowSave(owner Form) (accepted bool, err error) {
 func pathFromPIDL(pidl uintptr) (string, error) {
 	var path [kernel32.MAX_PATH]uint16
 	if !shell32.SHGetPathFromIDList(pidl, &path[0]) {
-		return "", errs.NewError("SHGetPathFromIDList failed")
+		return "", errs.NewWin32Error("SHGetPathFromIDList")
 	}
 
 	return syscall.UTF16ToString(path[:]), nil
@@ -0,0 +1,384 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/handle"
+	"github.com/Gipcomp/win32/ole32"
+	"github.com/Gipcomp/win32/win"
+	"github.com/Gipcomp/winapi/errs"
+)
+
+// FileFilter describes one entry of the file type combobox of a
+// CommonItemDialog, e.g. FileFilter{"Images", []string{"*.png", "*.jpg"}}.
+type FileFilter struct {
+	Name     string
+	Patterns []string
+}
+
+// FileDialogControl is a custom control that can be added to a
+// CommonItemDialog. Its state is read back after the dialog was accepted.
+type FileDialogControl interface {
+	add(customize *iFileDialogCustomize, id uint32) win.HRESULT
+	read(customize *iFileDialogCustomize, id uint32)
+}
+
+// FileDialogCheckBox adds a check box to a CommonItemDialog.
+type FileDialogCheckBox struct {
+	Text    string
+	Checked bool
+}
+
+func (cb *FileDialogCheckBox) add(customize *iFileDialogCustomize, id uint32) win.HRESULT {
+	return customize.AddCheckButton(id, cb.Text, cb.Checked)
+}
+
+func (cb *FileDialogCheckBox) read(customize *iFileDialogCustomize, id uint32) {
+	var checked win.BOOL
+	if win.SUCCEEDED(customize.GetCheckButtonState(id, &checked)) {
+		cb.Checked = checked != 0
+	}
+}
+
+// FileDialogComboBox adds a labeled combobox to a CommonItemDialog.
+type FileDialogComboBox struct {
+	Label        string
+	Items        []string
+	CurrentIndex int
+}
+
+func (cb *FileDialogComboBox) add(customize *iFileDialogCustomize, id uint32) win.HRESULT {
+	if cb.Label != "" {
+		if hr := customize.StartVisualGroup(id+1, cb.Label); win.FAILED(hr) {
+			return hr
+		}
+		defer customize.EndVisualGroup()
+	}
+
+	if hr := customize.AddComboBox(id); win.FAILED(hr) {
+		return hr
+	}
+
+	for i, item := range cb.Items {
+		if hr := customize.AddControlItem(id, uint32(i), item); win.FAILED(hr) {
+			return hr
+		}
+	}
+
+	if cb.CurrentIndex >= 0 && cb.CurrentIndex < len(cb.Items) {
+		return customize.SetSelectedControlItem(id, uint32(cb.CurrentIndex))
+	}
+
+	return win.S_OK
+}
+
+func (cb *FileDialogComboBox) read(customize *iFileDialogCustomize, id uint32) {
+	var index uint32
+	if win.SUCCEEDED(customize.GetSelectedControlItem(id, &index)) {
+		cb.CurrentIndex = int(index)
+	}
+}
+
+// CommonItemDialog is a file and folder picker backed by the common item
+// dialog (IFileOpenDialog/IFileSaveDialog) available since Windows Vista.
+//
+// If ID is set, the dialog remembers the last directory in App().Settings()
+// and lets the shell keep its own per-dialog state (size, view mode) apart
+// from the other dialogs of the application.
+type CommonItemDialog struct {
+	ID               string
+	Title            string
+	OKButtonText     string
+	FileNameLabel    string
+	FilePath         string
+	FilePaths        []string
+	InitialDirPath   string
+	Filters          []FileFilter
+	FilterIndex      int
+	DefaultExtension string
+	EnforceExtension bool
+	Places           []string
+	PlacesAtTop      bool
+	Controls         []FileDialogControl
+	ShowHidden       bool
+	Flags            uint32
+}
+
+func (dlg *CommonItemDialog) ShowOpen(owner Form) (accepted bool, err error) {
+	return dlg.show(owner, false, fosFileMustExist)
+}
+
+func (dlg *CommonItemDialog) ShowOpenMultiple(owner Form) (accepted bool, err error) {
+	return dlg.show(owner, false, fosFileMustExist|fosAllowMultiSelect)
+}
+
+func (dlg *CommonItemDialog) ShowSave(owner Form) (accepted bool, err error) {
+	return dlg.show(owner, true, fosOverwritePrompt)
+}
+
+func (dlg *CommonItemDialog) ShowBrowseFolder(owner Form) (accepted bool, err error) {
+	return dlg.show(owner, false, fosPickFolders)
+}
+
+func (dlg *CommonItemDialog) ShowBrowseFolders(owner Form) (accepted bool, err error) {
+	return dlg.show(owner, false, fosPickFolders|fosAllowMultiSelect)
+}
+
+func (dlg *CommonItemDialog) settingsKey() string {
+	return "CommonItemDialog/" + dlg.ID
+}
+
+func (dlg *CommonItemDialog) rememberedDirPath() string {
+	if dlg.ID == "" {
+		return ""
+	}
+
+	settings := App().Settings()
+	if settings == nil {
+		return ""
+	}
+
+	dirPath, _ := settings.Get(dlg.settingsKey())
+	return dirPath
+}
+
+func (dlg *CommonItemDialog) rememberDirPath(dirPath string) {
+	if dlg.ID == "" || dirPath == "" {
+		return
+	}
+
+	if settings := App().Settings(); settings != nil {
+		settings.Put(dlg.settingsKey(), dirPath)
+	}
+}
+
+func (dlg *CommonItemDialog) clientGUID() *syscall.GUID {
+	sum := md5.Sum([]byte(App().ProductName() + "/" + dlg.ID))
+
+	return &syscall.GUID{
+		Data1: binary.LittleEndian.Uint32(sum[0:4]),
+		Data2: binary.LittleEndian.Uint16(sum[4:6]),
+		Data3: binary.LittleEndian.Uint16(sum[6:8]),
+		Data4: [8]byte{sum[8], sum[9], sum[10], sum[11], sum[12], sum[13], sum[14], sum[15]},
+	}
+}
+
+func (dlg *CommonItemDialog) show(owner Form, save bool, options uint32) (accepted bool, err error) {
+	clsid, iid := &clsidFileOpenDialog, &iidIFileOpenDialog
+	if save {
+		clsid, iid = &clsidFileSaveDialog, &iidIFileSaveDialog
+	}
+
+	var fdPtr unsafe.Pointer
+	if hr := ole32.CoCreateInstance(clsid, nil, ole32.CLSCTX_INPROC_SERVER, iid, &fdPtr); win.FAILED(hr) {
+		return false, errs.ErrorFromHRESULT("CoCreateInstance", hr)
+	}
+	fd := (*iFileDialog)(fdPtr)
+	defer fd.Release()
+
+	var fos uint32
+	if hr := fd.GetOptions(&fos); win.FAILED(hr) {
+		return false, errs.ErrorFromHRESULT("IFileDialog.GetOptions", hr)
+	}
+	fos |= options | dlg.Flags | fosForceFileSystem | fosPathMustExist | fosNoChangeDir
+	if dlg.EnforceExtension {
+		fos |= fosStrictFileTypes
+	}
+	if dlg.ShowHidden {
+		fos |= fosForceShowHidden
+	}
+	if hr := fd.SetOptions(fos); win.FAILED(hr) {
+		return false, errs.ErrorFromHRESULT("IFileDialog.SetOptions", hr)
+	}
+
+	if dlg.ID != "" {
+		if hr := fd.SetClientGuid(dlg.clientGUID()); win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IFileDialog.SetClientGuid", hr)
+		}
+	}
+
+	if dlg.Title != "" {
+		if hr := fd.SetTitle(dlg.Title); win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IFileDialog.SetTitle", hr)
+		}
+	}
+	if dlg.OKButtonText != "" {
+		if hr := fd.SetOkButtonLabel(dlg.OKButtonText); win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IFileDialog.SetOkButtonLabel", hr)
+		}
+	}
+	if dlg.FileNameLabel != "" {
+		if hr := fd.SetFileNameLabel(dlg.FileNameLabel); win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IFileDialog.SetFileNameLabel", hr)
+		}
+	}
+
+	if fos&fosPickFolders == 0 && len(dlg.Filters) > 0 {
+		specs := make([]comdlgFilterSpec, len(dlg.Filters))
+		for i, f := range dlg.Filters {
+			if specs[i].pszName, err = syscall.UTF16PtrFromString(f.Name); err != nil {
+				return false, errs.WrapError(err)
+			}
+			if specs[i].pszSpec, err = syscall.UTF16PtrFromString(strings.Join(f.Patterns, ";")); err != nil {
+				return false, errs.WrapError(err)
+			}
+		}
+		if hr := fd.SetFileTypes(specs); win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IFileDialog.SetFileTypes", hr)
+		}
+		if dlg.FilterIndex > 0 {
+			if hr := fd.SetFileTypeIndex(uint32(dlg.FilterIndex)); win.FAILED(hr) {
+				return false, errs.ErrorFromHRESULT("IFileDialog.SetFileTypeIndex", hr)
+			}
+		}
+	}
+
+	if dlg.DefaultExtension != "" {
+		if hr := fd.SetDefaultExtension(strings.TrimPrefix(dlg.DefaultExtension, ".")); win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IFileDialog.SetDefaultExtension", hr)
+		}
+	}
+
+	dirPath := dlg.InitialDirPath
+	if dlg.FilePath != "" {
+		dir, name := filepath.Split(dlg.FilePath)
+		if dirPath == "" {
+			dirPath = dir
+		}
+		if name != "" && fos&fosPickFolders == 0 {
+			if hr := fd.SetFileName(name); win.FAILED(hr) {
+				return false, errs.ErrorFromHRESULT("IFileDialog.SetFileName", hr)
+			}
+		}
+	}
+	if dirPath == "" {
+		dirPath = dlg.rememberedDirPath()
+	}
+	if dirPath != "" {
+		// A folder that vanished since it was remembered is not an error.
+		if item, hr := newShellItemFromPath(dirPath); win.SUCCEEDED(hr) {
+			fd.SetFolder(item)
+			item.Release()
+		}
+	}
+
+	fdap := uintptr(fdapBottom)
+	if dlg.PlacesAtTop {
+		fdap = fdapTop
+	}
+	for _, place := range dlg.Places {
+		item, hr := newShellItemFromPath(place)
+		if win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("SHCreateItemFromParsingName", hr)
+		}
+		hr = fd.AddPlace(item, fdap)
+		item.Release()
+		if win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IFileDialog.AddPlace", hr)
+		}
+	}
+
+	var customize *iFileDialogCustomize
+	if len(dlg.Controls) > 0 {
+		var customizePtr unsafe.Pointer
+		if hr := fd.QueryInterface(&iidIFileDialogCustomize, &customizePtr); win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IFileDialog.QueryInterface", hr)
+		}
+		customize = (*iFileDialogCustomize)(customizePtr)
+		defer customize.Release()
+
+		for i, c := range dlg.Controls {
+			if hr := c.add(customize, controlID(i)); win.FAILED(hr) {
+				return false, errs.ErrorFromHRESULT("IFileDialogCustomize", hr)
+			}
+		}
+	}
+
+	var ownerHWnd handle.HWND
+	if owner != nil {
+		ownerHWnd = owner.Handle()
+	}
+
+	if hr := fd.Show(ownerHWnd); hr == hrErrorCancelled {
+		return false, nil
+	} else if win.FAILED(hr) {
+		return false, errs.ErrorFromHRESULT("IFileDialog.Show", hr)
+	}
+
+	for i, c := range dlg.Controls {
+		c.read(customize, controlID(i))
+	}
+
+	if fos&fosAllowMultiSelect != 0 {
+		var items *iShellItemArray
+		if hr := fd.GetResults(&items); win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IFileOpenDialog.GetResults", hr)
+		}
+		defer items.Release()
+
+		var count uint32
+		if hr := items.GetCount(&count); win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IShellItemArray.GetCount", hr)
+		}
+
+		dlg.FilePaths = make([]string, 0, count)
+		for i := uint32(0); i < count; i++ {
+			var item *iShellItem
+			if hr := items.GetItemAt(i, &item); win.FAILED(hr) {
+				return false, errs.ErrorFromHRESULT("IShellItemArray.GetItemAt", hr)
+			}
+			path, hr := item.FileSysPath()
+			item.Release()
+			if win.FAILED(hr) {
+				return false, errs.ErrorFromHRESULT("IShellItem.GetDisplayName", hr)
+			}
+			dlg.FilePaths = append(dlg.FilePaths, path)
+		}
+
+		if len(dlg.FilePaths) > 0 {
+			dlg.FilePath = dlg.FilePaths[0]
+		}
+	} else {
+		var item *iShellItem
+		if hr := fd.GetResult(&item); win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IFileDialog.GetResult", hr)
+		}
+		path, hr := item.FileSysPath()
+		item.Release()
+		if win.FAILED(hr) {
+			return false, errs.ErrorFromHRESULT("IShellItem.GetDisplayName", hr)
+		}
+		dlg.FilePath = path
+		dlg.FilePaths = []string{path}
+	}
+
+	var filterIndex uint32
+	if win.SUCCEEDED(fd.GetFileTypeIndex(&filterIndex)) {
+		dlg.FilterIndex = int(filterIndex)
+	}
+
+	if fos&fosPickFolders != 0 {
+		dlg.rememberDirPath(dlg.FilePath)
+	} else {
+		dlg.rememberDirPath(filepath.Dir(dlg.FilePath))
+	}
+
+	return true, nil
+}
+
+// controlID returns the id of the i-th custom control. Ids are spaced so
+// controls like FileDialogComboBox can use id+1 for a visual group.
+func controlID(i int) uint32 {
+	return uint32(1000 + i*2)
+}
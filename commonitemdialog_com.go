@@ -0,0 +1,463 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/handle"
+	"github.com/Gipcomp/win32/ole32"
+	"github.com/Gipcomp/win32/win"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	libshell32                      = windows.NewLazySystemDLL("shell32.dll")
+	procSHCreateItemFromParsingName = libshell32.NewProc("SHCreateItemFromParsingName")
+)
+
+var (
+	clsidFileOpenDialog     = ole32.CLSID{Data1: 0xDC1C5A9C, Data2: 0xE88A, Data3: 0x4DDE, Data4: [8]byte{0xA5, 0xA1, 0x60, 0xF8, 0x2A, 0x20, 0xAE, 0xF7}}
+	clsidFileSaveDialog     = ole32.CLSID{Data1: 0xC0B4E2F3, Data2: 0xBA21, Data3: 0x4773, Data4: [8]byte{0x8D, 0xBA, 0x33, 0x5E, 0xC9, 0x46, 0xEB, 0x8B}}
+	iidIFileOpenDialog      = ole32.IID{Data1: 0xD57C7288, Data2: 0xD4AD, Data3: 0x4768, Data4: [8]byte{0xBE, 0x02, 0x9D, 0x96, 0x95, 0x32, 0xD9, 0x60}}
+	iidIFileSaveDialog      = ole32.IID{Data1: 0x84BCCD23, Data2: 0x5FDE, Data3: 0x4CDB, Data4: [8]byte{0xAE, 0xA4, 0xAF, 0x64, 0xB8, 0x3D, 0x78, 0xAB}}
+	iidIFileDialogCustomize = ole32.IID{Data1: 0xE6FDD21A, Data2: 0x163F, Data3: 0x4975, Data4: [8]byte{0x9C, 0x8C, 0xA6, 0x9F, 0x1B, 0xA3, 0x70, 0x34}}
+	iidIShellItem           = ole32.IID{Data1: 0x43826D1E, Data2: 0xE718, Data3: 0x42EE, Data4: [8]byte{0xBC, 0x55, 0xA1, 0xE2, 0x61, 0xC3, 0x7B, 0xFE}}
+)
+
+const (
+	hrInvalidArg     win.HRESULT = -2147024809 // E_INVALIDARG
+	hrNotImpl        win.HRESULT = -2147467263 // E_NOTIMPL
+	hrErrorCancelled win.HRESULT = -2147023673 // HRESULT_FROM_WIN32(ERROR_CANCELLED)
+)
+
+const (
+	sigdnFileSysPath = 0x80058000
+
+	fdapBottom = 0
+	fdapTop    = 1
+
+	fosOverwritePrompt  = 0x00000002
+	fosStrictFileTypes  = 0x00000004
+	fosNoChangeDir      = 0x00000008
+	fosPickFolders      = 0x00000020
+	fosForceFileSystem  = 0x00000040
+	fosAllowMultiSelect = 0x00000200
+	fosPathMustExist    = 0x00000800
+	fosFileMustExist    = 0x00001000
+	fosForceShowHidden  = 0x10000000
+)
+
+type comdlgFilterSpec struct {
+	pszName *uint16
+	pszSpec *uint16
+}
+
+type iFileDialogVtbl struct {
+	QueryInterface      uintptr
+	AddRef              uintptr
+	Release             uintptr
+	Show                uintptr
+	SetFileTypes        uintptr
+	SetFileTypeIndex    uintptr
+	GetFileTypeIndex    uintptr
+	Advise              uintptr
+	Unadvise            uintptr
+	SetOptions          uintptr
+	GetOptions          uintptr
+	SetDefaultFolder    uintptr
+	SetFolder           uintptr
+	GetFolder           uintptr
+	GetCurrentSelection uintptr
+	SetFileName         uintptr
+	GetFileName         uintptr
+	SetTitle            uintptr
+	SetOkButtonLabel    uintptr
+	SetFileNameLabel    uintptr
+	GetResult           uintptr
+	AddPlace            uintptr
+	SetDefaultExtension uintptr
+	Close               uintptr
+	SetClientGuid       uintptr
+	ClearClientData     uintptr
+	SetFilter           uintptr
+
+	// IFileOpenDialog only
+	GetResults       uintptr
+	GetSelectedItems uintptr
+}
+
+type iFileDialog struct {
+	lpVtbl *iFileDialogVtbl
+}
+
+func (obj *iFileDialog) QueryInterface(riid *ole32.IID, ppvObject *unsafe.Pointer) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.QueryInterface, 3,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(unsafe.Pointer(riid)),
+		uintptr(unsafe.Pointer(ppvObject)))
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) Release() uint32 {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.Release, 1,
+		uintptr(unsafe.Pointer(obj)),
+		0,
+		0)
+	return uint32(ret)
+}
+
+func (obj *iFileDialog) Show(hwndOwner handle.HWND) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.Show, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(hwndOwner),
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) SetFileTypes(specs []comdlgFilterSpec) win.HRESULT {
+	if len(specs) == 0 {
+		return win.S_OK
+	}
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.SetFileTypes, 3,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(len(specs)),
+		uintptr(unsafe.Pointer(&specs[0])))
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) SetFileTypeIndex(index uint32) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.SetFileTypeIndex, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(index),
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) GetFileTypeIndex(index *uint32) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.GetFileTypeIndex, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(unsafe.Pointer(index)),
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) SetOptions(fos uint32) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.SetOptions, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(fos),
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) GetOptions(fos *uint32) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.GetOptions, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(unsafe.Pointer(fos)),
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) SetFolder(item *iShellItem) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.SetFolder, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(unsafe.Pointer(item)),
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) setString(method uintptr, s string) win.HRESULT {
+	p, err := syscall.UTF16PtrFromString(s)
+	if err != nil {
+		return hrInvalidArg
+	}
+	ret, _, _ := syscall.Syscall(method, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(unsafe.Pointer(p)),
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) SetFileName(name string) win.HRESULT {
+	return obj.setString(obj.lpVtbl.SetFileName, name)
+}
+
+func (obj *iFileDialog) SetTitle(title string) win.HRESULT {
+	return obj.setString(obj.lpVtbl.SetTitle, title)
+}
+
+func (obj *iFileDialog) SetOkButtonLabel(label string) win.HRESULT {
+	return obj.setString(obj.lpVtbl.SetOkButtonLabel, label)
+}
+
+func (obj *iFileDialog) SetFileNameLabel(label string) win.HRESULT {
+	return obj.setString(obj.lpVtbl.SetFileNameLabel, label)
+}
+
+func (obj *iFileDialog) SetDefaultExtension(ext string) win.HRESULT {
+	return obj.setString(obj.lpVtbl.SetDefaultExtension, ext)
+}
+
+func (obj *iFileDialog) GetResult(item **iShellItem) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.GetResult, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(unsafe.Pointer(item)),
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) AddPlace(item *iShellItem, fdap uintptr) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.AddPlace, 3,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(unsafe.Pointer(item)),
+		fdap)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialog) SetClientGuid(guid *syscall.GUID) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.SetClientGuid, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(unsafe.Pointer(guid)),
+		0)
+	return win.HRESULT(ret)
+}
+
+// GetResults must only be called on dialogs created as IFileOpenDialog.
+func (obj *iFileDialog) GetResults(items **iShellItemArray) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.GetResults, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(unsafe.Pointer(items)),
+		0)
+	return win.HRESULT(ret)
+}
+
+type iShellItemVtbl struct {
+	QueryInterface uintptr
+	AddRef         uintptr
+	Release        uintptr
+	BindToHandler  uintptr
+	GetParent      uintptr
+	GetDisplayName uintptr
+	GetAttributes  uintptr
+	Compare        uintptr
+}
+
+type iShellItem struct {
+	lpVtbl *iShellItemVtbl
+}
+
+func newShellItemFromPath(path string) (*iShellItem, win.HRESULT) {
+	if err := procSHCreateItemFromParsingName.Find(); err != nil {
+		return nil, hrNotImpl
+	}
+
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, hrInvalidArg
+	}
+
+	var item *iShellItem
+	ret, _, _ := syscall.Syscall6(procSHCreateItemFromParsingName.Addr(), 4,
+		uintptr(unsafe.Pointer(p)),
+		0,
+		uintptr(unsafe.Pointer(&iidIShellItem)),
+		uintptr(unsafe.Pointer(&item)),
+		0,
+		0)
+	return item, win.HRESULT(ret)
+}
+
+func (obj *iShellItem) Release() uint32 {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.Release, 1,
+		uintptr(unsafe.Pointer(obj)),
+		0,
+		0)
+	return uint32(ret)
+}
+
+func (obj *iShellItem) FileSysPath() (string, win.HRESULT) {
+	var p *uint16
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.GetDisplayName, 3,
+		uintptr(unsafe.Pointer(obj)),
+		sigdnFileSysPath,
+		uintptr(unsafe.Pointer(&p)))
+	if hr := win.HRESULT(ret); win.FAILED(hr) {
+		return "", hr
+	}
+	defer ole32.CoTaskMemFree(uintptr(unsafe.Pointer(p)))
+
+	return win.UTF16PtrToString(p), win.S_OK
+}
+
+type iShellItemArrayVtbl struct {
+	QueryInterface             uintptr
+	AddRef                     uintptr
+	Release                    uintptr
+	BindToHandler              uintptr
+	GetPropertyStore           uintptr
+	GetPropertyDescriptionList uintptr
+	GetAttributes              uintptr
+	GetCount                   uintptr
+	GetItemAt                  uintptr
+	EnumItems                  uintptr
+}
+
+type iShellItemArray struct {
+	lpVtbl *iShellItemArrayVtbl
+}
+
+func (obj *iShellItemArray) Release() uint32 {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.Release, 1,
+		uintptr(unsafe.Pointer(obj)),
+		0,
+		0)
+	return uint32(ret)
+}
+
+func (obj *iShellItemArray) GetCount(count *uint32) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.GetCount, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(unsafe.Pointer(count)),
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iShellItemArray) GetItemAt(index uint32, item **iShellItem) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.GetItemAt, 3,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(index),
+		uintptr(unsafe.Pointer(item)))
+	return win.HRESULT(ret)
+}
+
+type iFileDialogCustomizeVtbl struct {
+	QueryInterface         uintptr
+	AddRef                 uintptr
+	Release                uintptr
+	EnableOpenDropDown     uintptr
+	AddMenu                uintptr
+	AddPushButton          uintptr
+	AddComboBox            uintptr
+	AddRadioButtonList     uintptr
+	AddCheckButton         uintptr
+	AddEditBox             uintptr
+	AddSeparator           uintptr
+	AddText                uintptr
+	SetControlLabel        uintptr
+	GetControlState        uintptr
+	SetControlState        uintptr
+	GetEditBoxText         uintptr
+	SetEditBoxText         uintptr
+	GetCheckButtonState    uintptr
+	SetCheckButtonState    uintptr
+	AddControlItem         uintptr
+	RemoveControlItem      uintptr
+	RemoveAllControlItems  uintptr
+	GetControlItemState    uintptr
+	SetControlItemState    uintptr
+	GetSelectedControlItem uintptr
+	SetSelectedControlItem uintptr
+	StartVisualGroup       uintptr
+	EndVisualGroup         uintptr
+	MakeProminent          uintptr
+	SetControlItemText     uintptr
+}
+
+type iFileDialogCustomize struct {
+	lpVtbl *iFileDialogCustomizeVtbl
+}
+
+func (obj *iFileDialogCustomize) Release() uint32 {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.Release, 1,
+		uintptr(unsafe.Pointer(obj)),
+		0,
+		0)
+	return uint32(ret)
+}
+
+func (obj *iFileDialogCustomize) AddCheckButton(id uint32, label string, checked bool) win.HRESULT {
+	p, err := syscall.UTF16PtrFromString(label)
+	if err != nil {
+		return hrInvalidArg
+	}
+	ret, _, _ := syscall.Syscall6(obj.lpVtbl.AddCheckButton, 4,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(id),
+		uintptr(unsafe.Pointer(p)),
+		uintptr(win.BoolToBOOL(checked)),
+		0,
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialogCustomize) GetCheckButtonState(id uint32, checked *win.BOOL) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.GetCheckButtonState, 3,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(id),
+		uintptr(unsafe.Pointer(checked)))
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialogCustomize) AddComboBox(id uint32) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.AddComboBox, 2,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(id),
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialogCustomize) AddControlItem(id, itemID uint32, label string) win.HRESULT {
+	p, err := syscall.UTF16PtrFromString(label)
+	if err != nil {
+		return hrInvalidArg
+	}
+	ret, _, _ := syscall.Syscall6(obj.lpVtbl.AddControlItem, 4,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(id),
+		uintptr(itemID),
+		uintptr(unsafe.Pointer(p)),
+		0,
+		0)
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialogCustomize) GetSelectedControlItem(id uint32, itemID *uint32) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.GetSelectedControlItem, 3,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(id),
+		uintptr(unsafe.Pointer(itemID)))
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialogCustomize) SetSelectedControlItem(id, itemID uint32) win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.SetSelectedControlItem, 3,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(id),
+		uintptr(itemID))
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialogCustomize) StartVisualGroup(id uint32, label string) win.HRESULT {
+	p, err := syscall.UTF16PtrFromString(label)
+	if err != nil {
+		return hrInvalidArg
+	}
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.StartVisualGroup, 3,
+		uintptr(unsafe.Pointer(obj)),
+		uintptr(id),
+		uintptr(unsafe.Pointer(p)))
+	return win.HRESULT(ret)
+}
+
+func (obj *iFileDialogCustomize) EndVisualGroup() win.HRESULT {
+	ret, _, _ := syscall.Syscall(obj.lpVtbl.EndVisualGroup, 1,
+		uintptr(unsafe.Pointer(obj)),
+		0,
+		0)
+	return win.HRESULT(ret)
+}
@@ -0,0 +1,311 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/Gipcomp/winapi/bindexpr"
+	"github.com/Gipcomp/winapi/errs"
+)
+
+// ComputedPrefix starts the paths that refer to computed values of a
+// DataBinder, in dependencies as well as in DataBinder.Expression and
+// declarative Bind expressions, e.g. "computed.total".
+const ComputedPrefix = "computed."
+
+// ComputeFunc computes a value from the data source of a DataBinder.
+type ComputeFunc func(dataSource interface{}) (interface{}, error)
+
+type computedValue struct {
+	db               *DataBinder
+	name             string
+	dependencies     []string
+	compute          ComputeFunc
+	value            interface{}
+	err              error
+	valid            bool
+	changedPublisher EventPublisher
+}
+
+func (cv *computedValue) Value() interface{} {
+	if !cv.valid {
+		cv.recompute()
+	}
+
+	return cv.value
+}
+
+func (cv *computedValue) Changed() *Event {
+	return cv.changedPublisher.Event()
+}
+
+func (cv *computedValue) recompute() {
+	cv.valid = true
+
+	if cv.db.dataSource == nil {
+		cv.value, cv.err = nil, nil
+		return
+	}
+
+	cv.value, cv.err = cv.compute(cv.db.dataSource)
+	if cv.err != nil {
+		log.Printf("walk - failed to compute value %q: %s", cv.name, cv.err)
+		cv.value = nil
+	}
+}
+
+// dependsOn reports whether cv depends on the data source path, which
+// includes members of path and values containing it.
+func (cv *computedValue) dependsOn(path string) bool {
+	for _, dep := range cv.dependencies {
+		if dep == path || strings.HasPrefix(path, dep+".") || strings.HasPrefix(dep, path+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RegisterComputed registers a value called name that compute derives from
+// the data source and returns the Expression that provides it.
+//
+// dependencies are the data source paths, like "Items" or "Customer.Name",
+// and the other computed values, like "computed.subtotal", the value depends
+// on. It is recomputed and its Expression publishes Changed if it differs
+// afterwards, whenever a dependency is submitted, the DataBinder is reset or
+// its data source is replaced. Registering a value that would depend on
+// itself, directly or through other computed values, is an error.
+func (db *DataBinder) RegisterComputed(name string, dependencies []string, compute ComputeFunc) (Expression, error) {
+	if name == "" || strings.ContainsAny(name, ".?") {
+		return nil, errs.NewInvalidArgumentError(fmt.Sprintf("invalid computed value name %q", name))
+	}
+	if compute == nil {
+		return nil, errs.NewInvalidArgumentError("compute must not be nil")
+	}
+	if _, ok := db.name2Computed[name]; ok {
+		return nil, errs.NewInvalidArgumentError(fmt.Sprintf("computed value %q already registered", name))
+	}
+
+	// Dependencies are stored without "?", so they compare equal to the
+	// sources of submitted properties.
+	deps := make([]string, len(dependencies))
+	for i, dep := range dependencies {
+		parts, err := bindexpr.ParsePath(dep)
+		if err != nil {
+			return nil, errs.NewInvalidArgumentError(fmt.Sprintf("computed value %q: invalid dependency %q", name, dep))
+		}
+
+		names := make([]string, len(parts))
+		for j, part := range parts {
+			names[j] = part.Name
+		}
+		deps[i] = strings.Join(names, ".")
+	}
+
+	cv := &computedValue{
+		db:           db,
+		name:         name,
+		dependencies: deps,
+		compute:      compute,
+	}
+
+	if db.name2Computed == nil {
+		db.name2Computed = make(map[string]*computedValue)
+	}
+	db.name2Computed[name] = cv
+
+	if cycle := db.computedCycle(name); cycle != nil {
+		delete(db.name2Computed, name)
+		return nil, errs.NewInvalidArgumentError(fmt.Sprintf("computed value %q has a dependency cycle: %s", name, strings.Join(cycle, " -> ")))
+	}
+
+	return cv, nil
+}
+
+// Computed returns the Expression of the computed value called name, or nil.
+func (db *DataBinder) Computed(name string) Expression {
+	if cv, ok := db.name2Computed[name]; ok {
+		return cv
+	}
+
+	return nil
+}
+
+// ComputedNames returns the names of the registered computed values.
+func (db *DataBinder) ComputedNames() []string {
+	names := make([]string, 0, len(db.name2Computed))
+	for name := range db.name2Computed {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// computedDependencies returns the names of the computed values cv depends
+// on.
+func computedDependencies(cv *computedValue) []string {
+	var names []string
+
+	for _, dep := range cv.dependencies {
+		if name, _, ok := splitComputedPath(dep); ok {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// computedCycle returns the names forming a cycle through name, or nil.
+func (db *DataBinder) computedCycle(name string) []string {
+	var path []string
+	visited := make(map[string]bool)
+
+	var visit func(n string) bool
+	visit = func(n string) bool {
+		path = append(path, n)
+
+		cv, ok := db.name2Computed[n]
+		if ok {
+			for _, dep := range computedDependencies(cv) {
+				if dep == name {
+					path = append(path, dep)
+					return true
+				}
+				if !visited[dep] {
+					visited[dep] = true
+					if visit(dep) {
+						return true
+					}
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		return false
+	}
+
+	if visit(name) {
+		return path
+	}
+
+	return nil
+}
+
+// updateComputed recomputes the computed values that depend on one of
+// paths, or all of them if paths is nil, and those depending on these in
+// turn, and publishes Changed for those whose value changed.
+func (db *DataBinder) updateComputed(paths []string) {
+	if len(db.name2Computed) == 0 {
+		return
+	}
+
+	affected := make(map[string]bool)
+	for name, cv := range db.name2Computed {
+		if paths == nil {
+			affected[name] = true
+			continue
+		}
+		for _, path := range paths {
+			if cv.dependsOn(path) {
+				affected[name] = true
+				break
+			}
+		}
+	}
+
+	// Add the values that depend on affected ones.
+	for changed := true; changed; {
+		changed = false
+		for name, cv := range db.name2Computed {
+			if affected[name] {
+				continue
+			}
+			for _, dep := range computedDependencies(cv) {
+				if affected[dep] {
+					affected[name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	// Recompute dependencies first.
+	done := make(map[string]bool)
+	var update func(name string)
+	update = func(name string) {
+		if done[name] {
+			return
+		}
+		done[name] = true
+
+		cv := db.name2Computed[name]
+		for _, dep := range computedDependencies(cv) {
+			if affected[dep] {
+				update(dep)
+			}
+		}
+
+		oldValue, wasValid := cv.value, cv.valid
+		cv.recompute()
+		if !wasValid || !reflect.DeepEqual(oldValue, cv.value) {
+			cv.changedPublisher.Publish()
+		}
+	}
+
+	for name := range affected {
+		update(name)
+	}
+}
+
+// splitComputedPath splits path, which must start with ComputedPrefix, into
+// the name of a computed value and the path of a member of its value. If that
+// member is accessed with "?.", rest starts with "?." as well.
+func splitComputedPath(path string) (name, rest string, ok bool) {
+	if !strings.HasPrefix(path, ComputedPrefix) {
+		return "", "", false
+	}
+
+	parts, err := bindexpr.ParsePath(path)
+	if err != nil || len(parts) < 2 {
+		return "", "", false
+	}
+
+	if len(parts) > 2 {
+		offset := parts[2].Offset
+		if parts[2].NullSafe {
+			offset -= len("?.")
+		}
+		rest = path[offset:]
+	}
+
+	return parts[1].Name, rest, true
+}
+
+// computedExpression returns the Expression for path, which starts with
+// ComputedPrefix, or nil.
+func (db *DataBinder) computedExpression(path string) Expression {
+	name, rest, ok := splitComputedPath(path)
+	if !ok {
+		return nil
+	}
+
+	cv, ok := db.name2Computed[name]
+	if !ok {
+		return nil
+	}
+
+	if rest == "" {
+		return cv
+	}
+
+	return NewReflectExpression(cv, rest)
+}
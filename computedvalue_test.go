@@ -0,0 +1,253 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"errors"
+	"testing"
+)
+
+type testOrder struct {
+	Customer *testCustomer
+	Quantity int
+	Price    float64
+	Discount float64
+}
+
+// newTestOrderBinder returns a DataBinder for order with the computed values
+// subtotal, which depends on Quantity and Price, and total, which depends on
+// subtotal and Discount. calls counts the computations per name.
+func newTestOrderBinder(t *testing.T, order *testOrder) (db *DataBinder, calls map[string]int) {
+	t.Helper()
+
+	db = NewDataBinder()
+	calls = make(map[string]int)
+
+	if _, err := db.RegisterComputed("subtotal", []string{"Quantity", "Price"}, func(ds interface{}) (interface{}, error) {
+		calls["subtotal"]++
+		o := ds.(*testOrder)
+		return float64(o.Quantity) * o.Price, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.RegisterComputed("total", []string{"computed.subtotal", "Discount"}, func(ds interface{}) (interface{}, error) {
+		calls["total"]++
+		return db.Computed("subtotal").Value().(float64) - ds.(*testOrder).Discount, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.SetDataSource(order); err != nil {
+		t.Fatal(err)
+	}
+
+	return db, calls
+}
+
+func TestComputedValues(t *testing.T) {
+	order := &testOrder{Quantity: 2, Price: 10, Discount: 5}
+	db, _ := newTestOrderBinder(t, order)
+
+	if v := db.Computed("subtotal").Value(); v != 20.0 {
+		t.Errorf("subtotal = %v; want 20", v)
+	}
+	if v := db.Computed("total").Value(); v != 15.0 {
+		t.Errorf("total = %v; want 15", v)
+	}
+	if db.Computed("missing") != nil {
+		t.Error("Computed() of an unknown name is not nil")
+	}
+}
+
+func TestComputedValueDependencyTracking(t *testing.T) {
+	order := &testOrder{Quantity: 2, Price: 10, Discount: 5}
+	db, calls := newTestOrderBinder(t, order)
+
+	var changed []string
+	for _, name := range []string{"subtotal", "total"} {
+		name := name
+		db.Computed(name).Changed().Attach(func() {
+			changed = append(changed, name)
+		})
+	}
+
+	reset := func() {
+		changed = nil
+		for name := range calls {
+			delete(calls, name)
+		}
+	}
+
+	// Discount only affects total.
+	reset()
+	order.Discount = 7
+	db.updateComputed([]string{"Discount"})
+	if calls["subtotal"] != 0 || calls["total"] != 1 {
+		t.Errorf("Discount: calls = %v; want only total recomputed", calls)
+	}
+	if len(changed) != 1 || changed[0] != "total" || db.Computed("total").Value() != 13.0 {
+		t.Errorf("Discount: changed = %v, total = %v", changed, db.Computed("total").Value())
+	}
+
+	// Quantity affects subtotal and, through it, total, which is recomputed
+	// after subtotal.
+	reset()
+	order.Quantity = 3
+	db.updateComputed([]string{"Quantity"})
+	if calls["subtotal"] != 1 || calls["total"] != 1 {
+		t.Errorf("Quantity: calls = %v; want both recomputed once", calls)
+	}
+	if db.Computed("total").Value() != 23.0 {
+		t.Errorf("Quantity: total = %v; want 23", db.Computed("total").Value())
+	}
+	if len(changed) != 2 || changed[0] != "subtotal" || changed[1] != "total" {
+		t.Errorf("Quantity: changed = %v; want subtotal, total", changed)
+	}
+
+	// Unrelated paths recompute nothing.
+	reset()
+	db.updateComputed([]string{"Customer.Name"})
+	if len(calls) != 0 || len(changed) != 0 {
+		t.Errorf("unrelated: calls = %v, changed = %v", calls, changed)
+	}
+
+	// Values that stay the same are recomputed, but do not publish Changed.
+	reset()
+	order.Price, order.Quantity = 7.5, 4
+	db.updateComputed([]string{"Price", "Quantity"})
+	if calls["subtotal"] != 1 || len(changed) != 0 {
+		t.Errorf("same value: calls = %v, changed = %v", calls, changed)
+	}
+
+	// A new data source recomputes everything.
+	reset()
+	if err := db.SetDataSource(&testOrder{Quantity: 1, Price: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if calls["subtotal"] != 1 || calls["total"] != 1 || db.Computed("total").Value() != 1.0 {
+		t.Errorf("SetDataSource: calls = %v, total = %v", calls, db.Computed("total").Value())
+	}
+}
+
+func TestComputedValueDependsOn(t *testing.T) {
+	db := NewDataBinder()
+
+	e, err := db.RegisterComputed("city", []string{"Customer?.Address?.City"}, func(ds interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv := e.(*computedValue)
+
+	for path, want := range map[string]bool{
+		"Customer.Address.City":      true,
+		"Customer.Address":           true,
+		"Customer":                   true,
+		"Customer.Address.City.Code": true,
+		"Customer.Name":              false,
+		"CustomerAddress":            false,
+	} {
+		if got := cv.dependsOn(path); got != want {
+			t.Errorf("dependsOn(%q) = %t; want %t", path, got, want)
+		}
+	}
+}
+
+func TestComputedValueErrors(t *testing.T) {
+	db := NewDataBinder()
+	compute := func(interface{}) (interface{}, error) { return nil, nil }
+
+	for _, name := range []string{"", "a.b", "a?"} {
+		if _, err := db.RegisterComputed(name, nil, compute); err == nil {
+			t.Errorf("RegisterComputed(%q) succeeded", name)
+		}
+	}
+	if _, err := db.RegisterComputed("a", nil, nil); err == nil {
+		t.Error("RegisterComputed() with nil compute succeeded")
+	}
+	if _, err := db.RegisterComputed("a", []string{"Bad..Path"}, compute); err == nil {
+		t.Error("RegisterComputed() with an invalid dependency succeeded")
+	}
+
+	if _, err := db.RegisterComputed("a", []string{"computed.b"}, compute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.RegisterComputed("a", nil, compute); err == nil {
+		t.Error("registering a name twice succeeded")
+	}
+	if _, err := db.RegisterComputed("b", []string{"computed.c?.X"}, compute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.RegisterComputed("c", []string{"computed.a"}, compute); err == nil {
+		t.Error("registering a dependency cycle succeeded")
+	}
+	if db.Computed("c") != nil {
+		t.Error("a value with a dependency cycle stayed registered")
+	}
+
+	errCompute := errors.New("compute failed")
+	e, err := db.RegisterComputed("failing", nil, func(interface{}) (interface{}, error) { return 1, errCompute })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDataSource(&testOrder{}); err != nil {
+		t.Fatal(err)
+	}
+	if v := e.Value(); v != nil {
+		t.Errorf("Value() of a failing computation = %v; want nil", v)
+	}
+}
+
+func TestComputedValueExpression(t *testing.T) {
+	db := NewDataBinder()
+
+	var customer *testCustomer
+	if _, err := db.RegisterComputed("customer", nil, func(interface{}) (interface{}, error) {
+		return customer, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDataSource(&testOrder{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if db.Expression("computed.customer") != db.Computed("customer") {
+		t.Error("Expression() of a computed value differs from Computed()")
+	}
+
+	expr := db.Expression("computed.customer?.Address?.City")
+	if v := expr.Value(); v != nil {
+		t.Errorf("Value() through a nil computed value = %v; want nil", v)
+	}
+
+	customer = &testCustomer{Address: &testAddress{City: "Oslo"}}
+	db.updateComputed(nil)
+	if v := expr.Value(); v != "Oslo" {
+		t.Errorf("Value() = %v; want Oslo", v)
+	}
+}
+
+func TestSplitComputedPath(t *testing.T) {
+	for _, test := range []struct {
+		path, name, rest string
+		ok               bool
+	}{
+		{"computed.total", "total", "", true},
+		{"computed.customer.Name", "customer", "Name", true},
+		{"computed.customer?.Address?.City", "customer", "?.Address?.City", true},
+		{"computed", "", "", false},
+		{"Total", "", "", false},
+		{"computed.a..b", "", "", false},
+	} {
+		name, rest, ok := splitComputedPath(test.path)
+		if name != test.name || rest != test.rest || ok != test.ok {
+			t.Errorf("splitComputedPath(%q) = %q, %q, %t; want %q, %q, %t", test.path, name, rest, ok, test.name, test.rest, test.ok)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package crashreport collects information about a crashed process, writes
+// it to disk as a zip bundle and uploads such bundles to an HTTP endpoint.
+//
+// The package has no dependencies on the Windows API, so it can be used and
+// tested on any platform.
+package crashreport
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+const timeFormat = "20060102-150405"
+
+// Report holds everything known about a crash.
+type Report struct {
+	Time             time.Time `json:"time"`
+	OrganizationName string    `json:"organizationName,omitempty"`
+	ProductName      string    `json:"productName,omitempty"`
+	ProductVersion   string    `json:"productVersion,omitempty"`
+	GoVersion        string    `json:"goVersion"`
+	GOOS             string    `json:"goos"`
+	GOARCH           string    `json:"goarch"`
+	Message          string    `json:"message"`
+	Stack            string    `json:"-"`
+	Goroutines       string    `json:"-"`
+	LogLines         []string  `json:"-"`
+	WidgetTree       string    `json:"-"`
+}
+
+// New returns a Report for the panic value x, including the stack of the
+// calling goroutine and a dump of all goroutines. It should be called from
+// the deferred function that recovered x so the stack still shows where the
+// panic happened.
+func New(x interface{}) *Report {
+	return &Report{
+		Time:       time.Now(),
+		GoVersion:  runtime.Version(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		Message:    fmt.Sprint(x),
+		Stack:      string(debug.Stack()),
+		Goroutines: goroutineDump(),
+	}
+}
+
+func goroutineDump() string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 1<<24 {
+			return string(buf[:n])
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+// FileName returns the base name of the bundle WriteBundle creates.
+func (r *Report) FileName() string {
+	name := r.ProductName
+	if name == "" {
+		name = "crash"
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\/:*?"<>| `, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	return fmt.Sprintf("%s-%s.zip", name, r.Time.Format(timeFormat))
+}
+
+// WriteBundle writes the report as zip file into dirPath, creating the
+// directory if necessary, and returns the path of the file.
+func (r *Report) WriteBundle(dirPath string) (string, error) {
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(dirPath, r.FileName())
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.WriteZip(file); err != nil {
+		file.Close()
+		os.Remove(filePath)
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+// WriteZip writes the report as zip archive to w.
+func (r *Report) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	meta, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"report.json", string(meta)},
+		{"stack.txt", r.Stack},
+		{"goroutines.txt", r.Goroutines},
+		{"log.txt", strings.Join(r.LogLines, "\n")},
+		{"widgets.txt", r.WidgetTree},
+	}
+
+	for _, f := range files {
+		if f.content == "" {
+			continue
+		}
+
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.content); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// Summary returns a short human readable description of the report, suitable
+// for display in a dialog.
+func (r *Report) Summary() string {
+	var sb strings.Builder
+
+	if r.ProductName != "" {
+		fmt.Fprintf(&sb, "%s %s\n", r.ProductName, r.ProductVersion)
+	}
+	fmt.Fprintf(&sb, "%s %s/%s\n", r.GoVersion, r.GOOS, r.GOARCH)
+	fmt.Fprintf(&sb, "%s\n\n%s", r.Message, r.Stack)
+
+	return sb.String()
+}
+
+// Upload posts the bundle at filePath to url and reports an error unless the
+// server answers with a 2xx status code. If client is nil,
+// http.DefaultClient is used.
+func Upload(client *http.Client, url, filePath string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	req, err := http.NewRequest(http.MethodPost, url, file)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/zip")
+	req.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(filePath)))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("crashreport: upload failed: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// LogBuffer is an io.Writer that keeps the most recent lines written to it.
+// It is typically installed with log.SetOutput(io.MultiWriter(...)).
+type LogBuffer struct {
+	mutex    sync.Mutex
+	lines    []string
+	next     int
+	full     bool
+	partial  string
+	capacity int
+}
+
+// NewLogBuffer returns a LogBuffer that keeps up to capacity lines.
+func NewLogBuffer(capacity int) *LogBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &LogBuffer{lines: make([]string, capacity), capacity: capacity}
+}
+
+func (lb *LogBuffer) Write(p []byte) (int, error) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	s := lb.partial + string(p)
+	for {
+		i := strings.IndexByte(s, '\n')
+		if i == -1 {
+			break
+		}
+
+		lb.lines[lb.next] = strings.TrimSuffix(s[:i], "\r")
+		lb.next = (lb.next + 1) % lb.capacity
+		if lb.next == 0 {
+			lb.full = true
+		}
+
+		s = s[i+1:]
+	}
+	lb.partial = s
+
+	return len(p), nil
+}
+
+// Lines returns the buffered lines, oldest first.
+func (lb *LogBuffer) Lines() []string {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	var lines []string
+	if lb.full {
+		lines = append(lines, lb.lines[lb.next:]...)
+	}
+	lines = append(lines, lb.lines[:lb.next]...)
+	if lb.partial != "" {
+		lines = append(lines, lb.partial)
+	}
+
+	return lines
+}
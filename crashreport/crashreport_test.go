@@ -0,0 +1,214 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crashreport
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestReport() *Report {
+	r := New(errors.New("boom"))
+	r.Time = time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	r.ProductName = "My App: Pro"
+	r.ProductVersion = "1.2.3"
+	r.LogLines = []string{"first", "second"}
+	r.WidgetTree = "*winapi.MainWindow\n"
+
+	return r
+}
+
+func TestNew(t *testing.T) {
+	r := New("boom")
+
+	if r.Message != "boom" {
+		t.Errorf("Message = %q, want %q", r.Message, "boom")
+	}
+	if !strings.Contains(r.Stack, "TestNew") {
+		t.Errorf("Stack does not contain the calling test:\n%s", r.Stack)
+	}
+	if !strings.Contains(r.Goroutines, "goroutine") {
+		t.Errorf("Goroutines does not look like a goroutine dump:\n%s", r.Goroutines)
+	}
+}
+
+func TestFileName(t *testing.T) {
+	if got, want := newTestReport().FileName(), "My_App__Pro-20210304-050607.zip"; got != want {
+		t.Errorf("FileName() = %q, want %q", got, want)
+	}
+
+	r := newTestReport()
+	r.ProductName = ""
+	if got, want := r.FileName(), "crash-20210304-050607.zip"; got != want {
+		t.Errorf("FileName() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	r := newTestReport()
+	dirPath := filepath.Join(t.TempDir(), "reports")
+
+	filePath, err := r.WriteBundle(dirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(filePath) != dirPath {
+		t.Errorf("bundle written to %q, want a file in %q", filePath, dirPath)
+	}
+
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+	}
+
+	for _, name := range []string{"report.json", "stack.txt", "goroutines.txt", "log.txt", "widgets.txt"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("bundle lacks %s", name)
+		}
+	}
+	if got, want := files["log.txt"], "first\nsecond"; got != want {
+		t.Errorf("log.txt = %q, want %q", got, want)
+	}
+	if !strings.Contains(files["report.json"], `"productVersion": "1.2.3"`) {
+		t.Errorf("report.json lacks the product version:\n%s", files["report.json"])
+	}
+}
+
+func TestWriteZipSkipsEmptyFiles(t *testing.T) {
+	r := newTestReport()
+	r.LogLines = nil
+	r.WidgetTree = ""
+
+	var buf bytes.Buffer
+	if err := r.WriteZip(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if f.Name == "log.txt" || f.Name == "widgets.txt" {
+			t.Errorf("bundle contains empty %s", f.Name)
+		}
+	}
+}
+
+func TestUpload(t *testing.T) {
+	var gotBody []byte
+	var gotHeader http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", req.Method)
+		}
+		gotHeader = req.Header
+		gotBody, _ = io.ReadAll(req.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	filePath, err := newTestReport().WriteBundle(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Upload(server.Client(), server.URL, filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotBody, want) {
+		t.Error("uploaded body differs from the bundle")
+	}
+	if got := gotHeader.Get("Content-Type"); got != "application/zip" {
+		t.Errorf("Content-Type = %q, want application/zip", got)
+	}
+	if got := gotHeader.Get("Content-Disposition"); !strings.Contains(got, filepath.Base(filePath)) {
+		t.Errorf("Content-Disposition = %q, want it to name %s", got, filepath.Base(filePath))
+	}
+}
+
+func TestUploadErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	filePath, err := newTestReport().WriteBundle(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Upload(nil, server.URL, filePath)
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Upload() error = %v, want one mentioning the status", err)
+	}
+}
+
+func TestUploadMissingFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Error("unexpected request")
+	}))
+	defer server.Close()
+
+	if err := Upload(server.Client(), server.URL, filepath.Join(t.TempDir(), "missing.zip")); err == nil {
+		t.Error("Upload() of a missing file succeeded")
+	}
+}
+
+func TestLogBuffer(t *testing.T) {
+	lb := NewLogBuffer(3)
+
+	io.WriteString(lb, "one\ntwo\r\nthr")
+	io.WriteString(lb, "ee\nfour\nfive")
+
+	// The last 3 complete lines, followed by the partial one.
+	if got, want := lb.Lines(), []string{"two", "three", "four", "five"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+}
+
+func TestLogBufferNotFull(t *testing.T) {
+	lb := NewLogBuffer(0)
+
+	if got := lb.Lines(); len(got) != 0 {
+		t.Errorf("Lines() of an empty buffer = %q", got)
+	}
+
+	io.WriteString(lb, "a\nb\n")
+	if got, want := lb.Lines(), []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Gipcomp/winapi/crashreport"
+)
+
+const (
+	crashReportSendButton     = 101
+	crashReportDontSendButton = 102
+)
+
+// crashReportLogLines is the number of log lines kept by a CrashReporter that
+// was not created by NewCrashReporter.
+const crashReportLogLines = 100
+
+// CrashReporter writes a report bundle for panics recovered from window
+// procedures and Synchronize callbacks and offers to send it to Endpoint.
+// Event handlers are covered as long as they are called from one of those,
+// which is the case for events raised in response to window messages.
+//
+// Panics on other goroutines, or on the UI thread outside of a message loop,
+// e.g. while building a form before Run, are not recovered. Recover them and
+// call Report to include them.
+//
+// Install it with App().SetCrashReporter.
+type CrashReporter struct {
+	// DirPath is the directory report bundles are written to. It defaults to
+	// "<AppDataPath>/<OrganizationName>/<ProductName>/CrashReports".
+	DirPath string
+
+	// Endpoint is the URL report bundles are posted to. If it is empty, the
+	// user is only told where the bundle was written.
+	Endpoint string
+
+	// HTTPClient is used for uploads. If it is nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// Silent suppresses the "send report" dialog. If Endpoint is set, reports
+	// are sent without asking.
+	Silent bool
+
+	// KeepRunning prevents the application from exiting after a crash.
+	KeepRunning bool
+
+	logBuffer *crashreport.LogBuffer
+	reporting bool
+}
+
+// NewCrashReporter returns a CrashReporter that posts reports to endpoint and
+// includes the last logLines lines written to its LogWriter.
+func NewCrashReporter(endpoint string, logLines int) *CrashReporter {
+	return &CrashReporter{
+		Endpoint:  endpoint,
+		logBuffer: crashreport.NewLogBuffer(logLines),
+	}
+}
+
+// LogWriter returns a writer whose most recent lines are included in crash
+// reports. To include the output of the log package, install it like this:
+//
+//	log.SetOutput(io.MultiWriter(log.Writer(), cr.LogWriter()))
+func (cr *CrashReporter) LogWriter() io.Writer {
+	if cr.logBuffer == nil {
+		cr.logBuffer = crashreport.NewLogBuffer(crashReportLogLines)
+	}
+
+	return cr.logBuffer
+}
+
+func (cr *CrashReporter) dirPath() (string, error) {
+	if cr.DirPath != "" {
+		return cr.DirPath, nil
+	}
+
+	appDataPath, err := AppDataPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(
+		appDataPath,
+		App().OrganizationName(),
+		App().ProductName(),
+		"CrashReports"), nil
+}
+
+// Report builds a crash report for the panic value x, writes it to disk and,
+// unless Silent is set, asks the user whether to send it. It must be called
+// from the deferred function that recovered x.
+func (cr *CrashReporter) Report(x interface{}) {
+	if cr.reporting {
+		// A panic while showing the dialog must not recurse.
+		return
+	}
+	cr.reporting = true
+	defer func() {
+		cr.reporting = false
+	}()
+
+	report := crashreport.New(x)
+	report.OrganizationName = App().OrganizationName()
+	report.ProductName = App().ProductName()
+	report.ProductVersion = App().ProductVersion()
+	if cr.logBuffer != nil {
+		report.LogLines = cr.logBuffer.Lines()
+	}
+	if form := App().ActiveForm(); form != nil {
+		report.WidgetTree = widgetTreeSnapshot(form)
+	}
+
+	dirPath, err := cr.dirPath()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	filePath, err := report.WriteBundle(dirPath)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	send := cr.Silent && cr.Endpoint != ""
+	if !cr.Silent {
+		send = cr.ask(report, filePath)
+	}
+
+	if send {
+		if err := crashreport.Upload(cr.HTTPClient, cr.Endpoint, filePath); err != nil {
+			log.Print(err)
+			if !cr.Silent {
+				MsgBox(nil, report.ProductName, fmt.Sprintf("The crash report could not be sent:\n%s", err), MsgBoxIconError)
+			}
+		}
+	}
+
+	if !cr.KeepRunning {
+		App().Exit(1)
+	}
+}
+
+func (cr *CrashReporter) ask(report *crashreport.Report, filePath string) bool {
+	productName := report.ProductName
+	if productName == "" {
+		productName = "The application"
+	}
+
+	dlg := TaskDialog{
+		Title:               report.ProductName,
+		MainInstruction:     productName + " has encountered a problem.",
+		Content:             "A crash report has been written to disk.",
+		ExpandedInformation: report.Summary(),
+		Footer:              fmt.Sprintf(`<a href="%s">%s</a>`, filePath, filePath),
+		EnableHyperlinks:    true,
+		Icon:                TaskDialogIconError,
+		AllowCancel:         true,
+	}
+	dlg.HyperlinkClicked().Attach(func(href string) {
+		openInShell(filepath.Dir(href))
+	})
+
+	if cr.Endpoint != "" {
+		dlg.Content += " Please send it to help us fix the problem."
+		dlg.Buttons = []TaskDialogButton{
+			{ID: crashReportSendButton, Text: "Send report"},
+			{ID: crashReportDontSendButton, Text: "Don't send"},
+		}
+		dlg.DefaultButton = crashReportSendButton
+	} else {
+		dlg.CommonButtons = TaskDialogCloseButton
+	}
+
+	button, err := dlg.Show(App().ActiveForm())
+	if err != nil {
+		log.Print(err)
+		return false
+	}
+
+	return button == crashReportSendButton
+}
+
+// widgetTreeSnapshot returns an indented description of window and its
+// descendants.
+func widgetTreeSnapshot(window Window) string {
+	var sb strings.Builder
+
+	var walk func(w Window, depth int)
+	walk = func(w Window, depth int) {
+		b := w.BoundsPixels()
+		fmt.Fprintf(&sb, "%s%T name=%q bounds=%v visible=%t enabled=%t\n",
+			strings.Repeat("  ", depth), w, w.Name(), b, w.Visible(), w.Enabled())
+
+		if c, ok := w.(Container); ok && c.Children() != nil {
+			for i := 0; i < c.Children().Len(); i++ {
+				walk(c.Children().At(i), depth+1)
+			}
+		}
+	}
+	walk(window, 0)
+
+	return sb.String()
+}
@@ -0,0 +1,26 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestCrashReporterLogWriter(t *testing.T) {
+	// CrashReporters are also built as struct literals.
+	cr := &CrashReporter{Endpoint: "https://example.com/crashes"}
+
+	if _, err := io.WriteString(cr.LogWriter(), "first\nsecond\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cr.logBuffer.Lines(), []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+}
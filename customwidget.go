@@ -117,7 +117,7 @@ func (cw *CustomWidget) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uin
 	switch msg {
 	case user32.WM_PAINT:
 		if cw.paint == nil && cw.paintPixels == nil {
-			errs.NewError("paint(Pixels) func is nil")
+			errs.NewInvalidArgumentError("paint(Pixels) func is nil")
 			break
 		}
 
@@ -130,7 +130,7 @@ func (cw *CustomWidget) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uin
 			hdc = gdi32.HDC(wParam)
 		}
 		if hdc == 0 {
-			errs.NewError("BeginPaint failed")
+			errs.NewWin32Error("BeginPaint")
 			break
 		}
 		defer func() {
@@ -189,7 +189,7 @@ func (cw *CustomWidget) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uin
 func (cw *CustomWidget) bufferedPaint(canvas *Canvas, updateBounds Rectangle) error {
 	hdc := gdi32.CreateCompatibleDC(canvas.hdc)
 	if hdc == 0 {
-		return errs.NewError("CreateCompatibleDC failed")
+		return errs.NewWin32Error("CreateCompatibleDC")
 	}
 	defer gdi32.DeleteDC(hdc)
 
@@ -213,7 +213,7 @@ func (cw *CustomWidget) bufferedPaint(canvas *Canvas, updateBounds Rectangle) er
 
 	oldbmp := gdi32.SelectObject(buffered.hdc, gdi32.HGDIOBJ(hbmp))
 	if oldbmp == 0 {
-		return errs.NewError("SelectObject failed")
+		return errs.NewWin32Error("SelectObject")
 	}
 	defer gdi32.SelectObject(buffered.hdc, oldbmp)
 
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Gipcomp/winapi/bindexpr"
 	"github.com/Gipcomp/winapi/errs"
 )
 
@@ -32,10 +33,12 @@ type DataBinder struct {
 	property2ChangedHandle     map[Property]int
 	rootExpression             Expression
 	path2Expression            map[string]Expression
+	name2Computed              map[string]*computedValue
 	errorPresenter             ErrorPresenter
 	dataSourceChangedPublisher EventPublisher
 	canSubmitChangedPublisher  EventPublisher
 	submittedPublisher         EventPublisher
+	dirtyChangedPublisher      EventPublisher
 	resetPublisher             EventPublisher
 	autoSubmitDelay            time.Duration
 	autoSubmitTimer            *time.Timer
@@ -109,12 +112,14 @@ func (db *DataBinder) SetDataSource(dataSource interface{}) error {
 
 	if dataSource != nil {
 		if t := reflect.TypeOf(dataSource); t.Kind() != reflect.Map && (t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct) {
-			return errs.NewError("dataSource must be pointer to struct or map[string]interface{}")
+			return errs.NewInvalidArgumentError("dataSource must be pointer to struct or map[string]interface{}")
 		}
 	}
 
 	db.dataSource = dataSource
 
+	db.updateComputed(nil)
+
 	db.dataSourceChangedPublisher.Publish()
 
 	return nil
@@ -163,7 +168,9 @@ func (db *DataBinder) SetBoundWidgets(boundWidgets []Widget) {
 			db.property2Widget[prop] = widget
 
 			db.property2ChangedHandle[prop] = prop.Changed().Attach(func() {
-				db.dirty = true
+				if !db.inReset {
+					db.setDirty(true)
+				}
 
 				if db.autoSubmit && !db.autoSubmitSuspended {
 					if db.autoSubmitDelay > 0 {
@@ -187,6 +194,12 @@ func (db *DataBinder) SetBoundWidgets(boundWidgets []Widget) {
 							return
 						}
 
+						db.setDirty(false)
+
+						if source, ok := prop.Source().(string); ok {
+							db.updateComputed([]string{source})
+						}
+
 						db.submittedPublisher.Publish()
 					}
 				} else {
@@ -200,6 +213,12 @@ func (db *DataBinder) SetBoundWidgets(boundWidgets []Widget) {
 }
 
 func (db *DataBinder) Expression(path string) Expression {
+	if strings.HasPrefix(path, ComputedPrefix) {
+		if expr := db.computedExpression(path); expr != nil {
+			return expr
+		}
+	}
+
 	if db.path2Expression == nil {
 		db.path2Expression = make(map[string]Expression)
 	}
@@ -326,7 +345,9 @@ func (db *DataBinder) Reset() error {
 
 	db.validateProperties()
 
-	db.dirty = false
+	db.setDirty(false)
+
+	db.updateComputed(nil)
 
 	db.resetPublisher.Publish()
 
@@ -348,7 +369,9 @@ func (db *DataBinder) Submit() error {
 		return err
 	}
 
-	db.dirty = false
+	db.setDirty(false)
+
+	db.updateComputed(nil)
 
 	db.submittedPublisher.Publish()
 
@@ -359,6 +382,21 @@ func (db *DataBinder) Dirty() bool {
 	return db.dirty
 }
 
+// DirtyChanged returns the event that is published when Dirty changes.
+func (db *DataBinder) DirtyChanged() *Event {
+	return db.dirtyChangedPublisher.Event()
+}
+
+func (db *DataBinder) setDirty(dirty bool) {
+	if dirty == db.dirty {
+		return
+	}
+
+	db.dirty = dirty
+
+	db.dirtyChangedPublisher.Publish()
+}
+
 func (db *DataBinder) submitProperty(prop Property, field DataField) error {
 	if !field.CanSet() {
 		// FIXME: handle properly
@@ -416,15 +454,19 @@ func (db *DataBinder) fieldBoundToProperty(v reflect.Value, prop Property) DataF
 
 	f, err := dataFieldFromPath(v, source)
 	if err != nil {
-		panic(fmt.Sprintf("invalid source '%s'", source))
+		panic(fmt.Sprintf("invalid source '%s': %s", source, err))
+	}
+	if f == nil {
+		// A null-safe member access hit a nil value.
+		return nilField{prop: prop}
 	}
 
 	return f
 }
 
 func validateBindingMemberSyntax(member string) error {
-	// FIXME
-	return nil
+	_, err := bindexpr.ParsePath(member)
+	return err
 }
 
 type DataField interface {
@@ -439,6 +481,9 @@ func dataFieldFromPath(root reflect.Value, path string) (DataField, error) {
 	if err != nil {
 		return nil, err
 	}
+	if !value.IsValid() {
+		return nil, nil
+	}
 
 	// convert to DataField
 	if i, ok := value.Interface().(DataField); ok {
@@ -448,22 +493,51 @@ func dataFieldFromPath(root reflect.Value, path string) (DataField, error) {
 	return &reflectField{parent: parent, value: value, key: path[strings.LastIndexByte(path, '.')+1:]}, nil
 }
 
+// reflectValueFromPath resolves path, whose members are separated by '.' or
+// "?.", starting at root. If a member accessed with "?." is reached through a
+// nil pointer, interface or map, or a missing map element, the returned value
+// is invalid and err is nil. A leading "?." makes the first member null-safe,
+// for paths continuing the one of an expression root.
 func reflectValueFromPath(root reflect.Value, path string) (parent, value reflect.Value, err error) {
 	fullPath := path
 	value = root
 
+	nullSafe := strings.HasPrefix(path, "?.")
+	if nullSafe {
+		path = path[len("?."):]
+	}
 	for path != "" {
 		var name string
-		name, path = nextPathPart(path)
+		var nextNullSafe bool
+		name, path, nextNullSafe = nextPathPart(path)
 
 		var p reflect.Value
 		for value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				if nullSafe {
+					return parent, reflect.Value{}, nil
+				}
+
+				return parent, value, fmt.Errorf("nil value accessing '%s', use '?.' for null-safe access, path: '%s'", name, fullPath)
+			}
+
 			p = value
 			value = value.Elem()
 		}
 
 		switch value.Kind() {
+		case reflect.Invalid:
+			if nullSafe {
+				return parent, value, nil
+			}
+
+			return parent, value, fmt.Errorf("no value accessing '%s', use '?.' for null-safe access, path: '%s'", name, fullPath)
+
 		case reflect.Map:
+			if value.IsNil() && nullSafe {
+				return parent, reflect.Value{}, nil
+			}
+
 			parent = value
 			value = value.MapIndex(reflect.ValueOf(name))
 
@@ -527,18 +601,25 @@ func reflectValueFromPath(root reflect.Value, path string) (parent, value reflec
 				}
 			}
 		}
+
+		nullSafe = nextNullSafe
 	}
 
 	return parent, value, nil
 }
 
-func nextPathPart(p string) (next, remaining string) {
+// nextPathPart splits the first member off p. nullSafe reports whether it is
+// followed by "?." instead of '.'.
+func nextPathPart(p string) (next, remaining string, nullSafe bool) {
 	for i, r := range p {
 		if r == '.' {
-			return p[:i], p[i+1:]
+			if i > 0 && p[i-1] == '?' {
+				return p[:i-1], p[i+1:], true
+			}
+			return p[:i], p[i+1:], false
 		}
 	}
-	return p, ""
+	return p, "", false
 }
 
 type nilField struct {
@@ -558,7 +639,12 @@ func (nilField) Set(interface{}) error {
 }
 
 func (f nilField) Zero() interface{} {
-	return reflect.Zero(reflect.TypeOf(f.prop.Get())).Interface()
+	v := f.prop.Get()
+	if v == nil {
+		return nil
+	}
+
+	return reflect.Zero(reflect.TypeOf(v)).Interface()
 }
 
 type reflectField struct {
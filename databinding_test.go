@@ -0,0 +1,105 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testAddress struct {
+	City string
+}
+
+type testCustomer struct {
+	Name    string
+	Address *testAddress
+	Extra   map[string]interface{}
+}
+
+func (c *testCustomer) Label() string {
+	return "customer " + c.Name
+}
+
+type constExpression struct {
+	value interface{}
+}
+
+func (e constExpression) Value() interface{} {
+	return e.value
+}
+
+func (constExpression) Changed() *Event {
+	return nil
+}
+
+func TestReflectValueFromPathNullSafe(t *testing.T) {
+	root := reflect.ValueOf(&testCustomer{Name: "Alice"})
+
+	for _, path := range []string{"Address?.City", "Extra?.key?.City"} {
+		_, value, err := reflectValueFromPath(root, path)
+		if err != nil || value.IsValid() {
+			t.Errorf("reflectValueFromPath(%q) = %v, %v; want an invalid value and no error", path, value, err)
+		}
+	}
+
+	if _, _, err := reflectValueFromPath(root, "Address.City"); err == nil {
+		t.Error("reflectValueFromPath() through a nil pointer without '?.' succeeded")
+	}
+
+	root = reflect.ValueOf(&testCustomer{Address: &testAddress{City: "Berlin"}})
+	if _, value, err := reflectValueFromPath(root, "Address?.City"); err != nil || value.Interface() != "Berlin" {
+		t.Errorf("reflectValueFromPath() = %v, %v; want Berlin", value, err)
+	}
+}
+
+func TestReflectValueFromPathLeadingNullSafe(t *testing.T) {
+	root := reflect.ValueOf((*testCustomer)(nil))
+
+	if _, value, err := reflectValueFromPath(root, "?.Name"); err != nil || value.IsValid() {
+		t.Errorf("reflectValueFromPath(\"?.Name\") on nil = %v, %v; want an invalid value and no error", value, err)
+	}
+	if _, _, err := reflectValueFromPath(root, "Name"); err == nil {
+		t.Error("reflectValueFromPath(\"Name\") on nil succeeded")
+	}
+
+	root = reflect.ValueOf(&testCustomer{Name: "Bob"})
+	if _, value, err := reflectValueFromPath(root, "?.Label"); err != nil || value.Interface() != "customer Bob" {
+		t.Errorf("reflectValueFromPath(\"?.Label\") = %v, %v", value, err)
+	}
+}
+
+func TestReflectExpressionNullSafe(t *testing.T) {
+	expr := NewReflectExpression(constExpression{(*testCustomer)(nil)}, "?.Address?.City")
+	if v := expr.Value(); v != nil {
+		t.Errorf("Value() = %v; want nil", v)
+	}
+
+	expr = NewReflectExpression(constExpression{&testCustomer{Address: &testAddress{City: "Paris"}}}, "?.Address?.City")
+	if v := expr.Value(); v != "Paris" {
+		t.Errorf("Value() = %v; want Paris", v)
+	}
+}
+
+func TestDataFieldFromPathNullSafe(t *testing.T) {
+	f, err := dataFieldFromPath(reflect.ValueOf(&testCustomer{}), "Address?.City")
+	if err != nil || f != nil {
+		t.Errorf("dataFieldFromPath() = %v, %v; want nil, nil", f, err)
+	}
+
+	c := &testCustomer{Address: new(testAddress)}
+	f, err = dataFieldFromPath(reflect.ValueOf(c), "Address?.City")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("Rome"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Address.City != "Rome" {
+		t.Errorf("City = %q after Set; want Rome", c.Address.City)
+	}
+}
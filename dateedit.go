@@ -156,7 +156,7 @@ func (de *DateEdit) SetFormat(format string) error {
 	lp := uintptr(unsafe.Pointer(strPtr))
 
 	if de.SendMessage(commctrl.DTM_SETFORMAT, 0, lp) == 0 {
-		return errs.NewError("DTM_SETFORMAT failed")
+		return errs.NewWin32Error("DTM_SETFORMAT")
 	}
 
 	de.format = format
@@ -185,7 +185,7 @@ func (de *DateEdit) SetRange(min, max time.Time) error {
 		if min.Year() > max.Year() ||
 			min.Year() == max.Year() && min.Month() > max.Month() ||
 			min.Year() == max.Year() && min.Month() == max.Month() && min.Day() > max.Day() {
-			return errs.NewError("invalid range")
+			return errs.NewInvalidArgumentError("invalid range")
 		}
 	}
 
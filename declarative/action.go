@@ -201,7 +201,9 @@ func setActionBoolOrCondition(setBool func(bool) error, setCond func(winapi.Cond
 			if err := setBool(b); err != nil {
 				return err
 			}
-		} else if s := builder.conditionOrProperty(value); s != nil {
+		} else if s, err := builder.conditionOrProperty(value); err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		} else if s != nil {
 			if c, ok := s.(winapi.Condition); ok {
 				setCond(c)
 			} else {
@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	"github.com/Gipcomp/winapi"
+	"github.com/Gipcomp/winapi/bindexpr"
 	"gopkg.in/Knetic/govaluate.v3"
 )
 
@@ -24,7 +25,7 @@ var (
 
 func init() {
 	winapi.AppendToWalkInit(func() {
-		propertyRE = regexp.MustCompile("[A-Za-z]+[0-9A-Za-z]*(\\.[A-Za-z]+[0-9A-Za-z]*)+")
+		propertyRE = regexp.MustCompile("[A-Za-z]+[0-9A-Za-z]*(\\??\\.[A-Za-z]+[0-9A-Za-z]*)+")
 	})
 }
 
@@ -42,9 +43,17 @@ func MustRegisterCondition(name string, condition winapi.Condition) {
 	conditionsByName[name] = condition
 }
 
+// MustRegisterFunction makes fn available in the Bind expressions of all
+// forms. Functions set via the Functions field of a form take precedence.
+// See package bindexpr for the builtin functions.
+func MustRegisterFunction(name string, fn func(args ...interface{}) (interface{}, error)) {
+	bindexpr.MustRegister(name, fn)
+}
+
 type declWidget struct {
-	d Widget
-	w winapi.Window
+	d          Widget
+	w          winapi.Window
+	dataSource interface{} // of the nearest enclosing DataBinder, if declared
 }
 
 type Builder struct {
@@ -59,10 +68,12 @@ type Builder struct {
 	declWidgets              []declWidget
 	name2Window              map[string]winapi.Window
 	name2DataBinder          map[string]*winapi.DataBinder
+	dataBinders              []*winapi.DataBinder
 	deferredFuncs            []func() error
 	knownCompositeConditions map[string]winapi.Condition
 	expressions              map[string]winapi.Expression
 	functions                map[string]govaluate.ExpressionFunction
+	dataSources              []interface{}
 }
 
 func NewBuilder(parent winapi.Container) *Builder {
@@ -72,7 +83,7 @@ func NewBuilder(parent winapi.Container) *Builder {
 		dpi = parent.DPI()
 	}
 
-	return &Builder{
+	b := &Builder{
 		dpi:                      dpi,
 		parent:                   parent,
 		name2Window:              make(map[string]winapi.Window),
@@ -81,6 +92,12 @@ func NewBuilder(parent winapi.Container) *Builder {
 		expressions:              make(map[string]winapi.Expression),
 		functions:                make(map[string]govaluate.ExpressionFunction),
 	}
+
+	for name, fn := range bindexpr.Functions() {
+		b.functions[name] = govaluate.ExpressionFunction(fn)
+	}
+
+	return b
 }
 
 func (b *Builder) Parent() winapi.Container {
@@ -142,7 +159,11 @@ func (b *Builder) InitWidget(d Widget, w winapi.Window, customInit func() error)
 		}
 	}()
 
-	b.declWidgets = append(b.declWidgets, declWidget{d, w})
+	var dataSource interface{}
+	if len(b.dataSources) > 0 {
+		dataSource = b.dataSources[len(b.dataSources)-1]
+	}
+	b.declWidgets = append(b.declWidgets, declWidget{d, w, dataSource})
 
 	// Window
 	b.initAccessibility(d, w)
@@ -378,6 +399,15 @@ func (b *Builder) InitWidget(d Widget, w winapi.Window, customInit func() error)
 			}
 		}
 
+		if val := b.widgetValue.FieldByName("DataBinder"); val.IsValid() {
+			if dataSource := val.Interface().(DataBinder).DataSource; dataSource != nil {
+				b.dataSources = append(b.dataSources, dataSource)
+				defer func() {
+					b.dataSources = b.dataSources[:len(b.dataSources)-1]
+				}()
+			}
+		}
+
 		if val := b.widgetValue.FieldByName("Children"); val.IsValid() {
 			for _, child := range val.Interface().([]Widget) {
 				if err := child.Create(b); err != nil {
@@ -395,6 +425,7 @@ func (b *Builder) InitWidget(d Widget, w winapi.Window, customInit func() error)
 				db = dataB
 
 				b.name2DataBinder[dataBinder.Name] = db
+				b.dataBinders = append(b.dataBinders, db)
 
 				if ep := db.ErrorPresenter(); ep != nil {
 					if dep, ok := ep.(winapi.Disposable); ok {
@@ -624,14 +655,21 @@ func (b *Builder) initProperties() error {
 
 			case bindData:
 				if prop == nil {
-					panic(sf.Name + " is not a property")
+					return fmt.Errorf("%s: %s is not a property", declWidgetName(dw), sf.Name)
 				}
 
-				src := b.conditionOrProperty(val)
+				src, err := b.conditionOrProperty(val)
+				if err != nil {
+					return fmt.Errorf("%s.%s: %s", declWidgetName(dw), sf.Name, err)
+				}
 
 				if src == nil {
 					// No luck so far, so we assume the expression refers to
 					// something in the data source.
+					if err := validateDataSourcePath(val.expression, dw.dataSource); err != nil {
+						return fmt.Errorf("%s.%s: %s", declWidgetName(dw), sf.Name, err)
+					}
+
 					src = val.expression
 
 					if val.validator != nil {
@@ -651,7 +689,7 @@ func (b *Builder) initProperties() error {
 
 			case winapi.Condition:
 				if prop == nil {
-					panic(sf.Name + " is not a property")
+					return fmt.Errorf("%s: %s is not a property", declWidgetName(dw), sf.Name)
 				}
 
 				if err := prop.SetSource(val); err != nil {
@@ -673,11 +711,89 @@ func (b *Builder) initProperties() error {
 	return nil
 }
 
-func (b *Builder) conditionOrProperty(data Property) interface{} {
+// declWidgetName returns a description of dw for error messages.
+func declWidgetName(dw declWidget) string {
+	typ := reflect.TypeOf(dw.d).Name()
+
+	if name := dw.w.Name(); name != "" {
+		return fmt.Sprintf("%s %q", typ, name)
+	}
+
+	return typ
+}
+
+// validateDataSourcePath checks that expression, which could not be
+// evaluated by the Builder, is a path a DataBinder can resolve on
+// dataSource. If dataSource is nil, only the syntax is checked.
+func validateDataSourcePath(expression string, dataSource interface{}) error {
+	if !bindexpr.IsPath(expression) {
+		return fmt.Errorf(`Bind("%s"): not a data source path and refers to unknown widgets, data binders, conditions or expressions`, expression)
+	}
+
+	if dataSource == nil {
+		return nil
+	}
+
+	if _, err := bindexpr.ValidatePath(reflect.TypeOf(dataSource), expression); err != nil {
+		return fmt.Errorf(`Bind("%s"): %s`, expression, strings.TrimPrefix(err.Error(), "bindexpr: "))
+	}
+
+	return nil
+}
+
+// pathAfter returns the part of path, parsed into parts, after its first n
+// members. If the next member is accessed with "?.", the result starts with
+// "?." as well, so it is resolved null-safely on the value of the first n
+// members.
+func pathAfter(path string, parts []bindexpr.PathPart, n int) string {
+	if n >= len(parts) {
+		return ""
+	}
+
+	offset := parts[n].Offset
+	if parts[n].NullSafe {
+		offset -= len("?.")
+	}
+
+	return path[offset:]
+}
+
+// computedExpression returns the Expression for path, which refers to a
+// computed value that must be declared by exactly one DataBinder.
+func (b *Builder) computedExpression(path string) (winapi.Expression, error) {
+	parts, err := bindexpr.ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) < 2 {
+		return nil, fmt.Errorf(`computed value "%s" lacks a name`, path)
+	}
+	name := parts[1].Name
+
+	var expr winapi.Expression
+
+	for _, db := range b.dataBinders {
+		if db.Computed(name) == nil {
+			continue
+		}
+		if expr != nil {
+			return nil, fmt.Errorf(`computed value "%s" is declared by several data binders, prefix it with the name of one`, path)
+		}
+		expr = db.Expression(path)
+	}
+
+	if expr == nil {
+		return nil, fmt.Errorf(`unknown computed value "%s"`, path)
+	}
+
+	return expr, nil
+}
+
+func (b *Builder) conditionOrProperty(data Property) (interface{}, error) {
 	switch val := data.(type) {
 	case bindData:
 		if val.expression == "" {
-			return nil
+			return nil, nil
 		}
 
 		e := &expression{
@@ -686,13 +802,18 @@ func (b *Builder) conditionOrProperty(data Property) interface{} {
 		}
 
 		var singleExpr winapi.Expression
+		var subExprErr error
 
 		text := propertyRE.ReplaceAllStringFunc(val.expression, func(s string) string {
 			if _, ok := e.subExprsByPath[s]; !ok {
-				parts := strings.Split(s, ".")
+				parts, err := bindexpr.ParsePath(s)
+				if err != nil {
+					subExprErr = err
+					return s
+				}
 
-				if w, ok := b.name2Window[parts[0]]; ok {
-					if prop := w.AsWindowBase().Property(parts[1]); prop != nil {
+				if w, ok := b.name2Window[parts[0].Name]; ok {
+					if prop := w.AsWindowBase().Property(parts[1].Name); prop != nil {
 						if len(s) == len(val.expression) {
 							singleExpr = prop
 							return ""
@@ -701,28 +822,40 @@ func (b *Builder) conditionOrProperty(data Property) interface{} {
 						if len(parts) == 2 {
 							e.addSubExpression(s, prop)
 						} else {
-							e.addSubExpression(s, winapi.NewReflectExpression(prop, s[len(parts[0])+len(parts[1])+2:]))
+							e.addSubExpression(s, winapi.NewReflectExpression(prop, pathAfter(s, parts, 2)))
+						}
+					} else if subExprErr == nil {
+						subExprErr = fmt.Errorf(`invalid sub expression "%s": %s has no property %s`, s, parts[0].Name, parts[1].Name)
+					}
+				} else if db, ok := b.name2DataBinder[parts[0].Name]; ok {
+					e.addSubExpression(s, db.Expression(pathAfter(s, parts, 1)))
+				} else if expr, ok := b.expressions[parts[0].Name]; ok {
+					e.addSubExpression(s, winapi.NewReflectExpression(expr, pathAfter(s, parts, 1)))
+				} else if strings.HasPrefix(s, winapi.ComputedPrefix) {
+					if expr, err := b.computedExpression(s); err != nil {
+						if subExprErr == nil {
+							subExprErr = err
 						}
 					} else {
-						panic(fmt.Errorf(`invalid sub expression: "%s"`, s))
+						e.addSubExpression(s, expr)
 					}
-				} else if db, ok := b.name2DataBinder[parts[0]]; ok {
-					e.addSubExpression(s, db.Expression(s[len(parts[0])+1:]))
-				} else if expr, ok := b.expressions[parts[0]]; ok {
-					e.addSubExpression(s, winapi.NewReflectExpression(expr, s[len(parts[0])+1:]))
 				}
 			}
 
-			return strings.Replace(s, ".", "\\.", -1)
+			return strings.NewReplacer("?", "\\?", ".", "\\.").Replace(s)
 		})
 
+		if subExprErr != nil {
+			return nil, fmt.Errorf(`Bind("%s"): %s`, e.text, subExprErr)
+		}
+
 		if singleExpr != nil {
-			return singleExpr
+			return singleExpr, nil
 		}
 
 		expr, err := govaluate.NewEvaluableExpressionWithFunctions(text, b.functions)
 		if err != nil {
-			panic(fmt.Errorf(`invalid expression "%s": %s`, e.text, err.Error()))
+			return nil, fmt.Errorf(`Bind("%s"): invalid expression: %s`, e.text, err)
 		}
 
 		for _, token := range expr.Tokens() {
@@ -741,20 +874,20 @@ func (b *Builder) conditionOrProperty(data Property) interface{} {
 
 		if _, err := e.expr.Eval(e.subExprsByPath); err != nil {
 			// We hope for the best and leave it to a DataBinder...
-			return nil
+			return nil, nil
 		}
 
 		if _, ok := e.Value().(bool); ok {
-			return &boolExpression{expression: e}
+			return &boolExpression{expression: e}, nil
 		}
 
-		return e
+		return e, nil
 
 	case winapi.Expression:
-		return val
+		return val, nil
 	}
 
-	return nil
+	return nil, nil
 }
 
 type expression struct {
@@ -12,10 +12,19 @@ import (
 	"github.com/Gipcomp/winapi"
 )
 
+// Computed declares a computed value of a DataBinder, which can be used in
+// Bind expressions as "computed." + Name. See winapi.DataBinder.RegisterComputed.
+type Computed struct {
+	Name      string
+	DependsOn []string
+	Compute   winapi.ComputeFunc
+}
+
 type DataBinder struct {
 	AssignTo            **winapi.DataBinder
 	AutoSubmit          bool
 	AutoSubmitDelay     time.Duration
+	Computed            []Computed
 	DataSource          interface{}
 	ErrorPresenter      ErrorPresenter
 	Name                string
@@ -40,6 +49,12 @@ func (db DataBinder) create() (*winapi.DataBinder, error) {
 		b.SetErrorPresenter(ep)
 	}
 
+	for _, c := range db.Computed {
+		if _, err := b.RegisterComputed(c.Name, c.DependsOn, c.Compute); err != nil {
+			return nil, err
+		}
+	}
+
 	b.SetDataSource(db.DataSource)
 
 	b.SetAutoSubmit(db.AutoSubmit)
@@ -0,0 +1,155 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package declarative
+
+import (
+	"github.com/Gipcomp/winapi"
+	"github.com/Gipcomp/winapi/dock"
+)
+
+type DockManager struct {
+	// Window
+
+	Accessibility      Accessibility
+	Background         Brush
+	ContextMenuItems   []MenuItem
+	DoubleBuffering    bool
+	Enabled            Property
+	Font               Font
+	MaxSize            Size
+	MinSize            Size
+	Name               string
+	OnBoundsChanged    winapi.EventHandler
+	OnKeyDown          winapi.KeyEventHandler
+	OnKeyPress         winapi.KeyEventHandler
+	OnKeyUp            winapi.KeyEventHandler
+	OnMouseDown        winapi.MouseEventHandler
+	OnMouseMove        winapi.MouseEventHandler
+	OnMouseUp          winapi.MouseEventHandler
+	OnSizeChanged      winapi.EventHandler
+	Persistent         bool
+	RightToLeftReading bool
+	ToolTipText        Property
+	Visible            Property
+
+	// Widget
+
+	Alignment          Alignment2D
+	AlwaysConsumeSpace bool
+	Column             int
+	ColumnSpan         int
+	GraphicsEffects    []winapi.WidgetGraphicsEffect
+	Row                int
+	RowSpan            int
+	StretchFactor      int
+
+	// Container
+
+	// Children are created in the document area.
+	Children   []Widget
+	DataBinder DataBinder
+
+	// DockManager
+
+	AssignTo        **winapi.DockManager
+	DocumentLayout  Layout
+	OnLayoutChanged winapi.EventHandler
+	Panels          []DockPanel
+}
+
+// DockPanel describes a panel added to a DockManager, see
+// winapi.DockManager.AddPanel.
+type DockPanel struct {
+	AssignTo **winapi.DockPanel
+	Children []Widget
+	ID       string
+	Layout   Layout
+	Side     dock.Side
+	Title    string
+}
+
+func (dm DockManager) Create(builder *Builder) error {
+	w, err := winapi.NewDockManager(builder.Parent())
+	if err != nil {
+		return err
+	}
+
+	if dm.AssignTo != nil {
+		*dm.AssignTo = w
+	}
+
+	w.SetSuspended(true)
+	builder.Defer(func() error {
+		w.SetSuspended(false)
+		return nil
+	})
+
+	if dm.DocumentLayout != nil {
+		l, err := dm.DocumentLayout.Create()
+		if err != nil {
+			w.Dispose()
+			return err
+		}
+
+		if err := w.Document().SetLayout(l); err != nil {
+			w.Dispose()
+			return err
+		}
+	}
+
+	return builder.InitWidget(dm, w, func() error {
+		for _, p := range dm.Panels {
+			if err := p.create(builder, w); err != nil {
+				return err
+			}
+		}
+
+		if dm.OnLayoutChanged != nil {
+			w.LayoutChanged().Attach(dm.OnLayoutChanged)
+		}
+
+		return nil
+	})
+}
+
+func (p DockPanel) create(builder *Builder, dm *winapi.DockManager) error {
+	w, err := dm.AddPanel(p.ID, p.Title, p.Side)
+	if err != nil {
+		return err
+	}
+
+	if p.AssignTo != nil {
+		*p.AssignTo = w
+	}
+
+	content := w.Content()
+
+	if p.Layout != nil {
+		l, err := p.Layout.Create()
+		if err != nil {
+			return err
+		}
+
+		if err := content.SetLayout(l); err != nil {
+			return err
+		}
+	}
+
+	oldParent := builder.parent
+	builder.parent = content
+	defer func() {
+		builder.parent = oldParent
+	}()
+
+	for _, child := range p.Children {
+		if err := child.Create(builder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
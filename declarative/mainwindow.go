@@ -140,7 +140,17 @@ func (mw MainWindow) Create() error {
 		}
 
 		for _, sbi := range mw.StatusBarItems {
-			s := winapi.NewStatusBarItem()
+			var s *winapi.StatusBarItem
+			switch sbi.Kind {
+			case winapi.StatusBarItemProgress:
+				s = winapi.NewStatusBarProgressItem()
+
+			case winapi.StatusBarItemSpinner:
+				s = winapi.NewStatusBarSpinnerItem()
+
+			default:
+				s = winapi.NewStatusBarItem()
+			}
 			if sbi.AssignTo != nil {
 				*sbi.AssignTo = s
 			}
@@ -150,6 +160,18 @@ func (mw MainWindow) Create() error {
 			if sbi.Width > 0 {
 				s.SetWidth(sbi.Width)
 			}
+			s.SetAutoSize(sbi.AutoSize)
+			s.SetLink(sbi.Link)
+			if sbi.Widget != nil {
+				if err := sbi.Widget.Create(builder); err != nil {
+					return err
+				}
+
+				children := w.Children()
+				if err := s.SetWidget(children.At(children.Len() - 1)); err != nil {
+					return err
+				}
+			}
 			if sbi.OnClicked != nil {
 				s.Clicked().Attach(sbi.OnClicked)
 			}
@@ -215,9 +237,13 @@ func (mw MainWindow) Run() (int, error) {
 
 type StatusBarItem struct {
 	AssignTo    **winapi.StatusBarItem
+	AutoSize    bool
 	Icon        *winapi.Icon
+	Kind        winapi.StatusBarItemKind
+	Link        bool
 	Text        string
 	ToolTipText string
+	Widget      Widget
 	Width       int
 	OnClicked   winapi.EventHandler
 }
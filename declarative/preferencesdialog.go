@@ -0,0 +1,507 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package declarative
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Gipcomp/winapi"
+)
+
+// PreferencesDialog is a Dialog generated from a winapi.SettingsSchema. It
+// shows a group box per Category of the schema with an editor per setting,
+// a search box that filters the settings, and buttons to restore the
+// defaults, accept, cancel and apply the changes.
+//
+// Values are edited through a DataBinder and only written to Settings when
+// the changes are applied or the dialog is accepted.
+type PreferencesDialog struct {
+	AssignTo  **winapi.Dialog
+	Title     string
+	Schema    *winapi.SettingsSchema
+	Settings  winapi.Settings
+	Size      Size
+	MinSize   Size
+	OnApplied winapi.EventHandler
+}
+
+type preferencesRow struct {
+	sd       *winapi.SettingDescriptor
+	name     string
+	label    *winapi.Label
+	editor   *winapi.Composite
+	lineEdit *winapi.LineEdit
+	group    int
+}
+
+// Run creates and runs the dialog. If Settings is nil, App().Settings() is
+// used.
+func (pd PreferencesDialog) Run(owner winapi.Form) (int, error) {
+	if pd.Schema == nil {
+		return 0, errors.New("PreferencesDialog.Run: Schema must not be nil")
+	}
+
+	settings := pd.Settings
+	if settings == nil {
+		settings = winapi.App().Settings()
+	}
+	if settings == nil {
+		return 0, errors.New("PreferencesDialog.Run: App().Settings() must not be nil")
+	}
+
+	title := pd.Title
+	if title == "" {
+		title = "Preferences"
+	}
+	minSize := pd.MinSize
+	if minSize.Width == 0 && minSize.Height == 0 {
+		minSize = Size{400, 300}
+	}
+
+	var dlg *winapi.Dialog
+	if pd.AssignTo == nil {
+		pd.AssignTo = &dlg
+	}
+
+	var db *winapi.DataBinder
+	var acceptPB, cancelPB, applyPB *winapi.PushButton
+
+	descriptors := pd.Schema.Descriptors()
+	rows := make([]preferencesRow, len(descriptors))
+	data := make(map[string]interface{}, len(descriptors))
+
+	var categories []string
+	category2Rows := make(map[string][]int)
+	category2Index := make(map[string]int)
+
+	for i, sd := range descriptors {
+		row := &rows[i]
+		row.sd = sd
+		row.name = fmt.Sprintf("s%d", i)
+
+		value, ok := settings.Get(sd.Key)
+		data[row.name] = preferencesEditorValue(sd, value, ok)
+
+		category := sd.Category
+		if category == "" {
+			category = "General"
+		}
+		if _, ok := category2Rows[category]; !ok {
+			category2Index[category] = len(categories)
+			categories = append(categories, category)
+		}
+		row.group = category2Index[category]
+		category2Rows[category] = append(category2Rows[category], i)
+	}
+
+	groups := make([]*winapi.GroupBox, len(categories))
+	groupWidgets := make([]Widget, 0, len(categories)+1)
+
+	for gi, category := range categories {
+		var children []Widget
+
+		for r, i := range category2Rows[category] {
+			children = append(children,
+				Label{
+					AssignTo:    &rows[i].label,
+					Row:         r,
+					Column:      0,
+					Text:        rows[i].sd.DisplayTitle() + ":",
+					ToolTipText: rows[i].sd.Description,
+				},
+				Composite{
+					AssignTo:    &rows[i].editor,
+					Row:         r,
+					Column:      1,
+					Layout:      HBox{MarginsZero: true},
+					ToolTipText: rows[i].sd.Description,
+					Children:    preferencesEditor(&rows[i], pd.AssignTo),
+				},
+			)
+		}
+
+		groupWidgets = append(groupWidgets, GroupBox{
+			AssignTo: &groups[gi],
+			Title:    category,
+			Layout:   Grid{Columns: 2},
+			Children: children,
+		})
+	}
+	groupWidgets = append(groupWidgets, VSpacer{})
+
+	filter := func(text string) {
+		text = strings.ToLower(strings.TrimSpace(text))
+
+		groupVisible := make([]bool, len(groups))
+
+		for i := range rows {
+			row := &rows[i]
+
+			visible := text == "" ||
+				strings.Contains(strings.ToLower(row.sd.DisplayTitle()), text) ||
+				strings.Contains(strings.ToLower(row.sd.Description), text) ||
+				strings.Contains(strings.ToLower(row.sd.Key), text) ||
+				strings.Contains(strings.ToLower(categories[row.group]), text)
+
+			row.label.SetVisible(visible)
+			row.editor.SetVisible(visible)
+
+			if visible {
+				groupVisible[row.group] = true
+			}
+		}
+
+		for gi, gb := range groups {
+			gb.SetVisible(groupVisible[gi])
+		}
+	}
+
+	apply := func() bool {
+		if err := db.Submit(); err != nil {
+			return false
+		}
+
+		var messages []string
+		for i := range rows {
+			row := &rows[i]
+
+			value, err := preferencesSettingValue(row.sd, data[row.name])
+			if err == nil {
+				err = preferencesStore(settings, row.sd, value)
+			}
+			if err != nil {
+				messages = append(messages, fmt.Sprintf("%s: %v", row.sd.DisplayTitle(), preferencesErrorMessage(err)))
+			}
+		}
+
+		if len(messages) > 0 {
+			winapi.MsgBox(*pd.AssignTo, title, strings.Join(messages, "\n"), winapi.MsgBoxIconError)
+			return false
+		}
+
+		if pd.OnApplied != nil {
+			pd.OnApplied()
+		}
+
+		return true
+	}
+
+	var searchLE *winapi.LineEdit
+
+	return Dialog{
+		AssignTo:      pd.AssignTo,
+		Title:         title,
+		MinSize:       minSize,
+		Size:          pd.Size,
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		DataBinder: DataBinder{
+			AssignTo:       &db,
+			DataSource:     data,
+			ErrorPresenter: ToolTipErrorPresenter{},
+			OnCanSubmitChanged: func() {
+				if applyPB != nil {
+					applyPB.SetEnabled(db.CanSubmit())
+				}
+			},
+		},
+		Layout: VBox{},
+		Children: []Widget{
+			LineEdit{
+				AssignTo:  &searchLE,
+				CueBanner: "Search",
+				OnTextChanged: func() {
+					filter(searchLE.Text())
+				},
+			},
+			ScrollView{
+				HorizontalFixed: true,
+				Layout:          VBox{MarginsZero: true},
+				Children:        groupWidgets,
+			},
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					PushButton{
+						Text: "Restore Defaults",
+						OnClicked: func() {
+							// Applying removes the keys again, see
+							// preferencesStore.
+							for i := range rows {
+								data[rows[i].name] = preferencesEditorValue(rows[i].sd, "", false)
+							}
+							db.Reset()
+						},
+					},
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "OK",
+						OnClicked: func() {
+							if apply() {
+								(*pd.AssignTo).Accept()
+							}
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							(*pd.AssignTo).Cancel()
+						},
+					},
+					PushButton{
+						AssignTo: &applyPB,
+						Text:     "Apply",
+						OnClicked: func() {
+							apply()
+						},
+					},
+				},
+			},
+		},
+	}.Run(owner)
+}
+
+// preferencesEditor returns the editor widgets for row.
+func preferencesEditor(row *preferencesRow, dlg **winapi.Dialog) []Widget {
+	sd := row.sd
+
+	switch sd.Type {
+	case winapi.SettingBool:
+		return []Widget{
+			CheckBox{Checked: Bind(row.name)},
+			HSpacer{},
+		}
+
+	case winapi.SettingInt, winapi.SettingFloat:
+		ne := NumberEdit{
+			SpinButtonsVisible: sd.Type == winapi.SettingInt,
+		}
+		if sd.Type == winapi.SettingFloat {
+			ne.Decimals = 2
+		}
+
+		min, max := -math.MaxFloat64, math.MaxFloat64
+		if sd.Min != nil {
+			min = preferencesFloat(sd.Min)
+		}
+		if sd.Max != nil {
+			max = preferencesFloat(sd.Max)
+		}
+		if sd.Min != nil || sd.Max != nil {
+			ne.MinValue, ne.MaxValue = min, max
+			ne.Value = Bind(row.name, Range{Min: min, Max: max})
+		} else {
+			ne.Value = Bind(row.name)
+		}
+
+		return []Widget{ne}
+	}
+
+	if sd.Type == winapi.SettingString && len(sd.Choices) > 0 {
+		return []Widget{
+			ComboBox{
+				Model: sd.Choices,
+				Value: Bind(row.name, SelRequired{}),
+			},
+		}
+	}
+
+	var validator Validator = ValidatorRef{sd}
+	if sd.Type == winapi.SettingString && sd.Pattern != "" {
+		validator = Regexp{Pattern: sd.Pattern}
+	}
+
+	le := LineEdit{
+		AssignTo: &row.lineEdit,
+		Text:     Bind(row.name, validator),
+	}
+
+	var browse func()
+
+	switch sd.Type {
+	case winapi.SettingPath:
+		browse = func() {
+			fd := winapi.FileDialog{FilePath: row.lineEdit.Text(), Title: sd.DisplayTitle()}
+
+			var accepted bool
+			if sd.BrowseFolder {
+				fd.InitialDirPath = fd.FilePath
+				accepted, _ = fd.ShowBrowseFolder(*dlg)
+			} else {
+				accepted, _ = fd.ShowOpen(*dlg)
+			}
+
+			if accepted {
+				row.lineEdit.SetText(fd.FilePath)
+			}
+		}
+
+	case winapi.SettingColor:
+		browse = func() {
+			var cd winapi.ColorDialog
+			if value, err := sd.Parse(row.lineEdit.Text()); err == nil {
+				cd.Color = value.(winapi.Color)
+			}
+
+			if accepted, _ := cd.Show(*dlg); accepted {
+				if text, err := sd.Format(cd.Color); err == nil {
+					row.lineEdit.SetText(text)
+				}
+			}
+		}
+
+	case winapi.SettingFont:
+		browse = func() {
+			fd := winapi.FontDialog{Effects: true}
+			if value, err := sd.Parse(row.lineEdit.Text()); err == nil {
+				fd.Font = value.(*winapi.Font)
+			}
+
+			if accepted, _ := fd.Show(*dlg); accepted {
+				if text, err := sd.Format(fd.Font); err == nil {
+					row.lineEdit.SetText(text)
+				}
+			}
+		}
+
+	case winapi.SettingShortcut:
+		le.ReadOnly = true
+		le.CueBanner = "Press a key combination"
+		le.OnKeyDown = func(key winapi.Key) {
+			switch key {
+			case winapi.KeyShift, winapi.KeyControl, winapi.KeyAlt:
+				return
+
+			case winapi.KeyBack, winapi.KeyDelete:
+				if winapi.ModifiersDown() == 0 {
+					row.lineEdit.SetText("")
+					return
+				}
+			}
+
+			row.lineEdit.SetText(winapi.Shortcut{Modifiers: winapi.ModifiersDown(), Key: key}.String())
+		}
+	}
+
+	if browse == nil {
+		return []Widget{le}
+	}
+
+	return []Widget{
+		le,
+		PushButton{
+			Text:      "...",
+			MaxSize:   Size{Width: 30},
+			OnClicked: browse,
+		},
+	}
+}
+
+// preferencesEditorValue returns the value the editor of sd is bound to for
+// the stored value s, or the default if ok is false or s is invalid.
+func preferencesEditorValue(sd *winapi.SettingDescriptor, s string, ok bool) interface{} {
+	value := sd.DefaultValue()
+	if ok {
+		if v, err := sd.Parse(s); err == nil {
+			value = v
+		}
+	}
+
+	switch sd.Type {
+	case winapi.SettingBool, winapi.SettingFloat:
+		return value
+
+	case winapi.SettingInt:
+		return float64(value.(int))
+	}
+
+	text, err := sd.Format(value)
+	if err != nil {
+		return ""
+	}
+
+	return text
+}
+
+// preferencesSettingValue converts the editor value v back to the string
+// stored in Settings.
+func preferencesSettingValue(sd *winapi.SettingDescriptor, v interface{}) (string, error) {
+	var value interface{}
+
+	switch v := v.(type) {
+	case string:
+		if sd.Type == winapi.SettingString || sd.Type == winapi.SettingPath {
+			value = v
+		} else {
+			var err error
+			if value, err = sd.Parse(v); err != nil {
+				return "", err
+			}
+		}
+
+	case float64:
+		if sd.Type == winapi.SettingInt {
+			value = int(math.Round(v))
+		} else {
+			value = v
+		}
+
+	default:
+		value = v
+	}
+
+	return sd.Format(value)
+}
+
+// preferencesStore puts value into settings, if it differs from the stored
+// one. A value equal to the default is removed instead, so defaults are not
+// pinned in settings and lower layers of a LayeredSettings stay effective.
+func preferencesStore(settings winapi.Settings, sd *winapi.SettingDescriptor, value string) error {
+	stored, ok := settings.Get(sd.Key)
+
+	defaultValue, err := sd.Format(sd.DefaultValue())
+	isDefault := err == nil && value == defaultValue
+
+	if isDefault && ok {
+		if err := settings.Remove(sd.Key); err != nil {
+			return err
+		}
+
+		// Another layer may still provide a value.
+		stored, ok = settings.Get(sd.Key)
+	}
+
+	if ok && stored == value || !ok && isDefault {
+		return nil
+	}
+
+	return settings.Put(sd.Key, value)
+}
+
+func preferencesFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	}
+
+	return 0
+}
+
+func preferencesErrorMessage(err error) string {
+	if msg, ok := err.(interface{ Message() string }); ok {
+		return msg.Message()
+	}
+
+	return err.Error()
+}
@@ -51,8 +51,9 @@ type HSplitter struct {
 
 	// Splitter
 
-	AssignTo    **winapi.Splitter
-	HandleWidth int
+	AssignTo         **winapi.Splitter
+	HandleWidth      int
+	HandlesFocusable bool
 }
 
 func (s HSplitter) Create(builder *Builder) error {
@@ -78,6 +79,10 @@ func (s HSplitter) Create(builder *Builder) error {
 			}
 		}
 
+		if err := w.SetHandlesFocusable(s.HandlesFocusable); err != nil {
+			return err
+		}
+
 		return nil
 	})
 }
@@ -122,8 +127,9 @@ type VSplitter struct {
 
 	// Splitter
 
-	AssignTo    **winapi.Splitter
-	HandleWidth int
+	AssignTo         **winapi.Splitter
+	HandleWidth      int
+	HandlesFocusable bool
 }
 
 func (s VSplitter) Create(builder *Builder) error {
@@ -149,6 +155,10 @@ func (s VSplitter) Create(builder *Builder) error {
 			}
 		}
 
+		if err := w.SetHandlesFocusable(s.HandlesFocusable); err != nil {
+			return err
+		}
+
 		return nil
 	})
 }
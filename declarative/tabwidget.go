@@ -50,7 +50,13 @@ type TabWidget struct {
 	ContentMargins        Margins
 	ContentMarginsZero    bool
 	OnCurrentIndexChanged winapi.EventHandler
+	OnPageClosed          winapi.TabPageEventHandler
+	OnPageClosing         winapi.TabPageCancelEventHandler
+	OnPagesReordered      winapi.EventHandler
 	Pages                 []TabPage
+	TabsClosable          bool
+	TabsDetachable        bool
+	TabsMovable           bool
 }
 
 func (tw TabWidget) Create(builder *Builder) error {
@@ -64,6 +70,10 @@ func (tw TabWidget) Create(builder *Builder) error {
 	}
 
 	return builder.InitWidget(tw, w, func() error {
+		w.SetTabsClosable(tw.TabsClosable)
+		w.SetTabsMovable(tw.TabsMovable)
+		w.SetTabsDetachable(tw.TabsDetachable)
+
 		for _, tp := range tw.Pages {
 			var wp *winapi.TabPage
 			if tp.AssignTo == nil {
@@ -86,6 +96,15 @@ func (tw TabWidget) Create(builder *Builder) error {
 		if tw.OnCurrentIndexChanged != nil {
 			w.CurrentIndexChanged().Attach(tw.OnCurrentIndexChanged)
 		}
+		if tw.OnPageClosing != nil {
+			w.PageClosing().Attach(tw.OnPageClosing)
+		}
+		if tw.OnPageClosed != nil {
+			w.PageClosed().Attach(tw.OnPageClosed)
+		}
+		if tw.OnPagesReordered != nil {
+			w.PagesReordered().Attach(tw.OnPagesReordered)
+		}
 
 		return nil
 	})
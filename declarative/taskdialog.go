@@ -0,0 +1,125 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package declarative
+
+import (
+	"time"
+
+	"github.com/Gipcomp/winapi"
+)
+
+type TaskDialogButton struct {
+	ID   int
+	Text string
+}
+
+type TaskDialog struct {
+	AssignTo                **winapi.TaskDialog
+	Title                   string
+	MainInstruction         string
+	Content                 string
+	ExpandedInformation     string
+	ExpandedControlText     string
+	CollapsedControlText    string
+	ExpandedByDefault       bool
+	ExpandFooterArea        bool
+	Footer                  string
+	Icon                    winapi.TaskDialogIcon
+	CustomIcon              *winapi.Icon
+	FooterIcon              winapi.TaskDialogIcon
+	CommonButtons           winapi.TaskDialogCommonButtons
+	Buttons                 []TaskDialogButton
+	CommandLinks            []TaskDialogButton
+	DefaultButton           int
+	RadioButtons            []TaskDialogButton
+	RadioButton             int
+	VerificationText        string
+	VerificationChecked     bool
+	VerificationSettingsKey string
+	ShowProgressBar         bool
+	ProgressMarquee         bool
+	EnableHyperlinks        bool
+	AllowCancel             bool
+	CanBeMinimized          bool
+	Timeout                 time.Duration
+	TimeoutButton           int
+	Width                   int
+	OnCreated               winapi.EventHandler
+	OnButtonClicked         winapi.IntEventHandler
+	OnHyperlinkClicked      winapi.StringEventHandler
+	OnRadioButtonClicked    winapi.IntEventHandler
+	OnTimedOut              winapi.EventHandler
+}
+
+func (td TaskDialog) Create() *winapi.TaskDialog {
+	dlg := &winapi.TaskDialog{
+		Title:                   td.Title,
+		MainInstruction:         td.MainInstruction,
+		Content:                 td.Content,
+		ExpandedInformation:     td.ExpandedInformation,
+		ExpandedControlText:     td.ExpandedControlText,
+		CollapsedControlText:    td.CollapsedControlText,
+		ExpandedByDefault:       td.ExpandedByDefault,
+		ExpandFooterArea:        td.ExpandFooterArea,
+		Footer:                  td.Footer,
+		Icon:                    td.Icon,
+		CustomIcon:              td.CustomIcon,
+		FooterIcon:              td.FooterIcon,
+		CommonButtons:           td.CommonButtons,
+		DefaultButton:           td.DefaultButton,
+		RadioButton:             td.RadioButton,
+		VerificationText:        td.VerificationText,
+		VerificationChecked:     td.VerificationChecked,
+		VerificationSettingsKey: td.VerificationSettingsKey,
+		ShowProgressBar:         td.ShowProgressBar,
+		ProgressMarquee:         td.ProgressMarquee,
+		EnableHyperlinks:        td.EnableHyperlinks,
+		AllowCancel:             td.AllowCancel,
+		CanBeMinimized:          td.CanBeMinimized,
+		Timeout:                 td.Timeout,
+		TimeoutButton:           td.TimeoutButton,
+		Width:                   td.Width,
+	}
+
+	buttons := td.Buttons
+	if len(td.CommandLinks) > 0 {
+		buttons = td.CommandLinks
+		dlg.UseCommandLinks = true
+	}
+	for _, b := range buttons {
+		dlg.Buttons = append(dlg.Buttons, winapi.TaskDialogButton{ID: b.ID, Text: b.Text})
+	}
+	for _, b := range td.RadioButtons {
+		dlg.RadioButtons = append(dlg.RadioButtons, winapi.TaskDialogButton{ID: b.ID, Text: b.Text})
+	}
+
+	if td.OnCreated != nil {
+		dlg.Created().Attach(td.OnCreated)
+	}
+	if td.OnButtonClicked != nil {
+		dlg.ButtonClicked().Attach(td.OnButtonClicked)
+	}
+	if td.OnHyperlinkClicked != nil {
+		dlg.HyperlinkClicked().Attach(td.OnHyperlinkClicked)
+	}
+	if td.OnRadioButtonClicked != nil {
+		dlg.RadioButtonClicked().Attach(td.OnRadioButtonClicked)
+	}
+	if td.OnTimedOut != nil {
+		dlg.TimedOut().Attach(td.OnTimedOut)
+	}
+
+	if td.AssignTo != nil {
+		*td.AssignTo = dlg
+	}
+
+	return dlg
+}
+
+func (td TaskDialog) Run(owner winapi.Form) (int, error) {
+	return td.Create().Show(owner)
+}
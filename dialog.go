@@ -98,7 +98,7 @@ func (dlg *Dialog) DefaultButton() *PushButton {
 
 func (dlg *Dialog) SetDefaultButton(button *PushButton) error {
 	if button != nil && !user32.IsChild(dlg.hWnd, button.hWnd) {
-		return errs.NewError("not a descendant of the dialog")
+		return errs.NewInvalidArgumentError("not a descendant of the dialog")
 	}
 
 	succeeded := false
@@ -132,7 +132,7 @@ func (dlg *Dialog) CancelButton() *PushButton {
 
 func (dlg *Dialog) SetCancelButton(button *PushButton) error {
 	if button != nil && !user32.IsChild(dlg.hWnd, button.hWnd) {
-		return errs.NewError("not a descendant of the dialog")
+		return errs.NewInvalidArgumentError("not a descendant of the dialog")
 	}
 
 	dlg.cancelButton = button
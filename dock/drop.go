@@ -0,0 +1,95 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dock
+
+// DropSide returns the side of bounds that a panel dragged to (x, y) docks
+// at. The outer quarter of each edge selects that edge, the rest selects
+// Center if allowCenter is true. ok is false if (x, y) is outside bounds or
+// no side applies.
+func DropSide(bounds Rect, x, y int, allowCenter bool) (side Side, ok bool) {
+	if !bounds.Contains(x, y) {
+		return 0, false
+	}
+
+	// Distances to the edges, relative to the size.
+	left := float64(x-bounds.X) / float64(bounds.Width)
+	top := float64(y-bounds.Y) / float64(bounds.Height)
+	right := 1 - left
+	bottom := 1 - top
+
+	side, min := Left, left
+	if top < min {
+		side, min = Top, top
+	}
+	if right < min {
+		side, min = Right, right
+	}
+	if bottom < min {
+		side, min = Bottom, bottom
+	}
+
+	if min < 0.25 {
+		return side, true
+	}
+	if allowCenter {
+		return Center, true
+	}
+
+	return 0, false
+}
+
+// EdgeSide returns the side of bounds that (x, y) is within band pixels of,
+// for docking at the outer edges of the docked area.
+func EdgeSide(bounds Rect, x, y, band int) (side Side, ok bool) {
+	if !bounds.Contains(x, y) {
+		return 0, false
+	}
+
+	switch {
+	case x < bounds.X+band:
+		return Left, true
+
+	case y < bounds.Y+band:
+		return Top, true
+
+	case x >= bounds.X+bounds.Width-band:
+		return Right, true
+
+	case y >= bounds.Y+bounds.Height-band:
+		return Bottom, true
+	}
+
+	return 0, false
+}
+
+// DropPreview returns the part of bounds that a panel docked at side with
+// fraction, as accepted by Layout.Dock, would occupy.
+func DropPreview(bounds Rect, side Side, fraction float64) Rect {
+	if fraction <= 0 || fraction >= 1 {
+		fraction = 0.5
+	}
+
+	r := bounds
+	w := int(float64(bounds.Width) * fraction)
+	h := int(float64(bounds.Height) * fraction)
+
+	switch side {
+	case Left:
+		r.Width = w
+
+	case Top:
+		r.Height = h
+
+	case Right:
+		r.X += bounds.Width - w
+		r.Width = w
+
+	case Bottom:
+		r.Y += bounds.Height - h
+		r.Height = h
+	}
+
+	return r
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dock
+
+import "testing"
+
+func TestDropSide(t *testing.T) {
+	bounds := Rect{X: 100, Y: 100, Width: 200, Height: 100}
+
+	for _, test := range []struct {
+		x, y        int
+		allowCenter bool
+		side        Side
+		ok          bool
+	}{
+		{110, 150, false, Left, true},
+		{290, 150, false, Right, true},
+		{200, 105, false, Top, true},
+		{200, 195, false, Bottom, true},
+		{200, 150, true, Center, true},
+		{200, 150, false, 0, false},
+
+		// The edges are relative to the size, so the quarter of the wide
+		// bounds is 50 pixels horizontally, but 25 vertically.
+		{145, 150, false, Left, true},
+		{150, 150, true, Center, true},
+		{200, 124, false, Top, true},
+		{200, 125, true, Center, true},
+
+		// The nearest edge wins in corners.
+		{102, 110, false, Left, true},
+		{110, 102, false, Top, true},
+
+		// Outside, including the exclusive right and bottom edges.
+		{99, 150, true, 0, false},
+		{300, 150, true, 0, false},
+		{200, 200, true, 0, false},
+	} {
+		side, ok := DropSide(bounds, test.x, test.y, test.allowCenter)
+		if side != test.side || ok != test.ok {
+			t.Errorf("DropSide(%d, %d, %t) = %s, %t; want %s, %t", test.x, test.y, test.allowCenter, side, ok, test.side, test.ok)
+		}
+	}
+}
+
+func TestEdgeSide(t *testing.T) {
+	bounds := Rect{Width: 100, Height: 80}
+
+	for _, test := range []struct {
+		x, y int
+		side Side
+		ok   bool
+	}{
+		{0, 40, Left, true},
+		{9, 40, Left, true},
+		{10, 40, 0, false},
+		{50, 0, Top, true},
+		{99, 40, Right, true},
+		{90, 40, Right, true},
+		{89, 40, 0, false},
+		{50, 79, Bottom, true},
+		{50, 70, Bottom, true},
+
+		// Left and top win in corners.
+		{5, 5, Left, true},
+		{95, 5, Top, true},
+		{95, 75, Right, true},
+
+		{100, 40, 0, false},
+		{-1, 40, 0, false},
+	} {
+		side, ok := EdgeSide(bounds, test.x, test.y, 10)
+		if side != test.side || ok != test.ok {
+			t.Errorf("EdgeSide(%d, %d) = %s, %t; want %s, %t", test.x, test.y, side, ok, test.side, test.ok)
+		}
+	}
+}
+
+func TestDropPreview(t *testing.T) {
+	bounds := Rect{X: 10, Y: 20, Width: 200, Height: 100}
+
+	for _, test := range []struct {
+		side     Side
+		fraction float64
+		want     Rect
+	}{
+		{Left, 0.25, Rect{10, 20, 50, 100}},
+		{Right, 0.25, Rect{160, 20, 50, 100}},
+		{Top, 0.3, Rect{10, 20, 200, 30}},
+		{Bottom, 0.3, Rect{10, 90, 200, 30}},
+		{Center, 0.3, bounds},
+
+		// Fractions outside (0, 1) mean one half, like in Layout.Dock.
+		{Left, 0, Rect{10, 20, 100, 100}},
+		{Bottom, 1, Rect{10, 70, 200, 50}},
+		{Right, -2, Rect{110, 20, 100, 100}},
+	} {
+		if got := DropPreview(bounds, test.side, test.fraction); got != test.want {
+			t.Errorf("DropPreview(%s, %g) = %+v; want %+v", test.side, test.fraction, got, test.want)
+		}
+	}
+}
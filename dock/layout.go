@@ -0,0 +1,804 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dock implements the model of a docking layout: a tree of splits
+// and tab groups of panels around a document area, floating groups and
+// panels auto-hidden to the edges. It does not depend on a GUI, so layouts
+// can be built, manipulated and serialized headlessly.
+//
+// Panels are identified by strings chosen by the application.
+package dock
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Version is the version of the serialized Layout.
+const Version = 1
+
+// Document is the target for docking next to the document area.
+const Document = "#document"
+
+// Side is where a panel docks relative to a target.
+type Side int
+
+const (
+	Left Side = iota
+	Top
+	Right
+	Bottom
+
+	// Center adds a panel as tab to the group of the target.
+	Center
+)
+
+var sideNames = []string{"left", "top", "right", "bottom", "center"}
+
+func (s Side) String() string {
+	if s < 0 || int(s) >= len(sideNames) {
+		return fmt.Sprintf("Side(%d)", int(s))
+	}
+
+	return sideNames[s]
+}
+
+func (s Side) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(sideNames) {
+		return nil, fmt.Errorf("dock: invalid side %d", int(s))
+	}
+
+	return []byte(sideNames[s]), nil
+}
+
+func (s *Side) UnmarshalText(text []byte) error {
+	for i, name := range sideNames {
+		if name == string(text) {
+			*s = Side(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("dock: invalid side %q", text)
+}
+
+// Orientation returns the orientation of the split that docking at s
+// creates.
+func (s Side) Orientation() Orientation {
+	if s == Top || s == Bottom {
+		return Vertical
+	}
+
+	return Horizontal
+}
+
+// before reports whether docking at s puts the panel before the target.
+func (s Side) before() bool {
+	return s == Left || s == Top
+}
+
+// Orientation is the direction in which a split arranges its children.
+type Orientation int
+
+const (
+	Horizontal Orientation = iota
+	Vertical
+)
+
+func (o Orientation) String() string {
+	if o == Vertical {
+		return "vertical"
+	}
+
+	return "horizontal"
+}
+
+func (o Orientation) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+func (o *Orientation) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "horizontal":
+		*o = Horizontal
+
+	case "vertical":
+		*o = Vertical
+
+	default:
+		return fmt.Errorf("dock: invalid orientation %q", text)
+	}
+
+	return nil
+}
+
+// Rect is a rectangle in pixels.
+type Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Contains reports whether (x, y) is inside r.
+func (r Rect) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+// Node is a split, a tab group or the document area.
+//
+// A split has Children, which are arranged in Orientation and share the
+// space according to Weights. A tab group has Panels, of which Current is
+// shown.
+type Node struct {
+	Orientation Orientation `json:"orientation,omitempty"`
+	Children    []*Node     `json:"children,omitempty"`
+	Weights     []float64   `json:"weights,omitempty"`
+	Panels      []string    `json:"panels,omitempty"`
+	Current     int         `json:"current,omitempty"`
+	Document    bool        `json:"document,omitempty"`
+}
+
+// IsSplit reports whether n is a split.
+func (n *Node) IsSplit() bool {
+	return len(n.Children) > 0
+}
+
+// Weight returns the share of the space of the split for child i.
+func (n *Node) Weight(i int) float64 {
+	if i < len(n.Weights) && n.Weights[i] > 0 {
+		return n.Weights[i]
+	}
+
+	return 1 / float64(len(n.Children))
+}
+
+func (n *Node) clone() *Node {
+	if n == nil {
+		return nil
+	}
+
+	c := *n
+	c.Weights = append([]float64(nil), n.Weights...)
+	c.Panels = append([]string(nil), n.Panels...)
+	c.Children = make([]*Node, len(n.Children))
+	for i, child := range n.Children {
+		c.Children[i] = child.clone()
+	}
+	if len(c.Children) == 0 {
+		c.Children = nil
+	}
+
+	return &c
+}
+
+// Floating is a group of panels in its own window.
+type Floating struct {
+	Bounds Rect  `json:"bounds"`
+	Root   *Node `json:"root"`
+}
+
+// AutoHidden is a panel collapsed to a button at an edge, which shows
+// Size pixels of the panel when activated.
+type AutoHidden struct {
+	Panel string `json:"panel"`
+	Side  Side   `json:"side"`
+	Size  int    `json:"size,omitempty"`
+}
+
+// Layout is the complete arrangement of the panels.
+type Layout struct {
+	Version    int           `json:"version"`
+	Root       *Node         `json:"root,omitempty"`
+	Floating   []*Floating   `json:"floating,omitempty"`
+	AutoHidden []*AutoHidden `json:"autoHidden,omitempty"`
+}
+
+// New returns a Layout holding only the document area.
+func New() *Layout {
+	return &Layout{Version: Version, Root: &Node{Document: true}}
+}
+
+// Clone returns a deep copy of l.
+func (l *Layout) Clone() *Layout {
+	c := &Layout{Version: l.Version, Root: l.Root.clone()}
+
+	for _, f := range l.Floating {
+		c.Floating = append(c.Floating, &Floating{Bounds: f.Bounds, Root: f.Root.clone()})
+	}
+	for _, ah := range l.AutoHidden {
+		a := *ah
+		c.AutoHidden = append(c.AutoHidden, &a)
+	}
+
+	return c
+}
+
+// State is where a panel is.
+type State int
+
+const (
+	StateHidden State = iota
+	StateDocked
+	StateFloating
+	StateAutoHidden
+)
+
+// State returns where panel is.
+func (l *Layout) State(panel string) State {
+	if l.Root != nil && findPanel(l.Root, panel) != nil {
+		return StateDocked
+	}
+	for _, f := range l.Floating {
+		if findPanel(f.Root, panel) != nil {
+			return StateFloating
+		}
+	}
+	if l.autoHiddenIndex(panel) > -1 {
+		return StateAutoHidden
+	}
+
+	return StateHidden
+}
+
+// Panels returns all panels in l, docked ones first.
+func (l *Layout) Panels() []string {
+	var panels []string
+
+	collect := func(n *Node) {
+		walk(n, func(n *Node) {
+			panels = append(panels, n.Panels...)
+		})
+	}
+
+	collect(l.Root)
+	for _, f := range l.Floating {
+		collect(f.Root)
+	}
+	for _, ah := range l.AutoHidden {
+		panels = append(panels, ah.Panel)
+	}
+
+	return panels
+}
+
+// Group returns the tab group holding panel, or nil.
+func (l *Layout) Group(panel string) *Node {
+	if n := findPanel(l.Root, panel); n != nil {
+		return n
+	}
+	for _, f := range l.Floating {
+		if n := findPanel(f.Root, panel); n != nil {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// FloatingOf returns the Floating holding panel, or nil.
+func (l *Layout) FloatingOf(panel string) *Floating {
+	for _, f := range l.Floating {
+		if findPanel(f.Root, panel) != nil {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// AutoHiddenOf returns the AutoHidden entry of panel, or nil.
+func (l *Layout) AutoHiddenOf(panel string) *AutoHidden {
+	if i := l.autoHiddenIndex(panel); i > -1 {
+		return l.AutoHidden[i]
+	}
+
+	return nil
+}
+
+func (l *Layout) autoHiddenIndex(panel string) int {
+	for i, ah := range l.AutoHidden {
+		if ah.Panel == panel {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// SetCurrent makes panel the current tab of its group.
+func (l *Layout) SetCurrent(panel string) {
+	if g := l.Group(panel); g != nil {
+		for i, p := range g.Panels {
+			if p == panel {
+				g.Current = i
+			}
+		}
+	}
+}
+
+// Dock docks panel at side of target, which is a panel, Document or "" for
+// the outer edges of the docked area. fraction is the share of the space
+// of target that panel gets, with values outside (0, 1) meaning one half.
+// Center adds panel as tab to the group of target, which must be a panel.
+//
+// panel is removed from where it was before.
+func (l *Layout) Dock(panel, target string, side Side, fraction float64) error {
+	if panel == "" || panel == Document {
+		return fmt.Errorf("dock: invalid panel %q", panel)
+	}
+	if panel == target {
+		return fmt.Errorf("dock: cannot dock %q to itself", panel)
+	}
+	if side < Left || side > Center {
+		return fmt.Errorf("dock: invalid side %d", int(side))
+	}
+	if fraction <= 0 || fraction >= 1 {
+		fraction = 0.5
+	}
+
+	var root **Node
+	var targetNode *Node
+	switch target {
+	case "":
+		if side == Center {
+			return fmt.Errorf("dock: cannot dock %q to the center of the dock area", panel)
+		}
+		root = &l.Root
+
+	case Document:
+		if side == Center {
+			return fmt.Errorf("dock: cannot dock %q to the center of the document area", panel)
+		}
+		root = &l.Root
+		if targetNode = findDocument(l.Root); targetNode == nil {
+			return fmt.Errorf("dock: no document area")
+		}
+
+	default:
+		if l.State(target) != StateDocked && l.State(target) != StateFloating {
+			return fmt.Errorf("dock: target %q neither docked nor floating", target)
+		}
+	}
+
+	l.remove(panel)
+
+	if target != "" && target != Document {
+		// Find the target after removing, which may have restructured the
+		// tree.
+		root = &l.Root
+		targetNode = findPanel(l.Root, target)
+		if targetNode == nil {
+			f := l.FloatingOf(target)
+			root = &f.Root
+			targetNode = findPanel(f.Root, target)
+		}
+	}
+
+	leaf := &Node{Panels: []string{panel}}
+
+	switch {
+	case side == Center:
+		targetNode.Panels = append(targetNode.Panels, panel)
+		targetNode.Current = len(targetNode.Panels) - 1
+
+	case targetNode == nil:
+		*root = insertAt(*root, nil, leaf, side, fraction)
+
+	default:
+		*root = insertAt(*root, targetNode, leaf, side, fraction)
+	}
+
+	l.Normalize()
+
+	return nil
+}
+
+// insertAt inserts leaf at side of target, or of root if target is nil,
+// and returns the new root.
+func insertAt(root, target, leaf *Node, side Side, fraction float64) *Node {
+	orientation := side.Orientation()
+
+	if root == nil {
+		return leaf
+	}
+
+	if target == nil || target == root {
+		if root.IsSplit() && root.Orientation == orientation {
+			insertChild(root, len(root.Children)*boolToInt(!side.before()), leaf, fraction)
+			return root
+		}
+
+		return newSplit(orientation, root, leaf, side, fraction)
+	}
+
+	parent, index := findParent(root, target)
+
+	if parent.Orientation == orientation {
+		// Share the space of target.
+		weight := parent.Weight(index)
+		fillWeights(parent)
+		parent.Weights[index] = weight * (1 - fraction)
+
+		at := index
+		if !side.before() {
+			at++
+		}
+
+		parent.Children = append(parent.Children, nil)
+		copy(parent.Children[at+1:], parent.Children[at:])
+		parent.Children[at] = leaf
+
+		parent.Weights = append(parent.Weights, 0)
+		copy(parent.Weights[at+1:], parent.Weights[at:])
+		parent.Weights[at] = weight * fraction
+
+		return root
+	}
+
+	parent.Children[index] = newSplit(orientation, target, leaf, side, fraction)
+
+	return root
+}
+
+func newSplit(orientation Orientation, node, leaf *Node, side Side, fraction float64) *Node {
+	if side.before() {
+		return &Node{Orientation: orientation, Children: []*Node{leaf, node}, Weights: []float64{fraction, 1 - fraction}}
+	}
+
+	return &Node{Orientation: orientation, Children: []*Node{node, leaf}, Weights: []float64{1 - fraction, fraction}}
+}
+
+// insertChild inserts child at index of split, giving it fraction of the
+// total weight.
+func insertChild(split *Node, index int, child *Node, fraction float64) {
+	fillWeights(split)
+
+	for i := range split.Weights {
+		split.Weights[i] *= 1 - fraction
+	}
+
+	split.Children = append(split.Children, nil)
+	copy(split.Children[index+1:], split.Children[index:])
+	split.Children[index] = child
+
+	split.Weights = append(split.Weights, 0)
+	copy(split.Weights[index+1:], split.Weights[index:])
+	split.Weights[index] = fraction
+}
+
+func fillWeights(split *Node) {
+	weights := make([]float64, len(split.Children))
+	for i := range weights {
+		weights[i] = split.Weight(i)
+	}
+
+	split.Weights = weights
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// Float moves panel into a new floating group at bounds.
+func (l *Layout) Float(panel string, bounds Rect) error {
+	if panel == "" || panel == Document {
+		return fmt.Errorf("dock: invalid panel %q", panel)
+	}
+
+	l.remove(panel)
+
+	l.Floating = append(l.Floating, &Floating{Bounds: bounds, Root: &Node{Panels: []string{panel}}})
+
+	l.Normalize()
+
+	return nil
+}
+
+// AutoHide collapses panel to a button at side, showing size pixels of it
+// when activated. Center is not allowed.
+func (l *Layout) AutoHide(panel string, side Side, size int) error {
+	if panel == "" || panel == Document {
+		return fmt.Errorf("dock: invalid panel %q", panel)
+	}
+	if side < Left || side > Bottom {
+		return fmt.Errorf("dock: invalid auto-hide side %s", side)
+	}
+
+	l.remove(panel)
+
+	l.AutoHidden = append(l.AutoHidden, &AutoHidden{Panel: panel, Side: side, Size: size})
+
+	l.Normalize()
+
+	return nil
+}
+
+// Remove removes panel from l, so that its state becomes StateHidden.
+func (l *Layout) Remove(panel string) {
+	l.remove(panel)
+
+	l.Normalize()
+}
+
+func (l *Layout) remove(panel string) {
+	removeFromTree := func(n *Node) {
+		walk(n, func(n *Node) {
+			for i, p := range n.Panels {
+				if p == panel {
+					n.Panels = append(n.Panels[:i], n.Panels[i+1:]...)
+					if n.Current >= len(n.Panels) || n.Current > i {
+						n.Current--
+					}
+					if n.Current < 0 {
+						n.Current = 0
+					}
+					return
+				}
+			}
+		})
+	}
+
+	removeFromTree(l.Root)
+	for _, f := range l.Floating {
+		removeFromTree(f.Root)
+	}
+
+	if i := l.autoHiddenIndex(panel); i > -1 {
+		l.AutoHidden = append(l.AutoHidden[:i], l.AutoHidden[i+1:]...)
+	}
+}
+
+// Retain removes all panels not in panels from l, e.g. after decoding a
+// layout saved by a version of the application with other panels, and
+// returns the panels not in l.
+func (l *Layout) Retain(panels []string) (missing []string) {
+	keep := make(map[string]bool, len(panels))
+	for _, p := range panels {
+		keep[p] = true
+	}
+
+	for _, p := range l.Panels() {
+		if !keep[p] {
+			l.remove(p)
+		}
+		delete(keep, p)
+	}
+
+	for _, p := range panels {
+		if keep[p] {
+			missing = append(missing, p)
+		}
+	}
+
+	l.Normalize()
+
+	return missing
+}
+
+// Normalize removes empty groups and splits, collapses splits with a
+// single child, merges nested splits of the same orientation and scales
+// the weights of each split to sum to 1.
+func (l *Layout) Normalize() {
+	l.Root = normalize(l.Root)
+
+	floating := l.Floating[:0]
+	for _, f := range l.Floating {
+		if f.Root = normalize(f.Root); f.Root != nil {
+			floating = append(floating, f)
+		}
+	}
+	l.Floating = floating
+	if len(l.Floating) == 0 {
+		l.Floating = nil
+	}
+	if len(l.AutoHidden) == 0 {
+		l.AutoHidden = nil
+	}
+}
+
+func normalize(n *Node) *Node {
+	if n == nil {
+		return nil
+	}
+
+	if !n.IsSplit() {
+		if n.Document {
+			n.Panels = nil
+			n.Current = 0
+			return n
+		}
+		if len(n.Panels) == 0 {
+			return nil
+		}
+		if n.Current < 0 || n.Current >= len(n.Panels) {
+			n.Current = 0
+		}
+		return n
+	}
+
+	var children []*Node
+	var weights []float64
+	for i, child := range n.Children {
+		weight := n.Weight(i)
+
+		child = normalize(child)
+		if child == nil {
+			continue
+		}
+
+		if child.IsSplit() && child.Orientation == n.Orientation {
+			var total float64
+			for j := range child.Children {
+				total += child.Weight(j)
+			}
+			for j, grandChild := range child.Children {
+				children = append(children, grandChild)
+				weights = append(weights, weight*child.Weight(j)/total)
+			}
+			continue
+		}
+
+		children = append(children, child)
+		weights = append(weights, weight)
+	}
+
+	switch len(children) {
+	case 0:
+		return nil
+
+	case 1:
+		return children[0]
+	}
+
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	for i := range weights {
+		weights[i] /= total
+	}
+
+	n.Children = children
+	n.Weights = weights
+	n.Panels = nil
+	n.Current = 0
+	n.Document = false
+
+	return n
+}
+
+// Validate checks that each panel appears once, that the docked tree holds
+// the only document area and that the weights match the children.
+func (l *Layout) Validate() error {
+	seen := make(map[string]bool)
+	var documents int
+	var err error
+
+	check := func(n *Node) {
+		walk(n, func(n *Node) {
+			if err != nil {
+				return
+			}
+			if n.Document {
+				documents++
+			}
+			if n.IsSplit() && len(n.Weights) > 0 && len(n.Weights) != len(n.Children) {
+				err = fmt.Errorf("dock: %d weights for %d children", len(n.Weights), len(n.Children))
+			}
+			for _, p := range n.Panels {
+				if p == "" || p == Document || seen[p] {
+					err = fmt.Errorf("dock: invalid or duplicate panel %q", p)
+				}
+				seen[p] = true
+			}
+		})
+	}
+
+	check(l.Root)
+	for _, f := range l.Floating {
+		if findDocument(f.Root) != nil {
+			return fmt.Errorf("dock: document area in floating group")
+		}
+		check(f.Root)
+	}
+	if err != nil {
+		return err
+	}
+	if documents != 1 {
+		return fmt.Errorf("dock: %d document areas instead of 1", documents)
+	}
+
+	for _, ah := range l.AutoHidden {
+		if ah.Panel == "" || seen[ah.Panel] {
+			return fmt.Errorf("dock: invalid or duplicate panel %q", ah.Panel)
+		}
+		if ah.Side < Left || ah.Side > Bottom {
+			return fmt.Errorf("dock: invalid auto-hide side %s", ah.Side)
+		}
+		seen[ah.Panel] = true
+	}
+
+	return nil
+}
+
+// Encode returns l as JSON.
+func (l *Layout) Encode() ([]byte, error) {
+	return json.Marshal(l)
+}
+
+// Decode returns the validated and normalized Layout encoded in data.
+func Decode(data []byte) (*Layout, error) {
+	l := new(Layout)
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("dock: invalid layout: %s", err)
+	}
+	if l.Version > Version {
+		return nil, fmt.Errorf("dock: unsupported layout version %d", l.Version)
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+
+	l.Version = Version
+	l.Normalize()
+
+	return l, nil
+}
+
+// Walk calls f for n and all its descendants, parents first.
+func (n *Node) Walk(f func(n *Node)) {
+	if n == nil {
+		return
+	}
+
+	f(n)
+
+	for _, child := range n.Children {
+		child.Walk(f)
+	}
+}
+
+func walk(n *Node, f func(n *Node)) {
+	n.Walk(f)
+}
+
+func findPanel(root *Node, panel string) (group *Node) {
+	walk(root, func(n *Node) {
+		for _, p := range n.Panels {
+			if p == panel {
+				group = n
+			}
+		}
+	})
+
+	return
+}
+
+func findDocument(root *Node) (document *Node) {
+	walk(root, func(n *Node) {
+		if n.Document {
+			document = n
+		}
+	})
+
+	return
+}
+
+// findParent returns the split holding child and its index there.
+func findParent(root, child *Node) (parent *Node, index int) {
+	walk(root, func(n *Node) {
+		for i, c := range n.Children {
+			if c == child {
+				parent, index = n, i
+			}
+		}
+	})
+
+	return
+}
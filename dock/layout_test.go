@@ -0,0 +1,401 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// describe returns a compact representation of n: "doc" for the document
+// area, "[a *b]" for a group with current panel b and "H(x 0.25, y 0.75)"
+// for a horizontal split with weighted children.
+func describe(n *Node) string {
+	switch {
+	case n == nil:
+		return "nil"
+
+	case n.Document:
+		return "doc"
+
+	case !n.IsSplit():
+		panels := make([]string, len(n.Panels))
+		for i, p := range n.Panels {
+			if i == n.Current && len(n.Panels) > 1 {
+				p = "*" + p
+			}
+			panels[i] = p
+		}
+		return "[" + strings.Join(panels, " ") + "]"
+	}
+
+	children := make([]string, len(n.Children))
+	for i, child := range n.Children {
+		children[i] = fmt.Sprintf("%s %.2f", describe(child), n.Weight(i))
+	}
+
+	return fmt.Sprintf("%s(%s)", strings.ToUpper(n.Orientation.String()[:1]), strings.Join(children, ", "))
+}
+
+func mustDock(t *testing.T, l *Layout, panel, target string, side Side, fraction float64) {
+	t.Helper()
+
+	if err := l.Dock(panel, target, side, fraction); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Validate(); err != nil {
+		t.Fatalf("after docking %s: %v", panel, err)
+	}
+}
+
+func checkRoot(t *testing.T, l *Layout, want string) {
+	t.Helper()
+
+	if got := describe(l.Root); got != want {
+		t.Errorf("root = %s\nwant   %s", got, want)
+	}
+}
+
+func TestSide(t *testing.T) {
+	for side, want := range map[Side]Orientation{Left: Horizontal, Right: Horizontal, Top: Vertical, Bottom: Vertical} {
+		if got := side.Orientation(); got != want {
+			t.Errorf("%s.Orientation() = %s; want %s", side, got, want)
+		}
+	}
+
+	if got := Side(9).String(); got != "Side(9)" {
+		t.Errorf("String() of an invalid side = %q", got)
+	}
+
+	var s Side
+	if err := s.UnmarshalText([]byte("bottom")); err != nil || s != Bottom {
+		t.Errorf("UnmarshalText(bottom) = %s, %v", s, err)
+	}
+	if err := s.UnmarshalText([]byte("middle")); err == nil {
+		t.Error("UnmarshalText(middle) succeeded")
+	}
+	if _, err := Side(-1).MarshalText(); err == nil {
+		t.Error("MarshalText() of an invalid side succeeded")
+	}
+}
+
+func TestDock(t *testing.T) {
+	l := New()
+	checkRoot(t, l, "doc")
+
+	mustDock(t, l, "files", "", Left, 0.25)
+	checkRoot(t, l, "H([files] 0.25, doc 0.75)")
+
+	// Docking at the outer edge in the same orientation extends the split.
+	mustDock(t, l, "outline", "", Right, 0.2)
+	checkRoot(t, l, "H([files] 0.20, doc 0.60, [outline] 0.20)")
+
+	// Docking at a target shares its space.
+	mustDock(t, l, "search", "files", Bottom, 0.5)
+	checkRoot(t, l, "H(V([files] 0.50, [search] 0.50) 0.20, doc 0.60, [outline] 0.20)")
+
+	mustDock(t, l, "console", Document, Bottom, 0.25)
+	checkRoot(t, l, "H(V([files] 0.50, [search] 0.50) 0.20, V(doc 0.75, [console] 0.25) 0.60, [outline] 0.20)")
+
+	// Center adds a tab and makes it current.
+	mustDock(t, l, "problems", "console", Center, 0)
+	checkRoot(t, l, "H(V([files] 0.50, [search] 0.50) 0.20, V(doc 0.75, [console *problems] 0.25) 0.60, [outline] 0.20)")
+
+	if got := l.State("problems"); got != StateDocked {
+		t.Errorf("State(problems) = %d; want StateDocked", got)
+	}
+	if g := l.Group("console"); g == nil || !reflect.DeepEqual(g.Panels, []string{"console", "problems"}) {
+		t.Errorf("Group(console) = %+v", g)
+	}
+
+	want := []string{"files", "search", "console", "problems", "outline"}
+	if got := l.Panels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Panels() = %v; want %v", got, want)
+	}
+}
+
+func TestDockMovesPanel(t *testing.T) {
+	l := New()
+	mustDock(t, l, "files", "", Left, 0.25)
+	mustDock(t, l, "search", "files", Center, 0)
+
+	// Moving the current tab next to its own group leaves the other tab.
+	mustDock(t, l, "search", "files", Top, 0.4)
+	checkRoot(t, l, "H(V([search] 0.40, [files] 0.60) 0.25, doc 0.75)")
+
+	// Moving a panel out of a split collapses it.
+	mustDock(t, l, "search", Document, Right, 0.5)
+	checkRoot(t, l, "H([files] 0.25, doc 0.38, [search] 0.38)")
+}
+
+func TestDockErrors(t *testing.T) {
+	l := New()
+	mustDock(t, l, "files", "", Left, 0)
+
+	for _, test := range []struct {
+		panel, target string
+		side          Side
+	}{
+		{"", "", Left},
+		{Document, "", Left},
+		{"files", "files", Left},
+		{"x", "", Side(7)},
+		{"x", "", Center},
+		{"x", Document, Center},
+		{"x", "missing", Left},
+	} {
+		if err := l.Dock(test.panel, test.target, test.side, 0.5); err == nil {
+			t.Errorf("Dock(%q, %q, %s) succeeded", test.panel, test.target, test.side)
+		}
+	}
+
+	checkRoot(t, l, "H([files] 0.50, doc 0.50)")
+}
+
+func TestFloat(t *testing.T) {
+	l := New()
+	mustDock(t, l, "files", "", Left, 0.25)
+
+	bounds := Rect{X: 10, Y: 20, Width: 300, Height: 200}
+	if err := l.Float("files", bounds); err != nil {
+		t.Fatal(err)
+	}
+	checkRoot(t, l, "doc")
+
+	f := l.FloatingOf("files")
+	if f == nil || f.Bounds != bounds || describe(f.Root) != "[files]" {
+		t.Fatalf("FloatingOf(files) = %+v", f)
+	}
+	if got := l.State("files"); got != StateFloating {
+		t.Errorf("State(files) = %d; want StateFloating", got)
+	}
+
+	// Panels can be docked to floating ones.
+	mustDock(t, l, "search", "files", Right, 0.5)
+	if got := describe(f.Root); got != "H([files] 0.50, [search] 0.50)" {
+		t.Errorf("floating root = %s", got)
+	}
+
+	// Docking the panels back removes the empty floating group.
+	mustDock(t, l, "files", "", Left, 0.5)
+	mustDock(t, l, "search", "", Left, 0.5)
+	if l.Floating != nil {
+		t.Errorf("Floating = %+v; want nil", l.Floating)
+	}
+
+	if err := l.Float(Document, bounds); err == nil {
+		t.Error("floating the document area succeeded")
+	}
+}
+
+func TestAutoHide(t *testing.T) {
+	l := New()
+	mustDock(t, l, "files", "", Left, 0.25)
+
+	if err := l.AutoHide("files", Left, 250); err != nil {
+		t.Fatal(err)
+	}
+	checkRoot(t, l, "doc")
+
+	if ah := l.AutoHiddenOf("files"); ah == nil || ah.Side != Left || ah.Size != 250 {
+		t.Errorf("AutoHiddenOf(files) = %+v", ah)
+	}
+	if got := l.State("files"); got != StateAutoHidden {
+		t.Errorf("State(files) = %d; want StateAutoHidden", got)
+	}
+
+	if err := l.AutoHide("files", Center, 100); err == nil {
+		t.Error("auto-hiding at the center succeeded")
+	}
+	if err := l.AutoHide("", Left, 100); err == nil {
+		t.Error("auto-hiding an empty panel name succeeded")
+	}
+
+	// Docking an auto-hidden panel restores it.
+	mustDock(t, l, "files", "", Right, 0.3)
+	if l.AutoHiddenOf("files") != nil || l.AutoHidden != nil {
+		t.Errorf("AutoHidden = %+v after docking", l.AutoHidden)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	l := New()
+	mustDock(t, l, "a", "", Left, 0.5)
+	mustDock(t, l, "b", "a", Center, 0)
+	mustDock(t, l, "c", "a", Center, 0)
+	l.SetCurrent("b")
+
+	// Removing a tab before the current one keeps the current panel.
+	l.Remove("a")
+	checkRoot(t, l, "H([*b c] 0.50, doc 0.50)")
+
+	// Removing the current last tab selects the one before it.
+	l.SetCurrent("c")
+	l.Remove("c")
+	checkRoot(t, l, "H([b] 0.50, doc 0.50)")
+
+	l.Remove("b")
+	checkRoot(t, l, "doc")
+	if got := l.State("b"); got != StateHidden {
+		t.Errorf("State(b) = %d; want StateHidden", got)
+	}
+
+	// Removing unknown panels does nothing.
+	l.Remove("missing")
+	checkRoot(t, l, "doc")
+}
+
+func TestRetain(t *testing.T) {
+	l := New()
+	mustDock(t, l, "a", "", Left, 0.5)
+	mustDock(t, l, "b", "", Right, 0.5)
+	if err := l.AutoHide("c", Bottom, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := l.Retain([]string{"b", "d"})
+	if !reflect.DeepEqual(missing, []string{"d"}) {
+		t.Errorf("Retain() = %v; want [d]", missing)
+	}
+	if got := l.Panels(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("Panels() = %v; want [b]", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	l := &Layout{
+		Version: Version,
+		Root: &Node{Orientation: Horizontal, Weights: []float64{1, 3, 4}, Children: []*Node{
+			{Panels: []string{"a"}},
+			// Nested split of the same orientation, merged into the parent.
+			{Orientation: Horizontal, Children: []*Node{
+				{Panels: []string{"b"}},
+				{Document: true, Panels: []string{"stray"}},
+			}},
+			// Split with a single remaining child, collapsed.
+			{Orientation: Vertical, Children: []*Node{
+				{},
+				{Panels: []string{"c", "d"}, Current: 5},
+			}},
+		}},
+		Floating: []*Floating{{Root: &Node{}}},
+	}
+
+	l.Normalize()
+
+	checkRoot(t, l, "H([a] 0.12, [b] 0.19, doc 0.19, [*c d] 0.50)")
+	if l.Floating != nil {
+		t.Errorf("Floating = %+v; want empty groups removed", l.Floating)
+	}
+
+	var total float64
+	for i := range l.Root.Children {
+		total += l.Root.Weight(i)
+	}
+	if total < 0.999 || total > 1.001 {
+		t.Errorf("weights sum to %f; want 1", total)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		layout *Layout
+	}{
+		{"no document", &Layout{Root: &Node{Panels: []string{"a"}}}},
+		{"two documents", &Layout{Root: &Node{Children: []*Node{{Document: true}, {Document: true}}}}},
+		{"duplicate panel", &Layout{Root: &Node{Children: []*Node{{Document: true}, {Panels: []string{"a", "a"}}}}}},
+		{"document panel name", &Layout{Root: &Node{Children: []*Node{{Document: true}, {Panels: []string{Document}}}}}},
+		{"weights", &Layout{Root: &Node{Weights: []float64{1}, Children: []*Node{{Document: true}, {Panels: []string{"a"}}}}}},
+		{"floating document", &Layout{Root: &Node{Document: true}, Floating: []*Floating{{Root: &Node{Document: true}}}}},
+		{"duplicate floating", &Layout{Root: &Node{Children: []*Node{{Document: true}, {Panels: []string{"a"}}}}, Floating: []*Floating{{Root: &Node{Panels: []string{"a"}}}}}},
+		{"duplicate auto-hidden", &Layout{Root: &Node{Children: []*Node{{Document: true}, {Panels: []string{"a"}}}}, AutoHidden: []*AutoHidden{{Panel: "a"}}}},
+		{"auto-hidden center", &Layout{Root: &Node{Document: true}, AutoHidden: []*AutoHidden{{Panel: "a", Side: Center}}}},
+	} {
+		if err := test.layout.Validate(); err == nil {
+			t.Errorf("%s: Validate() succeeded", test.name)
+		}
+	}
+
+	if err := New().Validate(); err != nil {
+		t.Errorf("Validate() of a new layout: %v", err)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	l := New()
+	mustDock(t, l, "files", "", Left, 0.25)
+	mustDock(t, l, "search", "files", Center, 0)
+	mustDock(t, l, "console", Document, Bottom, 0.3)
+	if err := l.Float("preview", Rect{X: 1, Y: 2, Width: 3, Height: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AutoHide("log", Right, 120); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := l.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"side":"right"`) {
+		t.Errorf("sides are not encoded by name: %s", data)
+	}
+
+	decoded, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, l) {
+		t.Errorf("Decode(Encode()) = %s\nwant %s", mustEncode(t, decoded), data)
+	}
+
+	// Clones are deep copies.
+	c := l.Clone()
+	if !reflect.DeepEqual(c, l) {
+		t.Fatal("Clone() differs")
+	}
+	c.Root.Children[0].Panels[0] = "changed"
+	c.AutoHidden[0].Size = 1
+	if l.Root.Children[0].Panels[0] == "changed" || l.AutoHidden[0].Size == 1 {
+		t.Error("modifying a clone changed the original")
+	}
+}
+
+func mustEncode(t *testing.T, l *Layout) []byte {
+	t.Helper()
+
+	data, err := l.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, data := range []string{
+		`{`,
+		`{"version": 2, "root": {"document": true}}`,
+		`{"version": 1, "root": {"panels": ["a"]}}`,
+		`{"version": 1, "root": {"document": true}, "autoHidden": [{"panel": "a", "side": "middle"}]}`,
+	} {
+		if _, err := Decode([]byte(data)); err == nil {
+			t.Errorf("Decode(%s) succeeded", data)
+		}
+	}
+
+	// Older versions are upgraded and normalized.
+	l, err := Decode([]byte(`{"version": 0, "root": {"orientation": "vertical", "children": [{"document": true}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Version != Version {
+		t.Errorf("Version = %d; want %d", l.Version, Version)
+	}
+	checkRoot(t, l, "doc")
+}
@@ -0,0 +1,1309 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/gdi32"
+	"github.com/Gipcomp/win32/handle"
+	"github.com/Gipcomp/win32/kernel32"
+	"github.com/Gipcomp/win32/user32"
+	"github.com/Gipcomp/winapi/dock"
+	"github.com/Gipcomp/winapi/errs"
+)
+
+const dockIndicatorWindowClass = `\o/ Walk_DockIndicator_Class \o/`
+
+const (
+	dockEdgeBand96        = 24
+	dockEdgeFraction      = 0.25
+	dockDefaultSize96     = 250
+	dockFloatingOffsetX96 = 40
+	dockFloatingOffsetY96 = 10
+)
+
+var dockIndicatorClassRegistered bool
+
+// DockManager is a widget that arranges DockPanels around a document area.
+//
+// Panels can be docked at the sides of the document area, of other panels or
+// of the whole docked area, be grouped as tabs, float in tool windows or be
+// auto-hidden to buttons at the edges, from where they fly out on demand.
+// Users rearrange panels by dragging their captions, while a translucent
+// indicator shows where they would be dropped.
+//
+// The arrangement is described by a dock.Layout, which can be saved to and
+// restored from the Settings of the application, either as persistent state
+// of the DockManager or explicitly with SaveLayout and RestoreLayout.
+type DockManager struct {
+	*Composite
+	dockArea               *Composite
+	parking                *Composite
+	document               *Composite
+	strips                 [4]*Composite // indexed by dock.Side
+	panels                 map[string]*DockPanel
+	order                  []string
+	layout                 *dock.Layout
+	splits                 []dockSplit
+	floats                 map[*dock.Floating]*MainWindow
+	flyout                 *MainWindow
+	flyoutPanel            *DockPanel
+	dragPanel              *DockPanel
+	hwndIndicator          handle.HWND
+	realizing              bool
+	layoutChangedPublisher EventPublisher
+}
+
+// dockSplit links a split of the layout to the widgets of its children, to
+// read back the sizes the user gave them.
+type dockSplit struct {
+	node    *dock.Node
+	widgets []Widget
+}
+
+// dockDrop is where a dragged DockPanel would be dropped.
+type dockDrop struct {
+	target string
+	side   dock.Side
+	float  bool
+	bounds Rectangle // preview in native pixels, screen coordinates
+}
+
+// NewDockManager returns a new DockManager with an empty document area.
+func NewDockManager(parent Container) (*DockManager, error) {
+	composite, err := NewComposite(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &DockManager{
+		Composite: composite,
+		panels:    make(map[string]*DockPanel),
+		layout:    dock.New(),
+		floats:    make(map[*dock.Floating]*MainWindow),
+	}
+
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			m.Dispose()
+		}
+	}()
+
+	if err := InitWrapperWindow(m); err != nil {
+		return nil, err
+	}
+
+	grid := NewGridLayout()
+	if err := grid.SetMargins(Margins{}); err != nil {
+		return nil, err
+	}
+	if err := grid.SetSpacing(0); err != nil {
+		return nil, err
+	}
+	if err := m.SetLayout(grid); err != nil {
+		return nil, err
+	}
+
+	stripRanges := [4]Rectangle{
+		dock.Left:   {0, 1, 1, 1},
+		dock.Top:    {0, 0, 3, 1},
+		dock.Right:  {2, 1, 1, 1},
+		dock.Bottom: {0, 2, 3, 1},
+	}
+	for side, r := range stripRanges {
+		var layout *BoxLayout
+		if dock.Side(side).Orientation() == dock.Horizontal {
+			layout = NewVBoxLayout()
+		} else {
+			layout = NewHBoxLayout()
+		}
+
+		if m.strips[side], err = m.newStructureComposite(m, layout); err != nil {
+			return nil, err
+		}
+		m.strips[side].SetVisible(false)
+
+		if err := grid.SetRange(m.strips[side], r); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.dockArea, err = m.newStructureComposite(m, NewVBoxLayout()); err != nil {
+		return nil, err
+	}
+	if err := grid.SetRange(m.dockArea, Rectangle{1, 1, 1, 1}); err != nil {
+		return nil, err
+	}
+
+	// Panels are parked in this hidden composite while not shown anywhere
+	// else, so that restructuring the layout does not dispose of them.
+	if m.parking, err = m.newStructureComposite(m, NewVBoxLayout()); err != nil {
+		return nil, err
+	}
+	m.parking.SetVisible(false)
+
+	if m.document, err = NewComposite(m.dockArea); err != nil {
+		return nil, err
+	}
+
+	if err := m.realize(); err != nil {
+		return nil, err
+	}
+
+	succeeded = true
+
+	return m, nil
+}
+
+// newStructureComposite returns a non-persistent Composite without margins
+// and spacing.
+func (m *DockManager) newStructureComposite(parent Container, layout Layout) (*Composite, error) {
+	c, err := NewComposite(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetPersistent(false)
+
+	if err := layout.SetMargins(Margins{}); err != nil {
+		return nil, err
+	}
+	if err := layout.SetSpacing(0); err != nil {
+		return nil, err
+	}
+	if err := c.SetLayout(layout); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (m *DockManager) Dispose() {
+	if m.IsDisposed() {
+		return
+	}
+
+	m.realizing = true
+
+	for _, mw := range m.floats {
+		mw.Dispose()
+	}
+	m.floats = nil
+
+	if m.flyout != nil {
+		m.flyout.Dispose()
+		m.flyout = nil
+	}
+
+	if m.hwndIndicator != 0 {
+		user32.DestroyWindow(m.hwndIndicator)
+		m.hwndIndicator = 0
+	}
+
+	m.Composite.Dispose()
+}
+
+// Document returns the Composite in the center of the DockManager, which
+// holds the main content of the window.
+func (m *DockManager) Document() *Composite {
+	return m.document
+}
+
+// DelegateContainer returns the document area, so that declarative children
+// of the DockManager are created there.
+func (m *DockManager) DelegateContainer() Container {
+	return m.document
+}
+
+// AddPanel adds a new DockPanel with the unique id and title and docks it at
+// side, which must not be dock.Center.
+//
+// The panel becomes a tab of the last added panel of the same side that is
+// docked, or is docked at side of the whole docked area if there is none.
+// The same placement is used when the panel is shown again after it was
+// closed, floated or auto-hidden.
+func (m *DockManager) AddPanel(id, title string, side dock.Side) (*DockPanel, error) {
+	if id == "" || id == dock.Document {
+		return nil, errs.NewInvalidArgumentError("invalid panel id")
+	}
+	if m.panels[id] != nil {
+		return nil, errs.NewInvalidArgumentError("panel id already used")
+	}
+	if side < dock.Left || side > dock.Bottom {
+		return nil, errs.NewInvalidArgumentError("invalid side")
+	}
+
+	dp, err := newDockPanel(m, m.parking, id, title, side)
+	if err != nil {
+		return nil, err
+	}
+
+	m.panels[id] = dp
+	m.order = append(m.order, id)
+
+	if err := m.apply(func(l *dock.Layout) error {
+		return m.dockHome(l, dp)
+	}); err != nil {
+		return nil, err
+	}
+
+	return dp, nil
+}
+
+// dockHome docks dp at its home position, see AddPanel.
+func (m *DockManager) dockHome(l *dock.Layout, dp *DockPanel) error {
+	for i := len(m.order) - 1; i >= 0; i-- {
+		other := m.panels[m.order[i]]
+		if other != dp && other.side == dp.side && l.State(other.id) == dock.StateDocked {
+			return l.Dock(dp.id, other.id, dock.Center, 0)
+		}
+	}
+
+	return l.Dock(dp.id, "", dp.side, dockEdgeFraction)
+}
+
+// Panel returns the DockPanel with id, or nil.
+func (m *DockManager) Panel(id string) *DockPanel {
+	return m.panels[id]
+}
+
+// Panels returns the DockPanels in the order they were added.
+func (m *DockManager) Panels() []*DockPanel {
+	panels := make([]*DockPanel, len(m.order))
+	for i, id := range m.order {
+		panels[i] = m.panels[id]
+	}
+
+	return panels
+}
+
+func (m *DockManager) panel(id string) (*DockPanel, error) {
+	dp := m.panels[id]
+	if dp == nil {
+		return nil, errs.NewInvalidArgumentError("unknown panel id")
+	}
+
+	return dp, nil
+}
+
+// ShowPanel makes the DockPanel with id visible. A closed panel is docked at
+// its home position, see AddPanel, an auto-hidden one flies out and one in a
+// tab group becomes the current tab.
+func (m *DockManager) ShowPanel(id string) error {
+	dp, err := m.panel(id)
+	if err != nil {
+		return err
+	}
+
+	switch m.layout.State(id) {
+	case dock.StateHidden:
+		return m.apply(func(l *dock.Layout) error {
+			return m.dockHome(l, dp)
+		})
+
+	case dock.StateAutoHidden:
+		return m.showFlyout(dp)
+	}
+
+	m.layout.SetCurrent(id)
+
+	if tp, ok := dp.Parent().(*TabPage); ok && tp.tabWidget != nil {
+		return tp.tabWidget.SetCurrentIndex(tp.tabWidget.pages.Index(tp))
+	}
+
+	return nil
+}
+
+// ClosePanel hides the DockPanel with id. It can be shown again with
+// ShowPanel.
+func (m *DockManager) ClosePanel(id string) error {
+	if _, err := m.panel(id); err != nil {
+		return err
+	}
+
+	return m.apply(func(l *dock.Layout) error {
+		l.Remove(id)
+		return nil
+	})
+}
+
+// Dock docks the DockPanel with id at side of target, see dock.Layout.Dock.
+func (m *DockManager) Dock(id, target string, side dock.Side, fraction float64) error {
+	if _, err := m.panel(id); err != nil {
+		return err
+	}
+	if target != "" && target != dock.Document {
+		if _, err := m.panel(target); err != nil {
+			return err
+		}
+	}
+
+	return m.apply(func(l *dock.Layout) error {
+		return l.Dock(id, target, side, fraction)
+	})
+}
+
+// Float moves the DockPanel with id to a new tool window with bounds in
+// native pixels. Empty bounds place the window at the mouse cursor with the
+// current size of the panel.
+func (m *DockManager) Float(id string, bounds Rectangle) error {
+	dp, err := m.panel(id)
+	if err != nil {
+		return err
+	}
+
+	if bounds.Width <= 0 || bounds.Height <= 0 {
+		bounds = m.floatingBoundsAt(dp, cursorPosPixels())
+	}
+
+	return m.apply(func(l *dock.Layout) error {
+		return l.Float(id, dockRectFromRectangle(bounds))
+	})
+}
+
+// AutoHide collapses the DockPanel with id to a button at side, which must
+// not be dock.Center, of the DockManager.
+func (m *DockManager) AutoHide(id string, side dock.Side) error {
+	dp, err := m.panel(id)
+	if err != nil {
+		return err
+	}
+
+	size := m.IntFrom96DPI(dockDefaultSize96)
+	if dp.Visible() && user32.IsWindowVisible(dp.Handle()) {
+		b := dp.BoundsPixels()
+		if side.Orientation() == dock.Horizontal {
+			size = b.Width
+		} else {
+			size = b.Height
+		}
+	}
+
+	return m.apply(func(l *dock.Layout) error {
+		return l.AutoHide(id, side, size)
+	})
+}
+
+// DockLayout returns a copy of the current arrangement of the DockPanels.
+func (m *DockManager) DockLayout() *dock.Layout {
+	m.syncLayout()
+
+	return m.layout.Clone()
+}
+
+// SetDockLayout arranges the DockPanels as described by layout. Panels unknown
+// to the DockManager are dropped from it, panels missing in it are hidden.
+func (m *DockManager) SetDockLayout(layout *dock.Layout) error {
+	if layout == nil {
+		return errs.NewInvalidArgumentError("layout must not be nil")
+	}
+
+	l := layout.Clone()
+	if err := l.Validate(); err != nil {
+		return errs.WrapError(err)
+	}
+
+	l.Retain(m.order)
+
+	m.layout = l
+
+	if err := m.realize(); err != nil {
+		return err
+	}
+
+	m.layoutChangedPublisher.Publish()
+
+	return nil
+}
+
+// LayoutChanged returns the event that is published when the arrangement
+// of the DockPanels changed, except for sizes.
+func (m *DockManager) LayoutChanged() *Event {
+	return m.layoutChangedPublisher.Event()
+}
+
+// SaveLayout stores the layout as JSON under key in the Settings of the
+// application.
+func (m *DockManager) SaveLayout(key string) error {
+	settings := App().Settings()
+	if settings == nil {
+		return errs.NewError("App().Settings() must not be nil")
+	}
+
+	data, err := m.DockLayout().Encode()
+	if err != nil {
+		return errs.WrapError(err)
+	}
+
+	return settings.Put(key, string(data))
+}
+
+// RestoreLayout restores the layout stored by SaveLayout under key. It does
+// nothing if there is none.
+func (m *DockManager) RestoreLayout(key string) error {
+	settings := App().Settings()
+	if settings == nil {
+		return errs.NewError("App().Settings() must not be nil")
+	}
+
+	data, ok := settings.Get(key)
+	if !ok || data == "" {
+		return nil
+	}
+
+	return m.setLayoutJSON(data)
+}
+
+func (m *DockManager) setLayoutJSON(data string) error {
+	l, err := dock.Decode([]byte(data))
+	if err != nil {
+		return errs.WrapError(err)
+	}
+
+	return m.SetDockLayout(l)
+}
+
+// SaveState saves the layout and the state of the document and the panels.
+func (m *DockManager) SaveState() error {
+	data, err := m.DockLayout().Encode()
+	if err != nil {
+		return errs.WrapError(err)
+	}
+
+	if err := m.WriteState(string(data)); err != nil {
+		return err
+	}
+
+	return m.forEachPersistableContent(func(p Persistable) error {
+		return p.SaveState()
+	})
+}
+
+// RestoreState restores the layout and the state of the document and the
+// panels.
+func (m *DockManager) RestoreState() error {
+	data, err := m.ReadState()
+	if err != nil {
+		return err
+	}
+
+	if data != "" {
+		if err := m.setLayoutJSON(data); err != nil {
+			return err
+		}
+	}
+
+	return m.forEachPersistableContent(func(p Persistable) error {
+		return p.RestoreState()
+	})
+}
+
+func (m *DockManager) forEachPersistableContent(f func(p Persistable) error) error {
+	if m.document.Persistent() {
+		if err := f(m.document); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range m.order {
+		if content := m.panels[id].content; content.Persistent() {
+			if err := f(content); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// apply changes the layout with f and realizes it.
+func (m *DockManager) apply(f func(l *dock.Layout) error) error {
+	m.syncLayout()
+
+	if err := f(m.layout); err != nil {
+		return errs.WrapError(err)
+	}
+
+	if err := m.realize(); err != nil {
+		return err
+	}
+
+	m.layoutChangedPublisher.Publish()
+
+	return nil
+}
+
+// syncLayout updates the weights of the splits, the bounds of floating
+// windows and the size of the flyout from the widgets.
+func (m *DockManager) syncLayout() {
+	for _, s := range m.splits {
+		sizes := make([]int, len(s.widgets))
+		var total int
+
+		for i, w := range s.widgets {
+			if w.IsDisposed() {
+				total = 0
+				break
+			}
+
+			b := w.BoundsPixels()
+			if s.node.Orientation == dock.Horizontal {
+				sizes[i] = b.Width
+			} else {
+				sizes[i] = b.Height
+			}
+			total += sizes[i]
+		}
+
+		if total <= 0 || len(s.widgets) != len(s.node.Children) {
+			continue
+		}
+
+		s.node.Weights = make([]float64, len(sizes))
+		for i, size := range sizes {
+			s.node.Weights[i] = float64(size) / float64(total)
+		}
+	}
+
+	for f, mw := range m.floats {
+		if !mw.IsDisposed() {
+			f.Bounds = dockRectFromRectangle(mw.BoundsPixels())
+		}
+	}
+
+	m.syncFlyoutSize()
+}
+
+// realize rebuilds the widgets from the layout.
+func (m *DockManager) realize() error {
+	m.realizing = true
+	defer func() {
+		m.realizing = false
+	}()
+
+	m.hideFlyout()
+
+	m.SetSuspended(true)
+	defer m.SetSuspended(false)
+
+	// Park everything, so that disposing the old structure keeps it.
+	for _, id := range m.order {
+		if err := m.panels[id].SetParent(m.parking); err != nil {
+			return err
+		}
+	}
+	if err := m.document.SetParent(m.parking); err != nil {
+		return err
+	}
+
+	m.disposeChildren(m.dockArea)
+	m.splits = nil
+
+	if m.layout.Root != nil {
+		if _, err := m.build(m.dockArea, m.layout.Root); err != nil {
+			return err
+		}
+	}
+
+	floats := make(map[*dock.Floating]*MainWindow)
+	for _, f := range m.layout.Floating {
+		mw := m.floats[f]
+		created := mw == nil
+		if created {
+			var err error
+			if mw, err = m.newFloatingWindow(f); err != nil {
+				return err
+			}
+		}
+		floats[f] = mw
+
+		m.disposeChildren(mw.clientComposite)
+
+		if _, err := m.build(mw.clientComposite, f.Root); err != nil {
+			return err
+		}
+
+		m.updateFloatingTitle(f, mw)
+
+		if created {
+			mw.Show()
+		} else {
+			mw.RequestLayout()
+		}
+	}
+	for f, mw := range m.floats {
+		if floats[f] == nil {
+			mw.Dispose()
+		}
+	}
+	m.floats = floats
+
+	if err := m.buildStrips(); err != nil {
+		return err
+	}
+
+	for _, dp := range m.panels {
+		dp.caption.Invalidate()
+	}
+
+	return nil
+}
+
+func (m *DockManager) disposeChildren(c Container) {
+	children := c.Children()
+
+	widgets := make([]Widget, children.Len())
+	for i := range widgets {
+		widgets[i] = children.At(i)
+	}
+
+	for _, w := range widgets {
+		w.Dispose()
+	}
+}
+
+// build creates the widgets for n in parent and returns the top one.
+func (m *DockManager) build(parent Container, n *dock.Node) (Widget, error) {
+	switch {
+	case n.IsSplit():
+		var s *Splitter
+		var err error
+		if n.Orientation == dock.Horizontal {
+			s, err = NewHSplitter(parent)
+		} else {
+			s, err = NewVSplitter(parent)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s.SetPersistent(false)
+
+		split := dockSplit{node: n}
+		for _, child := range n.Children {
+			w, err := m.build(s, child)
+			if err != nil {
+				return nil, err
+			}
+
+			split.widgets = append(split.widgets, w)
+		}
+
+		layout := s.Layout().(*splitterLayout)
+		for i, w := range split.widgets {
+			factor := int(n.Weight(i)*1000 + 0.5)
+			if factor < 1 {
+				factor = 1
+			}
+
+			if err := layout.SetStretchFactor(w, factor); err != nil {
+				return nil, err
+			}
+		}
+
+		m.splits = append(m.splits, split)
+
+		return s, nil
+
+	case n.Document:
+		return m.document, m.document.SetParent(parent)
+
+	case len(n.Panels) == 1:
+		dp := m.panels[n.Panels[0]]
+
+		return dp, dp.SetParent(parent)
+	}
+
+	tw, err := NewTabWidget(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	tw.SetPersistent(false)
+
+	for _, id := range n.Panels {
+		dp := m.panels[id]
+
+		page, err := NewTabPage()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := page.SetTitle(dp.title); err != nil {
+			return nil, err
+		}
+
+		layout := NewVBoxLayout()
+		if err := layout.SetMargins(Margins{}); err != nil {
+			return nil, err
+		}
+		if err := page.SetLayout(layout); err != nil {
+			return nil, err
+		}
+
+		if err := tw.Pages().Add(page); err != nil {
+			return nil, err
+		}
+
+		if err := dp.SetParent(page); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.SetCurrentIndex(n.Current); err != nil {
+		return nil, err
+	}
+
+	tw.CurrentIndexChanged().Attach(func() {
+		if i := tw.CurrentIndex(); i > -1 && !m.realizing {
+			n.Current = i
+			m.updateFloatingTitles()
+		}
+	})
+
+	return tw, nil
+}
+
+// newFloatingWindow returns a new hidden tool window for f.
+func (m *DockManager) newFloatingWindow(f *dock.Floating) (*MainWindow, error) {
+	mw, err := m.newToolWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := rectangleFromDockRect(f.Bounds)
+	if bounds.Width > 0 && bounds.Height > 0 {
+		if err := mw.SetBoundsPixels(bounds); err != nil {
+			mw.Dispose()
+			return nil, err
+		}
+	}
+
+	mw.Closing().Attach(func(canceled *bool, reason CloseReason) {
+		if m.realizing {
+			return
+		}
+
+		// Closing the window closes its panels.
+		*canceled = true
+
+		var ids []string
+		f.Root.Walk(func(n *dock.Node) {
+			ids = append(ids, n.Panels...)
+		})
+
+		m.Synchronize(func() {
+			m.apply(func(l *dock.Layout) error {
+				for _, id := range ids {
+					l.Remove(id)
+				}
+				return nil
+			})
+		})
+	})
+
+	return mw, nil
+}
+
+// newToolWindow returns a new hidden MainWindow without margins, owned by
+// the form of m and without taskbar button.
+func (m *DockManager) newToolWindow() (*MainWindow, error) {
+	mw, err := NewMainWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			mw.Dispose()
+		}
+	}()
+
+	mw.SetPersistent(false)
+
+	layout := NewVBoxLayout()
+	if err := layout.SetMargins(Margins{}); err != nil {
+		return nil, err
+	}
+	if err := mw.SetLayout(layout); err != nil {
+		return nil, err
+	}
+
+	exStyle := uint32(user32.GetWindowLong(mw.hWnd, user32.GWL_EXSTYLE))
+	user32.SetWindowLong(mw.hWnd, user32.GWL_EXSTYLE, int32(exStyle|user32.WS_EX_TOOLWINDOW))
+
+	if form := m.Form(); form != nil {
+		if err := mw.SetOwner(form); err != nil {
+			return nil, err
+		}
+	}
+
+	succeeded = true
+
+	return mw, nil
+}
+
+// firstPanel returns the current panel of the first tab group of n.
+func firstPanel(n *dock.Node) string {
+	for n.IsSplit() {
+		n = n.Children[0]
+	}
+
+	if n.Current < len(n.Panels) {
+		return n.Panels[n.Current]
+	}
+
+	return ""
+}
+
+func (m *DockManager) updateFloatingTitle(f *dock.Floating, mw *MainWindow) {
+	if dp := m.panels[firstPanel(f.Root)]; dp != nil {
+		mw.SetTitle(dp.title)
+	}
+}
+
+func (m *DockManager) updateFloatingTitles() {
+	for f, mw := range m.floats {
+		m.updateFloatingTitle(f, mw)
+	}
+}
+
+func (m *DockManager) onPanelTitleChanged(dp *DockPanel) {
+	if tp, ok := dp.Parent().(*TabPage); ok {
+		tp.SetTitle(dp.title)
+	}
+
+	m.updateFloatingTitles()
+
+	if m.layout.State(dp.id) == dock.StateAutoHidden {
+		m.buildStrips()
+	}
+}
+
+func (m *DockManager) onCaptionButtonClicked(dp *DockPanel, button dockCaptionButton) {
+	state := m.layout.State(dp.id)
+
+	switch button {
+	case dockCaptionCloseButton:
+		m.ClosePanel(dp.id)
+
+	case dockCaptionAutoHideButton:
+		if state == dock.StateAutoHidden {
+			m.apply(func(l *dock.Layout) error {
+				return m.dockHome(l, dp)
+			})
+		} else {
+			m.AutoHide(dp.id, dp.side)
+		}
+
+	case dockCaptionFloatButton:
+		if state == dock.StateFloating {
+			m.apply(func(l *dock.Layout) error {
+				return m.dockHome(l, dp)
+			})
+		} else {
+			bounds := m.floatingBoundsAt(dp, cursorPosPixels())
+			if b, ok := windowRectPixels(dp.Handle()); ok && user32.IsWindowVisible(dp.Handle()) {
+				bounds.X, bounds.Y = b.X, b.Y
+			}
+
+			m.Float(dp.id, bounds)
+		}
+	}
+}
+
+// buildStrips recreates the buttons of auto-hidden panels at the edges.
+func (m *DockManager) buildStrips() error {
+	for side, strip := range m.strips {
+		m.disposeChildren(strip)
+
+		var count int
+		for _, ah := range m.layout.AutoHidden {
+			if int(ah.Side) != side {
+				continue
+			}
+
+			dp := m.panels[ah.Panel]
+
+			pb, err := NewPushButton(strip)
+			if err != nil {
+				return err
+			}
+			if err := pb.SetText(dp.title); err != nil {
+				return err
+			}
+
+			pb.Clicked().Attach(func() {
+				if m.flyoutPanel == dp {
+					m.hideFlyout()
+				} else {
+					m.showFlyout(dp)
+				}
+			})
+
+			count++
+		}
+
+		if count == 0 {
+			strip.SetVisible(false)
+			continue
+		}
+
+		var err error
+		if dock.Side(side).Orientation() == dock.Horizontal {
+			_, err = NewVSpacer(strip)
+		} else {
+			_, err = NewHSpacer(strip)
+		}
+		if err != nil {
+			return err
+		}
+
+		strip.SetVisible(true)
+	}
+
+	return nil
+}
+
+// showFlyout shows the auto-hidden dp in a popup window above the docked
+// area, next to its button.
+func (m *DockManager) showFlyout(dp *DockPanel) error {
+	ah := m.layout.AutoHiddenOf(dp.id)
+	if ah == nil {
+		return errs.NewInvalidArgumentError("panel not auto-hidden")
+	}
+
+	m.hideFlyout()
+
+	if m.flyout == nil {
+		flyout, err := m.newToolWindow()
+		if err != nil {
+			return err
+		}
+
+		style := uint32(user32.GetWindowLong(flyout.hWnd, user32.GWL_STYLE))
+		style &^= user32.WS_OVERLAPPEDWINDOW
+		style |= user32.WS_POPUP | user32.WS_THICKFRAME
+		user32.SetWindowLong(flyout.hWnd, user32.GWL_STYLE, int32(style))
+
+		flyout.Deactivating().Attach(func() {
+			m.Synchronize(m.hideFlyout)
+		})
+		flyout.Closing().Attach(func(canceled *bool, reason CloseReason) {
+			if !m.realizing {
+				*canceled = true
+				m.Synchronize(m.hideFlyout)
+			}
+		})
+
+		m.flyout = flyout
+	}
+
+	area, ok := windowRectPixels(m.dockArea.Handle())
+	if !ok {
+		return errs.LastError("GetWindowRect")
+	}
+
+	size := ah.Size
+	if size <= 0 {
+		size = m.IntFrom96DPI(dockDefaultSize96)
+	}
+
+	bounds := area
+	switch ah.Side {
+	case dock.Left:
+		bounds.Width = size
+
+	case dock.Top:
+		bounds.Height = size
+
+	case dock.Right:
+		bounds.X += area.Width - size
+		bounds.Width = size
+
+	case dock.Bottom:
+		bounds.Y += area.Height - size
+		bounds.Height = size
+	}
+
+	if err := dp.SetParent(m.flyout.clientComposite); err != nil {
+		return err
+	}
+
+	m.flyoutPanel = dp
+	dp.caption.Invalidate()
+
+	if err := m.flyout.SetBoundsPixels(bounds); err != nil {
+		return err
+	}
+
+	m.flyout.SetTitle(dp.title)
+	m.flyout.Show()
+
+	return nil
+}
+
+func (m *DockManager) hideFlyout() {
+	dp := m.flyoutPanel
+	if dp == nil {
+		return
+	}
+
+	m.syncFlyoutSize()
+
+	m.flyoutPanel = nil
+
+	m.flyout.Hide()
+	dp.SetParent(m.parking)
+}
+
+func (m *DockManager) syncFlyoutSize() {
+	if m.flyoutPanel == nil {
+		return
+	}
+
+	if ah := m.layout.AutoHiddenOf(m.flyoutPanel.id); ah != nil {
+		b := m.flyout.BoundsPixels()
+		if ah.Side.Orientation() == dock.Horizontal {
+			ah.Size = b.Width
+		} else {
+			ah.Size = b.Height
+		}
+	}
+}
+
+// floatingBoundsAt returns the bounds of a tool window for dp, so that p is
+// in its title bar.
+func (m *DockManager) floatingBoundsAt(dp *DockPanel, p Point) Rectangle {
+	size := dp.SizePixels()
+	if size.Width <= 0 || size.Height <= 0 || !user32.IsWindowVisible(dp.Handle()) {
+		s := m.IntFrom96DPI(dockDefaultSize96)
+		size = Size{s, s}
+	}
+
+	return Rectangle{
+		X:      p.X - m.IntFrom96DPI(dockFloatingOffsetX96),
+		Y:      p.Y - m.IntFrom96DPI(dockFloatingOffsetY96),
+		Width:  size.Width,
+		Height: size.Height,
+	}
+}
+
+func (m *DockManager) dragging() bool {
+	return m.dragPanel != nil
+}
+
+func (m *DockManager) beginDrag(dp *DockPanel) {
+	m.dragPanel = dp
+}
+
+func (m *DockManager) updateDrag(p Point) {
+	if drop, ok := m.dropAt(p); ok {
+		m.showIndicator(drop.bounds)
+	} else {
+		m.hideIndicator()
+	}
+}
+
+func (m *DockManager) endDrag(p Point) {
+	dp := m.dragPanel
+	drop, ok := m.dropAt(p)
+
+	m.dragPanel = nil
+	m.hideIndicator()
+
+	if !ok {
+		return
+	}
+
+	// Dropping restructures the layout, which may dispose the window
+	// currently processing the mouse message.
+	m.Synchronize(func() {
+		if drop.float {
+			m.Float(dp.id, drop.bounds)
+			return
+		}
+
+		fraction := 0.5
+		if drop.target == "" {
+			fraction = dockEdgeFraction
+		}
+
+		m.Dock(dp.id, drop.target, drop.side, fraction)
+	})
+}
+
+// dropAt returns where the dragged panel would be dropped at p, in native
+// pixels and screen coordinates.
+func (m *DockManager) dropAt(p Point) (dockDrop, bool) {
+	dp := m.dragPanel
+
+	if area, ok := windowRectPixels(m.dockArea.Handle()); ok {
+		r := dockRectFromRectangle(area)
+		if side, ok := dock.EdgeSide(r, p.X, p.Y, m.IntFrom96DPI(dockEdgeBand96)); ok {
+			return dockDrop{
+				side:   side,
+				bounds: rectangleFromDockRect(dock.DropPreview(r, side, dockEdgeFraction)),
+			}, true
+		}
+	}
+
+	// Floating windows are above the docked panels.
+	var ids []string
+	for _, f := range m.layout.Floating {
+		f.Root.Walk(func(n *dock.Node) {
+			ids = append(ids, n.Panels...)
+		})
+	}
+	m.layout.Root.Walk(func(n *dock.Node) {
+		ids = append(ids, n.Panels...)
+	})
+
+	for _, id := range ids {
+		other := m.panels[id]
+		if other == dp || !user32.IsWindowVisible(other.Handle()) {
+			continue
+		}
+
+		b, ok := windowRectPixels(other.Handle())
+		if !ok {
+			continue
+		}
+
+		r := dockRectFromRectangle(b)
+		if side, ok := dock.DropSide(r, p.X, p.Y, true); ok {
+			if side != dock.Center {
+				b = rectangleFromDockRect(dock.DropPreview(r, side, 0.5))
+			}
+
+			return dockDrop{target: id, side: side, bounds: b}, true
+		}
+	}
+
+	if user32.IsWindowVisible(m.document.Handle()) {
+		if b, ok := windowRectPixels(m.document.Handle()); ok {
+			r := dockRectFromRectangle(b)
+			if side, ok := dock.DropSide(r, p.X, p.Y, false); ok {
+				return dockDrop{
+					target: dock.Document,
+					side:   side,
+					bounds: rectangleFromDockRect(dock.DropPreview(r, side, 0.5)),
+				}, true
+			}
+		}
+	}
+
+	// Dropping elsewhere on a window of the DockManager does nothing,
+	// dropping outside floats the panel.
+	var hwnds []handle.HWND
+	if form := m.Form(); form != nil {
+		hwnds = append(hwnds, form.Handle())
+	}
+	for _, mw := range m.floats {
+		hwnds = append(hwnds, mw.Handle())
+	}
+	for _, hwnd := range hwnds {
+		if b, ok := windowRectPixels(hwnd); ok && dockRectFromRectangle(b).Contains(p.X, p.Y) {
+			return dockDrop{}, false
+		}
+	}
+
+	return dockDrop{float: true, bounds: m.floatingBoundsAt(dp, p)}, true
+}
+
+// showIndicator shows a translucent window at bounds, in native pixels and
+// screen coordinates, to preview where a dragged panel would be dropped.
+func (m *DockManager) showIndicator(bounds Rectangle) {
+	if m.hwndIndicator == 0 {
+		if err := m.createIndicator(); err != nil {
+			return
+		}
+	}
+
+	user32.SetWindowPos(
+		m.hwndIndicator,
+		user32.HWND_TOPMOST,
+		int32(bounds.X),
+		int32(bounds.Y),
+		int32(bounds.Width),
+		int32(bounds.Height),
+		user32.SWP_NOACTIVATE|user32.SWP_SHOWWINDOW)
+}
+
+func (m *DockManager) hideIndicator() {
+	if m.hwndIndicator != 0 {
+		user32.ShowWindow(m.hwndIndicator, user32.SW_HIDE)
+	}
+}
+
+func (m *DockManager) createIndicator() error {
+	if !dockIndicatorClassRegistered {
+		var wc user32.WNDCLASSEX
+		wc.CbSize = uint32(unsafe.Sizeof(wc))
+		wc.LpfnWndProc = defaultWndProcPtr
+		wc.HInstance = kernel32.GetModuleHandle(nil)
+		wc.HbrBackground = user32.COLOR_HIGHLIGHT + 1
+		wc.LpszClassName = syscall.StringToUTF16Ptr(dockIndicatorWindowClass)
+
+		if atom := user32.RegisterClassEx(&wc); atom == 0 {
+			return errs.NewWin32Error("RegisterClassEx")
+		}
+
+		dockIndicatorClassRegistered = true
+	}
+
+	m.hwndIndicator = user32.CreateWindowEx(
+		user32.WS_EX_LAYERED|user32.WS_EX_TOOLWINDOW|user32.WS_EX_TOPMOST|user32.WS_EX_NOACTIVATE|user32.WS_EX_TRANSPARENT,
+		syscall.StringToUTF16Ptr(dockIndicatorWindowClass),
+		nil,
+		user32.WS_POPUP,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		nil)
+	if m.hwndIndicator == 0 {
+		return errs.NewWin32Error("CreateWindowEx")
+	}
+
+	user32.SetLayeredWindowAttributes(m.hwndIndicator, 0, 80, user32.LWA_ALPHA)
+
+	return nil
+}
+
+func cursorPosPixels() Point {
+	var p gdi32.POINT
+	user32.GetCursorPos(&p)
+
+	return Point{int(p.X), int(p.Y)}
+}
+
+// windowRectPixels returns the bounds of the window in native pixels and
+// screen coordinates.
+func windowRectPixels(hwnd handle.HWND) (Rectangle, bool) {
+	var r gdi32.RECT
+	if !user32.GetWindowRect(hwnd, &r) {
+		return Rectangle{}, false
+	}
+
+	return rectangleFromRECT(r), true
+}
+
+func dockRectFromRectangle(r Rectangle) dock.Rect {
+	return dock.Rect{X: r.X, Y: r.Y, Width: r.Width, Height: r.Height}
+}
+
+func rectangleFromDockRect(r dock.Rect) Rectangle {
+	return Rectangle{X: r.X, Y: r.Y, Width: r.Width, Height: r.Height}
+}
@@ -0,0 +1,333 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"github.com/Gipcomp/winapi/dock"
+)
+
+const (
+	dockCaptionHeight96 = 22
+	dockCaptionButton96 = 18
+	dockCaptionGlyph96  = 5
+)
+
+// dockCaptionButton identifies a button in the caption of a DockPanel.
+type dockCaptionButton int
+
+const (
+	dockCaptionNoButton dockCaptionButton = iota - 1
+	dockCaptionCloseButton
+	dockCaptionAutoHideButton
+	dockCaptionFloatButton
+	dockCaptionButtonCount
+)
+
+// DockPanel is a panel managed by a DockManager. It consists of a caption,
+// which shows the title, buttons to float, auto-hide and close the panel and
+// serves as handle for dragging the panel to another place, and a Content
+// composite that holds the widgets of the panel.
+//
+// DockPanels are created with DockManager.AddPanel.
+type DockPanel struct {
+	*Composite
+	manager               *DockManager
+	id                    string
+	title                 string
+	side                  dock.Side
+	caption               *CustomWidget
+	content               *Composite
+	pressedButton         dockCaptionButton
+	pressing              bool
+	dragStart             Point // in native pixels, screen coordinates
+	titleChangedPublisher EventPublisher
+}
+
+func newDockPanel(manager *DockManager, parent Container, id, title string, side dock.Side) (*DockPanel, error) {
+	composite, err := NewComposite(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	dp := &DockPanel{
+		Composite:     composite,
+		manager:       manager,
+		id:            id,
+		title:         title,
+		side:          side,
+		pressedButton: dockCaptionNoButton,
+	}
+
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			dp.Dispose()
+		}
+	}()
+
+	if err := InitWrapperWindow(dp); err != nil {
+		return nil, err
+	}
+
+	dp.SetPersistent(false)
+
+	layout := NewVBoxLayout()
+	if err := layout.SetMargins(Margins{}); err != nil {
+		return nil, err
+	}
+	if err := layout.SetSpacing(0); err != nil {
+		return nil, err
+	}
+	if err := dp.SetLayout(layout); err != nil {
+		return nil, err
+	}
+
+	if dp.caption, err = NewCustomWidgetPixels(dp, 0, dp.paintCaption); err != nil {
+		return nil, err
+	}
+	dp.caption.SetPaintMode(PaintBuffered)
+	dp.caption.SetInvalidatesOnResize(true)
+	if err := dp.caption.SetMinMaxSize(Size{0, dockCaptionHeight96}, Size{0, dockCaptionHeight96}); err != nil {
+		return nil, err
+	}
+
+	dp.caption.MouseDown().Attach(dp.onCaptionMouseDown)
+	dp.caption.MouseMove().Attach(dp.onCaptionMouseMove)
+	dp.caption.MouseUp().Attach(dp.onCaptionMouseUp)
+
+	if dp.content, err = NewComposite(dp); err != nil {
+		return nil, err
+	}
+
+	succeeded = true
+
+	return dp, nil
+}
+
+// ID returns the identifier of the DockPanel, which is unique within its
+// DockManager and used in the dock.Layout.
+func (dp *DockPanel) ID() string {
+	return dp.id
+}
+
+// Manager returns the DockManager of the DockPanel.
+func (dp *DockPanel) Manager() *DockManager {
+	return dp.manager
+}
+
+// Content returns the Composite that holds the widgets of the DockPanel.
+func (dp *DockPanel) Content() *Composite {
+	return dp.content
+}
+
+// Title returns the text shown in the caption, tab or window of the
+// DockPanel.
+func (dp *DockPanel) Title() string {
+	return dp.title
+}
+
+// SetTitle sets the text shown in the caption, tab or window of the
+// DockPanel.
+func (dp *DockPanel) SetTitle(title string) error {
+	if title == dp.title {
+		return nil
+	}
+
+	dp.title = title
+
+	dp.caption.Invalidate()
+	dp.manager.onPanelTitleChanged(dp)
+
+	dp.titleChangedPublisher.Publish()
+
+	return nil
+}
+
+// TitleChanged returns the event that is published when the title of the
+// DockPanel changed.
+func (dp *DockPanel) TitleChanged() *Event {
+	return dp.titleChangedPublisher.Event()
+}
+
+// State returns where the DockPanel currently is.
+func (dp *DockPanel) State() dock.State {
+	return dp.manager.layout.State(dp.id)
+}
+
+// Close hides the DockPanel, see DockManager.ClosePanel.
+func (dp *DockPanel) Close() error {
+	return dp.manager.ClosePanel(dp.id)
+}
+
+// captionButtonRect returns the bounds of button in the caption in native
+// pixels.
+func (dp *DockPanel) captionButtonRect(button dockCaptionButton) Rectangle {
+	bounds := dp.caption.ClientBoundsPixels()
+	size := dp.IntFrom96DPI(dockCaptionButton96)
+
+	return Rectangle{
+		X:      bounds.Width - (int(button)+1)*(size+dp.IntFrom96DPI(2)),
+		Y:      (bounds.Height - size) / 2,
+		Width:  size,
+		Height: size,
+	}
+}
+
+func (dp *DockPanel) captionButtonAt(x, y int) dockCaptionButton {
+	for button := dockCaptionCloseButton; button < dockCaptionButtonCount; button++ {
+		r := dp.captionButtonRect(button)
+		if x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height {
+			return button
+		}
+	}
+
+	return dockCaptionNoButton
+}
+
+func (dp *DockPanel) paintCaption(canvas *Canvas, updateBounds Rectangle) error {
+	bounds := dp.caption.ClientBoundsPixels()
+
+	background, err := NewSystemColorBrush(SysColorInactiveCaption)
+	if err != nil {
+		return err
+	}
+	if err := canvas.FillRectanglePixels(background, bounds); err != nil {
+		return err
+	}
+
+	textColor := background.Color()
+	if textBrush, err := NewSystemColorBrush(SysColorInactiveCaptionText); err == nil {
+		textColor = textBrush.Color()
+	}
+
+	pen, err := NewCosmeticPen(PenSolid, textColor)
+	if err != nil {
+		return err
+	}
+	defer pen.Dispose()
+
+	var pressedBrush *SolidColorBrush
+	if dp.pressedButton != dockCaptionNoButton {
+		if pressedBrush, err = NewSolidColorBrush(RGB(218, 218, 218)); err != nil {
+			return err
+		}
+		defer pressedBrush.Dispose()
+	}
+
+	glyph := dp.IntFrom96DPI(dockCaptionGlyph96)
+
+	for button := dockCaptionCloseButton; button < dockCaptionButtonCount; button++ {
+		r := dp.captionButtonRect(button)
+
+		if button == dp.pressedButton {
+			canvas.FillRectanglePixels(pressedBrush, r)
+		}
+
+		l, t, rt, b := r.X+glyph, r.Y+glyph, r.X+r.Width-glyph, r.Y+r.Height-glyph
+
+		switch button {
+		case dockCaptionCloseButton:
+			canvas.DrawLinePixels(pen, Point{l, t}, Point{rt, b})
+			canvas.DrawLinePixels(pen, Point{rt - 1, t}, Point{l - 1, b})
+
+		case dockCaptionAutoHideButton:
+			// A pin, pointing down while docked and to the left while
+			// auto-hidden.
+			mx, my := (l+rt)/2, (t+b)/2
+			if dp.State() == dock.StateAutoHidden {
+				canvas.DrawRectanglePixels(pen, Rectangle{mx - 1, t + 1, rt - mx + 1, b - t - 2})
+				canvas.DrawLinePixels(pen, Point{mx - 1, t}, Point{mx - 1, b})
+				canvas.DrawLinePixels(pen, Point{l, my}, Point{mx - 1, my})
+			} else {
+				canvas.DrawRectanglePixels(pen, Rectangle{l + 1, t, rt - l - 2, my - t + 1})
+				canvas.DrawLinePixels(pen, Point{l, my}, Point{rt, my})
+				canvas.DrawLinePixels(pen, Point{mx, my}, Point{mx, b})
+			}
+
+		case dockCaptionFloatButton:
+			// A window while docked, two windows while floating.
+			if dp.State() == dock.StateFloating {
+				canvas.DrawRectanglePixels(pen, Rectangle{l + 2, t, rt - l - 2, b - t - 2})
+				canvas.DrawRectanglePixels(pen, Rectangle{l, t + 2, rt - l - 2, b - t - 2})
+			} else {
+				canvas.DrawRectanglePixels(pen, Rectangle{l, t, rt - l, b - t})
+				canvas.DrawLinePixels(pen, Point{l, t + 1}, Point{rt, t + 1})
+			}
+		}
+	}
+
+	textBounds := bounds
+	textBounds.X += dp.IntFrom96DPI(4)
+	textBounds.Width = dp.captionButtonRect(dockCaptionButtonCount-1).X - textBounds.X
+
+	return canvas.DrawTextPixels(
+		dp.title,
+		dp.Font(),
+		textColor,
+		textBounds,
+		TextLeft|TextVCenter|TextSingleLine|TextEndEllipsis)
+}
+
+func (dp *DockPanel) onCaptionMouseDown(x, y int, button MouseButton) {
+	if button != LeftButton {
+		return
+	}
+
+	dp.pressing = true
+	dp.dragStart = cursorPosPixels()
+
+	if dp.pressedButton = dp.captionButtonAt(x, y); dp.pressedButton != dockCaptionNoButton {
+		dp.caption.Invalidate()
+	}
+}
+
+func (dp *DockPanel) onCaptionMouseMove(x, y int, button MouseButton) {
+	if !dp.pressing || dp.pressedButton != dockCaptionNoButton {
+		return
+	}
+
+	p := cursorPosPixels()
+
+	if !dp.manager.dragging() {
+		threshold := dp.IntFrom96DPI(4)
+		dx, dy := p.X-dp.dragStart.X, p.Y-dp.dragStart.Y
+		if dx > -threshold && dx < threshold && dy > -threshold && dy < threshold {
+			return
+		}
+
+		dp.manager.beginDrag(dp)
+	}
+
+	dp.manager.updateDrag(p)
+}
+
+func (dp *DockPanel) onCaptionMouseUp(x, y int, button MouseButton) {
+	if button != LeftButton || !dp.pressing {
+		return
+	}
+
+	dp.pressing = false
+
+	if dp.manager.dragging() {
+		dp.manager.endDrag(cursorPosPixels())
+		return
+	}
+
+	pressed := dp.pressedButton
+	dp.pressedButton = dockCaptionNoButton
+	dp.caption.Invalidate()
+
+	if pressed == dockCaptionNoButton || pressed != dp.captionButtonAt(x, y) {
+		return
+	}
+
+	// The buttons restructure the layout, which may dispose the window
+	// currently processing the mouse message.
+	dp.Synchronize(func() {
+		dp.manager.onCaptionButtonClicked(dp, pressed)
+	})
+}
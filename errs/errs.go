@@ -7,45 +7,224 @@
 package errs
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"runtime/debug"
+	"sync"
+	"syscall"
 
 	"github.com/Gipcomp/win32/kernel32"
 	"github.com/Gipcomp/win32/win"
 )
 
+// Code classifies the failure an Error represents.
+type Code int
+
+const (
+	CodeUnknown Code = iota
+	CodeWin32
+	CodeInvalidArgument
+	CodeDisposed
+	CodeNotSupported
+)
+
+func (c Code) String() string {
+	switch c {
+	case CodeWin32:
+		return "Win32"
+	case CodeInvalidArgument:
+		return "InvalidArgument"
+	case CodeDisposed:
+		return "Disposed"
+	case CodeNotSupported:
+		return "NotSupported"
+	}
+
+	return "Unknown"
+}
+
+// Sentinel errors for use with errors.Is. An *Error matches the sentinel of
+// its Code.
 var (
-	logErrors    bool
-	panicOnError bool
+	ErrWin32           = errors.New("win32 API call failed")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrDisposed        = errors.New("object disposed")
+	ErrNotSupported    = errors.New("not supported")
+)
+
+var code2Sentinel = map[Code]error{
+	CodeWin32:           ErrWin32,
+	CodeInvalidArgument: ErrInvalidArgument,
+	CodeDisposed:        ErrDisposed,
+	CodeNotSupported:    ErrNotSupported,
+}
+
+// Hook is called with every error created or wrapped by this package, e.g.
+// to feed it into a structured logger.
+type Hook func(err *Error)
+
+var (
+	hooksMutex    sync.RWMutex
+	hooks         []Hook
+	panicFilter   func(err *Error) bool
+	logHookHandle = -1
 )
 
 type Error struct {
-	inner   error
-	message string
-	stack   []byte
+	inner    error
+	message  string
+	stack    []byte
+	code     Code
+	funcName string
+	errno    uint32
+	hresult  win.HRESULT
+}
+
+// AddHook registers hook to be called for every error and returns a handle
+// for RemoveHook.
+func AddHook(hook Hook) int {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	for i, h := range hooks {
+		if h == nil {
+			hooks[i] = hook
+			return i
+		}
+	}
+
+	hooks = append(hooks, hook)
+
+	return len(hooks) - 1
+}
+
+// RemoveHook unregisters the hook identified by handle.
+func RemoveHook(handle int) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	if handle >= 0 && handle < len(hooks) {
+		hooks[handle] = nil
+	}
+}
+
+// SetPanicFilter sets a function that decides whether an error returned by
+// NewError, LastError, WrapError etc. makes the package panic. A nil filter
+// never panics.
+func SetPanicFilter(filter func(err *Error) bool) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	panicFilter = filter
 }
 
 func LogErrors() bool {
-	return logErrors
+	hooksMutex.RLock()
+	defer hooksMutex.RUnlock()
+
+	return logHookHandle != -1
 }
 
+// SetLogErrors registers or removes a Hook that logs errors via the log
+// package.
 func SetLogErrors(v bool) {
-	logErrors = v
+	if v == LogErrors() {
+		return
+	}
+
+	if v {
+		handle := AddHook(logHook)
+
+		hooksMutex.Lock()
+		logHookHandle = handle
+		hooksMutex.Unlock()
+	} else {
+		hooksMutex.Lock()
+		handle := logHookHandle
+		logHookHandle = -1
+		hooksMutex.Unlock()
+
+		RemoveHook(handle)
+	}
+}
+
+func logHook(err *Error) {
+	log.Print(err.Error())
 }
 
 func PanicOnError() bool {
-	return panicOnError
+	hooksMutex.RLock()
+	defer hooksMutex.RUnlock()
+
+	return panicFilter != nil
 }
 
+// SetPanicOnError is a shorthand for a SetPanicFilter that panics for every
+// error.
 func SetPanicOnError(v bool) {
-	panicOnError = v
+	if v {
+		SetPanicFilter(func(*Error) bool { return true })
+	} else {
+		SetPanicFilter(nil)
+	}
 }
 
 func (err *Error) Inner() error {
 	return err.inner
 }
 
+// Unwrap returns the inner error, for use with errors.Is and errors.As.
+func (err *Error) Unwrap() error {
+	return err.inner
+}
+
+// Is reports whether target is the sentinel error of err's Code.
+func (err *Error) Is(target error) bool {
+	sentinel, ok := code2Sentinel[err.code]
+	return ok && target == sentinel
+}
+
+// Code returns the failure class of err.
+func (err *Error) Code() Code {
+	return err.code
+}
+
+// Func returns the name of the failed Win32 function, if any.
+func (err *Error) Func() string {
+	return err.funcName
+}
+
+// Errno returns the GetLastError code of a failed Win32 function, or 0.
+func (err *Error) Errno() uint32 {
+	return err.errno
+}
+
+// HRESULT returns the result of a failed COM call, or 0.
+func (err *Error) HRESULT() win.HRESULT {
+	return err.hresult
+}
+
+// Fields returns the structured data of err, e.g. for structured logging.
+func (err *Error) Fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"code":    err.code.String(),
+		"message": err.Message(),
+	}
+
+	if err.funcName != "" {
+		fields["func"] = err.funcName
+	}
+	if err.errno != 0 {
+		fields["errno"] = err.errno
+	}
+	if err.hresult != 0 {
+		fields["hresult"] = fmt.Sprintf("0x%08X", uint32(err.hresult))
+	}
+
+	return fields
+}
+
 func (err *Error) Message() string {
 	if err.message != "" {
 		return err.message
@@ -70,22 +249,50 @@ func (err *Error) Error() string {
 	return fmt.Sprintf("%s\n\nStack:\n%s", err.Message(), err.stack)
 }
 
+// asError returns the first *Error in the chain of err or, for foreign
+// errors, a new one wrapping err, so hooks and the panic filter see every
+// error.
+func asError(err error) *Error {
+	var walkErr *Error
+	if errors.As(err, &walkErr) {
+		return walkErr
+	}
+
+	return &Error{inner: err, stack: debug.Stack()}
+}
+
 func processErrorNoPanic(err error) error {
-	if logErrors {
-		if walkErr, ok := err.(*Error); ok {
-			log.Print(walkErr.Error())
-		} else {
-			log.Printf("%s\n\nStack:\n%s", err, debug.Stack())
+	if err == nil {
+		return nil
+	}
+
+	walkErr := asError(err)
+
+	hooksMutex.RLock()
+	hs := append([]Hook(nil), hooks...)
+	hooksMutex.RUnlock()
+
+	for _, hook := range hs {
+		if hook != nil {
+			hook(walkErr)
 		}
 	}
 
 	return err
 }
 
+func shouldPanic(err error) bool {
+	hooksMutex.RLock()
+	filter := panicFilter
+	hooksMutex.RUnlock()
+
+	return err != nil && filter != nil && filter(asError(err))
+}
+
 func processError(err error) error {
 	processErrorNoPanic(err)
 
-	if panicOnError {
+	if shouldPanic(err) {
 		panic(err)
 	}
 
@@ -96,6 +303,10 @@ func newErr(message string) error {
 	return &Error{message: message, stack: debug.Stack()}
 }
 
+func newCodeErr(code Code, message string) *Error {
+	return &Error{code: code, message: message, stack: debug.Stack()}
+}
+
 func NewError(message string) error {
 	return processError(newErr(message))
 }
@@ -104,16 +315,59 @@ func NewErrorNoPanic(message string) error {
 	return processErrorNoPanic(newErr(message))
 }
 
+// NewErrorWithCode returns an Error of the specified Code.
+func NewErrorWithCode(code Code, message string) error {
+	return processError(newCodeErr(code, message))
+}
+
+// NewInvalidArgumentError returns an Error that matches ErrInvalidArgument.
+func NewInvalidArgumentError(message string) error {
+	return NewErrorWithCode(CodeInvalidArgument, message)
+}
+
+// NewDisposedError returns an Error that matches ErrDisposed.
+func NewDisposedError(message string) error {
+	return NewErrorWithCode(CodeDisposed, message)
+}
+
+// NewNotSupportedError returns an Error that matches ErrNotSupported.
+func NewNotSupportedError(message string) error {
+	return NewErrorWithCode(CodeNotSupported, message)
+}
+
+// NewWin32Error returns an Error that matches ErrWin32 for a failed Win32
+// function or message that does not report a GetLastError code.
+func NewWin32Error(win32FuncName string) error {
+	err := newCodeErr(CodeWin32, win32FuncName+" failed")
+	err.funcName = win32FuncName
+
+	return processError(err)
+}
+
+// LastError returns an Error that matches ErrWin32 and carries the
+// GetLastError code, which is also available as syscall.Errno through
+// errors.As. Only use it for functions documented to set the last error;
+// most GDI functions do not, use NewWin32Error for those.
 func LastError(win32FuncName string) error {
+	err := newCodeErr(CodeWin32, win32FuncName)
+	err.funcName = win32FuncName
+
 	if errno := kernel32.GetLastError(); errno != kernel32.ERROR_SUCCESS {
-		return NewError(fmt.Sprintf("%s: Error %d", win32FuncName, errno))
+		err.message = fmt.Sprintf("%s: Error %d", win32FuncName, errno)
+		err.errno = errno
+		err.inner = syscall.Errno(errno)
 	}
 
-	return NewError(win32FuncName)
+	return processError(err)
 }
 
+// ErrorFromHRESULT returns an Error that matches ErrWin32 and carries hr.
 func ErrorFromHRESULT(funcName string, hr win.HRESULT) error {
-	return NewError(fmt.Sprintf("%s: Error %d", funcName, hr))
+	err := newCodeErr(CodeWin32, fmt.Sprintf("%s: Error %d", funcName, hr))
+	err.funcName = funcName
+	err.hresult = hr
+
+	return processError(err)
 }
 
 func wrapErr(err error) error {
@@ -121,7 +375,15 @@ func wrapErr(err error) error {
 		return err
 	}
 
-	return &Error{inner: err, stack: debug.Stack()}
+	walkErr := &Error{inner: err, stack: debug.Stack()}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		walkErr.code = CodeWin32
+		walkErr.errno = uint32(errno)
+	}
+
+	return walkErr
 }
 
 func WrapErrorNoPanic(err error) error {
@@ -150,7 +412,7 @@ func toErrorNoPanic(x interface{}) error {
 func toError(x interface{}) error {
 	err := toErrorNoPanic(x)
 
-	if panicOnError {
+	if shouldPanic(err) {
 		panic(err)
 	}
 
@@ -0,0 +1,89 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewWin32Error(t *testing.T) {
+	err := NewWin32Error("CreateDIBSection")
+
+	if !errors.Is(err, ErrWin32) {
+		t.Errorf("errors.Is(%v, ErrWin32) = false", err)
+	}
+
+	var walkErr *Error
+	if !errors.As(err, &walkErr) {
+		t.Fatalf("errors.As(%v, *Error) = false", err)
+	}
+	if walkErr.Func() != "CreateDIBSection" || walkErr.Errno() != 0 {
+		t.Errorf("Func() = %q, Errno() = %d", walkErr.Func(), walkErr.Errno())
+	}
+}
+
+func TestHooksSeeWrappedErrors(t *testing.T) {
+	var got []*Error
+	handle := AddHook(func(err *Error) {
+		got = append(got, err)
+	})
+	defer RemoveHook(handle)
+
+	inner := newCodeErr(CodeDisposed, "disposed")
+	processErrorNoPanic(fmt.Errorf("context: %w", inner))
+
+	foreign := errors.New("foreign")
+	processErrorNoPanic(foreign)
+
+	if len(got) != 2 {
+		t.Fatalf("hook called %d times, want 2", len(got))
+	}
+	if got[0] != inner {
+		t.Errorf("hook got %v, want the wrapped *Error", got[0])
+	}
+	if got[1].Inner() != foreign {
+		t.Errorf("hook got %v, want an *Error wrapping the foreign error", got[1])
+	}
+}
+
+func TestPanicFilterSeesWrappedErrors(t *testing.T) {
+	SetPanicFilter(func(err *Error) bool {
+		return err.Code() == CodeDisposed || err.Inner() != nil
+	})
+	defer SetPanicFilter(nil)
+
+	for _, test := range []struct {
+		err   error
+		panic bool
+	}{
+		{newCodeErr(CodeDisposed, "disposed"), true},
+		{fmt.Errorf("context: %w", newCodeErr(CodeDisposed, "disposed")), true},
+		{errors.New("foreign"), true},
+		{newCodeErr(CodeInvalidArgument, "invalid"), false},
+	} {
+		if got := shouldPanic(test.err); got != test.panic {
+			t.Errorf("shouldPanic(%q) = %t, want %t", test.err.Error(), got, test.panic)
+		}
+	}
+}
+
+func TestPanicOnError(t *testing.T) {
+	SetPanicOnError(true)
+	defer SetPanicOnError(false)
+
+	defer func() {
+		if x := recover(); !errors.Is(x.(error), ErrInvalidArgument) {
+			t.Errorf("recovered %v, want an invalid argument error", x)
+		}
+	}()
+
+	NewInvalidArgumentError("invalid")
+
+	t.Error("NewInvalidArgumentError did not panic")
+}
@@ -40,16 +40,16 @@ func (l *FlowLayout) StretchFactor(widget Widget) int {
 func (l *FlowLayout) SetStretchFactor(widget Widget, factor int) error {
 	if factor != l.StretchFactor(widget) {
 		if l.container == nil {
-			return errs.NewError("container required")
+			return errs.NewInvalidArgumentError("container required")
 		}
 
 		handle := widget.Handle()
 
 		if !l.container.Children().containsHandle(handle) {
-			return errs.NewError("unknown widget")
+			return errs.NewInvalidArgumentError("unknown widget")
 		}
 		if factor < 1 {
-			return errs.NewError("factor must be >= 1")
+			return errs.NewInvalidArgumentError("factor must be >= 1")
 		}
 
 		l.hwnd2StretchFactor[handle] = factor
@@ -59,7 +59,7 @@ type Font struct {
 // NewFont returns a new Font with the specified attributes.
 func NewFont(family string, pointSize int, style FontStyle) (*Font, error) {
 	if style > FontBold|FontItalic|FontUnderline|FontStrikeOut {
-		return nil, errs.NewError("invalid style")
+		return nil, errs.NewInvalidArgumentError("invalid style")
 	}
 
 	fi := fontInfo{
@@ -85,7 +85,7 @@ func NewFont(family string, pointSize int, style FontStyle) (*Font, error) {
 
 func newFontFromLOGFONT(lf *gdi32.LOGFONT, dpi int) (*Font, error) {
 	if lf == nil {
-		return nil, errs.NewError("lf cannot be nil")
+		return nil, errs.NewInvalidArgumentError("lf cannot be nil")
 	}
 
 	family := win.UTF16PtrToString(&lf.LfFaceName[0])
@@ -144,7 +144,7 @@ func (f *Font) createForDPI(dpi int) (gdi32.HFONT, error) {
 
 	hFont := gdi32.CreateFontIndirect(&lf)
 	if hFont == 0 {
-		return 0, errs.NewError("CreateFontIndirect failed")
+		return 0, errs.NewWin32Error("CreateFontIndirect")
 	}
 
 	return hFont, nil
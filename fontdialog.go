@@ -0,0 +1,150 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/comdlg32"
+	"github.com/Gipcomp/win32/gdi32"
+	"github.com/Gipcomp/win32/handle"
+	"github.com/Gipcomp/win32/kernel32"
+	"github.com/Gipcomp/winapi/errs"
+	"golang.org/x/sys/windows"
+)
+
+var procChooseFont = windows.NewLazySystemDLL("comdlg32.dll").NewProc("ChooseFontW")
+
+const (
+	cfScreenFonts         = 0x00000001
+	cfInitToLogFontStruct = 0x00000040
+	cfEffects             = 0x00000100
+	cfForceFontExist      = 0x00010000
+	cfNoVertFonts         = 0x01000000
+	cfScalableOnly        = 0x00020000
+	cfLimitSize           = 0x00002000
+)
+
+type chooseFont struct {
+	lStructSize    uint32
+	hwndOwner      handle.HWND
+	hDC            gdi32.HDC
+	lpLogFont      *gdi32.LOGFONT
+	iPointSize     int32
+	flags          uint32
+	rgbColors      gdi32.COLORREF
+	lCustData      uintptr
+	lpfnHook       uintptr
+	lpTemplateName *uint16
+	hInstance      kernel32.HINSTANCE
+	lpszStyle      *uint16
+	nFontType      uint16
+	_              uint16
+	nSizeMin       int32
+	nSizeMax       int32
+}
+
+// FontDialog wraps the common font dialog.
+type FontDialog struct {
+	Font *Font
+
+	// Effects shows the underline and strikeout options.
+	Effects bool
+
+	// ScalableOnly restricts the choice to scalable fonts.
+	ScalableOnly bool
+
+	// MinPointSize and MaxPointSize limit the point size, if greater than 0.
+	MinPointSize, MaxPointSize int
+}
+
+// Show shows the dialog and returns whether the user accepted a font, which
+// is then stored in Font.
+func (dlg *FontDialog) Show(owner Form) (accepted bool, err error) {
+	dpi := screenDPI()
+	if owner != nil {
+		dpi = owner.AsWindowBase().DPI()
+	}
+
+	var lf gdi32.LOGFONT
+	if font := dlg.Font; font != nil {
+		lf.LfHeight = -int32(font.PointSize() * dpi / 72)
+		lf.LfWeight = gdi32.FW_NORMAL
+		if font.Style()&FontBold != 0 {
+			lf.LfWeight = gdi32.FW_BOLD
+		}
+		if font.Style()&FontItalic != 0 {
+			lf.LfItalic = 1
+		}
+		if font.Style()&FontUnderline != 0 {
+			lf.LfUnderline = 1
+		}
+		if font.Style()&FontStrikeOut != 0 {
+			lf.LfStrikeOut = 1
+		}
+		lf.LfCharSet = gdi32.DEFAULT_CHARSET
+
+		family, err := syscall.UTF16FromString(font.Family())
+		if err != nil {
+			return false, errs.WrapError(err)
+		}
+		copy(lf.LfFaceName[:len(lf.LfFaceName)-1], family)
+	}
+
+	cf := chooseFont{
+		lpLogFont: &lf,
+		flags:     cfScreenFonts | cfForceFontExist | cfNoVertFonts,
+	}
+	cf.lStructSize = uint32(unsafe.Sizeof(cf))
+	if owner != nil {
+		cf.hwndOwner = owner.Handle()
+	}
+	if dlg.Font != nil {
+		cf.flags |= cfInitToLogFontStruct
+	}
+	if dlg.Effects {
+		cf.flags |= cfEffects
+	}
+	if dlg.ScalableOnly {
+		cf.flags |= cfScalableOnly
+	}
+	if dlg.MinPointSize > 0 || dlg.MaxPointSize > 0 {
+		cf.flags |= cfLimitSize
+		cf.nSizeMin = int32(dlg.MinPointSize)
+		cf.nSizeMax = int32(dlg.MaxPointSize)
+		if cf.nSizeMax == 0 {
+			cf.nSizeMax = 0x7fff
+		}
+	}
+
+	ret, _, _ := syscall.Syscall(procChooseFont.Addr(), 1, uintptr(unsafe.Pointer(&cf)), 0, 0)
+	if ret == 0 {
+		if errCode := comdlg32.CommDlgExtendedError(); errCode != 0 {
+			return false, errs.NewErrorWithCode(errs.CodeWin32, fmt.Sprintf("ChooseFont: Error %d", errCode))
+		}
+
+		return false, nil
+	}
+
+	font, err := newFontFromLOGFONT(&lf, dpi)
+	if err != nil {
+		return false, err
+	}
+
+	if cf.iPointSize > 0 {
+		// iPointSize is more precise than what can be derived from lfHeight.
+		if font, err = NewFont(font.Family(), int(cf.iPointSize+5)/10, font.Style()); err != nil {
+			return false, err
+		}
+	}
+
+	dlg.Font = font
+
+	return true, nil
+}
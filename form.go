@@ -8,6 +8,7 @@ package winapi
 
 import (
 	"fmt"
+	"log"
 	"math"
 	"sync"
 	"syscall"
@@ -172,11 +173,66 @@ func (fb *FormBase) init(form Form) error {
 func (fb *FormBase) Dispose() {
 	if fb.hWnd != 0 {
 		fb.quitLayoutPerformer <- struct{}{}
+
+		delete(formHWnd2DPIOverride, fb.hWnd)
 	}
 
 	fb.WindowBase.Dispose()
 }
 
+// formHWnd2DPIOverride maps the handles of forms to the DPI set with
+// SetDPIOverride. It is only accessed from the UI thread.
+var formHWnd2DPIOverride map[handle.HWND]int
+
+// DPIOverride returns the DPI set with SetDPIOverride, or 0 if the form uses
+// the DPI of its monitor.
+func (fb *FormBase) DPIOverride() int {
+	return formHWnd2DPIOverride[fb.hWnd]
+}
+
+// SetDPIOverride makes the form and its descendants scale as if they were on
+// a monitor with dpi, regardless of the monitor they are actually on, e.g. to
+// render them reproducibly in visual tests. A dpi of 0 restores the DPI of
+// the monitor.
+func (fb *FormBase) SetDPIOverride(dpi int) {
+	oldDPI := fb.DPI()
+
+	if dpi == 0 {
+		delete(formHWnd2DPIOverride, fb.hWnd)
+	} else {
+		if formHWnd2DPIOverride == nil {
+			formHWnd2DPIOverride = make(map[handle.HWND]int)
+		}
+		formHWnd2DPIOverride[fb.hWnd] = dpi
+	}
+
+	if newDPI := fb.DPI(); newDPI != oldDPI {
+		fb.applyDPIChange(newDPI)
+		fb.RequestLayout()
+	}
+}
+
+// applyDPIChange applies dpi to the form and its descendants.
+func (fb *FormBase) applyDPIChange(dpi int) {
+	wasSuspended := fb.Suspended()
+	fb.SetSuspended(true)
+	defer fb.SetSuspended(wasSuspended)
+
+	seenInApplyFontToDescendantsDuringDPIChange = make(map[*WindowBase]bool)
+	seenInApplyDPIToDescendantsDuringDPIChange = make(map[*WindowBase]bool)
+	defer func() {
+		seenInApplyFontToDescendantsDuringDPIChange = nil
+		seenInApplyDPIToDescendantsDuringDPIChange = nil
+	}()
+
+	fb.clientComposite.ApplyDPI(dpi)
+	fb.ApplyDPI(dpi)
+	if fb.progressIndicator != nil {
+		fb.progressIndicator.SetOverlayIcon(fb.progressIndicator.overlayIcon, fb.progressIndicator.overlayIconDescription)
+	}
+	applyDPIToDescendants(fb.window, dpi)
+}
+
 func (fb *FormBase) AsContainerBase() *ContainerBase {
 	if fb.clientComposite == nil {
 		return nil
@@ -397,6 +453,16 @@ func (fb *FormBase) handleKeyDown(msg *user32.MSG) bool {
 
 	key, mods := Key(msg.WParam), ModifiersDown()
 
+	// Inspector
+	if s := App().InspectorShortcut(); s.Key != 0 && s.Key == key && s.Modifiers == mods {
+		if form, ok := fb.window.(Form); ok {
+			if _, err := ShowInspector(form); err != nil {
+				log.Print(err)
+			}
+		}
+		return true
+	}
+
 	// Tabbing
 	if key == KeyTab && (mods&ModControl) != 0 {
 		doTabbing := func(tw *TabWidget) {
@@ -722,6 +788,27 @@ func (fb *FormBase) startLayout() bool {
 	return true
 }
 
+// PerformLayout lays out the contents of the form for its current size
+// synchronously and applies the results before returning. Usually layout
+// happens asynchronously, which is undesirable when the result must be
+// available immediately, e.g. before taking a Screenshot.
+func (fb *FormBase) PerformLayout() error {
+	size := maxSize(SizeFrom96DPI(fb.minSize96dpi, fb.DPI()), fb.SizePixels())
+	cs := fb.clientSizeFromSizePixels(size)
+
+	cbp := fb.window.ClientBoundsPixels()
+
+	fb.clientComposite.SetBoundsPixels(Rectangle{Y: cbp.Y, Width: cs.Width, Height: cs.Height})
+
+	cli := CreateLayoutItemsForContainer(fb)
+	cli.Geometry().ClientSize = cs
+
+	done := make(chan []LayoutResult)
+	go layoutTree(cli, cs, make(chan struct{}), done, nil)
+
+	return applyLayoutResults(<-done, nil)
+}
+
 func (fb *FormBase) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr) uintptr {
 	switch msg {
 	case user32.WM_ACTIVATE:
@@ -790,7 +877,7 @@ func (fb *FormBase) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr
 		}.toPOINT()
 		return 0
 
-	case user32.WM_NOTIFY:
+	case user32.WM_NOTIFY, user32.WM_DRAWITEM:
 		return fb.clientComposite.WndProc(hwnd, msg, wParam, lParam)
 
 	case user32.WM_SETTEXT:
@@ -843,27 +930,12 @@ func (fb *FormBase) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr
 		fb.ApplySysColors()
 
 	case user32.WM_DPICHANGED:
-		wasSuspended := fb.Suspended()
-		fb.SetSuspended(true)
-		defer fb.SetSuspended(wasSuspended)
-
-		dpi := int(win.HIWORD(uint32(wParam)))
-
-		seenInApplyFontToDescendantsDuringDPIChange = make(map[*WindowBase]bool)
-		seenInApplyDPIToDescendantsDuringDPIChange = make(map[*WindowBase]bool)
-		defer func() {
-			seenInApplyFontToDescendantsDuringDPIChange = nil
-			seenInApplyDPIToDescendantsDuringDPIChange = nil
-		}()
-
-		fb.clientComposite.ApplyDPI(dpi)
-		fb.ApplyDPI(dpi)
-		if fb.progressIndicator != nil {
-			fb.progressIndicator.SetOverlayIcon(fb.progressIndicator.overlayIcon, fb.progressIndicator.overlayIconDescription)
+		if fb.DPIOverride() != 0 {
+			// The form keeps its DPI when moved to another monitor.
+			break
 		}
-		applyDPIToDescendants(fb.window, dpi)
 
-		fb.SetSuspended(wasSuspended)
+		fb.applyDPIChange(int(win.HIWORD(uint32(wParam))))
 
 		rc := (*gdi32.RECT)(unsafe.Pointer(lParam))
 		bounds := rectangleFromRECT(*rc)
@@ -206,7 +206,7 @@ func newWidgetGraphicsEffectList(observer widgetGraphicsEffectListObserver) *Wid
 
 func (l *WidgetGraphicsEffectList) Add(effect WidgetGraphicsEffect) error {
 	if effect == nil {
-		return errs.NewError("effect == nil")
+		return errs.NewInvalidArgumentError("effect == nil")
 	}
 
 	return l.Insert(len(l.items), effect)
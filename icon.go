@@ -378,7 +378,7 @@ func createAlphaCursorOrIconFromBitmap(bmp *Bitmap, hotspot Point, fIcon bool) (
 	// Create an empty mask bitmap.
 	hMonoBitmap := gdi32.CreateBitmap(int32(bmp.size.Width), int32(bmp.size.Height), 1, 1, nil)
 	if hMonoBitmap == 0 {
-		return 0, errs.NewError("CreateBitmap failed")
+		return 0, errs.NewWin32Error("CreateBitmap")
 	}
 	defer gdi32.DeleteObject(gdi32.HGDIOBJ(hMonoBitmap))
 
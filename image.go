@@ -126,7 +126,7 @@ func (pfi *PaintFuncImage) drawStretchedOnCanvasPixels(canvas *Canvas, bounds Re
 		return pfi.paint(canvas, RectangleTo96DPI(bounds, canvas.DPI()))
 	}
 
-	return errs.NewError("paint(Pixels) func is nil")
+	return errs.NewInvalidArgumentError("paint(Pixels) func is nil")
 }
 
 func (pfi *PaintFuncImage) Dispose() {
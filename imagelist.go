@@ -50,7 +50,7 @@ func NewImageListForDPI(imageSize Size, maskColor Color, dpi int) (*ImageList, e
 		8,
 		8)
 	if hIml == 0 {
-		return nil, errs.NewError("ImageList_Create failed")
+		return nil, errs.NewWin32Error("ImageList_Create")
 	}
 
 	return &ImageList{
@@ -70,7 +70,7 @@ func (il *ImageList) Handle() comctl32.HIMAGELIST {
 
 func (il *ImageList) Add(bitmap, maskBitmap *Bitmap) (int, error) {
 	if bitmap == nil {
-		return 0, errs.NewError("bitmap cannot be nil")
+		return 0, errs.NewInvalidArgumentError("bitmap cannot be nil")
 	}
 
 	key := bitmapMaskedBitmap{bitmap: bitmap, mask: maskBitmap}
@@ -86,7 +86,7 @@ func (il *ImageList) Add(bitmap, maskBitmap *Bitmap) (int, error) {
 
 	index := int(comctl32.ImageList_Add(il.hIml, bitmap.handle(), maskHandle))
 	if index == -1 {
-		return 0, errs.NewError("ImageList_Add failed")
+		return 0, errs.NewWin32Error("ImageList_Add")
 	}
 
 	il.bitmapMaskedBitmap2Index[key] = index
@@ -96,7 +96,7 @@ func (il *ImageList) Add(bitmap, maskBitmap *Bitmap) (int, error) {
 
 func (il *ImageList) AddMasked(bitmap *Bitmap) (int32, error) {
 	if bitmap == nil {
-		return 0, errs.NewError("bitmap cannot be nil")
+		return 0, errs.NewInvalidArgumentError("bitmap cannot be nil")
 	}
 
 	if index, ok := il.colorMaskedBitmap2Index[bitmap]; ok {
@@ -108,7 +108,7 @@ func (il *ImageList) AddMasked(bitmap *Bitmap) (int32, error) {
 		bitmap.handle(),
 		gdi32.COLORREF(il.maskColor))
 	if index == -1 {
-		return 0, errs.NewError("ImageList_AddMasked failed")
+		return 0, errs.NewWin32Error("ImageList_AddMasked")
 	}
 
 	il.colorMaskedBitmap2Index[bitmap] = int(index)
@@ -118,7 +118,7 @@ func (il *ImageList) AddMasked(bitmap *Bitmap) (int32, error) {
 
 func (il *ImageList) AddIcon(icon *Icon) (int32, error) {
 	if icon == nil {
-		return 0, errs.NewError("icon cannot be nil")
+		return 0, errs.NewInvalidArgumentError("icon cannot be nil")
 	}
 
 	if index, ok := il.icon2Index[icon]; ok {
@@ -127,7 +127,7 @@ func (il *ImageList) AddIcon(icon *Icon) (int32, error) {
 
 	index := comctl32.ImageList_ReplaceIcon(il.hIml, -1, icon.handleForDPI(il.dpi))
 	if index == -1 {
-		return 0, errs.NewError("ImageList_ReplaceIcon failed")
+		return 0, errs.NewWin32Error("ImageList_ReplaceIcon")
 	}
 
 	il.icon2Index[icon] = index
@@ -190,7 +190,7 @@ func imageListForImage(image interface{}, dpi int) (hIml comctl32.HIMAGELIST, is
 
 		hIml = comctl32.ImageList_Create(w, h, comctl32.ILC_MASK|comctl32.ILC_COLOR32, 8, 8)
 		if hIml == 0 {
-			return 0, false, errs.NewError("ImageList_Create failed")
+			return 0, false, errs.NewWin32Error("ImageList_Create")
 		}
 	}
 
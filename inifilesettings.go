@@ -8,6 +8,7 @@ package winapi
 
 import (
 	"bufio"
+	"bytes"
 	"os"
 	"path/filepath"
 	"sort"
@@ -15,13 +16,29 @@ import (
 	"time"
 
 	"github.com/Gipcomp/winapi/errs"
+	"golang.org/x/sys/windows"
 )
 
 const iniFileTimeStampFormat = "2006-01-02"
 
+// IniFileSettings stores settings in an ini file.
+//
+// Keys containing '/' are grouped into sections: "MainWindow/Splitter" is
+// stored as "Splitter=..." below "[MainWindow]". Values with leading or
+// trailing white space, line breaks or tabs are written double-quoted with
+// backslash escapes. Comments and lines IniFileSettings does not understand
+// are preserved when saving.
+//
+// Save writes a temporary file and renames it over the settings file, while
+// holding a lock that serializes access by several running instances. Keys
+// changed by another instance since the last Load or Save are merged in,
+// unless they were changed here as well, in which case the change with the
+// more recent timestamp wins.
 type IniFileSettings struct {
 	fileName       string
 	key2Record     map[string]iniFileRecord
+	base           map[string]iniFileRecord
+	doc            *iniFileDocument
 	expireDuration time.Duration
 	portable       bool
 }
@@ -35,6 +52,7 @@ func NewIniFileSettings(fileName string) *IniFileSettings {
 	return &IniFileSettings{
 		fileName:   fileName,
 		key2Record: make(map[string]iniFileRecord),
+		base:       make(map[string]iniFileRecord),
 	}
 }
 
@@ -58,15 +76,13 @@ func (ifs *IniFileSettings) PutExpiring(key, value string) error {
 
 func (ifs *IniFileSettings) put(key, value string, expiring bool) error {
 	if key == "" {
-		return errs.NewError("key must not be empty")
+		return errs.NewInvalidArgumentError("key must not be empty")
 	}
-	// if strings.IndexAny(key, "|=\r\n") > -1 {
 	if strings.ContainsAny(key, "|=\r\n") {
-		return errs.NewError("key contains at least one of the invalid characters '|=\\r\\n'")
+		return errs.NewInvalidArgumentError("key contains at least one of the invalid characters '|=\\r\\n'")
 	}
-	// if strings.IndexAny(value, "\r\n") > -1 {
-	if strings.ContainsAny(value, "\r\n") {
-		return errs.NewError("value contains at least one of the invalid characters '\\r\\n'")
+	if strings.IndexAny(key, "[;#") == 0 || strings.TrimSpace(key) != key {
+		return errs.NewInvalidArgumentError("key must not start with '[', ';' or '#' or have leading or trailing white space")
 	}
 
 	var timestamp time.Time
@@ -85,6 +101,17 @@ func (ifs *IniFileSettings) Remove(key string) error {
 	return nil
 }
 
+func (ifs *IniFileSettings) Keys() []string {
+	keys := make([]string, 0, len(ifs.key2Record))
+	for key := range ifs.key2Record {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
 func (ifs *IniFileSettings) ExpireDuration() time.Duration {
 	return ifs.expireDuration
 }
@@ -102,8 +129,14 @@ func (ifs *IniFileSettings) SetPortable(portable bool) {
 }
 
 func (ifs *IniFileSettings) FilePath() string {
-	if ifs.portable {
-		absPath, err := filepath.Abs(ifs.fileName)
+	return settingsFilePath(ifs.fileName, ifs.portable)
+}
+
+// settingsFilePath returns the absolute path of fileName if portable is true,
+// otherwise its path in the application's AppData directory.
+func settingsFilePath(fileName string, portable bool) string {
+	if portable {
+		absPath, err := filepath.Abs(fileName)
 		if err != nil {
 			return ""
 		}
@@ -120,116 +153,450 @@ func (ifs *IniFileSettings) FilePath() string {
 		appDataPath,
 		App().OrganizationName(),
 		App().ProductName(),
-		ifs.fileName)
+		fileName)
 }
 
-func (ifs *IniFileSettings) fileExists() (bool, error) {
+// Load reads the settings file. Values already put are kept, unless the file
+// contains the same key.
+func (ifs *IniFileSettings) Load() error {
 	filePath := ifs.FilePath()
 
-	if _, err := os.Stat(filePath); err != nil {
-		// FIXME: Not necessarily a file does not exist error.
-		return false, nil
-	}
+	return withIniFileLock(filePath, false, func() error {
+		doc, key2Record, err := readIniFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		for key, record := range key2Record {
+			ifs.key2Record[key] = record
+		}
 
-	return true, nil
+		ifs.base = key2Record
+		ifs.doc = doc
+
+		return nil
+	})
 }
 
-func (ifs *IniFileSettings) withFile(flags int, f func(file *os.File) error) error {
+// Save merges the settings with changes other instances made to the settings
+// file since it was loaded or saved the last time and atomically replaces
+// the file.
+func (ifs *IniFileSettings) Save() error {
 	filePath := ifs.FilePath()
 
-	dirPath, _ := filepath.Split(filePath)
-	if err := os.MkdirAll(dirPath, 0644); err != nil {
-		return errs.WrapError(err)
+	return withIniFileLock(filePath, true, func() error {
+		doc, diskKey2Record, err := readIniFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		merged := ifs.merge(diskKey2Record)
+
+		for key, record := range merged {
+			if ifs.expireDuration > 0 && !record.timestamp.IsZero() && time.Since(record.timestamp) >= ifs.expireDuration {
+				delete(merged, key)
+			}
+		}
+
+		if err := writeFileAtomically(filePath, doc.render(merged)); err != nil {
+			return err
+		}
+
+		ifs.key2Record = merged
+		ifs.base = make(map[string]iniFileRecord, len(merged))
+		for key, record := range merged {
+			ifs.base[key] = record
+		}
+		ifs.doc = doc
+
+		return nil
+	})
+}
+
+// merge returns diskKey2Record with the local changes since the last Load or
+// Save applied. If a key has been changed both locally and on disk, the
+// record with the more recent timestamp wins. Local changes to non-expiring
+// values, which have no timestamp, and local removals always win.
+func (ifs *IniFileSettings) merge(diskKey2Record map[string]iniFileRecord) map[string]iniFileRecord {
+	merged := make(map[string]iniFileRecord, len(diskKey2Record))
+	for key, record := range diskKey2Record {
+		merged[key] = record
 	}
 
-	file, err := os.OpenFile(filePath, flags, 0644)
-	if err != nil {
-		return errs.WrapError(err)
+	keys := make(map[string]bool, len(ifs.key2Record))
+	for key := range ifs.key2Record {
+		keys[key] = true
 	}
-	defer file.Close()
+	for key := range ifs.base {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		local, localOK := ifs.key2Record[key]
+		base, baseOK := ifs.base[key]
+		if localOK == baseOK && local.equal(base) {
+			continue
+		}
 
-	return f(file)
+		disk, diskOK := diskKey2Record[key]
+		diskChanged := diskOK != baseOK || !disk.equal(base)
+
+		if diskChanged && diskOK && localOK && !local.timestamp.IsZero() && disk.timestamp.After(local.timestamp) {
+			continue
+		}
+
+		if localOK {
+			merged[key] = local
+		} else {
+			delete(merged, key)
+		}
+	}
+
+	return merged
 }
 
-func (ifs *IniFileSettings) Load() error {
-	exists, err := ifs.fileExists()
+func (r iniFileRecord) equal(other iniFileRecord) bool {
+	return r.value == other.value && r.timestamp.Equal(other.timestamp)
+}
+
+// iniFileDocument holds the lines of an ini file, so that comments and
+// unknown lines survive a Load/Save round trip.
+type iniFileDocument struct {
+	lines []iniFileLine
+}
+
+type iniFileLine struct {
+	raw     string // the line as read, for anything but entries
+	section string // the section the line belongs to
+	key     string // the full key, for entries only
+	header  bool   // whether the line is a section header
+}
+
+func readIniFile(filePath string) (*iniFileDocument, map[string]iniFileRecord, error) {
+	doc := new(iniFileDocument)
+	key2Record := make(map[string]iniFileRecord)
+
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return doc, key2Record, nil
+	}
 	if err != nil {
-		return err
+		return nil, nil, errs.WrapError(err)
 	}
+	defer file.Close()
 
-	if !exists {
-		return nil
-	}
+	var section string
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
 
-	return ifs.withFile(os.O_RDONLY, func(file *os.File) error {
-		scanner := bufio.NewScanner(file)
+		switch {
+		case trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#':
+			doc.lines = append(doc.lines, iniFileLine{raw: line, section: section})
 
-		for scanner.Scan() {
-			line := scanner.Text()
+		case trimmed[0] == '[' && trimmed[len(trimmed)-1] == ']':
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			doc.lines = append(doc.lines, iniFileLine{raw: line, section: section, header: true})
 
+		default:
 			assignIndex := strings.Index(line, "=")
 			if assignIndex == -1 {
-				return errs.NewError("bad line format: missing '='")
+				doc.lines = append(doc.lines, iniFileLine{raw: line, section: section})
+				continue
 			}
 
-			key := strings.TrimSpace(line[:assignIndex])
+			name := strings.TrimSpace(line[:assignIndex])
 
 			var ts time.Time
-			if parts := strings.Split(key, "|"); len(parts) > 1 {
-				key = parts[0]
-				if ts, _ = time.Parse(iniFileTimeStampFormat, parts[1]); ts.IsZero() {
-					ts = time.Now()
-				}
+			if parts := strings.Split(name, "|"); len(parts) > 1 {
+				name = parts[0]
+				ts = parseIniFileTimestamp(parts[1])
 			}
 
-			value := strings.TrimSpace(line[assignIndex+1:])
+			key := name
+			if section != "" {
+				key = section + "/" + name
+			}
 
-			ifs.key2Record[key] = iniFileRecord{value, ts}
+			key2Record[key] = iniFileRecord{unescapeIniFileValue(strings.TrimSpace(line[assignIndex+1:])), ts}
+			doc.lines = append(doc.lines, iniFileLine{section: section, key: key})
 		}
+	}
 
-		return scanner.Err()
-	})
+	if err := scanner.Err(); err != nil {
+		return nil, nil, errs.WrapError(err)
+	}
+
+	return doc, key2Record, nil
 }
 
-func (ifs *IniFileSettings) Save() error {
-	return ifs.withFile(os.O_CREATE|os.O_TRUNC|os.O_WRONLY, func(file *os.File) error {
-		bufWriter := bufio.NewWriter(file)
+// render returns the document with its entries replaced by key2Record.
+// Entries that are not in key2Record are dropped, new ones are appended to
+// their section.
+func (doc *iniFileDocument) render(key2Record map[string]iniFileRecord) []byte {
+	var buf bytes.Buffer
 
-		keys := make([]string, 0, len(ifs.key2Record))
+	written := make(map[string]bool)
+	existing := make(map[string]bool)
+	section2Insert := make(map[string]int)
+	firstHeader := -1
 
-		for key, record := range ifs.key2Record {
-			if ifs.expireDuration <= 0 || record.timestamp.IsZero() || time.Since(record.timestamp) < ifs.expireDuration {
-				keys = append(keys, key)
+	for i, line := range doc.lines {
+		if line.header && firstHeader == -1 {
+			firstHeader = i
+		}
+		if line.header || line.key != "" {
+			section2Insert[line.section] = i + 1
+		}
+		if line.key != "" {
+			existing[line.key] = true
+		}
+	}
+	if _, ok := section2Insert[""]; !ok {
+		insert := len(doc.lines)
+		if firstHeader != -1 {
+			insert = firstHeader
+			for insert > 0 && strings.TrimSpace(doc.lines[insert-1].raw) == "" {
+				insert--
 			}
 		}
+		section2Insert[""] = insert
+	}
 
-		sort.Strings(keys)
+	keys := make([]string, 0, len(key2Record))
+	for key := range key2Record {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-		for _, key := range keys {
-			record := ifs.key2Record[key]
+	index2Section := make(map[int]string)
+	index2NewKeys := make(map[int][]string)
+	var newSections []string
+	section2NewKeys := make(map[string][]string)
 
-			if _, err := bufWriter.WriteString(key); err != nil {
-				return errs.WrapError(err)
-			}
-			if !record.timestamp.IsZero() {
-				if _, err := bufWriter.WriteString("|"); err != nil {
-					return errs.WrapError(err)
-				}
-				if _, err := bufWriter.WriteString(record.timestamp.Format(iniFileTimeStampFormat)); err != nil {
-					return errs.WrapError(err)
-				}
-			}
-			if _, err := bufWriter.WriteString("="); err != nil {
-				return errs.WrapError(err)
+	for _, key := range keys {
+		if existing[key] {
+			continue
+		}
+
+		section, _ := splitIniFileKey(key)
+		if insert, ok := section2Insert[section]; ok {
+			index2Section[insert] = section
+			index2NewKeys[insert] = append(index2NewKeys[insert], key)
+		} else {
+			if _, ok := section2NewKeys[section]; !ok {
+				newSections = append(newSections, section)
 			}
-			if _, err := bufWriter.WriteString(record.value); err != nil {
-				return errs.WrapError(err)
+			section2NewKeys[section] = append(section2NewKeys[section], key)
+		}
+	}
+
+	writeEntries := func(section string, keys []string) {
+		for _, key := range keys {
+			if written[key] {
+				continue
 			}
-			if _, err := bufWriter.WriteString("\r\n"); err != nil {
-				return errs.WrapError(err)
+			written[key] = true
+
+			name := key
+			if section != "" {
+				name = strings.TrimPrefix(key, section+"/")
 			}
+
+			writeIniFileEntry(&buf, name, key2Record[key])
+		}
+	}
+
+	for i, line := range doc.lines {
+		writeEntries(index2Section[i], index2NewKeys[i])
+
+		if line.key == "" {
+			buf.WriteString(line.raw)
+			buf.WriteString("\r\n")
+		} else if _, ok := key2Record[line.key]; ok {
+			writeEntries(line.section, []string{line.key})
 		}
+	}
+	writeEntries(index2Section[len(doc.lines)], index2NewKeys[len(doc.lines)])
 
-		return bufWriter.Flush()
-	})
+	for _, section := range newSections {
+		if buf.Len() > 0 {
+			buf.WriteString("\r\n")
+		}
+		buf.WriteString("[" + section + "]\r\n")
+		writeEntries(section, section2NewKeys[section])
+	}
+
+	return buf.Bytes()
+}
+
+// splitIniFileKey returns the section and name a new key is written as.
+func splitIniFileKey(key string) (section, name string) {
+	slashIndex := strings.LastIndex(key, "/")
+	if slashIndex == -1 {
+		return "", key
+	}
+
+	section, name = key[:slashIndex], key[slashIndex+1:]
+	if section == "" || name == "" || strings.TrimSpace(section) != section || strings.TrimSpace(name) != name || strings.ContainsAny(section, "[]") {
+		return "", key
+	}
+
+	return section, name
+}
+
+func writeIniFileEntry(buf *bytes.Buffer, name string, record iniFileRecord) {
+	buf.WriteString(name)
+	if !record.timestamp.IsZero() {
+		buf.WriteString("|")
+		buf.WriteString(record.timestamp.Format(time.RFC3339Nano))
+	}
+	buf.WriteString("=")
+	buf.WriteString(escapeIniFileValue(record.value))
+	buf.WriteString("\r\n")
+}
+
+func parseIniFileTimestamp(s string) time.Time {
+	if ts, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return ts
+	}
+	if ts, err := time.Parse(iniFileTimeStampFormat, s); err == nil {
+		return ts
+	}
+
+	return time.Now()
+}
+
+// escapeIniFileValue returns value as is, if it survives being read back,
+// otherwise double-quoted with '\\', '"', '\n', '\r' and '\t' escaped.
+func escapeIniFileValue(value string) string {
+	if strings.TrimSpace(value) == value && !strings.ContainsAny(value, "\r\n\t") && !strings.HasPrefix(value, `"`) {
+		return value
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+
+	for _, r := range value {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	sb.WriteByte('"')
+
+	return sb.String()
+}
+
+// unescapeIniFileValue reverses escapeIniFileValue. Unquoted values are
+// returned unchanged, as older versions did not escape anything.
+func unescapeIniFileValue(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+
+	value = value[1 : len(value)-1]
+
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '\\' || i == len(value)-1 {
+			sb.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch value[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		default:
+			sb.WriteByte(value[i])
+		}
+	}
+
+	return sb.String()
+}
+
+// withIniFileLock calls f while holding a lock on a companion file of
+// filePath. The lock is advisory and only serializes IniFileSettings
+// instances, possibly in different processes.
+func withIniFileLock(filePath string, exclusive bool, f func() error) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return errs.WrapError(err)
+	}
+
+	lockFile, err := os.OpenFile(filePath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return errs.WrapError(err)
+	}
+	defer lockFile.Close()
+
+	var flags uint32
+	if exclusive {
+		flags = windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+
+	hFile := windows.Handle(lockFile.Fd())
+	ol := new(windows.Overlapped)
+
+	if err := windows.LockFileEx(hFile, flags, 0, 1, 0, ol); err != nil {
+		return errs.WrapError(err)
+	}
+	defer windows.UnlockFileEx(hFile, 0, 1, 0, ol)
+
+	return f()
+}
+
+// writeFileAtomically writes data to a temporary file next to filePath and
+// renames it to filePath, so that a crash leaves either the old or the new
+// file behind.
+func writeFileAtomically(filePath string, data []byte) error {
+	dirPath, fileName := filepath.Split(filePath)
+
+	file, err := os.CreateTemp(dirPath, fileName+".*.tmp")
+	if err != nil {
+		return errs.WrapError(err)
+	}
+	tempPath := file.Name()
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return errs.WrapError(err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return errs.WrapError(err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return errs.WrapError(err)
+	}
+
+	if err := os.Rename(tempPath, filePath); err != nil {
+		os.Remove(tempPath)
+		return errs.WrapError(err)
+	}
+
+	return nil
 }
@@ -0,0 +1,152 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIniFileSettingsMerge(t *testing.T) {
+	older := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	base := map[string]iniFileRecord{
+		"unchanged":        {"base", time.Time{}},
+		"disk only":        {"base", time.Time{}},
+		"local permanent":  {"base", time.Time{}},
+		"local older":      {"base", time.Time{}},
+		"local newer":      {"base", time.Time{}},
+		"local removed":    {"base", time.Time{}},
+		"removed on disk":  {"base", time.Time{}},
+		"changed and kept": {"base", time.Time{}},
+	}
+
+	local := map[string]iniFileRecord{
+		"unchanged":        {"base", time.Time{}},
+		"disk only":        {"base", time.Time{}},
+		"local permanent":  {"local", time.Time{}},
+		"local older":      {"local", older},
+		"local newer":      {"local", newer},
+		"removed on disk":  {"base", time.Time{}},
+		"changed and kept": {"local", time.Time{}},
+		"added locally":    {"local", time.Time{}},
+	}
+
+	disk := map[string]iniFileRecord{
+		"unchanged":        {"base", time.Time{}},
+		"disk only":        {"disk", newer},
+		"local permanent":  {"disk", newer},
+		"local older":      {"disk", newer},
+		"local newer":      {"disk", older},
+		"local removed":    {"disk", newer},
+		"changed and kept": {"base", time.Time{}},
+		"added on disk":    {"disk", time.Time{}},
+	}
+
+	ifs := &IniFileSettings{key2Record: local, base: base}
+	merged := ifs.merge(disk)
+
+	want := map[string]string{
+		"unchanged":        "base",
+		"disk only":        "disk",
+		"local permanent":  "local",
+		"local older":      "disk",
+		"local newer":      "local",
+		"changed and kept": "local",
+		"added locally":    "local",
+		"added on disk":    "disk",
+	}
+
+	for key, value := range want {
+		if record, ok := merged[key]; !ok || record.value != value {
+			t.Errorf("merged[%q] = %q, %t; want %q, true", key, record.value, ok, value)
+		}
+	}
+
+	for _, key := range []string{"local removed", "removed on disk"} {
+		if record, ok := merged[key]; ok {
+			t.Errorf("merged[%q] = %q; want it removed", key, record.value)
+		}
+	}
+
+	if len(merged) != len(want) {
+		t.Errorf("len(merged) = %d; want %d", len(merged), len(want))
+	}
+}
+
+func TestIniFileValueEscaping(t *testing.T) {
+	for _, test := range []struct {
+		value   string
+		escaped string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"a=b", "a=b"},
+		{`C:\Program Files`, `C:\Program Files`},
+		{`say "hi"`, `say "hi"`},
+		{" leading", `" leading"`},
+		{"trailing ", `"trailing "`},
+		{"line 1\r\nline 2", `"line 1\r\nline 2"`},
+		{"tab\there", `"tab\there"`},
+		{`"quoted"`, `"\"quoted\""`},
+		{`"C:\dir\"`, `"\"C:\\dir\\\""`},
+	} {
+		if escaped := escapeIniFileValue(test.value); escaped != test.escaped {
+			t.Errorf("escapeIniFileValue(%q) = %q; want %q", test.value, escaped, test.escaped)
+		}
+
+		if value := unescapeIniFileValue(test.escaped); value != test.value {
+			t.Errorf("unescapeIniFileValue(%q) = %q; want %q", test.escaped, value, test.value)
+		}
+	}
+}
+
+func TestIniFileValueUnescapingLegacy(t *testing.T) {
+	// Older versions wrote values unescaped.
+	for _, value := range []string{`"unbalanced`, `back\slash`, `"`} {
+		if got := unescapeIniFileValue(value); got != value {
+			t.Errorf("unescapeIniFileValue(%q) = %q; want it unchanged", value, got)
+		}
+	}
+}
+
+func TestIniFileSettingsRoundTrip(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "settings.ini")
+
+	values := map[string]string{
+		"plain":          "value",
+		"spaces":         "  padded  ",
+		"lines":          "a\r\nb\nc",
+		"quoted":         `"x" \ "y"`,
+		"section/nested": "nested",
+	}
+
+	saved := NewIniFileSettings(filePath)
+	saved.SetPortable(true)
+	for key, value := range values {
+		if err := saved.Put(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := saved.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewIniFileSettings(filePath)
+	loaded.SetPortable(true)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, value := range values {
+		if got, ok := loaded.Get(key); !ok || got != value {
+			t.Errorf("Get(%q) = %q, %t; want %q, true", key, got, ok, value)
+		}
+	}
+}
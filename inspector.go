@@ -0,0 +1,762 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/gdi32"
+	"github.com/Gipcomp/win32/handle"
+	"github.com/Gipcomp/win32/kernel32"
+	"github.com/Gipcomp/win32/user32"
+	"github.com/Gipcomp/winapi/errs"
+)
+
+const inspectorHighlightWindowClass = `\o/ Walk_InspectorHighlight_Class \o/`
+
+var (
+	activeInspector                   *Inspector
+	inspectorHighlightClassRegistered bool
+)
+
+// Inspector is a window for debugging layouts. It shows the widget tree of a
+// Form, highlights the selected widget, displays its layout information,
+// registered properties and attached event handler counts, and allows to
+// edit property values live.
+//
+// An Inspector is usually opened with the shortcut set via
+// Application.SetInspectorShortcut.
+type Inspector struct {
+	*MainWindow
+	form        Form
+	queryLE     *LineEdit
+	treeView    *TreeView
+	treeModel   *inspectorTreeModel
+	infoTE      *TextEdit
+	propsTV     *TableView
+	propsModel  *inspectorPropertyModel
+	valueLE     *LineEdit
+	setPB       *PushButton
+	hwndOverlay handle.HWND
+}
+
+// ShowInspector shows the Inspector for form, creating it if necessary.
+//
+// There is at most one Inspector at a time. If it is already open, it is
+// switched to form. If form is the Inspector itself, it is refreshed.
+func ShowInspector(form Form) (*Inspector, error) {
+	if form == nil {
+		return nil, errs.NewInvalidArgumentError("form must not be nil")
+	}
+
+	if activeInspector != nil {
+		if form != Form(activeInspector.MainWindow) {
+			activeInspector.SetForm(form)
+		} else {
+			activeInspector.Refresh()
+		}
+		activeInspector.Show()
+		return activeInspector, nil
+	}
+
+	insp, err := newInspector()
+	if err != nil {
+		return nil, err
+	}
+
+	activeInspector = insp
+
+	insp.SetForm(form)
+	insp.Show()
+
+	return insp, nil
+}
+
+func newInspector() (*Inspector, error) {
+	mw, err := NewMainWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	insp := &Inspector{MainWindow: mw}
+
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			mw.Dispose()
+		}
+	}()
+
+	mw.SetPersistent(false)
+	mw.SetTitle("Inspector")
+	if err := mw.SetLayout(NewVBoxLayout()); err != nil {
+		return nil, err
+	}
+
+	top, err := NewComposite(mw)
+	if err != nil {
+		return nil, err
+	}
+	topLayout := NewHBoxLayout()
+	topLayout.SetMargins(Margins{})
+	if err := top.SetLayout(topLayout); err != nil {
+		return nil, err
+	}
+
+	if insp.queryLE, err = NewLineEdit(top); err != nil {
+		return nil, err
+	}
+	insp.queryLE.SetCueBanner("Selector, e.g. PushButton#okPB")
+	insp.queryLE.KeyDown().Attach(func(key Key) {
+		if key == KeyReturn {
+			insp.selectQuery()
+		}
+	})
+
+	findPB, err := NewPushButton(top)
+	if err != nil {
+		return nil, err
+	}
+	findPB.SetText("Find")
+	findPB.Clicked().Attach(insp.selectQuery)
+
+	refreshPB, err := NewPushButton(top)
+	if err != nil {
+		return nil, err
+	}
+	refreshPB.SetText("Refresh")
+	refreshPB.Clicked().Attach(insp.Refresh)
+
+	splitter, err := NewHSplitter(mw)
+	if err != nil {
+		return nil, err
+	}
+
+	if insp.treeView, err = NewTreeView(splitter); err != nil {
+		return nil, err
+	}
+	insp.treeView.CurrentItemChanged().Attach(insp.onCurrentItemChanged)
+
+	right, err := NewComposite(splitter)
+	if err != nil {
+		return nil, err
+	}
+	rightLayout := NewVBoxLayout()
+	rightLayout.SetMargins(Margins{})
+	if err := right.SetLayout(rightLayout); err != nil {
+		return nil, err
+	}
+
+	if insp.infoTE, err = NewTextEditWithStyle(right, user32.WS_VSCROLL); err != nil {
+		return nil, err
+	}
+	insp.infoTE.SetReadOnly(true)
+
+	if insp.propsTV, err = NewTableView(right); err != nil {
+		return nil, err
+	}
+	for _, c := range []struct {
+		title string
+		width int
+	}{
+		{"Name", 120},
+		{"Type", 100},
+		{"Value", 160},
+		{"Read-Only", 70},
+	} {
+		col := NewTableViewColumn()
+		col.SetTitle(c.title)
+		col.SetWidth(c.width)
+		if err := insp.propsTV.Columns().Add(col); err != nil {
+			return nil, err
+		}
+	}
+	insp.propsTV.SetLastColumnStretched(true)
+	insp.propsModel = new(inspectorPropertyModel)
+	if err := insp.propsTV.SetModel(insp.propsModel); err != nil {
+		return nil, err
+	}
+	insp.propsTV.CurrentIndexChanged().Attach(insp.onCurrentPropertyChanged)
+
+	edit, err := NewComposite(right)
+	if err != nil {
+		return nil, err
+	}
+	editLayout := NewHBoxLayout()
+	editLayout.SetMargins(Margins{})
+	if err := edit.SetLayout(editLayout); err != nil {
+		return nil, err
+	}
+
+	if insp.valueLE, err = NewLineEdit(edit); err != nil {
+		return nil, err
+	}
+	insp.valueLE.KeyDown().Attach(func(key Key) {
+		if key == KeyReturn {
+			insp.setPropertyValue()
+		}
+	})
+
+	if insp.setPB, err = NewPushButton(edit); err != nil {
+		return nil, err
+	}
+	insp.setPB.SetText("Set")
+	insp.setPB.Clicked().Attach(insp.setPropertyValue)
+
+	rightLayout.SetStretchFactor(insp.infoTE, 1)
+	rightLayout.SetStretchFactor(insp.propsTV, 2)
+
+	mw.Disposing().Attach(func() {
+		insp.destroyOverlay()
+		if activeInspector == insp {
+			activeInspector = nil
+		}
+	})
+
+	mw.SetSize(Size{900, 600})
+
+	succeeded = true
+
+	return insp, nil
+}
+
+// Form returns the Form that is inspected.
+func (insp *Inspector) Form() Form {
+	return insp.form
+}
+
+// SetForm sets the Form to inspect and refreshes the Inspector.
+func (insp *Inspector) SetForm(form Form) {
+	insp.form = form
+
+	title := "Inspector"
+	if form != nil {
+		title = fmt.Sprintf("Inspector - %s %q", WindowTypeName(form), form.Title())
+	}
+	insp.SetTitle(title)
+
+	insp.Refresh()
+}
+
+// Refresh rebuilds the widget tree and the information about the selected
+// widget, which stays selected if it still exists.
+func (insp *Inspector) Refresh() {
+	selected := insp.Selected()
+
+	insp.treeModel = newInspectorTreeModel(insp.form)
+	insp.treeView.SetModel(insp.treeModel)
+
+	if selected != nil && insp.treeModel.item(selected) != nil {
+		insp.Select(selected)
+	} else {
+		insp.showWidget(nil)
+	}
+}
+
+// Selected returns the Widget selected in the tree, or nil.
+func (insp *Inspector) Selected() Widget {
+	if item, ok := insp.treeView.CurrentItem().(*inspectorTreeItem); ok && item != nil {
+		return item.widget
+	}
+
+	return nil
+}
+
+// Select selects w in the tree. w must be a descendant of Form.
+func (insp *Inspector) Select(w Widget) error {
+	if insp.treeModel == nil {
+		return errs.NewInvalidArgumentError("no form is inspected")
+	}
+
+	item := insp.treeModel.item(w)
+	if item == nil {
+		return errs.NewInvalidArgumentError("widget is not part of the inspected form")
+	}
+
+	for parent := item.parent; parent != nil; parent = parent.parent {
+		insp.treeView.SetExpanded(parent, true)
+	}
+
+	return insp.treeView.SetCurrentItem(item)
+}
+
+func (insp *Inspector) selectQuery() {
+	if insp.form == nil {
+		return
+	}
+
+	w, err := QueryWidget(insp.form, insp.queryLE.Text())
+	if err == nil && w == nil {
+		err = errs.NewError("no widget matches the selector")
+	}
+	if err == nil {
+		err = insp.Select(w)
+	}
+	if err != nil {
+		MsgBox(insp, "Inspector", err.Error(), MsgBoxIconWarning)
+	}
+}
+
+func (insp *Inspector) onCurrentItemChanged() {
+	insp.showWidget(insp.Selected())
+}
+
+func (insp *Inspector) showWidget(w Widget) {
+	if w == nil || w.IsDisposed() {
+		insp.infoTE.SetText("")
+		insp.propsModel.reset(nil)
+		insp.hideOverlay()
+		return
+	}
+
+	insp.infoTE.SetText(strings.ReplaceAll(inspectorWidgetInfo(w), "\n", "\r\n"))
+	insp.propsModel.reset(w)
+	insp.valueLE.SetText("")
+	insp.highlight(w)
+}
+
+func (insp *Inspector) onCurrentPropertyChanged() {
+	index := insp.propsTV.CurrentIndex()
+	if index < 0 || index >= len(insp.propsModel.infos) {
+		insp.valueLE.SetText("")
+		return
+	}
+
+	info := insp.propsModel.infos[index]
+	insp.valueLE.SetText(inspectorFormatValue(info.Value))
+	insp.valueLE.SetReadOnly(info.ReadOnly)
+	insp.setPB.SetEnabled(!info.ReadOnly)
+}
+
+func (insp *Inspector) setPropertyValue() {
+	w := insp.Selected()
+	index := insp.propsTV.CurrentIndex()
+	if w == nil || index < 0 || index >= len(insp.propsModel.infos) {
+		return
+	}
+
+	info := insp.propsModel.infos[index]
+	if info.ReadOnly {
+		return
+	}
+
+	value, err := inspectorParseValue(insp.valueLE.Text(), info.Value)
+	if err == nil {
+		err = w.AsWindowBase().Property(info.Name).Set(value)
+	}
+	if err != nil {
+		MsgBox(insp, "Inspector", fmt.Sprintf("Failed to set %s: %s", info.Name, err), MsgBoxIconWarning)
+		return
+	}
+
+	insp.showWidget(w)
+	insp.propsTV.SetCurrentIndex(index)
+}
+
+// highlight shows a semi-transparent overlay above the bounds of w.
+func (insp *Inspector) highlight(w Widget) {
+	if !w.Visible() {
+		insp.hideOverlay()
+		return
+	}
+
+	var r gdi32.RECT
+	if !user32.GetWindowRect(w.Handle(), &r) {
+		insp.hideOverlay()
+		return
+	}
+
+	if insp.hwndOverlay == 0 {
+		if err := insp.createOverlay(); err != nil {
+			return
+		}
+	}
+
+	user32.SetWindowPos(
+		insp.hwndOverlay,
+		user32.HWND_TOPMOST,
+		r.Left,
+		r.Top,
+		r.Right-r.Left,
+		r.Bottom-r.Top,
+		user32.SWP_NOACTIVATE|user32.SWP_SHOWWINDOW)
+}
+
+func (insp *Inspector) hideOverlay() {
+	if insp.hwndOverlay != 0 {
+		user32.ShowWindow(insp.hwndOverlay, user32.SW_HIDE)
+	}
+}
+
+func (insp *Inspector) createOverlay() error {
+	if !inspectorHighlightClassRegistered {
+		var wc user32.WNDCLASSEX
+		wc.CbSize = uint32(unsafe.Sizeof(wc))
+		wc.LpfnWndProc = defaultWndProcPtr
+		wc.HInstance = kernel32.GetModuleHandle(nil)
+		wc.HbrBackground = user32.COLOR_HIGHLIGHT + 1
+		wc.LpszClassName = syscall.StringToUTF16Ptr(inspectorHighlightWindowClass)
+
+		if atom := user32.RegisterClassEx(&wc); atom == 0 {
+			return errs.NewWin32Error("RegisterClassEx")
+		}
+
+		inspectorHighlightClassRegistered = true
+	}
+
+	insp.hwndOverlay = user32.CreateWindowEx(
+		user32.WS_EX_LAYERED|user32.WS_EX_TOOLWINDOW|user32.WS_EX_TOPMOST|user32.WS_EX_NOACTIVATE|user32.WS_EX_TRANSPARENT,
+		syscall.StringToUTF16Ptr(inspectorHighlightWindowClass),
+		nil,
+		user32.WS_POPUP,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		nil)
+	if insp.hwndOverlay == 0 {
+		return errs.NewWin32Error("CreateWindowEx")
+	}
+
+	user32.SetLayeredWindowAttributes(insp.hwndOverlay, 0, 80, user32.LWA_ALPHA)
+
+	return nil
+}
+
+func (insp *Inspector) destroyOverlay() {
+	if insp.hwndOverlay != 0 {
+		user32.DestroyWindow(insp.hwndOverlay)
+		insp.hwndOverlay = 0
+	}
+}
+
+// inspectorWidgetInfo returns a textual description of the layout related
+// state and the event handlers of w.
+func inspectorWidgetInfo(w Widget) string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "Type: %s\n", WindowTypeName(w))
+	fmt.Fprintf(&buf, "Name: %s\n", w.Name())
+	fmt.Fprintf(&buf, "Path: %s\n", WidgetNamePath(w))
+	b := w.BoundsPixels()
+	fmt.Fprintf(&buf, "Bounds: %d, %d, %d x %d\n", b.X, b.Y, b.Width, b.Height)
+	fmt.Fprintf(&buf, "Visible: %t, Enabled: %t\n", w.Visible(), w.Enabled())
+
+	buf.WriteString("\nLayout Item\n")
+	item := createLayoutItemForWidget(w)
+	if is, ok := item.(IdealSizer); ok {
+		s := is.IdealSize()
+		fmt.Fprintf(&buf, "  Ideal Size: %d x %d\n", s.Width, s.Height)
+	}
+	if ms, ok := item.(MinSizer); ok {
+		s := ms.MinSize()
+		fmt.Fprintf(&buf, "  Min Size: %d x %d\n", s.Width, s.Height)
+	}
+	geometry := item.Geometry()
+	fmt.Fprintf(&buf, "  Geometry Min Size: %d x %d\n", geometry.MinSize.Width, geometry.MinSize.Height)
+	fmt.Fprintf(&buf, "  Geometry Max Size: %d x %d\n", geometry.MaxSize.Width, geometry.MaxSize.Height)
+	fmt.Fprintf(&buf, "  Alignment: %d\n", geometry.Alignment)
+	fmt.Fprintf(&buf, "  Flags: %s\n", inspectorLayoutFlags(item.LayoutFlags()))
+
+	if parent := w.Parent(); parent != nil {
+		switch l := parent.Layout().(type) {
+		case *BoxLayout:
+			fmt.Fprintf(&buf, "  Stretch Factor: %d\n", l.StretchFactor(w))
+
+		case *FlowLayout:
+			fmt.Fprintf(&buf, "  Stretch Factor: %d\n", l.StretchFactor(w))
+		}
+	}
+
+	if c, ok := w.(Container); ok && c.Layout() != nil {
+		l := c.Layout()
+		m := l.Margins()
+		fmt.Fprintf(&buf, "\nLayout: %s\n", reflect.TypeOf(l).Elem().Name())
+		fmt.Fprintf(&buf, "  Margins: %d, %d, %d, %d\n", m.HNear, m.VNear, m.HFar, m.VFar)
+		fmt.Fprintf(&buf, "  Spacing: %d\n", l.Spacing())
+	}
+
+	buf.WriteString("\nEvent Handlers\n")
+	counts := make(map[string]int)
+	var names []string
+	inspectorCountHandlers(reflect.ValueOf(w).Elem(), func(name string, count int) {
+		if _, ok := counts[name]; !ok {
+			names = append(names, name)
+		}
+		counts[name] += count
+	})
+	n := 0
+	for _, name := range names {
+		if counts[name] > 0 {
+			fmt.Fprintf(&buf, "  %s: %d\n", name, counts[name])
+			n++
+		}
+	}
+	if n == 0 {
+		buf.WriteString("  (none)\n")
+	}
+
+	return buf.String()
+}
+
+var inspectorLayoutFlagNames = []struct {
+	flag LayoutFlags
+	name string
+}{
+	{ShrinkableHorz, "ShrinkableHorz"},
+	{ShrinkableVert, "ShrinkableVert"},
+	{GrowableHorz, "GrowableHorz"},
+	{GrowableVert, "GrowableVert"},
+	{GreedyHorz, "GreedyHorz"},
+	{GreedyVert, "GreedyVert"},
+}
+
+func inspectorLayoutFlags(flags LayoutFlags) string {
+	var names []string
+	for _, fn := range inspectorLayoutFlagNames {
+		if flags&fn.flag != 0 {
+			names = append(names, fn.name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "(none)"
+	}
+
+	return strings.Join(names, " | ")
+}
+
+// inspectorCountHandlers calls f with the name and the number of attached
+// handlers of every event publisher in the struct v, including those of
+// embedded structs.
+func inspectorCountHandlers(v reflect.Value, f func(name string, count int)) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			inspectorCountHandlers(fv, f)
+			continue
+		}
+
+		if fv.Kind() != reflect.Struct || !strings.HasSuffix(field.Type.Name(), "Publisher") {
+			continue
+		}
+
+		event := fv.FieldByName("event")
+		if !event.IsValid() {
+			continue
+		}
+		handlers := event.FieldByName("handlers")
+		if !handlers.IsValid() || handlers.Kind() != reflect.Slice {
+			continue
+		}
+
+		count := 0
+		for j := 0; j < handlers.Len(); j++ {
+			h := handlers.Index(j)
+			if h.Kind() == reflect.Struct {
+				h = h.FieldByName("handler")
+			}
+			if h.IsValid() && !h.IsNil() {
+				count++
+			}
+		}
+
+		f(strings.TrimSuffix(field.Name, "Publisher"), count)
+	}
+}
+
+func inspectorFormatValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+// inspectorParseValue parses s into a value of the type of current.
+func inspectorParseValue(s string, current interface{}) (interface{}, error) {
+	if current == nil {
+		return s, nil
+	}
+
+	t := reflect.TypeOf(current)
+	v := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 0, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 0, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetFloat(f)
+
+	default:
+		return nil, errs.NewNotSupportedError(fmt.Sprintf("editing values of type %s", t))
+	}
+
+	return v.Interface(), nil
+}
+
+type inspectorTreeItem struct {
+	widget   Widget
+	parent   *inspectorTreeItem
+	children []*inspectorTreeItem
+}
+
+func (item *inspectorTreeItem) Text() string {
+	text := WindowTypeName(item.widget)
+	if name := item.widget.Name(); name != "" {
+		text += " #" + name
+	}
+	if !item.widget.Visible() {
+		text += " (hidden)"
+	}
+
+	return text
+}
+
+func (item *inspectorTreeItem) Parent() TreeItem {
+	if item.parent == nil {
+		// We can't simply return item.parent in this case, because it
+		// would be a non-nil TreeItem interface value.
+		return nil
+	}
+
+	return item.parent
+}
+
+func (item *inspectorTreeItem) ChildCount() int {
+	return len(item.children)
+}
+
+func (item *inspectorTreeItem) ChildAt(index int) TreeItem {
+	return item.children[index]
+}
+
+type inspectorTreeModel struct {
+	TreeModelBase
+	roots       []*inspectorTreeItem
+	widget2Item map[Widget]*inspectorTreeItem
+}
+
+func newInspectorTreeModel(form Form) *inspectorTreeModel {
+	m := &inspectorTreeModel{widget2Item: make(map[Widget]*inspectorTreeItem)}
+
+	if form == nil {
+		return m
+	}
+
+	var build func(parent *inspectorTreeItem, w Window) []*inspectorTreeItem
+	build = func(parent *inspectorTreeItem, w Window) []*inspectorTreeItem {
+		var items []*inspectorTreeItem
+		for _, child := range windowChildren(w) {
+			item := &inspectorTreeItem{widget: child, parent: parent}
+			item.children = build(item, child)
+			m.widget2Item[child] = item
+			items = append(items, item)
+		}
+		return items
+	}
+	m.roots = build(nil, form)
+
+	return m
+}
+
+func (m *inspectorTreeModel) RootCount() int {
+	return len(m.roots)
+}
+
+func (m *inspectorTreeModel) RootAt(index int) TreeItem {
+	return m.roots[index]
+}
+
+func (m *inspectorTreeModel) item(w Widget) *inspectorTreeItem {
+	return m.widget2Item[w]
+}
+
+type inspectorPropertyModel struct {
+	TableModelBase
+	infos []PropertyInfo
+}
+
+func (m *inspectorPropertyModel) reset(w Widget) {
+	if w == nil {
+		m.infos = nil
+	} else {
+		m.infos = w.AsWindowBase().Properties()
+	}
+
+	m.PublishRowsReset()
+}
+
+func (m *inspectorPropertyModel) RowCount() int {
+	return len(m.infos)
+}
+
+func (m *inspectorPropertyModel) Value(row, col int) interface{} {
+	info := m.infos[row]
+
+	switch col {
+	case 0:
+		return info.Name
+
+	case 1:
+		if info.Type == nil {
+			return ""
+		}
+		return info.Type.String()
+
+	case 2:
+		return inspectorFormatValue(info.Value)
+
+	case 3:
+		return info.ReadOnly
+	}
+
+	panic("unexpected col")
+}
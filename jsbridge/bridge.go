@@ -0,0 +1,260 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsbridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var errorInterface = reflect.TypeOf((*error)(nil)).Elem()
+
+// Bridge dispatches JSON-RPC requests to bound Go functions.
+type Bridge struct {
+	mutex         sync.RWMutex
+	name2Function map[string]*function
+}
+
+type function struct {
+	value     reflect.Value
+	argTypes  []reflect.Type
+	hasResult bool
+	hasError  bool
+}
+
+// NewBridge returns a Bridge without bound functions.
+func NewBridge() *Bridge {
+	return &Bridge{name2Function: make(map[string]*function)}
+}
+
+// Bind makes fn callable as name, replacing a function bound before.
+//
+// fn must be a func that is not variadic and returns nothing, a value, an
+// error or a value and an error. Its arguments are decoded from the params
+// of a request, which must be an array with one element per argument. If
+// fn takes a single argument, params may also be an object decoded into it.
+// Results are encoded as JSON.
+func (b *Bridge) Bind(name string, fn interface{}) error {
+	if name == "" {
+		return fmt.Errorf("jsbridge: name must not be empty")
+	}
+
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("jsbridge: %s: want func, got %T", name, fn)
+	}
+
+	t := v.Type()
+	if t.IsVariadic() {
+		return fmt.Errorf("jsbridge: %s: variadic funcs are not supported", name)
+	}
+
+	f := &function{value: v}
+
+	for i := 0; i < t.NumIn(); i++ {
+		f.argTypes = append(f.argTypes, t.In(i))
+	}
+
+	switch t.NumOut() {
+	case 0:
+
+	case 1:
+		if t.Out(0) == errorInterface {
+			f.hasError = true
+		} else {
+			f.hasResult = true
+		}
+
+	case 2:
+		if t.Out(1) != errorInterface {
+			return fmt.Errorf("jsbridge: %s: second result must be error", name)
+		}
+		f.hasResult, f.hasError = true, true
+
+	default:
+		return fmt.Errorf("jsbridge: %s: want at most a value and an error as results", name)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.name2Function[name] = f
+
+	return nil
+}
+
+// Unbind removes the function bound as name.
+func (b *Bridge) Unbind(name string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.name2Function, name)
+}
+
+// Names returns the sorted names of the bound functions.
+func (b *Bridge) Names() []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	names := make([]string, 0, len(b.name2Function))
+	for name := range b.name2Function {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Call calls the function bound as name with the arguments decoded from
+// params and returns its result. Errors are of type *Error.
+func (b *Bridge) Call(name string, params json.RawMessage) (result interface{}, err error) {
+	b.mutex.RLock()
+	f, ok := b.name2Function[name]
+	b.mutex.RUnlock()
+
+	if !ok {
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q not found", name)}
+	}
+
+	args, err := f.decodeArgs(params)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if x := recover(); x != nil {
+			result, err = nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("%s panicked: %v", name, x)}
+		}
+	}()
+
+	out := f.value.Call(args)
+
+	if f.hasError {
+		if e, _ := out[len(out)-1].Interface().(error); e != nil {
+			return nil, &Error{Code: CodeCallError, Message: e.Error()}
+		}
+	}
+
+	if f.hasResult {
+		return out[0].Interface(), nil
+	}
+
+	return nil, nil
+}
+
+func (f *function) decodeArgs(params json.RawMessage) ([]reflect.Value, error) {
+	var raws []json.RawMessage
+
+	switch p := trimSpace(params); {
+	case len(p) == 0 || string(p) == "null":
+
+	case p[0] == '[':
+		if err := json.Unmarshal(p, &raws); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+
+	case p[0] == '{' && len(f.argTypes) == 1:
+		raws = []json.RawMessage{p}
+
+	default:
+		return nil, &Error{Code: CodeInvalidParams, Message: "params must be an array"}
+	}
+
+	if len(raws) != len(f.argTypes) {
+		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("want %d arguments, got %d", len(f.argTypes), len(raws))}
+	}
+
+	args := make([]reflect.Value, len(raws))
+	for i, raw := range raws {
+		arg := reflect.New(f.argTypes[i])
+		if err := json.Unmarshal(raw, arg.Interface()); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("argument %d: %s", i+1, err)}
+		}
+		args[i] = arg.Elem()
+	}
+
+	return args, nil
+}
+
+func trimSpace(data []byte) []byte {
+	for len(data) > 0 && (data[0] == ' ' || data[0] == '\t' || data[0] == '\r' || data[0] == '\n') {
+		data = data[1:]
+	}
+
+	return data
+}
+
+// Dispatch handles a message holding a single request or a batch and
+// returns the encoded response, or nil if there is nothing to respond, as
+// for notifications.
+func (b *Bridge) Dispatch(message []byte) []byte {
+	requests, batch, err := DecodeRequests(message)
+	if err != nil {
+		return encodeResponse(NewErrorResponse(nil, err.(*Error)))
+	}
+
+	var responses []*Response
+	for _, r := range requests {
+		if response := b.handle(r); response != nil {
+			responses = append(responses, response)
+		}
+	}
+
+	if len(responses) == 0 {
+		return nil
+	}
+
+	if !batch {
+		return encodeResponse(responses[0])
+	}
+
+	data, err := json.Marshal(responses)
+	if err != nil {
+		return encodeResponse(NewErrorResponse(nil, &Error{Code: CodeInternalError, Message: err.Error()}))
+	}
+
+	return data
+}
+
+func (b *Bridge) handle(r *Request) *Response {
+	if r == nil {
+		return NewErrorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "invalid request"})
+	}
+
+	if err := r.validate(); err != nil {
+		return NewErrorResponse(r.ID, err)
+	}
+
+	result, err := b.Call(r.Method, r.Params)
+
+	if r.IsNotification() {
+		return nil
+	}
+
+	if err != nil {
+		return NewErrorResponse(r.ID, err.(*Error))
+	}
+
+	response, err := NewResult(r.ID, result)
+	if err != nil {
+		return NewErrorResponse(r.ID, &Error{Code: CodeInternalError, Message: err.Error()})
+	}
+
+	return response
+}
+
+func encodeResponse(r *Response) []byte {
+	data, err := json.Marshal(r)
+	if err != nil {
+		// Only results can fail to encode and these were encoded before.
+		panic(err)
+	}
+
+	return data
+}
@@ -0,0 +1,259 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsbridge
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+}
+
+func newTestBridge(t *testing.T) *Bridge {
+	t.Helper()
+
+	b := NewBridge()
+
+	for name, fn := range map[string]interface{}{
+		"add":    func(a, b int) int { return a + b },
+		"concat": func(s []string, sep string) (string, error) { return strings.Join(s, sep), nil },
+		"norm":   func(p point) int { return p.X*p.X + p.Y*p.Y },
+		"fail":   func() error { return errors.New("it failed") },
+		"panic":  func() int { panic("boom") },
+		"noop":   func() {},
+	} {
+		if err := b.Bind(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return b
+}
+
+func TestBindSignatures(t *testing.T) {
+	b := NewBridge()
+
+	for _, fn := range []interface{}{
+		func() {},
+		func() int { return 0 },
+		func() error { return nil },
+		func(string) (int, error) { return 0, nil },
+	} {
+		if err := b.Bind("f", fn); err != nil {
+			t.Errorf("Bind(%T): %v", fn, err)
+		}
+	}
+
+	for _, test := range []struct {
+		name string
+		fn   interface{}
+	}{
+		{"", func() {}},
+		{"f", nil},
+		{"f", 42},
+		{"f", (func())(nil)},
+		{"f", func(...int) {}},
+		{"f", func() (int, int) { return 0, 0 }},
+		{"f", func() (int, int, error) { return 0, 0, nil }},
+	} {
+		if err := b.Bind(test.name, test.fn); err == nil {
+			t.Errorf("Bind(%q, %T) succeeded", test.name, test.fn)
+		}
+	}
+}
+
+func TestBridgeNames(t *testing.T) {
+	b := newTestBridge(t)
+	b.Unbind("panic")
+
+	want := []string{"add", "concat", "fail", "noop", "norm"}
+	if got := b.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v; want %v", got, want)
+	}
+}
+
+func TestBridgeCall(t *testing.T) {
+	b := newTestBridge(t)
+
+	for _, test := range []struct {
+		name   string
+		params string
+		want   interface{}
+	}{
+		{"add", `[1, 2]`, 3},
+		{"concat", `[["a", "b"], "-"]`, "a-b"},
+		{"norm", `[{"X": 3, "Y": 4}]`, 25},
+		{"norm", `{"X": 1, "Y": 2}`, 5},
+		{"noop", ``, nil},
+		{"noop", `null`, nil},
+		{"noop", ` [ ] `, nil},
+	} {
+		result, err := b.Call(test.name, json.RawMessage(test.params))
+		if err != nil {
+			t.Errorf("Call(%s, %s): %v", test.name, test.params, err)
+			continue
+		}
+		if result != test.want {
+			t.Errorf("Call(%s, %s) = %#v; want %#v", test.name, test.params, result, test.want)
+		}
+	}
+}
+
+func TestBridgeCallErrors(t *testing.T) {
+	b := newTestBridge(t)
+
+	for _, test := range []struct {
+		name   string
+		params string
+		code   int
+	}{
+		{"missing", `[]`, CodeMethodNotFound},
+		{"add", `[1]`, CodeInvalidParams},
+		{"add", `[1, 2, 3]`, CodeInvalidParams},
+		{"add", `[1, "2"]`, CodeInvalidParams},
+		{"add", `{"a": 1}`, CodeInvalidParams},
+		{"add", `"1, 2"`, CodeInvalidParams},
+		{"add", `[1, 2`, CodeInvalidParams},
+		{"fail", `[]`, CodeCallError},
+		{"panic", `[]`, CodeInternalError},
+	} {
+		_, err := b.Call(test.name, json.RawMessage(test.params))
+
+		var e *Error
+		if !errors.As(err, &e) || e.Code != test.code {
+			t.Errorf("Call(%s, %s) error = %v; want code %d", test.name, test.params, err, test.code)
+		}
+	}
+
+	_, err := b.Call("panic", nil)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Call(panic) error = %v; want it to contain the panic value", err)
+	}
+}
+
+func dispatch(t *testing.T, b *Bridge, message string) interface{} {
+	t.Helper()
+
+	data := b.Dispatch([]byte(message))
+	if data == nil {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("Dispatch(%s) returned invalid JSON %s: %v", message, data, err)
+	}
+
+	return v
+}
+
+func TestBridgeDispatch(t *testing.T) {
+	b := newTestBridge(t)
+
+	got := dispatch(t, b, `{"jsonrpc": "2.0", "id": 7, "method": "add", "params": [2, 3]}`)
+	want := map[string]interface{}{"jsonrpc": "2.0", "id": 7.0, "result": 5.0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Dispatch() = %v; want %v", got, want)
+	}
+
+	got = dispatch(t, b, `{"jsonrpc": "2.0", "id": "x", "method": "fail"}`)
+	want = map[string]interface{}{"jsonrpc": "2.0", "id": "x", "error": map[string]interface{}{"code": float64(CodeCallError), "message": "it failed"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Dispatch() = %v; want %v", got, want)
+	}
+}
+
+func TestBridgeDispatchNotifications(t *testing.T) {
+	b := NewBridge()
+
+	var calls []string
+	b.Bind("log", func(s string) { calls = append(calls, s) })
+	b.Bind("fail", func() error { return errors.New("ignored") })
+
+	if got := b.Dispatch([]byte(`{"jsonrpc": "2.0", "method": "log", "params": ["one"]}`)); got != nil {
+		t.Errorf("Dispatch() of a notification = %s; want nil", got)
+	}
+	if got := b.Dispatch([]byte(`{"jsonrpc": "2.0", "method": "fail"}`)); got != nil {
+		t.Errorf("Dispatch() of a failing notification = %s; want nil", got)
+	}
+	if got := b.Dispatch([]byte(`[{"jsonrpc": "2.0", "method": "log", "params": ["two"]}]`)); got != nil {
+		t.Errorf("Dispatch() of a batch of notifications = %s; want nil", got)
+	}
+	if !reflect.DeepEqual(calls, []string{"one", "two"}) {
+		t.Errorf("calls = %v", calls)
+	}
+}
+
+func TestBridgeDispatchBatch(t *testing.T) {
+	b := newTestBridge(t)
+
+	got := dispatch(t, b, `[
+		{"jsonrpc": "2.0", "id": 1, "method": "add", "params": [1, 1]},
+		{"jsonrpc": "2.0", "method": "noop"},
+		"bogus",
+		{"jsonrpc": "1.0", "id": 2, "method": "add"},
+		{"jsonrpc": "2.0", "id": 3, "method": "panic"}
+	]`)
+
+	responses, ok := got.([]interface{})
+	if !ok || len(responses) != 4 {
+		t.Fatalf("Dispatch() = %v; want 4 responses", got)
+	}
+
+	codes := make([]interface{}, len(responses))
+	for i, r := range responses {
+		r := r.(map[string]interface{})
+		if e, ok := r["error"].(map[string]interface{}); ok {
+			codes[i] = e["code"]
+		} else {
+			codes[i] = r["result"]
+		}
+	}
+
+	want := []interface{}{2.0, float64(CodeInvalidRequest), float64(CodeInvalidRequest), float64(CodeInternalError)}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("results and error codes = %v; want %v", codes, want)
+	}
+}
+
+func TestBridgeDispatchParseError(t *testing.T) {
+	got := dispatch(t, NewBridge(), `{"jsonrpc"`)
+
+	want := map[string]interface{}{"jsonrpc": "2.0", "id": nil}
+	r := got.(map[string]interface{})
+	if r["id"] != want["id"] || r["error"].(map[string]interface{})["code"] != float64(CodeParseError) {
+		t.Errorf("Dispatch() = %v; want a parse error with null id", got)
+	}
+}
+
+func TestDecodeEvalResult(t *testing.T) {
+	if v, err := DecodeEvalResult(`{"result": {"a": [1, "x"]}}`); err != nil || !reflect.DeepEqual(v, map[string]interface{}{"a": []interface{}{1.0, "x"}}) {
+		t.Errorf("DecodeEvalResult() = %v, %v", v, err)
+	}
+
+	var e *EvalError
+	if _, err := DecodeEvalResult(`{"error": "x is not defined"}`); !errors.As(err, &e) || e.Message != "x is not defined" {
+		t.Errorf("DecodeEvalResult() error = %v", err)
+	}
+
+	if _, err := DecodeEvalResult(`undefined`); err == nil {
+		t.Error("DecodeEvalResult() of invalid JSON succeeded")
+	}
+}
+
+func TestScriptQuotesNames(t *testing.T) {
+	s := Script("", "window.external.invoke", []string{"save", "it's\u2028"})
+
+	for _, want := range []string{`window["go"]`, `"save"`, `"it's\u2028"`} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Script() lacks %s:\n%s", want, s)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jsbridge implements the part of the bridge between page script
+// and Go that does not depend on a browser: JSON-RPC 2.0 messages, the
+// dispatch of requests to bound Go functions and the script that exposes
+// these functions to the page.
+//
+// A browser integration passes the messages the page sends to
+// Bridge.Dispatch and returns the response to the page. See the Script
+// function for the page side.
+package jsbridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Version is the JSON-RPC version of all messages.
+const Version = "2.0"
+
+// Error codes defined by JSON-RPC 2.0.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+
+	// CodeCallError is used for errors returned by bound functions.
+	CodeCallError = -32000
+)
+
+// Request is a JSON-RPC request. A request without ID is a notification,
+// which gets no response.
+type Request struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// IsNotification reports whether r has no ID.
+func (r *Request) IsNotification() bool {
+	return len(r.ID) == 0
+}
+
+// Response is a JSON-RPC response. Exactly one of Result and Error is set.
+type Response struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is the error object of a JSON-RPC response.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsbridge: %s (%d)", e.Message, e.Code)
+}
+
+// NewRequest returns a request for calling method with params, which are
+// encoded as JSON array. Passing a nil id makes it a notification.
+func NewRequest(id interface{}, method string, params ...interface{}) (*Request, error) {
+	r := &Request{Version: Version, Method: method}
+
+	if id != nil {
+		data, err := json.Marshal(id)
+		if err != nil {
+			return nil, fmt.Errorf("jsbridge: invalid id: %s", err)
+		}
+		r.ID = data
+	}
+
+	if params == nil {
+		params = []interface{}{}
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("jsbridge: invalid params: %s", err)
+	}
+	r.Params = data
+
+	return r, nil
+}
+
+// DecodeRequests decodes a message holding a single request or a batch of
+// them. It reports whether the message is a batch.
+func DecodeRequests(message []byte) (requests []*Request, batch bool, err error) {
+	message = bytes.TrimSpace(message)
+
+	if len(message) > 0 && message[0] == '[' {
+		var raws []json.RawMessage
+		if err := json.Unmarshal(message, &raws); err != nil {
+			return nil, true, &Error{Code: CodeParseError, Message: err.Error()}
+		}
+		if len(raws) == 0 {
+			return nil, true, &Error{Code: CodeInvalidRequest, Message: "empty batch"}
+		}
+
+		requests = make([]*Request, len(raws))
+		for i, raw := range raws {
+			r := new(Request)
+			if err := json.Unmarshal(raw, r); err != nil {
+				// Keep going, the request gets an error response.
+				r = nil
+			}
+			requests[i] = r
+		}
+
+		return requests, true, nil
+	}
+
+	r := new(Request)
+	if err := json.Unmarshal(message, r); err != nil {
+		return nil, false, &Error{Code: CodeParseError, Message: err.Error()}
+	}
+
+	return []*Request{r}, false, nil
+}
+
+// validate checks the fields of r that JSON decoding does not.
+func (r *Request) validate() *Error {
+	if r.Version != Version {
+		return &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf("unsupported jsonrpc version %q", r.Version)}
+	}
+	if r.Method == "" {
+		return &Error{Code: CodeInvalidRequest, Message: "missing method"}
+	}
+	if len(r.ID) > 0 {
+		switch r.ID[0] {
+		case '"', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+
+		default:
+			if string(r.ID) != "null" {
+				return &Error{Code: CodeInvalidRequest, Message: "id must be a string or number"}
+			}
+		}
+	}
+
+	return nil
+}
+
+// NewResult returns a response carrying result for the request with id.
+func NewResult(id json.RawMessage, result interface{}) (*Response, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("jsbridge: cannot encode result: %s", err)
+	}
+
+	return &Response{Version: Version, ID: responseID(id), Result: data}, nil
+}
+
+// NewErrorResponse returns a response carrying err for the request with id.
+func NewErrorResponse(id json.RawMessage, err *Error) *Response {
+	return &Response{Version: Version, ID: responseID(id), Error: err}
+}
+
+func responseID(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+
+	return id
+}
+
+// DecodeResponse decodes a single response.
+func DecodeResponse(message []byte) (*Response, error) {
+	r := new(Response)
+	if err := json.Unmarshal(message, r); err != nil {
+		return nil, fmt.Errorf("jsbridge: invalid response: %s", err)
+	}
+	if r.Version != Version {
+		return nil, fmt.Errorf("jsbridge: unsupported jsonrpc version %q", r.Version)
+	}
+
+	return r, nil
+}
+
+// Decode stores the result of r in the value pointed to by v, or returns
+// the error of r.
+func (r *Response) Decode(v interface{}) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if v == nil || len(r.Result) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(r.Result, v)
+}
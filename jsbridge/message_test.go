@@ -0,0 +1,158 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsbridge
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDecodeRequestsSingle(t *testing.T) {
+	requests, batch, err := DecodeRequests([]byte(` {"jsonrpc": "2.0", "id": 1, "method": "add", "params": [1, 2]} `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch || len(requests) != 1 {
+		t.Fatalf("batch = %t, len(requests) = %d; want false, 1", batch, len(requests))
+	}
+
+	r := requests[0]
+	if r.Method != "add" || string(r.ID) != "1" || string(r.Params) != "[1, 2]" || r.IsNotification() {
+		t.Errorf("request = %+v", r)
+	}
+}
+
+func TestDecodeRequestsBatch(t *testing.T) {
+	requests, batch, err := DecodeRequests([]byte(`[
+		{"jsonrpc": "2.0", "id": "a", "method": "first"},
+		42,
+		{"jsonrpc": "2.0", "method": "notify"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !batch || len(requests) != 3 {
+		t.Fatalf("batch = %t, len(requests) = %d; want true, 3", batch, len(requests))
+	}
+	if requests[0].Method != "first" || string(requests[0].ID) != `"a"` {
+		t.Errorf("requests[0] = %+v", requests[0])
+	}
+	if requests[1] != nil {
+		t.Errorf("requests[1] = %+v; want nil for an invalid element", requests[1])
+	}
+	if !requests[2].IsNotification() {
+		t.Error("a request without id is no notification")
+	}
+}
+
+func TestDecodeRequestsErrors(t *testing.T) {
+	for _, test := range []struct {
+		message string
+		batch   bool
+		code    int
+	}{
+		{`{"jsonrpc": "2.0", "method": `, false, CodeParseError},
+		{``, false, CodeParseError},
+		{`[{"jsonrpc": "2.0"}`, true, CodeParseError},
+		{`[]`, true, CodeInvalidRequest},
+	} {
+		_, batch, err := DecodeRequests([]byte(test.message))
+
+		var e *Error
+		if !errors.As(err, &e) || e.Code != test.code || batch != test.batch {
+			t.Errorf("DecodeRequests(%q) = batch %t, error %v; want batch %t, code %d", test.message, batch, err, test.batch, test.code)
+		}
+	}
+}
+
+func TestRequestValidate(t *testing.T) {
+	for _, test := range []struct {
+		request Request
+		valid   bool
+	}{
+		{Request{Version: Version, Method: "m"}, true},
+		{Request{Version: Version, Method: "m", ID: json.RawMessage(`7`)}, true},
+		{Request{Version: Version, Method: "m", ID: json.RawMessage(`-7`)}, true},
+		{Request{Version: Version, Method: "m", ID: json.RawMessage(`"x"`)}, true},
+		{Request{Version: Version, Method: "m", ID: json.RawMessage(`null`)}, true},
+		{Request{Version: Version, Method: "m", ID: json.RawMessage(`{}`)}, false},
+		{Request{Version: Version, Method: "m", ID: json.RawMessage(`true`)}, false},
+		{Request{Version: "1.0", Method: "m"}, false},
+		{Request{Version: Version}, false},
+	} {
+		err := test.request.validate()
+		if (err == nil) != test.valid {
+			t.Errorf("validate(%+v) = %v; want valid %t", test.request, err, test.valid)
+		}
+		if err != nil && err.Code != CodeInvalidRequest {
+			t.Errorf("validate(%+v) code = %d; want %d", test.request, err.Code, CodeInvalidRequest)
+		}
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	r, err := NewRequest(3, "save", "doc", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(r.ID) != "3" || string(r.Params) != `["doc",1]` || r.Version != Version {
+		t.Errorf("NewRequest() = %+v", r)
+	}
+
+	r, err = NewRequest(nil, "ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.IsNotification() || string(r.Params) != "[]" {
+		t.Errorf("NewRequest(nil) = %+v; want a notification with empty params", r)
+	}
+
+	if _, err := NewRequest(3, "save", make(chan int)); err == nil {
+		t.Error("NewRequest() with unencodable params succeeded")
+	}
+}
+
+func TestResponses(t *testing.T) {
+	r, err := NewResult(json.RawMessage(`"id"`), map[string]int{"n": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `{"jsonrpc":"2.0","id":"id","result":{"n":1}}`; got != want {
+		t.Errorf("encoded result = %s; want %s", got, want)
+	}
+
+	decoded, err := DecodeResponse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ N int }
+	if err := decoded.Decode(&v); err != nil || v.N != 1 {
+		t.Errorf("Decode() = %+v, %v", v, err)
+	}
+
+	data, _ = json.Marshal(NewErrorResponse(nil, &Error{Code: CodeCallError, Message: "failed"}))
+	if got, want := string(data), `{"jsonrpc":"2.0","id":null,"error":{"code":-32000,"message":"failed"}}`; got != want {
+		t.Errorf("encoded error = %s; want %s", got, want)
+	}
+
+	decoded, err = DecodeResponse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e *Error
+	if err := decoded.Decode(&v); !errors.As(err, &e) || e.Message != "failed" {
+		t.Errorf("Decode() of an error response = %v", err)
+	}
+
+	if _, err := DecodeResponse([]byte(`{"jsonrpc": "1.0", "id": 1}`)); err == nil {
+		t.Error("DecodeResponse() of a wrong version succeeded")
+	}
+}
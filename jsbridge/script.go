@@ -0,0 +1,173 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsbridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultNamespace is the global object the bound functions are added to by
+// default, e.g. window.go.save(doc).
+const DefaultNamespace = "go"
+
+// Script returns page script that adds a function for each of names to the
+// global object namespace, which is created if necessary, plus a call
+// function taking a name and an argument array.
+//
+// transport is a script expression evaluating to a function that passes a
+// JSON-RPC request message to Bridge.Dispatch and returns the response
+// message synchronously, like "window.external.invoke". The functions
+// return the result or throw an Error with the code and data of the error
+// response. Arguments and results must survive JSON.stringify.
+//
+// The script only uses ECMAScript 5, so it also runs in older browsers,
+// provided they offer JSON.
+func Script(namespace, transport string, names []string) string {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	quotedNames := make([]string, len(names))
+	for i, name := range names {
+		quotedNames[i] = quote(name)
+	}
+
+	return fmt.Sprintf(`(function() {
+	var ns = window[%s] = window[%s] || {};
+	var nextID = 1;
+	ns.call = function(method, params) {
+		var request = {jsonrpc: "2.0", id: nextID++, method: method, params: params || []};
+		var response = JSON.parse((%s)(JSON.stringify(request)));
+		if (response.error) {
+			var e = new Error(response.error.message);
+			e.code = response.error.code;
+			e.data = response.error.data;
+			throw e;
+		}
+		return response.result;
+	};
+	var names = [%s];
+	for (var i = 0; i < names.length; i++) {
+		(function(name) {
+			ns[name] = function() {
+				return ns.call(name, Array.prototype.slice.call(arguments));
+			};
+		})(names[i]);
+	}
+})();`, quote(namespace), quote(namespace), transport, strings.Join(quotedNames, ", "))
+}
+
+// AsyncScript returns page script like Script for browsers that pass
+// messages asynchronously, like WebView2. The functions return a Promise
+// and are looked up by name when called, through a Proxy, so functions
+// bound later need no script update.
+//
+// post is a script expression evaluating to a function that sends a
+// request message to Bridge.Dispatch. subscribe evaluates to a function
+// that registers a callback receiving the response messages.
+func AsyncScript(namespace, post, subscribe string) string {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	return fmt.Sprintf(`(function() {
+	if (window[%s] && window[%s].__jsbridge) {
+		return;
+	}
+	var pending = {};
+	var nextID = 1;
+	(%s)(function(message) {
+		var response;
+		try {
+			response = JSON.parse(message);
+		} catch (e) {
+			return;
+		}
+		if (!response || response.jsonrpc !== "2.0" || !pending[response.id]) {
+			return;
+		}
+		var p = pending[response.id];
+		delete pending[response.id];
+		if (response.error) {
+			var e = new Error(response.error.message);
+			e.code = response.error.code;
+			e.data = response.error.data;
+			p.reject(e);
+		} else {
+			p.resolve(response.result);
+		}
+	});
+	var call = function(method, params) {
+		return new Promise(function(resolve, reject) {
+			var id = nextID++;
+			pending[id] = {resolve: resolve, reject: reject};
+			(%s)(JSON.stringify({jsonrpc: "2.0", id: id, method: method, params: params || []}));
+		});
+	};
+	window[%s] = new Proxy({call: call, __jsbridge: true}, {
+		get: function(target, name) {
+			if (name in target || typeof name !== "string" || name === "then") {
+				return target[name];
+			}
+			return function() {
+				return call(name, Array.prototype.slice.call(arguments));
+			};
+		}
+	});
+})();`, quote(namespace), quote(namespace), subscribe, post, quote(namespace))
+}
+
+// EvalScript wraps script so that evaluating the result yields a JSON
+// string describing the value of script or the exception it threw, to be
+// decoded with DecodeEvalResult.
+func EvalScript(script string) string {
+	return fmt.Sprintf(`(function() {
+	try {
+		var v = eval(%s);
+		return JSON.stringify({result: v === undefined ? null : v});
+	} catch (e) {
+		return JSON.stringify({error: String(e && e.message !== undefined ? e.message : e)});
+	}
+})()`, quote(script))
+}
+
+// EvalError is returned by DecodeEvalResult if the script threw.
+type EvalError struct {
+	Message string
+}
+
+func (e *EvalError) Error() string {
+	return "jsbridge: script error: " + e.Message
+}
+
+// DecodeEvalResult decodes the value returned by a script made with
+// EvalScript. Values are decoded like json.Unmarshal does into an
+// interface{}.
+func DecodeEvalResult(s string) (interface{}, error) {
+	var envelope struct {
+		Result interface{} `json:"result"`
+		Error  *string     `json:"error"`
+	}
+
+	if err := json.Unmarshal([]byte(s), &envelope); err != nil {
+		return nil, fmt.Errorf("jsbridge: invalid eval result: %s", err)
+	}
+
+	if envelope.Error != nil {
+		return nil, &EvalError{Message: *envelope.Error}
+	}
+
+	return envelope.Result, nil
+}
+
+// quote returns s as script string literal. json.Marshal also escapes
+// U+2028 and U+2029, which are not allowed in script string literals.
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+
+	return string(data)
+}
@@ -0,0 +1,278 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gipcomp/winapi/errs"
+)
+
+const (
+	jsonSettingsTimestampsKey = "$timestamps"
+	jsonSettingsValueKey      = "$value"
+)
+
+// JSONFileSettings stores settings in a JSON file. Keys are split at '/' into
+// nested objects, so "MainWindow/Splitter" is stored as
+// {"MainWindow": {"Splitter": ...}}. Besides strings, values may be of any
+// type that round-trips through encoding/json, see PutValue.
+type JSONFileSettings struct {
+	fileName       string
+	key2Record     map[string]jsonFileRecord
+	expireDuration time.Duration
+	portable       bool
+}
+
+type jsonFileRecord struct {
+	value     interface{}
+	timestamp time.Time
+}
+
+func NewJSONFileSettings(fileName string) *JSONFileSettings {
+	return &JSONFileSettings{
+		fileName:   fileName,
+		key2Record: make(map[string]jsonFileRecord),
+	}
+}
+
+func (jfs *JSONFileSettings) Get(key string) (string, bool) {
+	record, ok := jfs.key2Record[key]
+	if !ok {
+		return "", false
+	}
+
+	switch v := record.value.(type) {
+	case string:
+		return v, true
+
+	case json.Number:
+		return v.String(), true
+
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+
+	case bool:
+		return strconv.FormatBool(v), true
+
+	case nil:
+		return "", true
+	}
+
+	data, err := json.Marshal(record.value)
+	if err != nil {
+		return "", false
+	}
+
+	return string(data), true
+}
+
+// Value returns the typed value stored for key. Numbers are returned as
+// json.Number and arrays as []interface{} once the settings have been
+// loaded from disk.
+func (jfs *JSONFileSettings) Value(key string) (interface{}, bool) {
+	record, ok := jfs.key2Record[key]
+	return record.value, ok
+}
+
+func (jfs *JSONFileSettings) Timestamp(key string) (time.Time, bool) {
+	record, ok := jfs.key2Record[key]
+	return record.timestamp, ok
+}
+
+func (jfs *JSONFileSettings) Put(key, value string) error {
+	return jfs.put(key, value, false)
+}
+
+func (jfs *JSONFileSettings) PutExpiring(key, value string) error {
+	return jfs.put(key, value, true)
+}
+
+// PutValue stores a typed value for key. value must be encodable by
+// encoding/json and must not encode to a JSON object; use nested keys
+// instead.
+func (jfs *JSONFileSettings) PutValue(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errs.NewInvalidArgumentError("value cannot be encoded as JSON: " + err.Error())
+	}
+	if bytes.HasPrefix(data, []byte("{")) {
+		return errs.NewInvalidArgumentError("value must not be a JSON object, use nested keys instead")
+	}
+
+	return jfs.put(key, value, false)
+}
+
+func (jfs *JSONFileSettings) put(key string, value interface{}, expiring bool) error {
+	if key == "" {
+		return errs.NewInvalidArgumentError("key must not be empty")
+	}
+	if strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") || strings.Contains(key, "//") {
+		return errs.NewInvalidArgumentError("key must not contain empty path elements")
+	}
+	if strings.HasPrefix(key, "$") || strings.Contains(key, "/$") {
+		return errs.NewInvalidArgumentError("key path elements must not start with '$'")
+	}
+
+	var timestamp time.Time
+	if expiring {
+		timestamp = time.Now()
+	}
+
+	jfs.key2Record[key] = jsonFileRecord{value, timestamp}
+
+	return nil
+}
+
+func (jfs *JSONFileSettings) Remove(key string) error {
+	delete(jfs.key2Record, key)
+
+	return nil
+}
+
+func (jfs *JSONFileSettings) Keys() []string {
+	keys := make([]string, 0, len(jfs.key2Record))
+	for key := range jfs.key2Record {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (jfs *JSONFileSettings) ExpireDuration() time.Duration {
+	return jfs.expireDuration
+}
+
+func (jfs *JSONFileSettings) SetExpireDuration(expireDuration time.Duration) {
+	jfs.expireDuration = expireDuration
+}
+
+func (jfs *JSONFileSettings) Portable() bool {
+	return jfs.portable
+}
+
+func (jfs *JSONFileSettings) SetPortable(portable bool) {
+	jfs.portable = portable
+}
+
+func (jfs *JSONFileSettings) FilePath() string {
+	return settingsFilePath(jfs.fileName, jfs.portable)
+}
+
+func (jfs *JSONFileSettings) Load() error {
+	data, err := os.ReadFile(jfs.FilePath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errs.WrapError(err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var root map[string]interface{}
+	if err := dec.Decode(&root); err != nil {
+		return errs.WrapError(err)
+	}
+
+	timestamps, _ := root[jsonSettingsTimestampsKey].(map[string]interface{})
+	delete(root, jsonSettingsTimestampsKey)
+
+	var flatten func(prefix string, obj map[string]interface{})
+	flatten = func(prefix string, obj map[string]interface{}) {
+		for name, value := range obj {
+			key := prefix + name
+			if name == jsonSettingsValueKey {
+				key = strings.TrimSuffix(prefix, "/")
+			}
+
+			if child, ok := value.(map[string]interface{}); ok && name != jsonSettingsValueKey {
+				flatten(key+"/", child)
+				continue
+			}
+
+			var ts time.Time
+			if s, ok := timestamps[key].(string); ok {
+				if ts, _ = time.Parse(iniFileTimeStampFormat, s); ts.IsZero() {
+					ts = time.Now()
+				}
+			}
+
+			jfs.key2Record[key] = jsonFileRecord{value, ts}
+		}
+	}
+	flatten("", root)
+
+	return nil
+}
+
+func (jfs *JSONFileSettings) Save() error {
+	root := make(map[string]interface{})
+	timestamps := make(map[string]interface{})
+
+	for _, key := range jfs.Keys() {
+		record := jfs.key2Record[key]
+
+		if jfs.expireDuration > 0 && !record.timestamp.IsZero() && time.Since(record.timestamp) >= jfs.expireDuration {
+			continue
+		}
+
+		parts := strings.Split(key, "/")
+
+		obj := root
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := obj[part].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				if leaf, exists := obj[part]; exists {
+					// A value and nested values share a key.
+					child[jsonSettingsValueKey] = leaf
+				}
+				obj[part] = child
+			}
+			obj = child
+		}
+
+		last := parts[len(parts)-1]
+		if child, ok := obj[last].(map[string]interface{}); ok {
+			child[jsonSettingsValueKey] = record.value
+		} else {
+			obj[last] = record.value
+		}
+
+		if !record.timestamp.IsZero() {
+			timestamps[key] = record.timestamp.Format(iniFileTimeStampFormat)
+		}
+	}
+
+	if len(timestamps) > 0 {
+		root[jsonSettingsTimestampsKey] = timestamps
+	}
+
+	data, err := json.MarshalIndent(root, "", "\t")
+	if err != nil {
+		return errs.WrapError(err)
+	}
+
+	filePath := jfs.FilePath()
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return errs.WrapError(err)
+	}
+
+	return writeFileAtomically(filePath, data)
+}
@@ -8,8 +8,10 @@ package winapi
 
 import (
 	"bytes"
+	"strings"
 
 	"github.com/Gipcomp/win32/user32"
+	"github.com/Gipcomp/winapi/errs"
 )
 
 type Key uint16
@@ -427,6 +429,55 @@ func (s Shortcut) String() string {
 	return b.String()
 }
 
+// ParseShortcut parses the format returned by Shortcut.String, e.g.
+// "Ctrl+Shift+S". Modifier names are case insensitive.
+func ParseShortcut(s string) (Shortcut, error) {
+	var shortcut Shortcut
+
+	parts := strings.Split(s, "+")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+
+		if i < len(parts)-1 {
+			switch strings.ToLower(part) {
+			case "shift":
+				shortcut.Modifiers |= ModShift
+			case "ctrl", "control":
+				shortcut.Modifiers |= ModControl
+			case "alt":
+				shortcut.Modifiers |= ModAlt
+			default:
+				return Shortcut{}, errs.NewInvalidArgumentError("invalid modifier: " + part)
+			}
+
+			continue
+		}
+
+		key, ok := string2Key()[part]
+		if !ok {
+			return Shortcut{}, errs.NewInvalidArgumentError("invalid key: " + part)
+		}
+		shortcut.Key = key
+	}
+
+	return shortcut, nil
+}
+
+var string2KeyMap map[string]Key
+
+func string2Key() map[string]Key {
+	if string2KeyMap == nil {
+		string2KeyMap = make(map[string]Key, len(key2string))
+		for key, name := range key2string {
+			if existing, ok := string2KeyMap[name]; !ok || key < existing {
+				string2KeyMap[name] = key
+			}
+		}
+	}
+
+	return string2KeyMap
+}
+
 func AltDown() bool {
 	return user32.GetKeyState(int32(KeyAlt))>>15 != 0
 }
@@ -0,0 +1,211 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Gipcomp/winapi/errs"
+)
+
+// LayeredSettings combines several Settings into one. Get returns the value
+// of the highest layer that contains the key, while Put, PutExpiring, Remove
+// and Save only affect the writable layer.
+//
+// A typical stack is defaults < machine < user < command line overrides, see
+// NewStandardLayeredSettings.
+type LayeredSettings struct {
+	layers           []Settings
+	writable         Settings
+	changedPublisher StringEventPublisher
+}
+
+// NewLayeredSettings returns LayeredSettings for layers, ordered from lowest
+// to highest priority. The highest layer is writable.
+func NewLayeredSettings(layers ...Settings) *LayeredSettings {
+	ls := new(LayeredSettings)
+
+	for _, layer := range layers {
+		if layer != nil {
+			ls.layers = append(ls.layers, layer)
+		}
+	}
+
+	if len(ls.layers) > 0 {
+		ls.writable = ls.layers[len(ls.layers)-1]
+	}
+
+	return ls
+}
+
+// NewStandardLayeredSettings returns LayeredSettings with the layers
+// defaults < machine < user < commandLine, where user is writable. Any layer
+// may be nil.
+func NewStandardLayeredSettings(defaults, machine, user, commandLine Settings) *LayeredSettings {
+	ls := NewLayeredSettings(defaults, machine, user, commandLine)
+	ls.writable = user
+
+	return ls
+}
+
+// Layers returns the layers, ordered from lowest to highest priority.
+func (ls *LayeredSettings) Layers() []Settings {
+	return ls.layers
+}
+
+// WritableLayer returns the layer Put, PutExpiring, Remove and Save operate on.
+func (ls *LayeredSettings) WritableLayer() Settings {
+	return ls.writable
+}
+
+func (ls *LayeredSettings) SetWritableLayer(layer Settings) error {
+	for _, l := range ls.layers {
+		if l == layer {
+			ls.writable = layer
+			return nil
+		}
+	}
+
+	return errs.NewInvalidArgumentError("layer is not part of the LayeredSettings")
+}
+
+// Changed returns the event that is published with the key whose effective
+// value changed through Put, PutExpiring, Remove or Load.
+func (ls *LayeredSettings) Changed() *StringEvent {
+	return ls.changedPublisher.Event()
+}
+
+func (ls *LayeredSettings) Get(key string) (string, bool) {
+	for i := len(ls.layers) - 1; i >= 0; i-- {
+		if value, ok := ls.layers[i].Get(key); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func (ls *LayeredSettings) Timestamp(key string) (time.Time, bool) {
+	for i := len(ls.layers) - 1; i >= 0; i-- {
+		if _, ok := ls.layers[i].Get(key); ok {
+			return ls.layers[i].Timestamp(key)
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (ls *LayeredSettings) Put(key, value string) error {
+	return ls.modify(key, func(w Settings) error {
+		return w.Put(key, value)
+	})
+}
+
+func (ls *LayeredSettings) PutExpiring(key, value string) error {
+	return ls.modify(key, func(w Settings) error {
+		return w.PutExpiring(key, value)
+	})
+}
+
+func (ls *LayeredSettings) Remove(key string) error {
+	return ls.modify(key, func(w Settings) error {
+		return w.Remove(key)
+	})
+}
+
+func (ls *LayeredSettings) modify(key string, f func(w Settings) error) error {
+	if ls.writable == nil {
+		return errs.NewNotSupportedError("LayeredSettings has no writable layer")
+	}
+
+	oldValue, oldOK := ls.Get(key)
+
+	if err := f(ls.writable); err != nil {
+		return err
+	}
+
+	if newValue, newOK := ls.Get(key); newValue != oldValue || newOK != oldOK {
+		ls.changedPublisher.Publish(key)
+	}
+
+	return nil
+}
+
+// Keys returns the union of the keys of all layers that implement
+// KeysProvider.
+func (ls *LayeredSettings) Keys() []string {
+	key2Present := make(map[string]bool)
+
+	for _, layer := range ls.layers {
+		if kp, ok := layer.(KeysProvider); ok {
+			for _, key := range kp.Keys() {
+				key2Present[key] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(key2Present))
+	for key := range key2Present {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (ls *LayeredSettings) ExpireDuration() time.Duration {
+	if ls.writable == nil {
+		return 0
+	}
+
+	return ls.writable.ExpireDuration()
+}
+
+func (ls *LayeredSettings) SetExpireDuration(expireDuration time.Duration) {
+	if ls.writable != nil {
+		ls.writable.SetExpireDuration(expireDuration)
+	}
+}
+
+// Load loads all layers and publishes Changed for every key whose effective
+// value differs afterwards.
+func (ls *LayeredSettings) Load() error {
+	before := make(map[string]string)
+	for _, key := range ls.Keys() {
+		before[key], _ = ls.Get(key)
+	}
+
+	for _, layer := range ls.layers {
+		if err := layer.Load(); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range ls.Keys() {
+		value, _ := ls.Get(key)
+		if oldValue, ok := before[key]; !ok || oldValue != value {
+			ls.changedPublisher.Publish(key)
+		}
+		delete(before, key)
+	}
+	for key := range before {
+		ls.changedPublisher.Publish(key)
+	}
+
+	return nil
+}
+
+// Save saves the writable layer.
+func (ls *LayeredSettings) Save() error {
+	if ls.writable == nil {
+		return nil
+	}
+
+	return ls.writable.Save()
+}
@@ -462,7 +462,7 @@ func (l *LayoutBase) SetMargins(value Margins) error {
 	}
 
 	if value.HNear < 0 || value.VNear < 0 || value.HFar < 0 || value.VFar < 0 {
-		return errs.NewError("margins must be positive")
+		return errs.NewInvalidArgumentError("margins must be positive")
 	}
 
 	l.margins96dpi = value
@@ -486,7 +486,7 @@ func (l *LayoutBase) SetSpacing(value int) error {
 	}
 
 	if value < 0 {
-		return errs.NewError("spacing cannot be negative")
+		return errs.NewInvalidArgumentError("spacing cannot be negative")
 	}
 
 	l.spacing96dpi = value
@@ -519,7 +519,7 @@ func (l *LayoutBase) Alignment() Alignment2D {
 func (l *LayoutBase) SetAlignment(alignment Alignment2D) error {
 	if alignment != l.alignment {
 		if alignment < AlignHVDefault || alignment > AlignHFarVFar {
-			return errs.NewError("invalid Alignment value")
+			return errs.NewInvalidArgumentError("invalid Alignment value")
 		}
 
 		l.alignment = alignment
@@ -567,7 +567,7 @@ func (ctx *LayoutContext) DPI() int {
 func newLayoutContext(handle handle.HWND) *LayoutContext {
 	return &LayoutContext{
 		layoutItem2MinSizeEffective: make(map[LayoutItem]Size),
-		dpi:                         int(user32.GetDpiForWindow(handle)),
+		dpi:                         dpiForHWnd(handle),
 	}
 }
 
@@ -79,7 +79,7 @@ func newLineEdit(parent Window) (*LineEdit, error) {
 
 func NewLineEdit(parent Container) (*LineEdit, error) {
 	if parent == nil {
-		return nil, errs.NewError("parent cannot be nil")
+		return nil, errs.NewInvalidArgumentError("parent cannot be nil")
 	}
 
 	le, err := newLineEdit(parent)
@@ -107,7 +107,7 @@ func NewLineEdit(parent Container) (*LineEdit, error) {
 func (le *LineEdit) CueBanner() string {
 	buf := make([]uint16, 128)
 	if win.FALSE == le.SendMessage(winuser.EM_GETCUEBANNER, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf))) {
-		errs.NewError("EM_GETCUEBANNER failed")
+		errs.NewWin32Error("EM_GETCUEBANNER")
 		return ""
 	}
 
@@ -120,7 +120,7 @@ func (le *LineEdit) SetCueBanner(value string) error {
 		errs.NewError(err.Error())
 	}
 	if win.FALSE == le.SendMessage(winuser.EM_SETCUEBANNER, win.FALSE, uintptr(unsafe.Pointer(strPtr))) {
-		return errs.NewError("EM_SETCUEBANNER failed")
+		return errs.NewWin32Error("EM_SETCUEBANNER")
 	}
 
 	return nil
@@ -271,7 +271,7 @@ func (le *LineEdit) initCharWidth() {
 
 	hdc := user32.GetDC(le.hWnd)
 	if hdc == 0 {
-		errs.NewError("GetDC failed")
+		errs.NewWin32Error("GetDC")
 		return
 	}
 	defer user32.ReleaseDC(le.hWnd, hdc)
@@ -282,7 +282,7 @@ func (le *LineEdit) initCharWidth() {
 
 	var s gdi32.SIZE
 	if !gdi32.GetTextExtentPoint32(hdc, &buf[0], int32(len(buf)), &s) {
-		errs.NewError("GetTextExtentPoint32 failed")
+		errs.NewWin32Error("GetTextExtentPoint32")
 		return
 	}
 	le.charWidth = int(s.CX)
@@ -130,7 +130,7 @@ func NewListBoxWithStyle(parent Container, style uint32) (*ListBox, error) {
 				if lb.model == nil {
 					return nil
 				} else {
-					return errs.NewError("Data binding is only supported using a model that implements BindingValueProvider.")
+					return errs.NewNotSupportedError("Data binding is only supported using a model that implements BindingValueProvider.")
 				}
 			}
 
@@ -458,7 +458,7 @@ func (lb *ListBox) BindingMember() string {
 func (lb *ListBox) SetBindingMember(bindingMember string) error {
 	if bindingMember != "" {
 		if _, ok := lb.providedModel.([]string); ok {
-			return errs.NewError("invalid for []string model")
+			return errs.NewInvalidArgumentError("invalid for []string model")
 		}
 	}
 
@@ -499,7 +499,7 @@ func (lb *ListBox) DisplayMember() string {
 func (lb *ListBox) SetDisplayMember(displayMember string) error {
 	if displayMember != "" {
 		if _, ok := lb.providedModel.([]string); ok {
-			return errs.NewError("invalid for []string model")
+			return errs.NewInvalidArgumentError("invalid for []string model")
 		}
 	}
 
@@ -532,7 +532,7 @@ func (lb *ListBox) SetPrecision(value int) {
 func (lb *ListBox) calculateMaxItemTextWidth() int {
 	hdc := user32.GetDC(lb.hWnd)
 	if hdc == 0 {
-		errs.NewError("GetDC failed")
+		errs.NewWin32Error("GetDC")
 		return -1
 	}
 	defer user32.ReleaseDC(lb.hWnd, hdc)
@@ -555,7 +555,7 @@ func (lb *ListBox) calculateMaxItemTextWidth() int {
 		}
 
 		if !gdi32.GetTextExtentPoint32(hdc, &str[0], int32(len(str)-1), &s) {
-			errs.NewError("GetTextExtentPoint32 failed")
+			errs.NewWin32Error("GetTextExtentPoint32")
 			return -1
 		}
 
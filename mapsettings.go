@@ -0,0 +1,152 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gipcomp/winapi/errs"
+)
+
+// KeysProvider is implemented by Settings that can enumerate their keys.
+type KeysProvider interface {
+	Keys() []string
+}
+
+// MapSettings is a Settings implementation that only lives in memory. It is
+// useful for defaults and command line overrides in LayeredSettings.
+type MapSettings struct {
+	mutex          sync.RWMutex
+	key2Record     map[string]iniFileRecord
+	expireDuration time.Duration
+}
+
+// NewMapSettings returns a MapSettings initialized with values.
+func NewMapSettings(values map[string]string) *MapSettings {
+	ms := &MapSettings{key2Record: make(map[string]iniFileRecord)}
+
+	for key, value := range values {
+		ms.key2Record[key] = iniFileRecord{value: value}
+	}
+
+	return ms
+}
+
+// NewCommandLineSettings returns a MapSettings holding the "key=value" pairs
+// passed through flag in args, as in "-set key=value" or "--set=key=value".
+func NewCommandLineSettings(args []string, flag string) *MapSettings {
+	values := make(map[string]string)
+
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimLeft(args[i], "-")
+		if len(arg) == len(args[i]) {
+			continue
+		}
+
+		var pair string
+		if arg == flag && i+1 < len(args) {
+			i++
+			pair = args[i]
+		} else if strings.HasPrefix(arg, flag+"=") {
+			pair = arg[len(flag)+1:]
+		} else {
+			continue
+		}
+
+		if assignIndex := strings.Index(pair, "="); assignIndex > 0 {
+			values[pair[:assignIndex]] = pair[assignIndex+1:]
+		}
+	}
+
+	return NewMapSettings(values)
+}
+
+func (ms *MapSettings) Get(key string) (string, bool) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	record, ok := ms.key2Record[key]
+	return record.value, ok
+}
+
+func (ms *MapSettings) Timestamp(key string) (time.Time, bool) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	record, ok := ms.key2Record[key]
+	return record.timestamp, ok
+}
+
+func (ms *MapSettings) Put(key, value string) error {
+	return ms.put(key, value, false)
+}
+
+func (ms *MapSettings) PutExpiring(key, value string) error {
+	return ms.put(key, value, true)
+}
+
+func (ms *MapSettings) put(key, value string, expiring bool) error {
+	if key == "" {
+		return errs.NewInvalidArgumentError("key must not be empty")
+	}
+
+	var timestamp time.Time
+	if expiring {
+		timestamp = time.Now()
+	}
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.key2Record[key] = iniFileRecord{value, timestamp}
+
+	return nil
+}
+
+func (ms *MapSettings) Remove(key string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	delete(ms.key2Record, key)
+
+	return nil
+}
+
+func (ms *MapSettings) Keys() []string {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	keys := make([]string, 0, len(ms.key2Record))
+	for key := range ms.key2Record {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (ms *MapSettings) ExpireDuration() time.Duration {
+	return ms.expireDuration
+}
+
+func (ms *MapSettings) SetExpireDuration(expireDuration time.Duration) {
+	ms.expireDuration = expireDuration
+}
+
+// Load does nothing, MapSettings are not persisted.
+func (ms *MapSettings) Load() error {
+	return nil
+}
+
+// Save does nothing, MapSettings are not persisted.
+func (ms *MapSettings) Save() error {
+	return nil
+}
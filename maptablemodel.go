@@ -23,7 +23,7 @@ type mapTableModel struct {
 func newMapTableModel(dataSource interface{}) (TableModel, error) {
 	items, ok := dataSource.([]map[string]interface{})
 	if !ok {
-		return nil, errs.NewError("dataSource must be assignable to []map[string]interface{}")
+		return nil, errs.NewInvalidArgumentError("dataSource must be assignable to []map[string]interface{}")
 	}
 
 	return &mapTableModel{dataSource: dataSource, items: items}, nil
@@ -53,6 +53,23 @@ func (m *mapTableModel) Value(row, col int) interface{} {
 	return m.items[row][m.dataMembers[col]]
 }
 
+// item returns the item at row, populating it first if necessary.
+func (m *mapTableModel) item(row int) interface{} {
+	if m.items[row] == nil {
+		if populator, ok := m.dataSource.(Populator); ok {
+			if err := populator.Populate(row); err != nil {
+				return nil
+			}
+		}
+
+		if m.items[row] == nil {
+			return nil
+		}
+	}
+
+	return m.items[row]
+}
+
 func (m *mapTableModel) Sort(col int, order SortOrder) error {
 	m.col, m.order = col, order
 
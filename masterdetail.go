@@ -0,0 +1,433 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"log"
+
+	"github.com/Gipcomp/winapi/errs"
+)
+
+// ItemProvider is the interface that a TableView model must implement to
+// be used with a MasterDetail, unless it is a slice or ReflectTableModel.
+type ItemProvider interface {
+	// Item returns the item at row, which becomes the data source of the
+	// DataBinder of a MasterDetail.
+	Item(row int) interface{}
+}
+
+// ItemInserter is the interface that a TableView model must implement to
+// support adding items through a MasterDetail.
+type ItemInserter interface {
+	// InsertItem inserts a new item at row, publishes the corresponding rows
+	// inserted event and returns the item.
+	InsertItem(row int) (interface{}, error)
+}
+
+// ItemRemover is the interface that a TableView model must implement to
+// support deleting items through a MasterDetail.
+type ItemRemover interface {
+	// RemoveItem removes the item at row and publishes the corresponding
+	// rows removed event.
+	RemoveItem(row int) error
+}
+
+// DirtyAction tells a MasterDetail what to do with the unsubmitted edits
+// of its detail form before the current row changes.
+type DirtyAction int
+
+const (
+	// DirtyActionSubmit submits the edits and changes the row, unless
+	// submitting fails.
+	DirtyActionSubmit DirtyAction = iota
+
+	// DirtyActionDiscard discards the edits and changes the row.
+	DirtyActionDiscard
+
+	// DirtyActionCancel keeps the edits and the current row.
+	DirtyActionCancel
+)
+
+// MasterDetail links the current item of a TableView, the master, to the
+// data source of a DataBinder, the detail form.
+//
+// While the DataBinder is dirty, changing the current row of the TableView
+// is held back until the DirtyHandler decided what to do with the edits. By
+// default the user is asked whether to save them.
+type MasterDetail struct {
+	tableView                   *TableView
+	dataBinder                  *DataBinder
+	row                         int
+	dirtyHandler                func(md *MasterDetail) DirtyAction
+	addAction                   *Action
+	deleteAction                *Action
+	revertAction                *Action
+	canAddCondition             *MutableCondition
+	canDeleteCondition          *MutableCondition
+	canRevertCondition          *MutableCondition
+	currentIndexChangedHandle   int
+	itemCountChangedHandle      int
+	dirtyChangedHandle          int
+	submittedHandle             int
+	rowChangedPublisher         IntEventPublisher
+	currentItemChangedPublisher EventPublisher
+}
+
+// NewMasterDetail returns a MasterDetail that shows the current item of tv
+// in the widgets bound by db.
+func NewMasterDetail(tv *TableView, db *DataBinder) (*MasterDetail, error) {
+	if tv == nil {
+		return nil, errs.NewInvalidArgumentError("tv must not be nil")
+	}
+	if db == nil {
+		return nil, errs.NewInvalidArgumentError("db must not be nil")
+	}
+
+	md := &MasterDetail{
+		tableView:          tv,
+		dataBinder:         db,
+		row:                -1,
+		canAddCondition:    NewMutableCondition(),
+		canDeleteCondition: NewMutableCondition(),
+		canRevertCondition: NewMutableCondition(),
+	}
+
+	md.addAction = md.newAction("&Add", md.canAddCondition, md.Add)
+	md.deleteAction = md.newAction("&Delete", md.canDeleteCondition, md.Delete)
+	md.revertAction = md.newAction("&Revert", md.canRevertCondition, md.Revert)
+
+	md.currentIndexChangedHandle = tv.CurrentIndexChanged().Attach(md.onCurrentIndexChanged)
+	md.itemCountChangedHandle = tv.ItemCountChanged().Attach(md.onItemCountChanged)
+	md.dirtyChangedHandle = db.DirtyChanged().Attach(md.updateConditions)
+	md.submittedHandle = db.Submitted().Attach(md.onSubmitted)
+
+	if err := md.load(tv.CurrentIndex()); err != nil {
+		md.Dispose()
+		return nil, err
+	}
+
+	return md, nil
+}
+
+func (md *MasterDetail) newAction(text string, enabled Condition, f func() error) *Action {
+	action := NewAction()
+	action.SetText(text)
+	action.SetEnabledCondition(enabled)
+	action.Triggered().Attach(func() {
+		if err := f(); err != nil {
+			log.Printf("*MasterDetail - %s failed: %s", action.Text(), err)
+		}
+	})
+
+	return action
+}
+
+// Dispose detaches the MasterDetail from its TableView and DataBinder.
+func (md *MasterDetail) Dispose() {
+	if md.tableView == nil {
+		return
+	}
+
+	md.tableView.CurrentIndexChanged().Detach(md.currentIndexChangedHandle)
+	md.tableView.ItemCountChanged().Detach(md.itemCountChangedHandle)
+	md.dataBinder.DirtyChanged().Detach(md.dirtyChangedHandle)
+	md.dataBinder.Submitted().Detach(md.submittedHandle)
+
+	md.tableView = nil
+}
+
+// TableView returns the master TableView.
+func (md *MasterDetail) TableView() *TableView {
+	return md.tableView
+}
+
+// DataBinder returns the DataBinder of the detail form.
+func (md *MasterDetail) DataBinder() *DataBinder {
+	return md.dataBinder
+}
+
+// CurrentRow returns the row of the item shown in the detail form, or -1.
+func (md *MasterDetail) CurrentRow() int {
+	return md.row
+}
+
+// CurrentItem returns the item shown in the detail form, or nil.
+func (md *MasterDetail) CurrentItem() interface{} {
+	return md.dataBinder.DataSource()
+}
+
+// CurrentItemChanged returns the event that is published after another item
+// was loaded into the detail form.
+func (md *MasterDetail) CurrentItemChanged() *Event {
+	return md.currentItemChangedPublisher.Event()
+}
+
+// RowChanged returns the event that is published with the row of the
+// current item after the detail form was submitted to it.
+func (md *MasterDetail) RowChanged() *IntEvent {
+	return md.rowChangedPublisher.Event()
+}
+
+// DirtyHandler returns the func deciding what happens to unsubmitted edits
+// before the current row changes, or nil if the user is asked.
+func (md *MasterDetail) DirtyHandler() func(md *MasterDetail) DirtyAction {
+	return md.dirtyHandler
+}
+
+// SetDirtyHandler sets the func deciding what happens to unsubmitted edits
+// before the current row changes. If handler is nil, the user is asked.
+func (md *MasterDetail) SetDirtyHandler(handler func(md *MasterDetail) DirtyAction) {
+	md.dirtyHandler = handler
+}
+
+// AddAction returns the Action that adds a new item. It is enabled if the
+// model of the TableView implements ItemInserter.
+func (md *MasterDetail) AddAction() *Action {
+	return md.addAction
+}
+
+// DeleteAction returns the Action that deletes the current item. It is
+// enabled if there is a current item and the model of the TableView
+// implements ItemRemover.
+func (md *MasterDetail) DeleteAction() *Action {
+	return md.deleteAction
+}
+
+// RevertAction returns the Action that discards the unsubmitted edits of
+// the detail form. It is enabled while the DataBinder is dirty.
+func (md *MasterDetail) RevertAction() *Action {
+	return md.revertAction
+}
+
+// Add resolves unsubmitted edits, then inserts a new item after the last
+// row and makes it current.
+func (md *MasterDetail) Add() error {
+	inserter, ok := md.tableView.Model().(ItemInserter)
+	if !ok {
+		return errs.NewError("model does not implement ItemInserter")
+	}
+
+	if !md.resolveDirty() {
+		return nil
+	}
+
+	row := md.tableView.model.RowCount()
+
+	if _, err := inserter.InsertItem(row); err != nil {
+		return err
+	}
+
+	return md.setCurrentRow(row)
+}
+
+// Delete discards unsubmitted edits and removes the current item. The item
+// at the same row, or else the new last one, becomes current.
+func (md *MasterDetail) Delete() error {
+	remover, ok := md.tableView.Model().(ItemRemover)
+	if !ok {
+		return errs.NewError("model does not implement ItemRemover")
+	}
+
+	row := md.row
+	if row < 0 {
+		return nil
+	}
+
+	md.dataBinder.setDirty(false)
+
+	if err := remover.RemoveItem(row); err != nil {
+		return err
+	}
+
+	if count := md.tableView.model.RowCount(); row >= count {
+		row = count - 1
+	}
+
+	md.row = -1
+
+	return md.setCurrentRow(row)
+}
+
+// Revert discards the unsubmitted edits of the detail form.
+func (md *MasterDetail) Revert() error {
+	return md.dataBinder.Reset()
+}
+
+// Submit submits the edits of the detail form to the current item.
+func (md *MasterDetail) Submit() error {
+	return md.dataBinder.Submit()
+}
+
+func (md *MasterDetail) onCurrentIndexChanged() {
+	index := md.tableView.CurrentIndex()
+	if index == md.row {
+		return
+	}
+
+	if !md.dataBinder.Dirty() {
+		if err := md.load(index); err != nil {
+			log.Printf("*MasterDetail - failed to load row %d: %s", index, err)
+		}
+		return
+	}
+
+	// Hold the change back. The TableView is in the middle of processing
+	// it, so the row is restored and the user asked afterwards.
+	row := md.row
+	md.tableView.Synchronize(func() {
+		if md.tableView == nil {
+			return
+		}
+
+		md.tableView.SetCurrentIndex(row)
+
+		if md.resolveDirty() {
+			if err := md.setCurrentRow(index); err != nil {
+				log.Printf("*MasterDetail - failed to load row %d: %s", index, err)
+			}
+		}
+	})
+}
+
+func (md *MasterDetail) onItemCountChanged() {
+	if md.tableView.model == nil {
+		// The model was removed, so there is no item to edit anymore.
+		md.dataBinder.setDirty(false)
+		md.row = -1
+
+		if err := md.load(-1); err != nil {
+			log.Printf("*MasterDetail - failed to clear the detail form: %s", err)
+		}
+		return
+	}
+
+	if count := md.tableView.model.RowCount(); md.row >= count {
+		md.dataBinder.setDirty(false)
+		md.row = -1
+	}
+
+	if index := md.tableView.CurrentIndex(); index != md.row {
+		md.onCurrentIndexChanged()
+	} else {
+		md.updateConditions()
+	}
+}
+
+func (md *MasterDetail) onSubmitted() {
+	if md.row < 0 {
+		return
+	}
+
+	if p, ok := md.tableView.model.(interface{ PublishRowChanged(row int) }); ok {
+		p.PublishRowChanged(md.row)
+	}
+
+	md.rowChangedPublisher.Publish(md.row)
+}
+
+// resolveDirty deals with unsubmitted edits and reports whether the current
+// row may change.
+func (md *MasterDetail) resolveDirty() bool {
+	if !md.dataBinder.Dirty() {
+		return true
+	}
+
+	var action DirtyAction
+	if md.dirtyHandler != nil {
+		action = md.dirtyHandler(md)
+	} else {
+		action = md.askDirtyAction()
+	}
+
+	switch action {
+	case DirtyActionSubmit:
+		return md.dataBinder.Submit() == nil
+
+	case DirtyActionDiscard:
+		md.dataBinder.setDirty(false)
+		return true
+	}
+
+	return false
+}
+
+func (md *MasterDetail) askDirtyAction() DirtyAction {
+	switch MsgBox(md.tableView.Form(), "Unsaved Changes", "Do you want to save the changes to the current item?", MsgBoxYesNoCancel|MsgBoxIconQuestion) {
+	case DlgCmdYes:
+		return DirtyActionSubmit
+
+	case DlgCmdNo:
+		return DirtyActionDiscard
+	}
+
+	return DirtyActionCancel
+}
+
+// setCurrentRow makes row current in the TableView and loads it, in case
+// the TableView does not publish CurrentIndexChanged right away.
+func (md *MasterDetail) setCurrentRow(row int) error {
+	if err := md.tableView.SetCurrentIndex(row); err != nil {
+		return err
+	}
+
+	if md.row != row {
+		return md.load(row)
+	}
+
+	return nil
+}
+
+// load makes the item at row, or nothing if row is -1, the data source of
+// the DataBinder.
+func (md *MasterDetail) load(row int) error {
+	var item interface{}
+	if row >= 0 {
+		var err error
+		if item, err = md.item(row); err != nil {
+			return err
+		}
+	}
+
+	md.row = row
+
+	if err := md.dataBinder.SetDataSource(item); err != nil {
+		return err
+	}
+
+	if err := md.dataBinder.Reset(); err != nil {
+		return err
+	}
+
+	md.updateConditions()
+
+	md.currentItemChangedPublisher.Publish()
+
+	return nil
+}
+
+func (md *MasterDetail) item(row int) (interface{}, error) {
+	if ip, ok := md.tableView.Model().(ItemProvider); ok {
+		return ip.Item(row), nil
+	}
+
+	if ip, ok := md.tableView.model.(interface{ item(row int) interface{} }); ok {
+		return ip.item(row), nil
+	}
+
+	return nil, errs.NewError("model does not implement ItemProvider")
+}
+
+func (md *MasterDetail) updateConditions() {
+	model := md.tableView.Model()
+
+	_, canAdd := model.(ItemInserter)
+	_, canRemove := model.(ItemRemover)
+
+	md.canAddCondition.SetSatisfied(canAdd)
+	md.canDeleteCondition.SetSatisfied(canRemove && md.row >= 0)
+	md.canRevertCondition.SetSatisfied(md.dataBinder.Dirty())
+}
@@ -185,7 +185,7 @@ func (m *Menu) onActionChanged(action *Action) error {
 	m.initMenuItemInfoFromAction(&mii, action)
 
 	if !user32.SetMenuItemInfo(m.hMenu, uint32(m.actions.indexInObserver(action)), true, &mii) {
-		return errs.NewError("SetMenuItemInfo failed")
+		return errs.LastError("SetMenuItemInfo")
 	}
 
 	if action.Default() {
@@ -212,7 +212,7 @@ func (m *Menu) onActionChanged(action *Action) error {
 		}
 
 		if !user32.CheckMenuRadioItem(m.hMenu, uint32(first), uint32(last), uint32(index), winuser.MF_BYPOSITION) {
-			return errs.NewError("CheckMenuRadioItem failed")
+			return errs.LastError("CheckMenuRadioItem")
 		}
 	}
 
@@ -254,7 +254,7 @@ func (m *Menu) insertAction(action *Action, visibleChanged bool) (err error) {
 	m.initMenuItemInfoFromAction(&mii, action)
 
 	if !user32.InsertMenuItem(m.hMenu, uint32(index), true, &mii) {
-		return errs.NewError("InsertMenuItem failed")
+		return errs.LastError("InsertMenuItem")
 	}
 
 	if action.Default() {
@@ -27,7 +27,7 @@ type Metafile struct {
 func NewMetafile(referenceCanvas *Canvas) (*Metafile, error) {
 	hdc := gdi32.CreateEnhMetaFile(referenceCanvas.hdc, nil, nil, nil)
 	if hdc == 0 {
-		return nil, errs.NewError("CreateEnhMetaFile failed")
+		return nil, errs.NewWin32Error("CreateEnhMetaFile")
 	}
 
 	return &Metafile{hdc: hdc}, nil
@@ -40,7 +40,7 @@ func NewMetafileFromFile(filePath string) (*Metafile, error) {
 	}
 	hemf := gdi32.GetEnhMetaFile(strPtr)
 	if hemf == 0 {
-		return nil, errs.NewError("GetEnhMetaFile failed")
+		return nil, errs.NewWin32Error("GetEnhMetaFile")
 	}
 
 	mf := &Metafile{hemf: hemf}
@@ -70,7 +70,7 @@ func (mf *Metafile) Save(filePath string) error {
 	}
 	hemf := gdi32.CopyEnhMetaFile(mf.hemf, strPtr)
 	if hemf == 0 {
-		return errs.NewError("CopyEnhMetaFile failed")
+		return errs.NewWin32Error("CopyEnhMetaFile")
 	}
 
 	gdi32.DeleteEnhMetaFile(hemf)
@@ -82,7 +82,7 @@ func (mf *Metafile) readSizeFromHeader() error {
 	var hdr gdi32.ENHMETAHEADER
 
 	if gdi32.GetEnhMetaFileHeader(mf.hemf, uint32(unsafe.Sizeof(hdr)), &hdr) == 0 {
-		return errs.NewError("GetEnhMetaFileHeader failed")
+		return errs.NewWin32Error("GetEnhMetaFileHeader")
 	}
 
 	mf.size = sizeFromRECT(hdr.RclBounds)
@@ -98,7 +98,7 @@ func (mf *Metafile) readSizeFromHeader() error {
 func (mf *Metafile) ensureFinished() error {
 	if mf.hdc == 0 {
 		if mf.hemf == 0 {
-			return errs.NewError("already disposed")
+			return errs.NewDisposedError("already disposed")
 		} else {
 			return nil
 		}
@@ -106,7 +106,7 @@ func (mf *Metafile) ensureFinished() error {
 
 	mf.hemf = gdi32.CloseEnhMetaFile(mf.hdc)
 	if mf.hemf == 0 {
-		return errs.NewError("CloseEnhMetaFile failed")
+		return errs.NewWin32Error("CloseEnhMetaFile")
 	}
 
 	mf.hdc = 0
@@ -130,7 +130,7 @@ func (mf *Metafile) drawStretched(hdc gdi32.HDC, bounds Rectangle) error {
 	rc := bounds.toRECT()
 
 	if !gdi32.PlayEnhMetaFile(hdc, mf.hemf, &rc) {
-		return errs.NewError("PlayEnhMetaFile failed")
+		return errs.NewWin32Error("PlayEnhMetaFile")
 	}
 
 	return nil
@@ -451,7 +451,7 @@ func (lis *ListItemStyle) DrawBackground() error {
 
 	if lis.hTheme != 0 && stateID != uxtheme.LISS_NORMAL {
 		if win.FAILED(uxtheme.DrawThemeBackground(lis.hTheme, lis.hdc, uxtheme.LVP_LISTITEM, stateID, &lis.rc, nil)) {
-			return errs.NewError("DrawThemeBackground failed")
+			return errs.NewWin32Error("DrawThemeBackground")
 		}
 	} else {
 		brush, err := NewSolidColorBrush(lis.BackgroundColor)
@@ -493,7 +493,7 @@ func (lis *ListItemStyle) DrawText(text string, bounds Rectangle, format DrawTex
 			return err
 		}
 		if win.FAILED(uxtheme.DrawThemeTextEx(lis.hTheme, lis.hdc, uxtheme.LVP_LISTITEM, lis.stateID(), strPtr, int32(len(([]rune)(text))), uint32(format), &rc, nil)) {
-			return errs.NewError("DrawThemeTextEx failed")
+			return errs.NewWin32Error("DrawThemeTextEx")
 		}
 	} else {
 		if canvas := lis.Canvas(); canvas != nil {
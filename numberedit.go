@@ -166,7 +166,7 @@ func (ne *NumberEdit) Decimals() int {
 // SetDecimals sets the number of decimal places in the NumberEdit.
 func (ne *NumberEdit) SetDecimals(decimals int) error {
 	if decimals < 0 || decimals > 8 {
-		return errs.NewError("decimals must >= 0 && <= 8")
+		return errs.NewInvalidArgumentError("decimals must >= 0 && <= 8")
 	}
 
 	ne.edit.decimals = decimals
@@ -313,7 +313,7 @@ func (ne *NumberEdit) SetValue(value float64) error {
 	if ne.edit.minValue != ne.edit.maxValue &&
 		(value < ne.edit.minValue || value > ne.edit.maxValue) {
 
-		return errs.NewError("value out of range")
+		return errs.NewInvalidArgumentError("value out of range")
 	}
 
 	return ne.edit.setValue(value, true)
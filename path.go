@@ -18,7 +18,7 @@ func knownFolderPath(id shell32.CSIDL) (string, error) {
 	var buf [kernel32.MAX_PATH]uint16
 
 	if !shell32.SHGetSpecialFolderPath(0, &buf[0], id, false) {
-		return "", errs.NewError("SHGetSpecialFolderPath failed")
+		return "", errs.NewWin32Error("SHGetSpecialFolderPath")
 	}
 
 	return syscall.UTF16ToString(buf[0:]), nil
@@ -91,7 +91,7 @@ func (pi *ProgressIndicator) Completed() uint32 {
 func (pi *ProgressIndicator) SetOverlayIcon(icon *Icon, description string) error {
 	handle := user32.HICON(0)
 	if icon != nil {
-		handle = icon.handleForDPI(int(user32.GetDpiForWindow(pi.hwnd)))
+		handle = icon.handleForDPI(dpiForHWnd(pi.hwnd))
 	}
 	description16, err := syscall.UTF16PtrFromString(description)
 	if err != nil {
@@ -106,7 +106,7 @@ func (p *property) SetSource(source interface{}) error {
 			})
 
 		default:
-			return errs.NewError("invalid source type")
+			return errs.NewInvalidArgumentError("invalid source type")
 		}
 	}
 
@@ -359,7 +359,7 @@ func checkPropertySource(prop Property, source interface{}) error {
 	case Property:
 		for cur := source; cur != nil; cur, _ = cur.Source().(Property) {
 			if cur == prop {
-				return errs.NewError("source cycle")
+				return errs.NewInvalidArgumentError("source cycle")
 			}
 		}
 	}
@@ -211,6 +211,31 @@ func (m *reflectTableModel) Value(row, col int) interface{} {
 	return valueFromSlice(m.dataSource, m.value, m.dataMembers[col], row)
 }
 
+// item returns the item at row, populating it first if necessary.
+func (m *reflectTableModel) item(row int) interface{} {
+	v := m.value.Index(row)
+
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		if populator, ok := m.dataSource.(Populator); ok {
+			if err := populator.Populate(row); err != nil {
+				return nil
+			}
+		}
+
+		if v.IsNil() {
+			return nil
+		}
+	}
+
+	// Struct values are returned by pointer, so a DataBinder accepts them and
+	// edits go to the slice instead of a copy.
+	if v.Kind() == reflect.Struct && v.CanAddr() {
+		return v.Addr().Interface()
+	}
+
+	return v.Interface()
+}
+
 func (m *reflectTableModel) Checked(row int) bool {
 	if m.value.Index(row).IsNil() {
 		return false
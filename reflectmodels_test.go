@@ -0,0 +1,58 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import "testing"
+
+type testPerson struct {
+	Name string
+	Age  int
+}
+
+func TestReflectTableModelItem(t *testing.T) {
+	people := []testPerson{{"Alice", 30}, {"Bob", 40}}
+
+	model, err := newReflectTableModel(people)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := model.(interface{ item(row int) interface{} }).item(1)
+
+	// Struct values are returned by pointer, as a DataBinder requires.
+	person, ok := item.(*testPerson)
+	if !ok {
+		t.Fatalf("item(1) = %T, want *testPerson", item)
+	}
+
+	person.Age = 41
+	if people[1].Age != 41 {
+		t.Errorf("editing the item did not change the slice element")
+	}
+
+	db := NewDataBinder()
+	if err := db.SetDataSource(item); err != nil {
+		t.Errorf("SetDataSource(item(1)) = %v", err)
+	}
+}
+
+func TestReflectTableModelItemPointers(t *testing.T) {
+	bob := &testPerson{"Bob", 40}
+
+	model, err := newReflectTableModel([]*testPerson{bob, nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ip := model.(interface{ item(row int) interface{} })
+	if item := ip.item(0); item != bob {
+		t.Errorf("item(0) = %v, want the pointer stored in the slice", item)
+	}
+	if item := ip.item(1); item != nil {
+		t.Errorf("item(1) = %v, want nil", item)
+	}
+}
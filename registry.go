@@ -13,6 +13,7 @@ import (
 	"github.com/Gipcomp/win32/advapi32"
 	"github.com/Gipcomp/win32/kernel32"
 	"github.com/Gipcomp/winapi/errs"
+	"golang.org/x/sys/windows/registry"
 )
 
 type RegistryKey struct {
@@ -117,3 +118,82 @@ func RegistryKeyUint32(rootKey *RegistryKey, subKeyPath, valueName string) (valu
 
 	return
 }
+
+// CreateSubKey opens the sub key at path below key with read and write
+// access, creating it if necessary. The returned key must be closed.
+func (key *RegistryKey) CreateSubKey(path string) (*RegistryKey, error) {
+	k, _, err := registry.CreateKey(registry.Key(key.hKey), path, registry.ALL_ACCESS)
+	if err != nil {
+		return nil, errs.WrapError(err)
+	}
+
+	return &RegistryKey{advapi32.HKEY(k)}, nil
+}
+
+// OpenSubKey opens the existing sub key at path below key. The returned key
+// must be closed.
+func (key *RegistryKey) OpenSubKey(path string, writable bool) (*RegistryKey, error) {
+	access := uint32(registry.READ)
+	if writable {
+		access = registry.ALL_ACCESS
+	}
+
+	k, err := registry.OpenKey(registry.Key(key.hKey), path, access)
+	if err != nil {
+		return nil, errs.WrapErrorNoPanic(err)
+	}
+
+	return &RegistryKey{advapi32.HKEY(k)}, nil
+}
+
+// Close closes a key returned by CreateSubKey or OpenSubKey.
+func (key *RegistryKey) Close() error {
+	if err := registry.Key(key.hKey).Close(); err != nil {
+		return errs.WrapError(err)
+	}
+
+	return nil
+}
+
+// ValueNames returns the names of all values of key.
+func (key *RegistryKey) ValueNames() ([]string, error) {
+	names, err := registry.Key(key.hKey).ReadValueNames(-1)
+	if err != nil {
+		return nil, errs.WrapError(err)
+	}
+
+	return names, nil
+}
+
+// String returns the string value called name. ok is false if there is no
+// such value.
+func (key *RegistryKey) String(name string) (value string, ok bool, err error) {
+	value, _, err = registry.Key(key.hKey).GetStringValue(name)
+	if err == registry.ErrNotExist {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, errs.WrapError(err)
+	}
+
+	return value, true, nil
+}
+
+// SetString sets the string value called name.
+func (key *RegistryKey) SetString(name, value string) error {
+	if err := registry.Key(key.hKey).SetStringValue(name, value); err != nil {
+		return errs.WrapError(err)
+	}
+
+	return nil
+}
+
+// DeleteValue removes the value called name. It is not an error if there is
+// no such value.
+func (key *RegistryKey) DeleteValue(name string) error {
+	if err := registry.Key(key.hKey).DeleteValue(name); err != nil && err != registry.ErrNotExist {
+		return errs.WrapError(err)
+	}
+
+	return nil
+}
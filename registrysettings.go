@@ -0,0 +1,221 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Gipcomp/winapi/errs"
+)
+
+const registrySettingsTimestampsKeyName = "Timestamps"
+
+// RegistrySettings stores settings as string values of a registry key,
+// by default HKEY_CURRENT_USER\Software\<OrganizationName>\<ProductName>.
+// Timestamps of expiring values are kept in the "Timestamps" sub key.
+type RegistrySettings struct {
+	rootKey        *RegistryKey
+	subKeyPath     string
+	key2Record     map[string]iniFileRecord
+	removed        map[string]bool
+	expireDuration time.Duration
+	readOnly       bool
+}
+
+// NewRegistrySettings returns RegistrySettings for the sub key at subKeyPath
+// below rootKey. If rootKey is nil, CurrentUserKey() is used. If subKeyPath
+// is empty, "Software\<OrganizationName>\<ProductName>" is used.
+func NewRegistrySettings(rootKey *RegistryKey, subKeyPath string) *RegistrySettings {
+	if rootKey == nil {
+		rootKey = CurrentUserKey()
+	}
+
+	return &RegistrySettings{
+		rootKey:    rootKey,
+		subKeyPath: subKeyPath,
+		key2Record: make(map[string]iniFileRecord),
+		removed:    make(map[string]bool),
+	}
+}
+
+// SubKeyPath returns the path of the key the settings are stored in.
+func (rs *RegistrySettings) SubKeyPath() string {
+	if rs.subKeyPath != "" {
+		return rs.subKeyPath
+	}
+
+	return `Software\` + App().OrganizationName() + `\` + App().ProductName()
+}
+
+// ReadOnly returns whether Save is a no-op, e.g. for machine wide settings
+// below LocalMachineKey() that users cannot write.
+func (rs *RegistrySettings) ReadOnly() bool {
+	return rs.readOnly
+}
+
+func (rs *RegistrySettings) SetReadOnly(readOnly bool) {
+	rs.readOnly = readOnly
+}
+
+func (rs *RegistrySettings) Get(key string) (string, bool) {
+	record, ok := rs.key2Record[key]
+	return record.value, ok
+}
+
+func (rs *RegistrySettings) Timestamp(key string) (time.Time, bool) {
+	record, ok := rs.key2Record[key]
+	return record.timestamp, ok
+}
+
+func (rs *RegistrySettings) Put(key, value string) error {
+	return rs.put(key, value, false)
+}
+
+func (rs *RegistrySettings) PutExpiring(key, value string) error {
+	return rs.put(key, value, true)
+}
+
+func (rs *RegistrySettings) put(key, value string, expiring bool) error {
+	if key == "" {
+		return errs.NewInvalidArgumentError("key must not be empty")
+	}
+
+	var timestamp time.Time
+	if expiring {
+		timestamp = time.Now()
+	}
+
+	rs.key2Record[key] = iniFileRecord{value, timestamp}
+	delete(rs.removed, key)
+
+	return nil
+}
+
+func (rs *RegistrySettings) Remove(key string) error {
+	delete(rs.key2Record, key)
+	rs.removed[key] = true
+
+	return nil
+}
+
+func (rs *RegistrySettings) Keys() []string {
+	keys := make([]string, 0, len(rs.key2Record))
+	for key := range rs.key2Record {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (rs *RegistrySettings) ExpireDuration() time.Duration {
+	return rs.expireDuration
+}
+
+func (rs *RegistrySettings) SetExpireDuration(expireDuration time.Duration) {
+	rs.expireDuration = expireDuration
+}
+
+func (rs *RegistrySettings) Load() error {
+	key, err := rs.rootKey.OpenSubKey(rs.SubKeyPath(), false)
+	if err != nil {
+		// Nothing has been saved yet.
+		return nil
+	}
+	defer key.Close()
+
+	names, err := key.ValueNames()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		value, ok, err := key.String(name)
+		if err != nil || !ok {
+			// Values of other types were not written by us.
+			continue
+		}
+
+		rs.key2Record[name] = iniFileRecord{value: value}
+	}
+
+	tsKey, err := key.OpenSubKey(registrySettingsTimestampsKeyName, false)
+	if err != nil {
+		return nil
+	}
+	defer tsKey.Close()
+
+	for name, record := range rs.key2Record {
+		s, ok, err := tsKey.String(name)
+		if err != nil || !ok {
+			continue
+		}
+
+		if record.timestamp, _ = time.Parse(iniFileTimeStampFormat, s); record.timestamp.IsZero() {
+			record.timestamp = time.Now()
+		}
+		rs.key2Record[name] = record
+	}
+
+	return nil
+}
+
+func (rs *RegistrySettings) Save() error {
+	if rs.readOnly {
+		return nil
+	}
+
+	key, err := rs.rootKey.CreateSubKey(rs.SubKeyPath())
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+
+	tsKey, err := key.CreateSubKey(registrySettingsTimestampsKeyName)
+	if err != nil {
+		return err
+	}
+	defer tsKey.Close()
+
+	for name := range rs.removed {
+		if err := key.DeleteValue(name); err != nil {
+			return err
+		}
+		if err := tsKey.DeleteValue(name); err != nil {
+			return err
+		}
+	}
+	rs.removed = make(map[string]bool)
+
+	for name, record := range rs.key2Record {
+		if rs.expireDuration > 0 && !record.timestamp.IsZero() && time.Since(record.timestamp) >= rs.expireDuration {
+			if err := key.DeleteValue(name); err != nil {
+				return err
+			}
+			if err := tsKey.DeleteValue(name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := key.SetString(name, record.value); err != nil {
+			return err
+		}
+
+		if record.timestamp.IsZero() {
+			if err := tsKey.DeleteValue(name); err != nil {
+				return err
+			}
+		} else if err := tsKey.SetString(name, record.timestamp.Format(iniFileTimeStampFormat)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
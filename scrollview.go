@@ -7,15 +7,32 @@
 package winapi
 
 import (
+	"fmt"
 	"unsafe"
 
+	"github.com/Gipcomp/win32/gdi32"
 	"github.com/Gipcomp/win32/handle"
 	"github.com/Gipcomp/win32/user32"
 	"github.com/Gipcomp/win32/win"
+	"github.com/Gipcomp/winapi/errs"
 )
 
 const scrollViewWindowClass = `\o/ Walk_ScrollView_Class \o/`
 
+const (
+	scrollViewSmoothScrollTimerId = 1
+
+	scrollViewSmoothScrollInterval = 10 // in milliseconds
+	scrollViewLineHeight96         = 20
+
+	// Not defined in user32.
+	scrollViewWMMouseHWheel          = 0x020E
+	scrollViewSPIGetWheelScrollLines = 0x0068
+	scrollViewSPIGetWheelScrollChars = 0x006C
+	scrollViewWheelDelta             = 120
+	scrollViewWheelPageScroll        = 0xFFFFFFFF
+)
+
 func init() {
 	AppendToWalkInit(func() {
 		MustRegisterWindowClass(scrollViewWindowClass)
@@ -24,13 +41,36 @@ func init() {
 
 type ScrollView struct {
 	WidgetBase
-	composite  *Composite
-	horizontal bool
-	vertical   bool
+	composite               *Composite
+	horizontal              bool
+	vertical                bool
+	smoothScrolling         bool
+	scrollsToFocus          bool
+	smoothScroll            [2]scrollViewSmoothScroll // horizontal, vertical
+	wheelRemainder          [2]int                    // horizontal, vertical
+	restoredPos             *Point                    // in native pixels
+	deferred                []*scrollViewDeferred
+	realizePending          bool
+	deferredFailedPublisher ErrorEventPublisher
+}
+
+type scrollViewSmoothScroll struct {
+	active bool
+	target int // in native pixels
+}
+
+type scrollViewDeferred struct {
+	placeholder *Composite
+	create      func(placeholder *Composite) error
 }
 
 func NewScrollView(parent Container) (*ScrollView, error) {
-	sv := &ScrollView{horizontal: true, vertical: true}
+	sv := &ScrollView{
+		horizontal:      true,
+		vertical:        true,
+		smoothScrolling: true,
+		scrollsToFocus:  true,
+	}
 
 	if err := InitWidget(
 		sv,
@@ -55,6 +95,7 @@ func NewScrollView(parent Container) (*ScrollView, error) {
 
 	sv.composite.SizeChanged().Attach(func() {
 		sv.updateScrollBars()
+		sv.scheduleRealizeDeferred()
 	})
 
 	sv.SetBackground(NullBrush())
@@ -147,14 +188,308 @@ func (sv *ScrollView) SetPersistent(value bool) {
 	sv.composite.SetPersistent(value)
 }
 
+// SmoothScrolling returns if scrolling with the mouse wheel, the touchpad or
+// EnsureVisible is animated.
+func (sv *ScrollView) SmoothScrolling() bool {
+	return sv.smoothScrolling
+}
+
+// SetSmoothScrolling sets if scrolling with the mouse wheel, the touchpad or
+// EnsureVisible is animated.
+func (sv *ScrollView) SetSmoothScrolling(value bool) {
+	sv.smoothScrolling = value
+
+	if !value {
+		sv.stopSmoothScroll()
+	}
+}
+
+// ScrollsToFocus returns if the ScrollView scrolls a descendant into view,
+// when it receives the keyboard focus.
+func (sv *ScrollView) ScrollsToFocus() bool {
+	return sv.scrollsToFocus
+}
+
+// SetScrollsToFocus sets if the ScrollView scrolls a descendant into view,
+// when it receives the keyboard focus.
+func (sv *ScrollView) SetScrollsToFocus(value bool) {
+	sv.scrollsToFocus = value
+}
+
+// ScrollPosition returns the current scroll position in native pixels.
+func (sv *ScrollView) ScrollPosition() Point {
+	return Point{sv.scrollPos(user32.SB_HORZ), sv.scrollPos(user32.SB_VERT)}
+}
+
+// SetScrollPosition scrolls to pos, which is in native pixels.
+func (sv *ScrollView) SetScrollPosition(pos Point) {
+	sv.restoredPos = nil
+
+	sv.scrollTo(user32.SB_HORZ, pos.X)
+	sv.scrollTo(user32.SB_VERT, pos.Y)
+}
+
+// EnsureVisible scrolls widget, which must be a descendant of the
+// ScrollView, into view. If widget is larger than the visible area, its top
+// left part is shown.
+func (sv *ScrollView) EnsureVisible(widget Widget) error {
+	if widget == nil {
+		return errs.NewInvalidArgumentError("widget cannot be nil")
+	}
+
+	if !sv.isAncestorOf(widget) {
+		return errs.NewInvalidArgumentError("widget is not a descendant of the ScrollView")
+	}
+
+	var rc gdi32.RECT
+	if !user32.GetWindowRect(widget.Handle(), &rc) {
+		return errs.LastError("GetWindowRect")
+	}
+
+	var origin gdi32.POINT
+	if !user32.ClientToScreen(sv.composite.hWnd, &origin) {
+		return errs.NewError("ClientToScreen failed")
+	}
+
+	sv.restoredPos = nil
+
+	view := sv.ClientBoundsPixels()
+
+	ensure := func(sb int32, start, length, viewLength int) {
+		pos := sv.scrollPos(sb)
+		if a := &sv.smoothScroll[scrollViewAxis(sb)]; a.active {
+			pos = a.target
+		}
+
+		switch {
+		case start < pos || length > viewLength:
+			pos = start
+
+		case start+length > pos+viewLength:
+			pos = start + length - viewLength
+
+		default:
+			return
+		}
+
+		sv.scrollTo(sb, pos)
+	}
+
+	ensure(user32.SB_HORZ, int(rc.Left-origin.X), int(rc.Right-rc.Left), view.Width)
+	ensure(user32.SB_VERT, int(rc.Top-origin.Y), int(rc.Bottom-rc.Top), view.Height)
+
+	return nil
+}
+
+func (sv *ScrollView) isAncestorOf(widget Widget) bool {
+	for w := widget; ; {
+		parent := w.Parent()
+		if parent == nil {
+			return false
+		}
+		if parent == Container(sv) || parent == Container(sv.composite) {
+			return true
+		}
+
+		var ok bool
+		if w, ok = parent.(Widget); !ok {
+			return false
+		}
+	}
+}
+
+// scrollFocusedIntoView scrolls widget, which just received the keyboard
+// focus, into view in the ScrollViews it is in.
+func scrollFocusedIntoView(widget Widget) {
+	for w := widget; ; {
+		parent := w.Parent()
+		if parent == nil {
+			return
+		}
+
+		if sv, ok := parent.(*ScrollView); ok && sv.scrollsToFocus {
+			sv.EnsureVisible(widget)
+		}
+
+		var ok bool
+		if w, ok = parent.(Widget); !ok {
+			return
+		}
+	}
+}
+
+// AddDeferred adds a placeholder Composite to the ScrollView, whose children
+// are only created by calling create, when the placeholder gets close to the
+// visible area for the first time. Until then, the placeholder occupies
+// estimatedSize, which is in 1/96" units.
+//
+// The placeholder has a VBoxLayout without margins, that create may replace.
+// This allows long forms to defer the costly creation and layout of widgets
+// that are never scrolled to.
+//
+// If create returns an error, it is published through DeferredFailed and
+// create is not called again.
+func (sv *ScrollView) AddDeferred(estimatedSize Size, create func(placeholder *Composite) error) (*Composite, error) {
+	if create == nil {
+		return nil, errs.NewInvalidArgumentError("create cannot be nil")
+	}
+
+	placeholder, err := NewComposite(sv)
+	if err != nil {
+		return nil, err
+	}
+
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			placeholder.Dispose()
+		}
+	}()
+
+	layout := NewVBoxLayout()
+	if err := layout.SetMargins(Margins{}); err != nil {
+		return nil, err
+	}
+	if err := placeholder.SetLayout(layout); err != nil {
+		return nil, err
+	}
+
+	if err := placeholder.SetMinMaxSize(estimatedSize, Size{}); err != nil {
+		return nil, err
+	}
+
+	sv.deferred = append(sv.deferred, &scrollViewDeferred{placeholder: placeholder, create: create})
+
+	sv.scheduleRealizeDeferred()
+
+	succeeded = true
+
+	return placeholder, nil
+}
+
+func (sv *ScrollView) scheduleRealizeDeferred() {
+	if len(sv.deferred) == 0 || sv.realizePending {
+		return
+	}
+
+	sv.realizePending = true
+
+	// Creating widgets right away could interfere with the layout or message
+	// that triggered this.
+	sv.Synchronize(func() {
+		sv.realizePending = false
+		sv.realizeDeferred()
+	})
+}
+
+// realizeDeferred creates the children of the placeholders added with
+// AddDeferred that are within one page of the visible area.
+func (sv *ScrollView) realizeDeferred() {
+	if sv.IsDisposed() {
+		return
+	}
+
+	view := sv.ClientBoundsPixels()
+	view.X -= view.Width
+	view.Y -= view.Height
+	view.Width *= 3
+	view.Height *= 3
+
+	var origin gdi32.POINT
+	user32.ClientToScreen(sv.hWnd, &origin)
+
+	var pending []*scrollViewDeferred
+	for _, d := range sv.deferred {
+		if d.placeholder.IsDisposed() {
+			continue
+		}
+
+		var rc gdi32.RECT
+		user32.GetWindowRect(d.placeholder.hWnd, &rc)
+
+		left, top := int(rc.Left-origin.X), int(rc.Top-origin.Y)
+		right, bottom := int(rc.Right-origin.X), int(rc.Bottom-origin.Y)
+
+		if right < view.X || left > view.X+view.Width || bottom < view.Y || top > view.Y+view.Height {
+			pending = append(pending, d)
+			continue
+		}
+
+		d.placeholder.SetSuspended(true)
+		err := d.create(d.placeholder)
+		d.placeholder.SetMinMaxSize(Size{}, Size{})
+		d.placeholder.SetSuspended(false)
+
+		if err != nil {
+			sv.deferredFailedPublisher.Publish(errs.WrapError(err))
+		}
+	}
+
+	sv.deferred = pending
+}
+
+// DeferredFailed returns the event that is published with the error of a
+// create func passed to AddDeferred.
+func (sv *ScrollView) DeferredFailed() *ErrorEvent {
+	return sv.deferredFailedPublisher.Event()
+}
+
+// SaveState saves the scroll position and the state of the children.
 func (sv *ScrollView) SaveState() error {
+	pos := sv.ScrollPosition()
+
+	if err := sv.WriteState(fmt.Sprintf("%d %d", sv.IntTo96DPI(pos.X), sv.IntTo96DPI(pos.Y))); err != nil {
+		return err
+	}
+
 	return sv.composite.SaveState()
 }
 
+// RestoreState restores the scroll position and the state of the children.
+// The scroll position is applied as soon as the content is large enough.
 func (sv *ScrollView) RestoreState() error {
+	state, err := sv.ReadState()
+	if err != nil {
+		return err
+	}
+
+	if state != "" {
+		var x, y int
+		if _, err := fmt.Sscanf(state, "%d %d", &x, &y); err != nil {
+			return errs.WrapError(err)
+		}
+
+		sv.restoredPos = &Point{sv.IntFrom96DPI(x), sv.IntFrom96DPI(y)}
+		sv.applyRestoredPos()
+	}
+
 	return sv.composite.RestoreState()
 }
 
+// applyRestoredPos scrolls to the position read by RestoreState. It stays
+// pending until the content is large enough to scroll there or the user
+// scrolls.
+func (sv *ScrollView) applyRestoredPos() {
+	pos := sv.restoredPos
+	if pos == nil {
+		return
+	}
+
+	sv.stopSmoothScroll()
+
+	x := -sv.setScrollPos(user32.SB_HORZ, pos.X)
+	y := -sv.setScrollPos(user32.SB_VERT, pos.Y)
+
+	sv.composite.SetXPixels(-x)
+	sv.composite.SetYPixels(-y)
+
+	if x == pos.X && y == pos.Y {
+		sv.restoredPos = nil
+	}
+
+	sv.scheduleRealizeDeferred()
+}
+
 func (sv *ScrollView) MouseDown() *MouseEvent {
 	return sv.composite.MouseDown()
 }
@@ -169,42 +504,52 @@ func (sv *ScrollView) MouseUp() *MouseEvent {
 
 func (sv *ScrollView) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr) uintptr {
 	if sv.composite != nil {
-		avoidBGArtifacts := func() {
-			if sv.hasComplexBackground() {
-				sv.composite.Invalidate()
-			}
-		}
-
 		switch msg {
 		case user32.WM_HSCROLL:
-			sv.composite.SetXPixels(sv.scroll(user32.SB_HORZ, win.LOWORD(uint32(wParam))))
+			sv.restoredPos = nil
+			sv.smoothScroll[0].active = false
+			sv.moveComposite(user32.SB_HORZ, sv.scroll(user32.SB_HORZ, win.LOWORD(uint32(wParam))))
 			if wParam == user32.SB_ENDSCROLL {
-				avoidBGArtifacts()
+				sv.avoidBGArtifacts()
 			}
 
 		case user32.WM_VSCROLL:
-			sv.composite.SetYPixels(sv.scroll(user32.SB_VERT, win.LOWORD(uint32(wParam))))
+			sv.restoredPos = nil
+			sv.smoothScroll[1].active = false
+			sv.moveComposite(user32.SB_VERT, sv.scroll(user32.SB_VERT, win.LOWORD(uint32(wParam))))
 			if wParam == user32.SB_ENDSCROLL {
-				avoidBGArtifacts()
+				sv.avoidBGArtifacts()
 			}
 
-		case user32.WM_MOUSEWHEEL:
-			if user32.GetWindowLong(sv.hWnd, user32.GWL_STYLE)&user32.WS_VSCROLL == 0 {
-				break
-			}
+		case user32.WM_MOUSEWHEEL, scrollViewWMMouseHWheel:
+			delta := int(int16(win.HIWORD(uint32(wParam))))
 
-			var cmd uint16
-			if delta := int16(win.HIWORD(uint32(wParam))); delta < 0 {
-				cmd = user32.SB_LINEDOWN
+			sb := int32(user32.SB_VERT)
+			if msg == scrollViewWMMouseHWheel {
+				sb = user32.SB_HORZ
+			} else if ShiftDown() {
+				sb = user32.SB_HORZ
+				delta = -delta
 			} else {
-				cmd = user32.SB_LINEUP
+				delta = -delta
 			}
 
-			sv.composite.SetYPixels(sv.scroll(user32.SB_VERT, cmd))
-			avoidBGArtifacts()
+			style := user32.GetWindowLong(sv.hWnd, user32.GWL_STYLE)
+			if sb == user32.SB_VERT && style&user32.WS_VSCROLL == 0 || sb == user32.SB_HORZ && style&user32.WS_HSCROLL == 0 {
+				break
+			}
+
+			sv.restoredPos = nil
+			sv.scrollBy(sb, sv.wheelPixels(sb, delta))
 
 			return 0
 
+		case user32.WM_TIMER:
+			if wParam == scrollViewSmoothScrollTimerId {
+				sv.smoothScrollStep()
+				return 0
+			}
+
 		case user32.WM_COMMAND, user32.WM_NOTIFY:
 			sv.composite.WndProc(hwnd, msg, wParam, lParam)
 
@@ -259,25 +604,184 @@ func (sv *ScrollView) updateScrollBars() {
 	newCompositeBounds.Y = sv.scroll(user32.SB_VERT, user32.SB_THUMBPOSITION)
 
 	sv.composite.SetBoundsPixels(newCompositeBounds)
+
+	sv.applyRestoredPos()
 }
 
-// scroll scrolls and returns new position in native pixels.
-func (sv *ScrollView) scroll(sb int32, cmd uint16) int {
-	var pos int32
+func (sv *ScrollView) avoidBGArtifacts() {
+	if sv.hasComplexBackground() {
+		sv.composite.Invalidate()
+	}
+}
+
+// scrollViewAxis returns the index into per axis arrays for scroll bar sb.
+func scrollViewAxis(sb int32) int {
+	if sb == user32.SB_HORZ {
+		return 0
+	}
+
+	return 1
+}
+
+// moveComposite moves the composite to pos, as returned by scroll or
+// setScrollPos, along the axis of scroll bar sb.
+func (sv *ScrollView) moveComposite(sb int32, pos int) {
+	if sb == user32.SB_HORZ {
+		sv.composite.SetXPixels(pos)
+	} else {
+		sv.composite.SetYPixels(pos)
+	}
+
+	sv.scheduleRealizeDeferred()
+}
+
+// wheelPixels converts the wheel delta to the distance to scroll in native
+// pixels, honoring the system settings. Fractions of a pixel, as caused by
+// the fine grained deltas of touchpads, are kept for the next call.
+func (sv *ScrollView) wheelPixels(sb int32, delta int) int {
+	var amount uint32 = 3
+	if sb == user32.SB_HORZ {
+		user32.SystemParametersInfo(scrollViewSPIGetWheelScrollChars, 0, unsafe.Pointer(&amount), 0)
+	} else {
+		user32.SystemParametersInfo(scrollViewSPIGetWheelScrollLines, 0, unsafe.Pointer(&amount), 0)
+	}
+
+	var unit int
+	if amount == scrollViewWheelPageScroll {
+		si := sv.scrollInfo(sb)
+		unit = int(si.NPage)
+	} else {
+		unit = int(amount) * sv.IntFrom96DPI(scrollViewLineHeight96)
+	}
+
+	remainder := &sv.wheelRemainder[scrollViewAxis(sb)]
+
+	total := delta*unit + *remainder
+	pixels := total / scrollViewWheelDelta
+	*remainder = total % scrollViewWheelDelta
+
+	return pixels
+}
+
+// scrollBy scrolls by delta native pixels along the axis of scroll bar sb.
+// While a smooth scroll is running, delta adds to its target, so that quick
+// successive wheel or touchpad events build up speed.
+func (sv *ScrollView) scrollBy(sb int32, delta int) {
+	pos := sv.scrollPos(sb)
+	if a := &sv.smoothScroll[scrollViewAxis(sb)]; a.active {
+		pos = a.target
+	}
+
+	sv.scrollTo(sb, pos+delta)
+}
+
+// scrollTo scrolls to pos in native pixels along the axis of scroll bar sb,
+// animated if smooth scrolling is enabled.
+func (sv *ScrollView) scrollTo(sb int32, pos int) {
+	a := &sv.smoothScroll[scrollViewAxis(sb)]
+
+	if !sv.smoothScrolling || !sv.Visible() {
+		a.active = false
+		sv.moveComposite(sb, sv.setScrollPos(sb, pos))
+		sv.avoidBGArtifacts()
+		return
+	}
+
+	si := sv.scrollInfo(sb)
+	if max := int(si.NMax) + 1 - int(si.NPage); pos > max {
+		pos = max
+	}
+	if pos < 0 {
+		pos = 0
+	}
+
+	if pos == int(si.NPos) {
+		a.active = false
+		return
+	}
+
+	a.target = pos
+
+	if !a.active {
+		a.active = true
+
+		if user32.SetTimer(sv.hWnd, scrollViewSmoothScrollTimerId, scrollViewSmoothScrollInterval, 0) == 0 {
+			errs.LastError("SetTimer")
+			sv.stopSmoothScroll()
+		}
+	}
+}
+
+// smoothScrollStep moves a quarter of the remaining distance towards the
+// targets of the running smooth scrolls, so scrolling decelerates smoothly.
+func (sv *ScrollView) smoothScrollStep() {
+	var running bool
+
+	for _, sb := range [...]int32{user32.SB_HORZ, user32.SB_VERT} {
+		a := &sv.smoothScroll[scrollViewAxis(sb)]
+		if !a.active {
+			continue
+		}
+
+		cur := sv.scrollPos(sb)
+		rest := a.target - cur
+		step := rest / 4
+		if step == 0 {
+			step = rest
+		}
+
+		sv.moveComposite(sb, sv.setScrollPos(sb, cur+step))
+
+		if pos := sv.scrollPos(sb); pos == a.target || pos == cur {
+			a.active = false
+		} else {
+			running = true
+		}
+	}
+
+	if !running {
+		sv.stopSmoothScroll()
+	}
+}
+
+// stopSmoothScroll stops running smooth scrolls where they currently are.
+func (sv *ScrollView) stopSmoothScroll() {
+	sv.smoothScroll[0].active = false
+	sv.smoothScroll[1].active = false
+
+	user32.KillTimer(sv.hWnd, scrollViewSmoothScrollTimerId)
+
+	sv.avoidBGArtifacts()
+}
+
+func (sv *ScrollView) scrollInfo(sb int32) user32.SCROLLINFO {
 	var si user32.SCROLLINFO
 	si.CbSize = uint32(unsafe.Sizeof(si))
 	si.FMask = user32.SIF_PAGE | user32.SIF_POS | user32.SIF_RANGE | user32.SIF_TRACKPOS
 
 	user32.GetScrollInfo(sv.hWnd, sb, &si)
 
-	pos = si.NPos
+	return si
+}
+
+// scrollPos returns the current position of scroll bar sb in native pixels.
+func (sv *ScrollView) scrollPos(sb int32) int {
+	si := sv.scrollInfo(sb)
+	return int(si.NPos)
+}
+
+// scroll scrolls and returns new position in native pixels.
+func (sv *ScrollView) scroll(sb int32, cmd uint16) int {
+	si := sv.scrollInfo(sb)
+
+	pos := si.NPos
 
 	switch cmd {
 	case user32.SB_LINELEFT: // == user32.SB_LINEUP
-		pos -= int32(sv.IntFrom96DPI(20))
+		pos -= int32(sv.IntFrom96DPI(scrollViewLineHeight96))
 
 	case user32.SB_LINERIGHT: // == user32.SB_LINEDOWN
-		pos += int32(sv.IntFrom96DPI(20))
+		pos += int32(sv.IntFrom96DPI(scrollViewLineHeight96))
 
 	case user32.SB_PAGELEFT: // == user32.SB_PAGEUP
 		pos -= int32(si.NPage)
@@ -289,18 +793,26 @@ func (sv *ScrollView) scroll(sb int32, cmd uint16) int {
 		pos = si.NTrackPos
 	}
 
+	return sv.setScrollPos(sb, int(pos))
+}
+
+// setScrollPos sets the position of scroll bar sb to pos, limited to its
+// range, and returns the new position of the composite in native pixels.
+func (sv *ScrollView) setScrollPos(sb int32, pos int) int {
+	si := sv.scrollInfo(sb)
+
 	if pos < 0 {
 		pos = 0
 	}
-	if pos > si.NMax+1-int32(si.NPage) {
-		pos = si.NMax + 1 - int32(si.NPage)
+	if max := int(si.NMax) + 1 - int(si.NPage); pos > max {
+		pos = max
 	}
 
 	si.FMask = user32.SIF_POS
-	si.NPos = pos
+	si.NPos = int32(pos)
 	user32.SetScrollInfo(sv.hWnd, sb, &si, true)
 
-	return -int(pos)
+	return -pos
 }
 
 func (sv *ScrollView) CreateLayoutItem(ctx *LayoutContext) LayoutItem {
@@ -0,0 +1,93 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Gipcomp/winapi"
+	"github.com/Gipcomp/winapi/settingstest"
+	"golang.org/x/sys/windows/registry"
+)
+
+func TestMapSettings(t *testing.T) {
+	if err := settingstest.TestSettings(func() winapi.Settings {
+		return winapi.NewMapSettings(nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONFileSettings(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "settings.json")
+
+	newSettings := func() winapi.Settings {
+		s := winapi.NewJSONFileSettings(filePath)
+		s.SetPortable(true)
+		return s
+	}
+
+	if err := settingstest.TestSettings(newSettings); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIniFileSettings(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "settings.ini")
+
+	newSettings := func() winapi.Settings {
+		s := winapi.NewIniFileSettings(filePath)
+		s.SetPortable(true)
+		return s
+	}
+
+	if err := settingstest.TestSettings(newSettings); err != nil {
+		t.Fatal(err)
+	}
+	if err := settingstest.TestConcurrentInstances(newSettings); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistrySettings(t *testing.T) {
+	subKeyPath := fmt.Sprintf(`Software\Walk Tests\%s-%d`, t.Name(), time.Now().UnixNano())
+	defer func() {
+		registry.DeleteKey(registry.CURRENT_USER, subKeyPath+`\Timestamps`)
+		if err := registry.DeleteKey(registry.CURRENT_USER, subKeyPath); err != nil && err != registry.ErrNotExist {
+			t.Errorf("deleting %s: %v", subKeyPath, err)
+		}
+		registry.DeleteKey(registry.CURRENT_USER, `Software\Walk Tests`)
+	}()
+
+	newSettings := func() winapi.Settings {
+		return winapi.NewRegistrySettings(winapi.CurrentUserKey(), subKeyPath)
+	}
+
+	if err := settingstest.TestSettings(newSettings); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLayeredSettings(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "settings.ini")
+
+	newSettings := func() winapi.Settings {
+		user := winapi.NewIniFileSettings(filePath)
+		user.SetPortable(true)
+
+		return winapi.NewLayeredSettings(
+			winapi.NewMapSettings(map[string]string{"settingstest.default": "default"}),
+			user)
+	}
+
+	if err := settingstest.TestSettings(newSettings); err != nil {
+		t.Fatal(err)
+	}
+}
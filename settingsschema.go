@@ -0,0 +1,603 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gipcomp/winapi/errs"
+)
+
+// SettingType is the type of a setting value, as declared in a
+// SettingDescriptor.
+type SettingType int
+
+const (
+	SettingString   SettingType = iota // string
+	SettingInt                         // int
+	SettingFloat                       // float64
+	SettingBool                        // bool
+	SettingDuration                    // time.Duration
+	SettingStrings                     // []string
+	SettingSize                        // Size
+	SettingPath                        // string, a file or folder path
+	SettingColor                       // Color
+	SettingFont                        // *Font
+	SettingShortcut                    // Shortcut
+)
+
+func (t SettingType) String() string {
+	switch t {
+	case SettingString:
+		return "string"
+	case SettingInt:
+		return "int"
+	case SettingFloat:
+		return "float"
+	case SettingBool:
+		return "bool"
+	case SettingDuration:
+		return "duration"
+	case SettingStrings:
+		return "strings"
+	case SettingSize:
+		return "size"
+	case SettingPath:
+		return "path"
+	case SettingColor:
+		return "color"
+	case SettingFont:
+		return "font"
+	case SettingShortcut:
+		return "shortcut"
+	}
+
+	return "unknown"
+}
+
+// SettingDescriptor declares a setting of a SettingsSchema.
+type SettingDescriptor struct {
+	// Key is the key the setting is stored under.
+	Key string
+
+	Type SettingType
+
+	// Default is returned if the setting is not stored. It must be of the Go
+	// type that corresponds to Type, see the SettingType constants. nil
+	// means the zero value.
+	Default interface{}
+
+	// Min and Max bound SettingInt, SettingFloat and SettingDuration values.
+	// They are of the same Go type as Default. nil means unbounded.
+	Min, Max interface{}
+
+	// Choices restricts SettingString values, if not empty.
+	Choices []string
+
+	// Pattern is a regular expression SettingString and SettingPath values
+	// must match, if not empty.
+	Pattern string
+
+	// BrowseFolder makes the editor of a SettingPath browse for a folder
+	// instead of a file.
+	BrowseFolder bool
+
+	// Title is the label of the setting in a preferences dialog. If empty,
+	// Key is used.
+	Title string
+
+	// Category groups settings in a preferences dialog.
+	Category string
+
+	// Description is a human readable description of the setting.
+	Description string
+
+	// OldKeys are keys the setting was stored under in previous versions of
+	// the application, most recent first. See SettingsSchema.Migrate.
+	OldKeys []string
+}
+
+// DefaultValue returns Default, or the zero value of Type if Default is nil.
+func (sd *SettingDescriptor) DefaultValue() interface{} {
+	if sd.Default != nil {
+		return sd.Default
+	}
+
+	switch sd.Type {
+	case SettingInt:
+		return 0
+	case SettingFloat:
+		return 0.0
+	case SettingBool:
+		return false
+	case SettingDuration:
+		return time.Duration(0)
+	case SettingStrings:
+		return []string(nil)
+	case SettingSize:
+		return Size{}
+	case SettingColor:
+		return Color(0)
+	case SettingFont:
+		return (*Font)(nil)
+	case SettingShortcut:
+		return Shortcut{}
+	}
+
+	return ""
+}
+
+// DisplayTitle returns Title, or Key if Title is empty.
+func (sd *SettingDescriptor) DisplayTitle() string {
+	if sd.Title != "" {
+		return sd.Title
+	}
+
+	return sd.Key
+}
+
+// Parse parses s into a value of the Go type corresponding to Type and
+// checks it.
+func (sd *SettingDescriptor) Parse(s string) (interface{}, error) {
+	value, err := parseSettingValue(sd.Type, s)
+	if err != nil {
+		return nil, errs.NewInvalidArgumentError(fmt.Sprintf("setting %q: %v", sd.Key, err))
+	}
+
+	if err := sd.Check(value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// Format checks value and returns its string representation.
+func (sd *SettingDescriptor) Format(value interface{}) (string, error) {
+	if err := sd.Check(value); err != nil {
+		return "", err
+	}
+
+	return formatSettingValue(sd.Type, value)
+}
+
+// Check returns an error if value is not of the Go type corresponding to
+// Type or violates Min, Max or Choices.
+func (sd *SettingDescriptor) Check(value interface{}) error {
+	if !settingValueHasType(sd.Type, value) {
+		return errs.NewInvalidArgumentError(fmt.Sprintf("setting %q: %T is not a valid %s value", sd.Key, value, sd.Type))
+	}
+
+	switch sd.Type {
+	case SettingInt, SettingFloat, SettingDuration:
+		v := settingValueToFloat(value)
+
+		if sd.Min != nil && v < settingValueToFloat(sd.Min) {
+			return errs.NewInvalidArgumentError(fmt.Sprintf("setting %q: %v is less than the minimum %v", sd.Key, value, sd.Min))
+		}
+		if sd.Max != nil && v > settingValueToFloat(sd.Max) {
+			return errs.NewInvalidArgumentError(fmt.Sprintf("setting %q: %v is greater than the maximum %v", sd.Key, value, sd.Max))
+		}
+
+	case SettingString, SettingPath:
+		if sd.Pattern != "" {
+			re, err := regexp.Compile(sd.Pattern)
+			if err != nil {
+				return errs.NewInvalidArgumentError(fmt.Sprintf("setting %q: invalid pattern: %v", sd.Key, err))
+			}
+
+			if !re.MatchString(value.(string)) {
+				return errs.NewInvalidArgumentError(fmt.Sprintf("setting %q: %q does not match %q", sd.Key, value, sd.Pattern))
+			}
+		}
+
+		if len(sd.Choices) == 0 || sd.Type != SettingString {
+			break
+		}
+
+		for _, choice := range sd.Choices {
+			if choice == value.(string) {
+				return nil
+			}
+		}
+
+		return errs.NewInvalidArgumentError(fmt.Sprintf("setting %q: %q is not one of %q", sd.Key, value, sd.Choices))
+	}
+
+	return nil
+}
+
+// Validate implements Validator. v may be a value of the Go type that
+// corresponds to Type or its string representation, as entered into a
+// LineEdit. float64 is accepted for SettingInt, as produced by a NumberEdit.
+func (sd *SettingDescriptor) Validate(v interface{}) error {
+	var err error
+
+	switch value := v.(type) {
+	case string:
+		if sd.Type == SettingString || sd.Type == SettingPath {
+			err = sd.Check(value)
+		} else {
+			_, err = sd.Parse(value)
+		}
+
+	case float64:
+		if sd.Type == SettingInt {
+			v = int(math.Round(value))
+		}
+		err = sd.Check(v)
+
+	default:
+		err = sd.Check(v)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	if walkErr, ok := err.(*errs.Error); ok {
+		message = walkErr.Message()
+	}
+
+	return NewValidationError(sd.DisplayTitle(), message)
+}
+
+// SettingsSchema declares the settings of an application, with types,
+// defaults, valid ranges and descriptions.
+type SettingsSchema struct {
+	descriptors    []*SettingDescriptor
+	key2Descriptor map[string]*SettingDescriptor
+}
+
+// NewSettingsSchema returns a SettingsSchema for descriptors. It returns an
+// error if keys are not unique or a Default, Min or Max does not match its
+// Type.
+func NewSettingsSchema(descriptors ...*SettingDescriptor) (*SettingsSchema, error) {
+	schema := &SettingsSchema{
+		key2Descriptor: make(map[string]*SettingDescriptor),
+	}
+
+	for _, sd := range descriptors {
+		if sd.Key == "" {
+			return nil, errs.NewInvalidArgumentError("setting key must not be empty")
+		}
+		if _, ok := schema.key2Descriptor[sd.Key]; ok {
+			return nil, errs.NewInvalidArgumentError(fmt.Sprintf("duplicate setting key %q", sd.Key))
+		}
+
+		for _, v := range []interface{}{sd.Min, sd.Max} {
+			if v != nil && !settingValueHasType(sd.Type, v) {
+				return nil, errs.NewInvalidArgumentError(fmt.Sprintf("setting %q: bound %v is not a valid %s value", sd.Key, v, sd.Type))
+			}
+		}
+
+		if err := sd.Check(sd.DefaultValue()); err != nil && sd.Default != nil {
+			return nil, err
+		}
+
+		schema.descriptors = append(schema.descriptors, sd)
+		schema.key2Descriptor[sd.Key] = sd
+	}
+
+	return schema, nil
+}
+
+// Descriptors returns the descriptors in declaration order.
+func (s *SettingsSchema) Descriptors() []*SettingDescriptor {
+	return s.descriptors
+}
+
+// Descriptor returns the descriptor for key or nil, if there is none.
+func (s *SettingsSchema) Descriptor(key string) *SettingDescriptor {
+	return s.key2Descriptor[key]
+}
+
+// Defaults returns MapSettings with the default values of all settings that
+// declare one, for use as the lowest layer of LayeredSettings.
+func (s *SettingsSchema) Defaults() *MapSettings {
+	values := make(map[string]string)
+
+	for _, sd := range s.descriptors {
+		if sd.Default == nil {
+			continue
+		}
+
+		if value, err := formatSettingValue(sd.Type, sd.Default); err == nil {
+			values[sd.Key] = value
+		}
+	}
+
+	return NewMapSettings(values)
+}
+
+// Validate returns an error describing all values stored in settings that
+// cannot be parsed or violate their descriptor.
+func (s *SettingsSchema) Validate(settings Settings) error {
+	var messages []string
+
+	for _, sd := range s.descriptors {
+		value, ok := settings.Get(sd.Key)
+		if !ok {
+			continue
+		}
+
+		if _, err := sd.Parse(value); err != nil {
+			if walkErr, ok := err.(*errs.Error); ok {
+				messages = append(messages, walkErr.Message())
+			} else {
+				messages = append(messages, err.Error())
+			}
+		}
+	}
+
+	if len(messages) > 0 {
+		return errs.NewInvalidArgumentError(strings.Join(messages, "\n"))
+	}
+
+	return nil
+}
+
+// Migrate moves values stored under an OldKeys entry of a descriptor to its
+// Key, unless a value is already stored under Key. Values stored under the
+// remaining old keys are removed. The timestamps of expiring values are not
+// preserved, they restart with the migration.
+//
+// For LayeredSettings, only the writable layer is considered, so a default
+// provided by a lower layer, e.g. the one returned by Defaults, does not
+// count as a stored value.
+//
+// Migrate should be called after loading the settings and before using them.
+func (s *SettingsSchema) Migrate(settings Settings) error {
+	stored := settings
+	if ls, ok := settings.(*LayeredSettings); ok && ls.WritableLayer() != nil {
+		stored = ls.WritableLayer()
+	}
+
+	for _, sd := range s.descriptors {
+		_, hasValue := stored.Get(sd.Key)
+
+		for _, oldKey := range sd.OldKeys {
+			oldValue, ok := stored.Get(oldKey)
+			if !ok {
+				continue
+			}
+
+			if !hasValue {
+				var err error
+				if ts, _ := stored.Timestamp(oldKey); ts.IsZero() {
+					err = settings.Put(sd.Key, oldValue)
+				} else {
+					err = settings.PutExpiring(sd.Key, oldValue)
+				}
+				if err != nil {
+					return err
+				}
+
+				hasValue = true
+			}
+
+			if err := settings.Remove(oldKey); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func settingValueHasType(typ SettingType, value interface{}) bool {
+	var ok bool
+
+	switch typ {
+	case SettingString, SettingPath:
+		_, ok = value.(string)
+	case SettingInt:
+		_, ok = value.(int)
+	case SettingFloat:
+		_, ok = value.(float64)
+	case SettingBool:
+		_, ok = value.(bool)
+	case SettingDuration:
+		_, ok = value.(time.Duration)
+	case SettingStrings:
+		_, ok = value.([]string)
+	case SettingSize:
+		_, ok = value.(Size)
+	case SettingColor:
+		_, ok = value.(Color)
+	case SettingFont:
+		_, ok = value.(*Font)
+	case SettingShortcut:
+		_, ok = value.(Shortcut)
+	}
+
+	return ok
+}
+
+func settingValueToFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	case time.Duration:
+		return float64(v)
+	}
+
+	return 0
+}
+
+func parseSettingValue(typ SettingType, s string) (interface{}, error) {
+	switch typ {
+	case SettingString, SettingPath:
+		return s, nil
+
+	case SettingInt:
+		return strconv.Atoi(strings.TrimSpace(s))
+
+	case SettingFloat:
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+
+	case SettingBool:
+		return strconv.ParseBool(strings.TrimSpace(s))
+
+	case SettingDuration:
+		return time.ParseDuration(strings.TrimSpace(s))
+
+	case SettingStrings:
+		if s == "" {
+			return []string(nil), nil
+		}
+
+		r := csv.NewReader(strings.NewReader(s))
+		r.FieldsPerRecord = -1
+
+		return r.Read()
+
+	case SettingSize:
+		var size Size
+		if _, err := fmt.Sscan(s, &size.Width, &size.Height); err != nil {
+			return nil, err
+		}
+
+		return size, nil
+
+	case SettingColor:
+		var r, g, b byte
+		if _, err := fmt.Sscanf(strings.TrimSpace(s), "#%02x%02x%02x", &r, &g, &b); err != nil {
+			return nil, err
+		}
+
+		return RGB(r, g, b), nil
+
+	case SettingFont:
+		return parseFontSetting(s)
+
+	case SettingShortcut:
+		return ParseShortcut(strings.TrimSpace(s))
+	}
+
+	return nil, errs.NewNotSupportedError(fmt.Sprintf("setting type %d", typ))
+}
+
+func formatSettingValue(typ SettingType, value interface{}) (string, error) {
+	if !settingValueHasType(typ, value) {
+		return "", errs.NewInvalidArgumentError(fmt.Sprintf("%T is not a valid %s value", value, typ))
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+
+	case int:
+		return strconv.Itoa(v), nil
+
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+
+	case bool:
+		return strconv.FormatBool(v), nil
+
+	case time.Duration:
+		return v.String(), nil
+
+	case []string:
+		if len(v) == 1 && v[0] == "" {
+			// csv would write an empty line, which reads as no strings.
+			return `""`, nil
+		}
+
+		var sb strings.Builder
+		w := csv.NewWriter(&sb)
+		if err := w.Write(v); err != nil {
+			return "", errs.WrapError(err)
+		}
+		w.Flush()
+
+		return strings.TrimSuffix(sb.String(), "\n"), nil
+
+	case Size:
+		return fmt.Sprint(v.Width, v.Height), nil
+
+	case Color:
+		return fmt.Sprintf("#%02X%02X%02X", v.R(), v.G(), v.B()), nil
+
+	case *Font:
+		return formatFontSetting(v), nil
+
+	case Shortcut:
+		return v.String(), nil
+	}
+
+	return "", errs.NewNotSupportedError(fmt.Sprintf("setting type %d", typ))
+}
+
+var fontStyle2Name = []struct {
+	style FontStyle
+	name  string
+}{
+	{FontBold, "Bold"},
+	{FontItalic, "Italic"},
+	{FontUnderline, "Underline"},
+	{FontStrikeOut, "StrikeOut"},
+}
+
+// formatFontSetting returns e.g. "Segoe UI, 9, Bold, Italic" for font.
+func formatFontSetting(font *Font) string {
+	if font == nil {
+		return ""
+	}
+
+	parts := []string{font.Family(), strconv.Itoa(font.PointSize())}
+	for _, sn := range fontStyle2Name {
+		if font.Style()&sn.style != 0 {
+			parts = append(parts, sn.name)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func parseFontSetting(s string) (*Font, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) < 2 {
+		return nil, errs.NewInvalidArgumentError("font must be specified as family, point size and styles")
+	}
+
+	pointSize, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, err
+	}
+
+	var style FontStyle
+	for _, part := range parts[2:] {
+		name := strings.TrimSpace(part)
+
+		var found bool
+		for _, sn := range fontStyle2Name {
+			if strings.EqualFold(name, sn.name) {
+				style |= sn.style
+				found = true
+			}
+		}
+		if !found {
+			return nil, errs.NewInvalidArgumentError("invalid font style: " + name)
+		}
+	}
+
+	return NewFont(strings.TrimSpace(parts[0]), pointSize, style)
+}
@@ -0,0 +1,329 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Gipcomp/winapi/errs"
+)
+
+func newTestSettingsSchema(t *testing.T) *SettingsSchema {
+	t.Helper()
+
+	schema, err := NewSettingsSchema(
+		&SettingDescriptor{Key: "name", Type: SettingString, Default: "anonymous"},
+		&SettingDescriptor{Key: "count", Type: SettingInt, Default: 3, Min: 1, Max: 10, OldKeys: []string{"Count", "num"}},
+		&SettingDescriptor{Key: "ratio", Type: SettingFloat, Min: 0.0, Max: 1.0},
+		&SettingDescriptor{Key: "enabled", Type: SettingBool, Default: true},
+		&SettingDescriptor{Key: "interval", Type: SettingDuration, Default: time.Second, Max: time.Minute},
+		&SettingDescriptor{Key: "tags", Type: SettingStrings},
+		&SettingDescriptor{Key: "size", Type: SettingSize},
+		&SettingDescriptor{Key: "color", Type: SettingColor},
+		&SettingDescriptor{Key: "theme", Type: SettingString, Choices: []string{"light", "dark"}},
+		&SettingDescriptor{Key: "dir", Type: SettingPath, Pattern: `^[A-Z]:\\`},
+		&SettingDescriptor{Key: "save", Type: SettingShortcut},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+func TestNewSettingsSchemaErrors(t *testing.T) {
+	for _, descriptors := range [][]*SettingDescriptor{
+		{{Key: ""}},
+		{{Key: "a"}, {Key: "a"}},
+		{{Key: "a", Type: SettingInt, Default: "3"}},
+		{{Key: "a", Type: SettingInt, Min: 1.5}},
+		{{Key: "a", Type: SettingInt, Default: 20, Max: 10}},
+	} {
+		if _, err := NewSettingsSchema(descriptors...); !errors.Is(err, errs.ErrInvalidArgument) {
+			t.Errorf("NewSettingsSchema(%+v) error = %v, want an invalid argument error", descriptors[len(descriptors)-1], err)
+		}
+	}
+}
+
+func TestTypedSettingsGetters(t *testing.T) {
+	ts := NewTypedSettings(NewMapSettings(map[string]string{
+		"count":    " 7 ",
+		"ratio":    "0.25",
+		"interval": "1m",
+		"tags":     `a,"b,c"`,
+		"size":     "640 480",
+		"color":    "#FF8000",
+	}), newTestSettingsSchema(t))
+
+	if v, err := ts.GetString("name"); v != "anonymous" || err != nil {
+		t.Errorf(`GetString("name") = %q, %v; want the default`, v, err)
+	}
+	if v, err := ts.GetInt("count"); v != 7 || err != nil {
+		t.Errorf(`GetInt("count") = %d, %v`, v, err)
+	}
+	if v, err := ts.GetFloat("ratio"); v != 0.25 || err != nil {
+		t.Errorf(`GetFloat("ratio") = %g, %v`, v, err)
+	}
+	if v, err := ts.GetBool("enabled"); !v || err != nil {
+		t.Errorf(`GetBool("enabled") = %t, %v; want the default`, v, err)
+	}
+	if v, err := ts.GetDuration("interval"); v != time.Minute || err != nil {
+		t.Errorf(`GetDuration("interval") = %s, %v`, v, err)
+	}
+	if v, err := ts.GetStrings("tags"); !reflect.DeepEqual(v, []string{"a", "b,c"}) || err != nil {
+		t.Errorf(`GetStrings("tags") = %q, %v`, v, err)
+	}
+	if v, err := ts.GetSize("size"); v != (Size{640, 480}) || err != nil {
+		t.Errorf(`GetSize("size") = %v, %v`, v, err)
+	}
+	if v, err := ts.GetColor("color"); v != RGB(0xFF, 0x80, 0x00) || err != nil {
+		t.Errorf(`GetColor("color") = %v, %v`, v, err)
+	}
+
+	// Accessing a declared key with another type is an error.
+	if _, err := ts.GetBool("count"); err == nil {
+		t.Error(`GetBool("count") succeeded`)
+	}
+
+	// Undeclared keys are accessed as the requested type.
+	if v, err := ts.GetInt("undeclared"); v != 0 || err != nil {
+		t.Errorf(`GetInt("undeclared") = %d, %v`, v, err)
+	}
+}
+
+func TestTypedSettingsRoundTrip(t *testing.T) {
+	ts := NewTypedSettings(NewMapSettings(nil), newTestSettingsSchema(t))
+
+	shortcut := Shortcut{Modifiers: ModControl | ModShift, Key: KeyS}
+	tags := []string{"", "quoted \"tag\"", "a,b"}
+
+	for _, err := range []error{
+		ts.PutString("theme", "dark"),
+		ts.PutPath("dir", `C:\Users`),
+		ts.PutStrings("tags", tags),
+		ts.PutShortcut("save", shortcut),
+		ts.PutDuration("interval", 90*time.Millisecond),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if v, err := ts.GetString("theme"); v != "dark" || err != nil {
+		t.Errorf(`GetString("theme") = %q, %v`, v, err)
+	}
+	if v, err := ts.GetPath("dir"); v != `C:\Users` || err != nil {
+		t.Errorf(`GetPath("dir") = %q, %v`, v, err)
+	}
+	if v, err := ts.GetStrings("tags"); !reflect.DeepEqual(v, tags) || err != nil {
+		t.Errorf(`GetStrings("tags") = %q, %v; want %q`, v, err, tags)
+	}
+	if v, err := ts.GetShortcut("save"); v != shortcut || err != nil {
+		t.Errorf(`GetShortcut("save") = %v, %v; want %v`, v, err, shortcut)
+	}
+	if v, err := ts.GetDuration("interval"); v != 90*time.Millisecond || err != nil {
+		t.Errorf(`GetDuration("interval") = %s, %v`, v, err)
+	}
+}
+
+func TestTypedSettingsValidation(t *testing.T) {
+	settings := NewMapSettings(map[string]string{
+		"count":    "11",
+		"ratio":    "x",
+		"interval": "2m",
+		"theme":    "blue",
+		"dir":      "relative",
+	})
+	schema := newTestSettingsSchema(t)
+	ts := NewTypedSettings(settings, schema)
+
+	// Invalid stored values return the default along with the error.
+	if v, err := ts.GetInt("count"); v != 3 || !errors.Is(err, errs.ErrInvalidArgument) {
+		t.Errorf(`GetInt("count") = %d, %v; want 3 and an invalid argument error`, v, err)
+	}
+	if v, err := ts.GetFloat("ratio"); v != 0 || err == nil {
+		t.Errorf(`GetFloat("ratio") = %g, %v; want 0 and an error`, v, err)
+	}
+	if _, err := ts.GetDuration("interval"); err == nil {
+		t.Error(`GetDuration("interval") above the maximum succeeded`)
+	}
+
+	for _, test := range []struct {
+		key   string
+		value interface{}
+	}{
+		{"count", 0},
+		{"count", 11},
+		{"ratio", 1.5},
+		{"theme", "blue"},
+		{"dir", "relative"},
+		{"count", "3"},
+	} {
+		if err := ts.SetValue(test.key, test.value); err == nil {
+			t.Errorf("SetValue(%q, %#v) succeeded", test.key, test.value)
+		}
+	}
+
+	for _, test := range []struct {
+		key   string
+		value interface{}
+	}{
+		{"count", 1},
+		{"count", 10},
+		{"ratio", 0.0},
+		{"theme", "light"},
+	} {
+		if err := ts.SetValue(test.key, test.value); err != nil {
+			t.Errorf("SetValue(%q, %#v) = %v", test.key, test.value, err)
+		}
+	}
+
+	// Validate reports every invalid value.
+	settings = NewMapSettings(map[string]string{"count": "0", "theme": "blue", "name": "ok"})
+	if err := schema.Validate(settings); err == nil {
+		t.Error("Validate() succeeded")
+	}
+	if err := schema.Validate(NewMapSettings(map[string]string{"count": "5"})); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+
+	// Values entered into a NumberEdit arrive as float64.
+	if err := schema.Descriptor("count").Validate(4.0); err != nil {
+		t.Errorf("Validate(4.0) = %v", err)
+	}
+	if err := schema.Descriptor("count").Validate("12"); err == nil {
+		t.Error(`Validate("12") succeeded`)
+	}
+}
+
+type testWindowSettings struct {
+	Title    string `settings:"title"`
+	Count    int    `settings:"count"`
+	Small    uint8
+	Ratio    float32
+	Enabled  bool          `settings:"enabled"`
+	Interval time.Duration `settings:"interval"`
+	Ignored  string        `settings:"-"`
+	Geometry struct {
+		Size Size `settings:"size"`
+		Max  bool
+	} `settings:"geometry"`
+	hidden string
+}
+
+func TestTypedSettingsStruct(t *testing.T) {
+	settings := NewMapSettings(map[string]string{
+		"title":         "Main",
+		"Small":         "200",
+		"Ratio":         "0.5",
+		"Ignored":       "stored",
+		"geometry/size": "800 600",
+		"geometry/Max":  "true",
+	})
+	ts := NewTypedSettings(settings, newTestSettingsSchema(t))
+
+	v := testWindowSettings{Count: 42, Ignored: "kept", hidden: "kept"}
+	if err := ts.GetStruct(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	// count and enabled have declared defaults, interval too.
+	if v.Title != "Main" || v.Count != 3 || v.Small != 200 || v.Ratio != 0.5 || !v.Enabled || v.Interval != time.Second {
+		t.Errorf("GetStruct() filled %+v", v)
+	}
+	if v.Ignored != "kept" || v.hidden != "kept" {
+		t.Errorf("GetStruct() changed skipped fields: %+v", v)
+	}
+	if v.Geometry.Size != (Size{800, 600}) || !v.Geometry.Max {
+		t.Errorf("GetStruct() filled Geometry = %+v", v.Geometry)
+	}
+
+	v.Title = "Other"
+	v.Count = 5
+	v.Geometry.Max = false
+	if err := ts.PutStruct(v); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"title": "Other", "count": "5", "geometry/Max": "false", "Ignored": "stored"} {
+		if got, _ := settings.Get(key); got != want {
+			t.Errorf("Get(%q) = %q after PutStruct(), want %q", key, got, want)
+		}
+	}
+
+	// Out of range values are reported, but the other fields are filled.
+	settings.Put("Small", "300")
+	settings.Put("title", "Again")
+	if err := ts.GetStruct(&v); err == nil {
+		t.Error("GetStruct() with an overflowing value succeeded")
+	}
+	if v.Title != "Again" {
+		t.Errorf("Title = %q, want the remaining fields filled", v.Title)
+	}
+
+	if err := ts.GetStruct(v); err == nil {
+		t.Error("GetStruct() of a non-pointer succeeded")
+	}
+}
+
+func TestSettingsSchemaMigrate(t *testing.T) {
+	schema := newTestSettingsSchema(t)
+
+	settings := NewMapSettings(map[string]string{"Count": "5", "num": "6"})
+	if err := schema.Migrate(settings); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := settings.Get("count"); v != "5" || !ok {
+		t.Errorf(`Get("count") = %q, %t; want the most recent old value`, v, ok)
+	}
+	for _, key := range []string{"Count", "num"} {
+		if _, ok := settings.Get(key); ok {
+			t.Errorf("old key %q was not removed", key)
+		}
+	}
+
+	// A value already stored under the new key wins.
+	settings = NewMapSettings(map[string]string{"count": "2", "num": "6"})
+	if err := schema.Migrate(settings); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := settings.Get("count"); v != "2" {
+		t.Errorf(`Get("count") = %q, want the stored value`, v)
+	}
+	if _, ok := settings.Get("num"); ok {
+		t.Error(`old key "num" was not removed`)
+	}
+}
+
+func TestSettingsSchemaMigrateLayered(t *testing.T) {
+	schema := newTestSettingsSchema(t)
+
+	user := NewMapSettings(map[string]string{"num": "8"})
+	if err := user.PutExpiring("Count", "9"); err != nil {
+		t.Fatal(err)
+	}
+	settings := NewLayeredSettings(schema.Defaults(), user)
+
+	// The default of count must not count as a stored value.
+	if err := schema.Migrate(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := user.Get("count"); v != "9" || !ok {
+		t.Errorf(`user Get("count") = %q, %t; want the migrated value`, v, ok)
+	}
+	if ts, _ := user.Timestamp("count"); ts.IsZero() {
+		t.Error("the migrated value is no longer expiring")
+	}
+	for _, key := range []string{"Count", "num"} {
+		if _, ok := user.Get(key); ok {
+			t.Errorf("old key %q was not removed", key)
+		}
+	}
+}
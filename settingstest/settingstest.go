@@ -0,0 +1,312 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+// Package settingstest implements a conformance suite for implementations of
+// the winapi.Settings interface, in the spirit of testing/fstest.
+//
+// A test of a custom Settings implementation typically looks like this:
+//
+//	func TestMySettings(t *testing.T) {
+//		if err := settingstest.TestSettings(func() winapi.Settings {
+//			return NewMySettings(path)
+//		}); err != nil {
+//			t.Fatal(err)
+//		}
+//	}
+package settingstest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Gipcomp/winapi"
+)
+
+// TestSettings checks that the Settings returned by newSettings behave as
+// expected. newSettings is called several times and every call must return
+// an instance backed by the same storage, so that values saved through one
+// instance can be loaded through another. Persistence checks are skipped for
+// implementations whose Save and Load do not persist, as detected by a first
+// round trip.
+//
+// The storage should be empty initially. TestSettings removes every key it
+// puts.
+func TestSettings(newSettings func() winapi.Settings) error {
+	var errList []string
+	fail := func(format string, args ...interface{}) {
+		errList = append(errList, fmt.Sprintf(format, args...))
+	}
+
+	testPutGet(newSettings(), fail)
+	testRemove(newSettings(), fail)
+	testTimestamps(newSettings(), fail)
+	testInvalidKey(newSettings(), fail)
+	testKeys(newSettings(), fail)
+	testPersistence(newSettings, fail)
+
+	if len(errList) > 0 {
+		return errors.New("settingstest: " + strings.Join(errList, "\n\t"))
+	}
+
+	return nil
+}
+
+var testValues = map[string]string{
+	"settingstest.plain":   "value",
+	"settingstest.empty":   "",
+	"settingstest.unicode": "Grüße, 世界",
+	"settingstest.equals":  "a=b=c",
+	"settingstest.path":    `C:\Program Files\Walk`,
+	"settingstest.spaces":  "  leading and trailing  ",
+	"settingstest.lines":   "line 1\r\nline 2\n\ttabbed",
+	"settingstest.quoted":  `"quoted" \"value\"`,
+	"settingstest/nested":  "value",
+}
+
+func sortedTestKeys() []string {
+	keys := make([]string, 0, len(testValues))
+	for key := range testValues {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func testPutGet(s winapi.Settings, fail func(string, ...interface{})) {
+	if _, ok := s.Get("settingstest.missing"); ok {
+		fail("Get of a missing key reported ok")
+	}
+
+	for _, key := range sortedTestKeys() {
+		if err := s.Put(key, testValues[key]); err != nil {
+			fail("Put(%q): %v", key, err)
+			continue
+		}
+
+		if value, ok := s.Get(key); !ok || value != testValues[key] {
+			fail("Get(%q) = %q, %t; want %q, true", key, value, ok, testValues[key])
+		}
+	}
+
+	if err := s.Put("settingstest.plain", "changed"); err != nil {
+		fail("Put of existing key: %v", err)
+	} else if value, _ := s.Get("settingstest.plain"); value != "changed" {
+		fail("Get after overwriting = %q; want %q", value, "changed")
+	}
+
+	removeTestKeys(s, fail)
+}
+
+func testRemove(s winapi.Settings, fail func(string, ...interface{})) {
+	if err := s.Put("settingstest.remove", "value"); err != nil {
+		fail("Put: %v", err)
+		return
+	}
+
+	if err := s.Remove("settingstest.remove"); err != nil {
+		fail("Remove: %v", err)
+	}
+	if _, ok := s.Get("settingstest.remove"); ok {
+		fail("Get after Remove reported ok")
+	}
+
+	if err := s.Remove("settingstest.missing"); err != nil {
+		fail("Remove of a missing key: %v", err)
+	}
+}
+
+func testTimestamps(s winapi.Settings, fail func(string, ...interface{})) {
+	if err := s.Put("settingstest.permanent", "value"); err != nil {
+		fail("Put: %v", err)
+	} else if ts, _ := s.Timestamp("settingstest.permanent"); !ts.IsZero() {
+		fail("Timestamp of a Put value = %v; want zero", ts)
+	}
+
+	before := time.Now().Add(-time.Second)
+	if err := s.PutExpiring("settingstest.expiring", "value"); err != nil {
+		fail("PutExpiring: %v", err)
+	} else {
+		if value, ok := s.Get("settingstest.expiring"); !ok || value != "value" {
+			fail("Get after PutExpiring = %q, %t; want %q, true", value, ok, "value")
+		}
+		if ts, ok := s.Timestamp("settingstest.expiring"); !ok || ts.Before(before) || ts.After(time.Now().Add(time.Second)) {
+			fail("Timestamp after PutExpiring = %v, %t; want about now", ts, ok)
+		}
+	}
+
+	if _, ok := s.Timestamp("settingstest.missing"); ok {
+		fail("Timestamp of a missing key reported ok")
+	}
+
+	old := s.ExpireDuration()
+	s.SetExpireDuration(time.Hour)
+	if d := s.ExpireDuration(); d != time.Hour {
+		fail("ExpireDuration after SetExpireDuration(time.Hour) = %v", d)
+	}
+	s.SetExpireDuration(old)
+
+	s.Remove("settingstest.permanent")
+	s.Remove("settingstest.expiring")
+}
+
+func testInvalidKey(s winapi.Settings, fail func(string, ...interface{})) {
+	if err := s.Put("", "value"); err == nil {
+		fail("Put with empty key succeeded")
+		s.Remove("")
+	}
+}
+
+func testKeys(s winapi.Settings, fail func(string, ...interface{})) {
+	kp, ok := s.(winapi.KeysProvider)
+	if !ok {
+		return
+	}
+
+	for _, key := range sortedTestKeys() {
+		s.Put(key, testValues[key])
+	}
+
+	key2Present := make(map[string]bool)
+	for _, key := range kp.Keys() {
+		key2Present[key] = true
+	}
+
+	for _, key := range sortedTestKeys() {
+		if !key2Present[key] {
+			fail("Keys() misses %q", key)
+		}
+	}
+
+	removeTestKeys(s, fail)
+
+	for _, key := range kp.Keys() {
+		if _, ok := testValues[key]; ok {
+			fail("Keys() still returns %q after Remove", key)
+		}
+	}
+}
+
+func testPersistence(newSettings func() winapi.Settings, fail func(string, ...interface{})) {
+	s := newSettings()
+	if err := s.Load(); err != nil {
+		fail("Load of empty storage: %v", err)
+		return
+	}
+
+	for _, key := range sortedTestKeys() {
+		s.Put(key, testValues[key])
+	}
+	s.PutExpiring("settingstest.expiring", "value")
+
+	if err := s.Save(); err != nil {
+		fail("Save: %v", err)
+		return
+	}
+
+	loaded := newSettings()
+	if err := loaded.Load(); err != nil {
+		fail("Load: %v", err)
+		return
+	}
+
+	if _, ok := loaded.Get("settingstest.plain"); !ok {
+		// Not persisted, e.g. MapSettings.
+		return
+	}
+
+	for _, key := range sortedTestKeys() {
+		if value, ok := loaded.Get(key); !ok || value != testValues[key] {
+			fail("Get(%q) after Save and Load = %q, %t; want %q, true", key, value, ok, testValues[key])
+		}
+	}
+
+	if ts, ok := loaded.Timestamp("settingstest.expiring"); !ok || ts.IsZero() {
+		fail("Timestamp of expiring value was not persisted")
+	}
+
+	removeTestKeys(loaded, fail)
+	loaded.Remove("settingstest.expiring")
+	if err := loaded.Save(); err != nil {
+		fail("Save after Remove: %v", err)
+		return
+	}
+
+	reloaded := newSettings()
+	if err := reloaded.Load(); err != nil {
+		fail("Load after Remove: %v", err)
+		return
+	}
+
+	for _, key := range sortedTestKeys() {
+		if _, ok := reloaded.Get(key); ok {
+			fail("%q is still present after Remove, Save and Load", key)
+		}
+	}
+}
+
+func removeTestKeys(s winapi.Settings, fail func(string, ...interface{})) {
+	for _, key := range sortedTestKeys() {
+		if err := s.Remove(key); err != nil {
+			fail("Remove(%q): %v", key, err)
+		}
+	}
+}
+
+// TestConcurrentInstances checks that changes saved through two instances
+// that were loaded at the same time are both retained, as is required of
+// Settings shared by several running instances of an application. The
+// requirements of TestSettings apply to newSettings.
+func TestConcurrentInstances(newSettings func() winapi.Settings) error {
+	a, b := newSettings(), newSettings()
+
+	for _, s := range []winapi.Settings{a, b} {
+		if err := s.Load(); err != nil {
+			return fmt.Errorf("settingstest: Load: %v", err)
+		}
+	}
+
+	a.Put("settingstest.a", "a")
+	b.Put("settingstest.b", "b")
+	a.Put("settingstest.both", "a")
+	b.Put("settingstest.both", "b")
+
+	for _, s := range []winapi.Settings{a, b} {
+		if err := s.Save(); err != nil {
+			return fmt.Errorf("settingstest: Save: %v", err)
+		}
+	}
+
+	c := newSettings()
+	if err := c.Load(); err != nil {
+		return fmt.Errorf("settingstest: Load: %v", err)
+	}
+
+	defer func() {
+		for _, key := range []string{"settingstest.a", "settingstest.b", "settingstest.both"} {
+			c.Remove(key)
+		}
+		c.Save()
+	}()
+
+	var errList []string
+	for key, want := range map[string]string{"settingstest.a": "a", "settingstest.b": "b", "settingstest.both": "b"} {
+		if value, ok := c.Get(key); !ok || value != want {
+			errList = append(errList, fmt.Sprintf("Get(%q) = %q, %t; want %q, true", key, value, ok, want))
+		}
+	}
+
+	if len(errList) > 0 {
+		sort.Strings(errList)
+		return errors.New("settingstest: " + strings.Join(errList, "\n\t"))
+	}
+
+	return nil
+}
@@ -33,11 +33,16 @@ func init() {
 
 type Splitter struct {
 	ContainerBase
-	handleWidth   int
-	mouseDownPos  Point // in native pixels
-	draggedHandle *splitterHandle
-	persistent    bool
-	removing      bool
+	handleWidth                   int
+	mouseDownPos                  Point // in native pixels
+	draggedHandle                 *splitterHandle
+	pressedButtonHandle           *splitterHandle
+	persistent                    bool
+	handlesFocusable              bool
+	removing                      bool
+	collapseAnimated              bool
+	animation                     *splitterAnimation
+	paneCollapsedChangedPublisher WidgetEventPublisher
 }
 
 func newSplitter(parent Container, orientation Orientation) (*Splitter, error) {
@@ -46,7 +51,8 @@ func newSplitter(parent Container, orientation Orientation) (*Splitter, error) {
 		ContainerBase: ContainerBase{
 			layout: layout,
 		},
-		handleWidth: 5,
+		handleWidth:      5,
+		collapseAnimated: true,
 	}
 	s.children = newWidgetList(s)
 	layout.container = s
@@ -89,7 +95,7 @@ func NewVSplitter(parent Container) (*Splitter, error) {
 }
 
 func (s *Splitter) SetLayout(value Layout) error {
-	return errs.NewError("not supported")
+	return errs.NewNotSupportedError("not supported")
 }
 
 func (s *Splitter) HandleWidth() int {
@@ -102,7 +108,7 @@ func (s *Splitter) SetHandleWidth(value int) error {
 	}
 
 	if value < 1 {
-		return errs.NewError("invalid handle width")
+		return errs.NewInvalidArgumentError("invalid handle width")
 	}
 
 	s.handleWidth = value
@@ -188,6 +194,32 @@ func (s *Splitter) SetPersistent(value bool) {
 	s.persistent = value
 }
 
+// HandlesFocusable returns if the handles are tab stops, which can then be
+// moved with the arrow keys.
+func (s *Splitter) HandlesFocusable() bool {
+	return s.handlesFocusable
+}
+
+// SetHandlesFocusable sets if the handles are tab stops, which can then be
+// moved with the arrow keys.
+func (s *Splitter) SetHandlesFocusable(value bool) error {
+	if value == s.handlesFocusable {
+		return nil
+	}
+
+	for _, wb := range s.children.items {
+		if handle, ok := wb.window.(*splitterHandle); ok {
+			if err := handle.ensureStyleBits(user32.WS_TABSTOP, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.handlesFocusable = value
+
+	return nil
+}
+
 func (s *Splitter) SaveState() error {
 	buf := bytes.NewBuffer(nil)
 
@@ -204,6 +236,12 @@ func (s *Splitter) SaveState() error {
 		if size == 0 {
 			size = item.size
 		}
+		if item.collapsed {
+			// Collapsed panes are stored with a "c" prefix and the size they
+			// get when expanded.
+			buf.WriteString("c")
+			size = item.expandedSize
+		}
 		buf.WriteString(strconv.FormatInt(int64(size), 10))
 	}
 
@@ -244,6 +282,8 @@ func (s *Splitter) RestoreState() error {
 
 	layout := s.layout.(*splitterLayout)
 
+	s.finishAnimation()
+
 	s.SetSuspended(true)
 	layout.suspended = true
 	defer func() {
@@ -260,6 +300,8 @@ func (s *Splitter) RestoreState() error {
 	}
 	regularSpace := space - layout.spaceUnavailableToRegularWidgets()
 
+	var collapsedChanged []Widget
+
 	for i, wb := range s.children.items {
 		widget := wb.window.(Widget)
 
@@ -267,6 +309,11 @@ func (s *Splitter) RestoreState() error {
 			j := i/2 + i%2
 			s := sizeStrs[j]
 
+			collapsed := strings.HasPrefix(s, "c")
+			if collapsed {
+				s = s[1:]
+			}
+
 			size, err := strconv.Atoi(s)
 			if err != nil {
 				// OK, we probably got old style settings which were stored as fractions.
@@ -279,11 +326,26 @@ func (s *Splitter) RestoreState() error {
 			}
 
 			item := layout.hwnd2Item[widget.Handle()]
+			if collapsed {
+				item.expandedSize = size
+				size = 0
+			}
+			if collapsed != item.collapsed {
+				item.collapsed = collapsed
+				collapsedChanged = append(collapsedChanged, widget)
+			}
 			item.size = size
 			item.oldExplicitSize = size
 		}
 	}
 
+	for _, widget := range collapsedChanged {
+		s.paneCollapsedChangedPublisher.Publish(widget)
+	}
+	if len(collapsedChanged) > 0 {
+		s.invalidateHandles()
+	}
+
 	for _, wb := range s.children.items {
 		if persistable, ok := wb.window.(Persistable); ok {
 			if err := persistable.RestoreState(); err != nil {
@@ -302,7 +364,7 @@ func (s *Splitter) Fixed(widget Widget) bool {
 func (s *Splitter) SetFixed(widget Widget, fixed bool) error {
 	item := s.layout.(*splitterLayout).hwnd2Item[widget.Handle()]
 	if item == nil {
-		return errs.NewError("unknown widget")
+		return errs.NewInvalidArgumentError("unknown widget")
 	}
 
 	item.fixed = fixed
@@ -330,6 +392,12 @@ func (s *Splitter) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr)
 		for _, item := range layout.hwnd2Item {
 			item.oldExplicitSize = 0
 		}
+
+	case user32.WM_TIMER:
+		if wParam == splitterAnimationTimerId {
+			s.animate()
+			return 0
+		}
 	}
 
 	return s.ContainerBase.WndProc(hwnd, msg, wParam, lParam)
@@ -348,8 +416,14 @@ func (s *Splitter) onInsertedWidget(index int, widget Widget) (err error) {
 		s.updateMarginsForFocusEffect()
 	}()
 
-	_, isHandle := widget.(*splitterHandle)
+	handle, isHandle := widget.(*splitterHandle)
 	if isHandle {
+		if s.handlesFocusable {
+			if err := handle.ensureStyleBits(user32.WS_TABSTOP, true); err != nil {
+				return err
+			}
+		}
+
 		if s.Orientation() == Horizontal {
 			widget.SetCursor(CursorSizeWE())
 		} else {
@@ -384,20 +458,6 @@ func (s *Splitter) onInsertedWidget(index int, widget Widget) (err error) {
 					return
 				}
 
-				closestVisibleWidget := func(offset, direction int) Widget {
-					index := offset + direction
-
-					for index >= 0 && index < len(s.children.items) {
-						if wb := s.children.items[index]; wb.visible {
-							return wb.window.(Widget)
-						}
-
-						index += direction
-					}
-
-					return nil
-				}
-
 				handleIndex := index + 1 - index%2
 				err = s.children.Insert(handleIndex, handle)
 				if err == nil {
@@ -407,6 +467,13 @@ func (s *Splitter) onInsertedWidget(index int, widget Widget) (err error) {
 							return
 						}
 
+						if s.handleButtonHit(handle, x, y) {
+							s.pressedButtonHandle = handle
+							return
+						}
+
+						s.finishAnimation()
+
 						s.draggedHandle = handle
 						s.mouseDownPos = Point{x, y}
 						handle.SetBackground(splitterHandleDraggingBrush)
@@ -420,13 +487,11 @@ func (s *Splitter) onInsertedWidget(index int, widget Widget) (err error) {
 						handleIndex := s.children.Index(s.draggedHandle)
 						bh := s.draggedHandle.BoundsPixels()
 
-						prev := closestVisibleWidget(handleIndex, -1)
+						prev := s.closestVisibleWidget(handleIndex, -1)
 						bp := prev.BoundsPixels()
-						msep := minSizeEffective(createLayoutItemForWidget(prev))
 
-						next := closestVisibleWidget(handleIndex, 1)
+						next := s.closestVisibleWidget(handleIndex, 1)
 						bn := next.BoundsPixels()
-						msen := minSizeEffective(createLayoutItemForWidget(next))
 
 						dpi := s.draggedHandle.DPI()
 						handleWidth := IntFrom96DPI(s.handleWidth, dpi)
@@ -434,11 +499,13 @@ func (s *Splitter) onInsertedWidget(index int, widget Widget) (err error) {
 						if s.Orientation() == Horizontal {
 							xh := s.draggedHandle.XPixels()
 
+							lo, hi := s.handleRange(prev, next, bp.X, bn.X+bn.Width-handleWidth)
+
 							xnew := xh + x - s.mouseDownPos.X
-							if xnew < bp.X+msep.Width {
-								xnew = bp.X + msep.Width
-							} else if xnew >= bn.X+bn.Width-msen.Width-handleWidth {
-								xnew = bn.X + bn.Width - msen.Width - handleWidth
+							if xnew < lo {
+								xnew = lo
+							} else if xnew >= hi {
+								xnew = hi
 							}
 
 							if e := s.draggedHandle.SetXPixels(xnew); e != nil {
@@ -447,11 +514,13 @@ func (s *Splitter) onInsertedWidget(index int, widget Widget) (err error) {
 						} else {
 							yh := s.draggedHandle.YPixels()
 
+							lo, hi := s.handleRange(prev, next, bp.Y, bn.Y+bn.Height-handleWidth)
+
 							ynew := yh + y - s.mouseDownPos.Y
-							if ynew < bp.Y+msep.Height {
-								ynew = bp.Y + msep.Height
-							} else if ynew >= bn.Y+bn.Height-msen.Height-handleWidth {
-								ynew = bn.Y + bn.Height - msen.Height - handleWidth
+							if ynew < lo {
+								ynew = lo
+							} else if ynew >= hi {
+								ynew = hi
 							}
 
 							if e := s.draggedHandle.SetYPixels(ynew); e != nil {
@@ -483,6 +552,15 @@ func (s *Splitter) onInsertedWidget(index int, widget Widget) (err error) {
 					})
 
 					handle.MouseUp().Attach(func(x, y int, button MouseButton) {
+						if s.pressedButtonHandle == handle {
+							s.pressedButtonHandle = nil
+
+							if s.handleButtonHit(handle, x, y) {
+								s.toggleAtHandle(handle)
+							}
+							return
+						}
+
 						if s.draggedHandle == nil {
 							return
 						}
@@ -492,8 +570,8 @@ func (s *Splitter) onInsertedWidget(index int, widget Widget) (err error) {
 						dragHandle := s.draggedHandle
 
 						handleIndex := s.children.Index(dragHandle)
-						prev := closestVisibleWidget(handleIndex, -1)
-						next := closestVisibleWidget(handleIndex, 1)
+						prev := s.closestVisibleWidget(handleIndex, -1)
+						next := s.closestVisibleWidget(handleIndex, 1)
 
 						s.draggedHandle = nil
 						dragHandle.SetBackground(NullBrush())
@@ -511,16 +589,18 @@ func (s *Splitter) onInsertedWidget(index int, widget Widget) (err error) {
 						bp := prev.BoundsPixels()
 						bn := next.BoundsPixels()
 
-						var sizePrev int
-						var sizeNext int
+						var sizePrev, oldPrev int
+						var sizeNext, oldNext int
 
 						if s.Orientation() == Horizontal {
+							oldPrev, oldNext = bp.Width, bn.Width
 							bp.Width = bh.X - bp.X
 							bn.Width -= (bh.X + bh.Width) - bn.X
 							bn.X = bh.X + bh.Width
 							sizePrev = bp.Width
 							sizeNext = bn.Width
 						} else {
+							oldPrev, oldNext = bp.Height, bn.Height
 							bp.Height = bh.Y - bp.Y
 							bn.Height -= (bh.Y + bh.Height) - bn.Y
 							bn.Y = bh.Y + bh.Height
@@ -528,15 +608,15 @@ func (s *Splitter) onInsertedWidget(index int, widget Widget) (err error) {
 							sizeNext = bn.Height
 						}
 
-						layout := s.Layout().(*splitterLayout)
+						s.resizePanes(prev, next, sizePrev, sizePrev+sizeNext, oldPrev, oldNext)
+					})
 
-						prevItem := layout.hwnd2Item[prev.Handle()]
-						prevItem.size = sizePrev
-						prevItem.oldExplicitSize = sizePrev
+					handle.KeyDown().Attach(func(key Key) {
+						s.onHandleKeyDown(handle, key)
+					})
 
-						nextItem := layout.hwnd2Item[next.Handle()]
-						nextItem.size = sizeNext
-						nextItem.oldExplicitSize = sizeNext
+					handle.FocusedChanged().Attach(func() {
+						handle.Invalidate()
 					})
 				}
 			}()
@@ -561,11 +641,14 @@ func (s *Splitter) onRemovedWidget(index int, widget Widget) (err error) {
 
 	_, isHandle := widget.(*splitterHandle)
 	if !s.removing && isHandle && s.children.Len()%2 == 1 {
-		return errs.NewError("cannot remove splitter handle")
+		return errs.NewInvalidArgumentError("cannot remove splitter handle")
 	}
 
 	if !isHandle {
 		sl := s.layout.(*splitterLayout)
+		if s.animation != nil && s.animation.item == sl.hwnd2Item[widget.Handle()] {
+			s.finishAnimation()
+		}
 		widget.AsWidgetBase().Property("Visible").Changed().Detach(sl.hwnd2Item[widget.Handle()].visibleChangedHandle)
 	}
 
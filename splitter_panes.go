@@ -0,0 +1,649 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"time"
+
+	"github.com/Gipcomp/win32/user32"
+	"github.com/Gipcomp/winapi/errs"
+)
+
+const (
+	splitterAnimationTimerId     = 1
+	splitterAnimationInterval    = 15 // in milliseconds
+	splitterAnimationDuration    = 150 * time.Millisecond
+	splitterHandleButtonLength96 = 24
+	splitterKeyboardStep96       = 8
+)
+
+// SplitterSizePolicy specifies how a pane of a Splitter is sized when the
+// Splitter itself is resized.
+type SplitterSizePolicy int
+
+const (
+	// SplitterSizeProportional panes share the space of the Splitter
+	// according to their stretch factors.
+	SplitterSizeProportional SplitterSizePolicy = iota
+
+	// SplitterSizeFixed panes keep their size, as long as there are
+	// proportional panes that can absorb the change.
+	SplitterSizeFixed
+)
+
+type splitterAnimation struct {
+	item  *splitterLayoutItem
+	from  int // in native pixels
+	to    int // in native pixels
+	start time.Time
+}
+
+// paneItem returns the layout item of widget, which must be a pane of s.
+func (s *Splitter) paneItem(widget Widget) (*splitterLayoutItem, error) {
+	if widget == nil {
+		return nil, errs.NewInvalidArgumentError("widget cannot be nil")
+	}
+
+	if _, isHandle := widget.(*splitterHandle); !isHandle && s.children.Index(widget) > -1 {
+		if item := s.layout.(*splitterLayout).hwnd2Item[widget.Handle()]; item != nil {
+			return item, nil
+		}
+	}
+
+	return nil, errs.NewInvalidArgumentError("unknown widget")
+}
+
+// SizePolicy returns how widget is sized when s is resized.
+func (s *Splitter) SizePolicy(widget Widget) SplitterSizePolicy {
+	if s.Fixed(widget) {
+		return SplitterSizeFixed
+	}
+
+	return SplitterSizeProportional
+}
+
+// SetSizePolicy sets how widget is sized when s is resized.
+func (s *Splitter) SetSizePolicy(widget Widget, policy SplitterSizePolicy) error {
+	switch policy {
+	case SplitterSizeProportional, SplitterSizeFixed:
+
+	default:
+		return errs.NewInvalidArgumentError("invalid SplitterSizePolicy value")
+	}
+
+	if err := s.SetFixed(widget, policy == SplitterSizeFixed); err != nil {
+		return err
+	}
+
+	s.RequestLayout()
+
+	return nil
+}
+
+// SizeRatioLimits returns the minimum and maximum size of widget as fraction
+// of the space available to the panes of s. 0 means no limit.
+func (s *Splitter) SizeRatioLimits(widget Widget) (min, max float64) {
+	item, err := s.paneItem(widget)
+	if err != nil {
+		return 0, 0
+	}
+
+	return item.minRatio, item.maxRatio
+}
+
+// SetSizeRatioLimits sets the minimum and maximum size of widget as fraction
+// of the space available to the panes of s. Both must be in the range [0, 1],
+// where 0 means no limit.
+//
+// The limits apply while widget is not collapsed.
+func (s *Splitter) SetSizeRatioLimits(widget Widget, min, max float64) error {
+	item, err := s.paneItem(widget)
+	if err != nil {
+		return err
+	}
+
+	if min < 0 || min > 1 || max < 0 || max > 1 || max > 0 && max < min {
+		return errs.NewInvalidArgumentError("invalid size ratio limits")
+	}
+
+	item.minRatio = min
+	item.maxRatio = max
+
+	s.RequestLayout()
+
+	return nil
+}
+
+// Collapsible returns if widget can be collapsed by the user.
+func (s *Splitter) Collapsible(widget Widget) bool {
+	item, err := s.paneItem(widget)
+	return err == nil && item.collapsible
+}
+
+// SetCollapsible sets if widget can be collapsed by the user, by
+// double-clicking or clicking the button of an adjacent handle, by dragging
+// an adjacent handle below the minimum size of widget or with the keyboard.
+//
+// Making a collapsed widget non-collapsible expands it.
+func (s *Splitter) SetCollapsible(widget Widget, collapsible bool) error {
+	item, err := s.paneItem(widget)
+	if err != nil {
+		return err
+	}
+
+	if collapsible == item.collapsible {
+		return nil
+	}
+
+	item.collapsible = collapsible
+
+	if !collapsible && item.collapsed {
+		s.setCollapsed(widget, item, false)
+	}
+
+	s.invalidateHandles()
+
+	return nil
+}
+
+// Collapsed returns if widget is collapsed.
+func (s *Splitter) Collapsed(widget Widget) bool {
+	item, err := s.paneItem(widget)
+	return err == nil && item.collapsed
+}
+
+// SetCollapsed collapses widget to zero size or expands it back to its size
+// from before collapsing. Only collapsible widgets can be collapsed.
+func (s *Splitter) SetCollapsed(widget Widget, collapsed bool) error {
+	item, err := s.paneItem(widget)
+	if err != nil {
+		return err
+	}
+
+	if collapsed && !item.collapsible {
+		return errs.NewInvalidArgumentError("widget is not collapsible")
+	}
+
+	s.setCollapsed(widget, item, collapsed)
+
+	return nil
+}
+
+// CollapseAnimated returns if collapsing and expanding panes is animated.
+func (s *Splitter) CollapseAnimated() bool {
+	return s.collapseAnimated
+}
+
+// SetCollapseAnimated sets if collapsing and expanding panes is animated.
+func (s *Splitter) SetCollapseAnimated(animated bool) {
+	s.collapseAnimated = animated
+
+	if !animated {
+		s.finishAnimation()
+	}
+}
+
+// PaneCollapsedChanged returns the event that is published after a pane of
+// s got collapsed or expanded.
+func (s *Splitter) PaneCollapsedChanged() *WidgetEvent {
+	return s.paneCollapsedChangedPublisher.Event()
+}
+
+func (s *Splitter) setCollapsed(widget Widget, item *splitterLayoutItem, collapsed bool) {
+	s.finishAnimation()
+
+	if collapsed == item.collapsed {
+		return
+	}
+
+	from := s.paneSize(widget)
+
+	var to int
+	if collapsed {
+		if from > 0 {
+			item.expandedSize = from
+		}
+	} else {
+		to = s.expandedPaneSize(widget, item)
+	}
+
+	item.collapsed = collapsed
+	item.size = to
+	item.oldExplicitSize = to
+
+	if s.collapseAnimated && s.Visible() && from != to {
+		s.startAnimation(item, from, to)
+	}
+
+	s.RequestLayout()
+	s.invalidateHandles()
+
+	s.paneCollapsedChangedPublisher.Publish(widget)
+}
+
+// expandedPaneSize returns the size in native pixels that the collapsed
+// widget gets when expanded.
+func (s *Splitter) expandedPaneSize(widget Widget, item *splitterLayoutItem) int {
+	size := item.expandedSize
+	if size <= 0 {
+		size = s.regularSpace() / (s.children.Len()/2 + 1)
+	}
+
+	min, max := s.paneLimits(widget, item)
+	if max > 0 && size > max {
+		size = max
+	}
+	if size < min {
+		size = min
+	}
+
+	return size
+}
+
+func (s *Splitter) startAnimation(item *splitterLayoutItem, from, to int) {
+	item.animating = true
+	item.animationSize = from
+
+	s.animation = &splitterAnimation{
+		item:  item,
+		from:  from,
+		to:    to,
+		start: time.Now(),
+	}
+
+	if user32.SetTimer(s.hWnd, splitterAnimationTimerId, splitterAnimationInterval, 0) == 0 {
+		errs.LastError("SetTimer")
+		s.finishAnimation()
+	}
+}
+
+func (s *Splitter) animate() {
+	a := s.animation
+	if a == nil {
+		return
+	}
+
+	t := float64(time.Since(a.start)) / float64(splitterAnimationDuration)
+	if t >= 1 {
+		s.finishAnimation()
+		return
+	}
+
+	// Ease out, so the pane slows down when reaching its final size.
+	t = 1 - (1-t)*(1-t)
+
+	a.item.animationSize = a.from + int(float64(a.to-a.from)*t)
+
+	s.RequestLayout()
+}
+
+func (s *Splitter) finishAnimation() {
+	a := s.animation
+	if a == nil {
+		return
+	}
+
+	s.animation = nil
+
+	if !user32.KillTimer(s.hWnd, splitterAnimationTimerId) {
+		errs.LastError("KillTimer")
+	}
+
+	a.item.animating = false
+
+	s.RequestLayout()
+}
+
+// paneSize returns the current size of widget along the orientation of s in
+// native pixels.
+func (s *Splitter) paneSize(widget Widget) int {
+	b := widget.BoundsPixels()
+
+	if s.Orientation() == Horizontal {
+		return b.Width
+	}
+
+	return b.Height
+}
+
+// regularSpace returns the space available to the panes of s in native
+// pixels.
+func (s *Splitter) regularSpace() int {
+	var space int
+	size := s.ClientBoundsPixels().Size()
+	if s.Orientation() == Horizontal {
+		space = size.Width
+	} else {
+		space = size.Height
+	}
+
+	return space - s.layout.(*splitterLayout).spaceUnavailableToRegularWidgets()
+}
+
+// paneLimits returns the minimum and maximum size of the expanded widget in
+// native pixels. A maximum of 0 means unlimited.
+func (s *Splitter) paneLimits(widget Widget, item *splitterLayoutItem) (min, max int) {
+	mse := minSizeEffective(createLayoutItemForWidget(widget))
+	if s.Orientation() == Horizontal {
+		min = mse.Width
+	} else {
+		min = mse.Height
+	}
+
+	rmin, rmax := item.ratioLimits(s.regularSpace())
+
+	return maxi(min, rmin), rmax
+}
+
+// handleRange returns the range that the handle between prev and next can be
+// moved in by the user, where start is the position of prev and end is the
+// position at which next would have no space left. Collapsible panes may be
+// shrunk below their minimum size, so they can be collapsed by dragging.
+func (s *Splitter) handleRange(prev, next Widget, start, end int) (lo, hi int) {
+	layout := s.layout.(*splitterLayout)
+	prevItem := layout.hwnd2Item[prev.Handle()]
+	nextItem := layout.hwnd2Item[next.Handle()]
+
+	minPrev, maxPrev := s.paneLimits(prev, prevItem)
+	if prevItem.collapsible {
+		minPrev = 0
+	}
+	minNext, maxNext := s.paneLimits(next, nextItem)
+	if nextItem.collapsible {
+		minNext = 0
+	}
+
+	lo = start + minPrev
+	if maxNext > 0 {
+		lo = maxi(lo, end-maxNext)
+	}
+
+	hi = end - minNext
+	if maxPrev > 0 {
+		hi = mini(hi, start+maxPrev)
+	}
+
+	return
+}
+
+// resizePanes gives prev size and next the rest of total, after the user
+// moved the handle between them. oldPrev and oldNext are the sizes before.
+func (s *Splitter) resizePanes(prev, next Widget, size, total, oldPrev, oldNext int) {
+	layout := s.layout.(*splitterLayout)
+	prevItem := layout.hwnd2Item[prev.Handle()]
+	nextItem := layout.hwnd2Item[next.Handle()]
+
+	size = s.setPaneSize(prev, prevItem, size, oldPrev)
+
+	if s.setPaneSize(next, nextItem, total-size, oldNext) == 0 && !prevItem.collapsed {
+		prevItem.size = total
+		prevItem.oldExplicitSize = total
+	}
+}
+
+// setPaneSize sets the size of widget as chosen by the user. A collapsible
+// widget that got smaller than its minimum size is collapsed, a collapsed one
+// that got large enough is expanded. It returns the resulting size.
+func (s *Splitter) setPaneSize(widget Widget, item *splitterLayoutItem, size, oldSize int) int {
+	min, _ := s.paneLimits(widget, item)
+
+	collapsed := item.collapsible && (size == 0 || size < min)
+	if collapsed {
+		if !item.collapsed && oldSize >= min {
+			item.expandedSize = oldSize
+		}
+
+		size = 0
+	}
+
+	item.size = size
+	item.oldExplicitSize = size
+
+	if collapsed != item.collapsed {
+		item.collapsed = collapsed
+
+		s.invalidateHandles()
+		s.paneCollapsedChangedPublisher.Publish(widget)
+	}
+
+	return size
+}
+
+func (s *Splitter) closestVisibleWidget(offset, direction int) Widget {
+	index := offset + direction
+
+	for index >= 0 && index < len(s.children.items) {
+		if wb := s.children.items[index]; wb.visible {
+			return wb.window.(Widget)
+		}
+
+		index += direction
+	}
+
+	return nil
+}
+
+// toggleTarget returns the pane that the button of handle collapses or
+// expands, and whether it is the pane before handle. Collapsed panes take
+// precedence over collapsible ones and the pane before handle over the one
+// after it.
+func (s *Splitter) toggleTarget(handle *splitterHandle) (pane Widget, before bool) {
+	index := s.children.Index(handle)
+	prev := s.closestVisibleWidget(index, -1)
+	next := s.closestVisibleWidget(index, 1)
+	if prev == nil || next == nil {
+		return nil, false
+	}
+
+	layout := s.layout.(*splitterLayout)
+	prevItem := layout.hwnd2Item[prev.Handle()]
+	nextItem := layout.hwnd2Item[next.Handle()]
+
+	switch {
+	case prevItem.collapsed:
+		return prev, true
+
+	case nextItem.collapsed:
+		return next, false
+
+	case prevItem.collapsible:
+		return prev, true
+
+	case nextItem.collapsible:
+		return next, false
+	}
+
+	return nil, false
+}
+
+func (s *Splitter) toggleAtHandle(handle *splitterHandle) {
+	pane, _ := s.toggleTarget(handle)
+	if pane == nil {
+		return
+	}
+
+	item := s.layout.(*splitterLayout).hwnd2Item[pane.Handle()]
+
+	s.setCollapsed(pane, item, !item.collapsed)
+}
+
+// handleButtonRect returns the bounds of the collapse button in the center
+// of handle in native pixels.
+func (s *Splitter) handleButtonRect(handle *splitterHandle) Rectangle {
+	cb := handle.ClientBoundsPixels()
+	length := handle.IntFrom96DPI(splitterHandleButtonLength96)
+
+	if s.Orientation() == Horizontal {
+		return Rectangle{0, (cb.Height - length) / 2, cb.Width, length}
+	}
+
+	return Rectangle{(cb.Width - length) / 2, 0, length, cb.Height}
+}
+
+func (s *Splitter) handleButtonHit(handle *splitterHandle, x, y int) bool {
+	if pane, _ := s.toggleTarget(handle); pane == nil {
+		return false
+	}
+
+	r := s.handleButtonRect(handle)
+
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+func (s *Splitter) invalidateHandles() {
+	for i, wb := range s.children.items {
+		if i%2 == 1 {
+			wb.window.(*splitterHandle).Invalidate()
+		}
+	}
+}
+
+func (s *Splitter) paintHandle(handle *splitterHandle, canvas *Canvas) error {
+	if handle.Focused() {
+		rc := handle.ClientBoundsPixels().toRECT()
+		user32.DrawFocusRect(canvas.HDC(), &rc)
+	}
+
+	pane, before := s.toggleTarget(handle)
+	if pane == nil {
+		return nil
+	}
+
+	r := s.handleButtonRect(handle)
+
+	if err := canvas.FillRectanglePixels(splitterHandleDraggingBrush, r); err != nil {
+		return err
+	}
+
+	arrowBrush, err := NewSystemColorBrush(SysColorBtnHighlight)
+	if err != nil {
+		return err
+	}
+
+	pen, err := NewCosmeticPen(PenSolid, arrowBrush.Color())
+	if err != nil {
+		return err
+	}
+	defer pen.Dispose()
+
+	// The arrow points in the direction the pane moves its handle to when
+	// toggled, i.e. towards the pane when collapsing it.
+	backwards := before != s.Collapsed(pane)
+
+	cx, cy := r.X+r.Width/2, r.Y+r.Height/2
+
+	var points []Point
+	if s.Orientation() == Horizontal {
+		a := maxi(1, (r.Width-1)/2)
+		d := a / 2
+		if backwards {
+			points = []Point{{cx + d, cy - a}, {cx - d, cy}, {cx + d, cy + a}}
+		} else {
+			points = []Point{{cx - d, cy - a}, {cx + d, cy}, {cx - d, cy + a}}
+		}
+	} else {
+		a := maxi(1, (r.Height-1)/2)
+		d := a / 2
+		if backwards {
+			points = []Point{{cx - a, cy + d}, {cx, cy - d}, {cx + a, cy + d}}
+		} else {
+			points = []Point{{cx - a, cy - d}, {cx, cy + d}, {cx + a, cy - d}}
+		}
+	}
+
+	return canvas.DrawPolylinePixels(pen, points)
+}
+
+// onHandleKeyDown moves handle with the arrow keys, see
+// SetHandlesFocusable.
+func (s *Splitter) onHandleKeyDown(handle *splitterHandle, key Key) {
+	step := handle.IntFrom96DPI(splitterKeyboardStep96)
+	if ShiftDown() {
+		step = 1
+	}
+
+	index := s.children.Index(handle)
+	prev := s.closestVisibleWidget(index, -1)
+	next := s.closestVisibleWidget(index, 1)
+	if prev == nil || next == nil {
+		return
+	}
+
+	horizontal := s.Orientation() == Horizontal
+
+	var delta int
+	switch key {
+	case KeyLeft:
+		if horizontal {
+			delta = -step
+		}
+
+	case KeyRight:
+		if horizontal {
+			delta = step
+		}
+
+	case KeyUp:
+		if !horizontal {
+			delta = -step
+		}
+
+	case KeyDown:
+		if !horizontal {
+			delta = step
+		}
+
+	case KeyHome:
+		delta = -s.paneSize(prev)
+
+	case KeyEnd:
+		delta = s.paneSize(next)
+
+	case KeySpace:
+		s.toggleAtHandle(handle)
+	}
+
+	if delta == 0 {
+		return
+	}
+
+	layout := s.layout.(*splitterLayout)
+	prevItem := layout.hwnd2Item[prev.Handle()]
+	nextItem := layout.hwnd2Item[next.Handle()]
+
+	// Moving the handle away from a collapsed pane expands it.
+	if delta > 0 && prevItem.collapsed {
+		s.setCollapsed(prev, prevItem, false)
+		return
+	}
+	if delta < 0 && nextItem.collapsed {
+		s.setCollapsed(next, nextItem, false)
+		return
+	}
+
+	s.finishAnimation()
+
+	oldPrev, oldNext := s.paneSize(prev), s.paneSize(next)
+	total := oldPrev + oldNext
+
+	lo, hi := s.handleRange(prev, next, 0, total)
+
+	size := oldPrev + delta
+	if size > hi {
+		size = hi
+	}
+	if size < lo {
+		size = lo
+	}
+	if size == oldPrev {
+		return
+	}
+
+	s.resizePanes(prev, next, size, total, oldPrev, oldNext)
+
+	s.RequestLayout()
+}
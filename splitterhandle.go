@@ -16,7 +16,7 @@ const splitterHandleWindowClass = `\o/ Walk_SplitterHandle_Class \o/`
 
 func init() {
 	AppendToWalkInit(func() {
-		MustRegisterWindowClass(splitterHandleWindowClass)
+		MustRegisterWindowClassWithStyle(splitterHandleWindowClass, user32.CS_DBLCLKS)
 	})
 }
 
@@ -26,7 +26,7 @@ type splitterHandle struct {
 
 func newSplitterHandle(splitter *Splitter) (*splitterHandle, error) {
 	if splitter == nil {
-		return nil, errs.NewError("splitter cannot be nil")
+		return nil, errs.NewInvalidArgumentError("splitter cannot be nil")
 	}
 
 	sh := new(splitterHandle)
@@ -61,11 +61,34 @@ func (sh *splitterHandle) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam u
 		if sh.Background() == nullBrushSingleton {
 			var ps user32.PAINTSTRUCT
 
-			user32.BeginPaint(hwnd, &ps)
+			hdc := user32.BeginPaint(hwnd, &ps)
 			defer user32.EndPaint(hwnd, &ps)
 
+			if splitter, ok := sh.Parent().(*Splitter); ok {
+				if canvas, err := newCanvasFromHDC(hdc); err == nil {
+					defer canvas.Dispose()
+
+					splitter.paintHandle(sh, canvas)
+				}
+			}
+
+			return 0
+		}
+
+	case user32.WM_LBUTTONDBLCLK:
+		x := int(user32.GET_X_LPARAM(lParam))
+		y := int(user32.GET_Y_LPARAM(lParam))
+
+		if splitter, ok := sh.Parent().(*Splitter); ok && !splitter.handleButtonHit(sh, x, y) {
+			splitter.toggleAtHandle(sh)
 			return 0
 		}
+
+		// A double-click on the button counts as two clicks.
+		return sh.WndProc(hwnd, user32.WM_LBUTTONDOWN, wParam, lParam)
+
+	case user32.WM_GETDLGCODE:
+		return user32.DLGC_WANTARROWS | user32.DLGC_WANTCHARS
 	}
 
 	return sh.WidgetBase.WndProc(hwnd, msg, wParam, lParam)
@@ -102,7 +125,7 @@ func (li *splitterHandleLayoutItem) LayoutFlags() LayoutFlags {
 
 func (li *splitterHandleLayoutItem) IdealSize() Size {
 	var size Size
-	dpi := int(user32.GetDpiForWindow(li.handle))
+	dpi := dpiForHWnd(li.handle)
 
 	if li.orientation == Horizontal {
 		size.Width = IntFrom96DPI(li.handleWidth, dpi)
@@ -31,6 +31,40 @@ type splitterLayoutItem struct {
 	fixed                bool
 	keepSize             bool
 	wasVisible           bool
+	collapsible          bool
+	collapsed            bool
+	animating            bool
+	animationSize        int // in native pixels
+	expandedSize         int // in native pixels
+	minRatio             float64
+	maxRatio             float64
+}
+
+// forcedSize reports whether the size of the item is dictated by its
+// collapsed state or a running collapse animation, rather than by the layout.
+func (sli *splitterLayoutItem) forcedSize() (size int, forced bool) {
+	if sli.animating {
+		return sli.animationSize, true
+	}
+	if sli.collapsed {
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// ratioLimits returns the minimum and maximum size of the item in native
+// pixels, as specified by its size ratio limits relative to space. A maximum
+// of 0 means unlimited.
+func (sli *splitterLayoutItem) ratioLimits(space int) (min, max int) {
+	if sli.minRatio > 0 {
+		min = int(sli.minRatio * float64(space))
+	}
+	if sli.maxRatio > 0 {
+		max = int(sli.maxRatio * float64(space))
+	}
+
+	return
 }
 
 func newSplitterLayout(orientation Orientation) *splitterLayout {
@@ -81,7 +115,7 @@ func (l *splitterLayout) Spacing() int {
 }
 
 func (l *splitterLayout) SetSpacing(value int) error {
-	return errs.NewError("not supported")
+	return errs.NewNotSupportedError("not supported")
 }
 
 func (l *splitterLayout) Orientation() Orientation {
@@ -94,7 +128,7 @@ func (l *splitterLayout) SetOrientation(value Orientation) error {
 		case Horizontal, Vertical:
 
 		default:
-			return errs.NewError("invalid Orientation value")
+			return errs.NewInvalidArgumentError("invalid Orientation value")
 		}
 
 		l.orientation = value
@@ -122,11 +156,11 @@ func (l *splitterLayout) StretchFactor(widget Widget) int {
 func (l *splitterLayout) SetStretchFactor(widget Widget, factor int) error {
 	if factor != l.StretchFactor(widget) {
 		if factor < 1 {
-			return errs.NewError("factor must be >= 1")
+			return errs.NewInvalidArgumentError("factor must be >= 1")
 		}
 
 		if l.container == nil {
-			return errs.NewError("container required")
+			return errs.NewInvalidArgumentError("container required")
 		}
 
 		item := l.hwnd2Item[widget.Handle()]
@@ -231,9 +265,16 @@ func (li *splitterContainerLayoutItem) MinSizeForSize(size Size) Size {
 			continue
 		}
 
+		sli, ok := li.hwnd2Item[item.Handle()]
+		if ok {
+			if _, forced := sli.forcedSize(); forced {
+				continue
+			}
+		}
+
 		var cur Size
 
-		if sli, ok := li.hwnd2Item[item.Handle()]; ok && li.anyNonFixed && sli.fixed {
+		if ok && li.anyNonFixed && sli.fixed {
 			cur = item.Geometry().Size
 
 			if li.orientation == Horizontal {
@@ -301,6 +342,12 @@ func (li *splitterContainerLayoutItem) PerformLayout() []LayoutResultItem {
 		if i%2 == 0 {
 			slItem := li.hwnd2Item[item.Handle()]
 
+			if size, forced := slItem.forcedSize(); forced {
+				totalRegularSize += size
+				sizes[i] = size
+				continue
+			}
+
 			var wi *WidgetItem
 
 			if !anyNonFixed || !slItem.fixed {
@@ -372,6 +419,21 @@ func (li *splitterContainerLayoutItem) PerformLayout() []LayoutResultItem {
 				}
 			}
 
+			if wi != nil {
+				rmin, rmax := slItem.ratioLimits(space1)
+				if rmin > 0 {
+					wi.min = maxi(wi.min, rmin)
+					size = maxi(size, rmin)
+				}
+				if rmax > 0 {
+					if wi.max == 0 || wi.max > rmax {
+						wi.max = rmax
+					}
+					size = mini(size, rmax)
+				}
+				slItem.size = size
+			}
+
 			totalRegularSize += size
 			sizes[i] = size
 		} else {
@@ -524,6 +586,8 @@ func (li *splitterContainerLayoutItem) reset() {
 
 		if sli := li.hwnd2Item[item.Handle()]; sli == nil {
 			li.hwnd2Item[item.Handle()] = &splitterLayoutItem{stretchFactor: 1}
+		} else if _, forced := sli.forcedSize(); forced {
+			continue
 		}
 
 		stretchTotal += li.StretchFactor(item)
@@ -537,6 +601,10 @@ func (li *splitterContainerLayoutItem) reset() {
 		sli := li.hwnd2Item[item.Handle()]
 		sli.growth = 0
 		sli.keepSize = false
+		if size, forced := sli.forcedSize(); forced {
+			sli.size = size
+			continue
+		}
 		if sli.oldExplicitSize > 0 {
 			sli.size = sli.oldExplicitSize
 		} else {
@@ -8,20 +8,36 @@ package winapi
 
 import (
 	"syscall"
+	"time"
 	"unsafe"
 
 	"github.com/Gipcomp/win32/comctl32"
 	"github.com/Gipcomp/win32/commctrl"
+	"github.com/Gipcomp/win32/gdi32"
 	"github.com/Gipcomp/win32/handle"
 	"github.com/Gipcomp/win32/user32"
 	"github.com/Gipcomp/win32/win"
 	"github.com/Gipcomp/winapi/errs"
 )
 
+const (
+	statusBarSpinnerTimerId = 1 + iota
+)
+
+const (
+	statusBarSpinnerInterval = 120 // in milliseconds
+	statusBarItemPadding96   = 10
+	statusBarIconSize96      = 16
+)
+
+var statusBarSpinnerFrames = []rune{'◐', '◓', '◑', '◒'}
+
 // StatusBar is a widget that displays status messages.
 type StatusBar struct {
 	WidgetBase
-	items *StatusBarItemList
+	items        *StatusBarItemList
+	spinnerFrame int
+	spinning     bool
 }
 
 // NewStatusBar returns a new StatusBar as child of container parent.
@@ -60,7 +76,32 @@ func (sb *StatusBar) ApplyDPI(dpi int) {
 	sb.update()
 }
 
+// Dispose disposes the StatusBar together with the widgets embedded in its
+// items.
+func (sb *StatusBar) Dispose() {
+	if sb.items != nil {
+		for _, item := range sb.items.items {
+			if item.progressBar != nil {
+				item.progressBar.Dispose()
+				item.progressBar = nil
+			}
+			if item.widget != nil {
+				item.widget.Dispose()
+				item.widget = nil
+			}
+		}
+	}
+
+	sb.WidgetBase.Dispose()
+}
+
 func (sb *StatusBar) update() error {
+	for _, item := range sb.items.items {
+		if err := item.embed(); err != nil {
+			return err
+		}
+	}
+
 	if err := sb.updateParts(); err != nil {
 		return err
 	}
@@ -71,11 +112,112 @@ func (sb *StatusBar) update() error {
 		}
 	}
 
+	sb.updateSpinnerTimer()
+
 	sb.SetVisible(sb.items.Len() > 0)
 
 	return nil
 }
 
+// embedWidget makes widget a child of the StatusBar window. Like the
+// StatusBar of a MainWindow, widget keeps its parent but is no longer
+// contained in its Children, so it is not laid out by the parent.
+func (sb *StatusBar) embedWidget(widget Widget) error {
+	wb := widget.AsWidgetBase()
+
+	if user32.GetParent(wb.hWnd) != sb.hWnd {
+		if parent := widget.Parent(); parent != nil && parent.Children() != nil {
+			wb.parent = nil
+			if err := parent.Children().Remove(widget); err != nil {
+				wb.parent = parent
+				return err
+			}
+		}
+
+		wb.parent = sb.parent
+
+		if err := wb.setAndClearStyleBits(user32.WS_CHILD, user32.WS_POPUP); err != nil {
+			return err
+		}
+
+		if user32.SetParent(wb.hWnd, sb.hWnd) == 0 {
+			return errs.LastError("SetParent")
+		}
+	}
+
+	widget.SetVisible(true)
+
+	return nil
+}
+
+// partBounds returns the bounds of the part at index in native pixels.
+func (sb *StatusBar) partBounds(index int) (Rectangle, bool) {
+	var rc gdi32.RECT
+	if 0 == sb.SendMessage(commctrl.SB_GETRECT, uintptr(index), uintptr(unsafe.Pointer(&rc))) {
+		return Rectangle{}, false
+	}
+
+	return rectangleFromRECT(rc), true
+}
+
+// layoutWidgets moves the embedded widgets of the items into their parts.
+func (sb *StatusBar) layoutWidgets() {
+	inset := sb.IntFrom96DPI(1)
+
+	for i, item := range sb.items.items {
+		widget := item.embeddedWidget()
+		if widget == nil {
+			continue
+		}
+
+		if b, ok := sb.partBounds(i); ok {
+			widget.SetBoundsPixels(Rectangle{b.X + inset, b.Y + inset, b.Width - 2*inset, b.Height - 2*inset})
+		}
+	}
+}
+
+// updateSpinnerTimer starts or stops the timer that animates the spinners of
+// busy spinner items.
+func (sb *StatusBar) updateSpinnerTimer() {
+	var busy bool
+	for _, item := range sb.items.items {
+		if item.kind == StatusBarItemSpinner && item.busy {
+			busy = true
+			break
+		}
+	}
+
+	if busy == sb.spinning {
+		return
+	}
+
+	if busy {
+		if user32.SetTimer(sb.hWnd, statusBarSpinnerTimerId, statusBarSpinnerInterval, 0) == 0 {
+			errs.LastError("SetTimer")
+			return
+		}
+	} else {
+		user32.KillTimer(sb.hWnd, statusBarSpinnerTimerId)
+	}
+
+	sb.spinning = busy
+}
+
+func (sb *StatusBar) itemAtCursor() *StatusBarItem {
+	var pt gdi32.POINT
+	if !user32.GetCursorPos(&pt) || !user32.ScreenToClient(sb.hWnd, &pt) {
+		return nil
+	}
+
+	for i, item := range sb.items.items {
+		if b, ok := sb.partBounds(i); ok && int(pt.X) >= b.X && int(pt.X) < b.X+b.Width && int(pt.Y) >= b.Y && int(pt.Y) < b.Y+b.Height {
+			return item
+		}
+	}
+
+	return nil
+}
+
 func (sb *StatusBar) updateParts() error {
 	items := sb.items.items
 
@@ -84,7 +226,7 @@ func (sb *StatusBar) updateParts() error {
 	rightEdges := make([]int32, len(items))
 	var right int32
 	for i, item := range items {
-		right += int32(IntFrom96DPI(item.width, dpi))
+		right += int32(item.widthPixels(dpi))
 		rightEdges[i] = right
 	}
 	var rep *int32
@@ -104,6 +246,8 @@ func (sb *StatusBar) updateParts() error {
 		return errs.NewError("SB_SETPARTS")
 	}
 
+	sb.layoutWidgets()
+
 	return nil
 }
 
@@ -112,6 +256,14 @@ func (sb *StatusBar) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintpt
 	case user32.WM_NOTIFY:
 		nmhdr := (*user32.NMHDR)(unsafe.Pointer(lParam))
 
+		if nmhdr.HwndFrom != sb.hWnd {
+			// Sent by an embedded widget.
+			if window := windowFromHandle(nmhdr.HwndFrom); window != nil {
+				return window.WndProc(hwnd, msg, wParam, lParam)
+			}
+			break
+		}
+
 		switch nmhdr.Code {
 		case comctl32.NM_CLICK:
 			lpnm := (*commctrl.NMMOUSE)(unsafe.Pointer(lParam))
@@ -119,6 +271,58 @@ func (sb *StatusBar) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintpt
 				sb.items.At(n).raiseClicked()
 			}
 		}
+
+	case user32.WM_COMMAND:
+		// Sent by an embedded widget.
+		if lParam != 0 {
+			if window := windowFromHandle(handle.HWND(lParam)); window != nil {
+				window.WndProc(hwnd, msg, wParam, lParam)
+				return 0
+			}
+		}
+
+	case user32.WM_DRAWITEM:
+		dis := (*user32.DRAWITEMSTRUCT)(unsafe.Pointer(lParam))
+		if dis.HwndItem != sb.hWnd {
+			break
+		}
+
+		if n := int(dis.ItemID); n >= 0 && n < sb.items.Len() {
+			sb.items.At(n).drawLink(dis)
+		}
+		return 1
+
+	case user32.WM_SETCURSOR:
+		if item := sb.itemAtCursor(); item != nil && item.link {
+			user32.SetCursor(CursorHand().handle())
+			return 1
+		}
+
+	case user32.WM_TIMER:
+		if wParam != statusBarSpinnerTimerId {
+			break
+		}
+
+		sb.spinnerFrame++
+
+		var autoSize bool
+		for i, item := range sb.items.items {
+			if item.kind == StatusBarItemSpinner && item.busy {
+				item.updateText(i)
+				autoSize = autoSize || item.autoSize
+			}
+		}
+		if autoSize {
+			sb.updateParts()
+		}
+		return 0
+
+	case user32.WM_SIZE:
+		result := sb.WidgetBase.WndProc(hwnd, msg, wParam, lParam)
+
+		sb.layoutWidgets()
+
+		return result
 	}
 
 	return sb.WidgetBase.WndProc(hwnd, msg, wParam, lParam)
@@ -140,22 +344,70 @@ func (*statusBarLayoutItem) IdealSize() Size {
 	return Size{}
 }
 
+// StatusBarItemKind specifies what a StatusBarItem shows.
+type StatusBarItemKind int
+
+const (
+	// StatusBarItemText items show an icon and text.
+	StatusBarItemText StatusBarItemKind = iota
+
+	// StatusBarItemProgress items show a ProgressBar.
+	StatusBarItemProgress
+
+	// StatusBarItemSpinner items show an icon and text, preceded by an
+	// animated spinner while busy.
+	StatusBarItemSpinner
+)
+
 // StatusBarItem represents a section of a StatusBar that can have its own icon,
 // text, tool tip text and width.
 type StatusBarItem struct {
 	sb               *StatusBar
+	kind             StatusBarItemKind
 	icon             *Icon
 	text             string
+	messages         []*statusBarMessage
 	toolTipText      string
 	width            int
+	autoSize         bool
+	link             bool
+	busy             bool
+	widget           Widget
+	progressBar      *ProgressBar
+	progressMin      int
+	progressMax      int
+	progressValue    int
 	clickedPublisher EventPublisher
 }
 
+// statusBarMessage is a text pushed onto the message stack of a
+// StatusBarItem.
+type statusBarMessage struct {
+	text string
+}
+
 // NewStatusBarItem returns a new StatusBarItem.
 func NewStatusBarItem() *StatusBarItem {
 	return &StatusBarItem{width: 100}
 }
 
+// NewStatusBarProgressItem returns a new StatusBarItem that shows a
+// ProgressBar with a range of 0 to 100.
+func NewStatusBarProgressItem() *StatusBarItem {
+	return &StatusBarItem{kind: StatusBarItemProgress, width: 150, progressMax: 100}
+}
+
+// NewStatusBarSpinnerItem returns a new StatusBarItem that shows a spinner in
+// front of its text while busy, to indicate background activity.
+func NewStatusBarSpinnerItem() *StatusBarItem {
+	return &StatusBarItem{kind: StatusBarItemSpinner, width: 100}
+}
+
+// Kind returns what the StatusBarItem shows.
+func (sbi *StatusBarItem) Kind() StatusBarItemKind {
+	return sbi.kind
+}
+
 // Icon returns the Icon of the StatusBarItem.
 func (sbi *StatusBarItem) Icon() *Icon {
 	return sbi.icon
@@ -207,6 +459,260 @@ func (sbi *StatusBarItem) SetToolTipText(toolTipText string) error {
 	return sbi.maybeTry(sbi.updateToolTipText, func() { sbi.toolTipText = old })
 }
 
+// PushText shows text instead of the current text, until PopText is called.
+func (sbi *StatusBarItem) PushText(text string) error {
+	_, err := sbi.pushMessage(text)
+
+	return err
+}
+
+// pushMessage pushes text like PushText and returns the message, which can
+// be removed with removeMessage regardless of what was pushed after it.
+func (sbi *StatusBarItem) pushMessage(text string) (*statusBarMessage, error) {
+	msg := &statusBarMessage{text}
+	sbi.messages = append(sbi.messages, msg)
+
+	if err := sbi.maybeTry(sbi.updateText, func() { sbi.messages = sbi.messages[:len(sbi.messages)-1] }); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// PopText removes the text shown by the last call to PushText and shows the
+// previous text again.
+func (sbi *StatusBarItem) PopText() error {
+	if len(sbi.messages) == 0 {
+		return nil
+	}
+
+	old := sbi.messages
+	sbi.messages = sbi.messages[:len(sbi.messages)-1]
+
+	return sbi.maybeTry(sbi.updateText, func() { sbi.messages = old })
+}
+
+// ShowTemporaryText shows text for duration, e.g. "Saved" for 3 seconds,
+// then shows the previous text again. Texts pushed in the meantime stay on
+// top. The StatusBarItem must be contained in a StatusBar.
+func (sbi *StatusBarItem) ShowTemporaryText(text string, duration time.Duration) error {
+	sb := sbi.sb
+	if sb == nil {
+		return errs.NewError("StatusBarItem must be contained in a StatusBar")
+	}
+
+	msg, err := sbi.pushMessage(text)
+	if err != nil {
+		return err
+	}
+
+	time.AfterFunc(duration, func() {
+		sb.Synchronize(func() {
+			sbi.removeMessage(msg)
+		})
+	})
+
+	return nil
+}
+
+func (sbi *StatusBarItem) removeMessage(msg *statusBarMessage) {
+	for i, m := range sbi.messages {
+		if m == msg {
+			sbi.messages = append(sbi.messages[:i:i], sbi.messages[i+1:]...)
+
+			sbi.maybeTry(sbi.updateText, func() {})
+			return
+		}
+	}
+}
+
+// displayText returns the text that is currently shown.
+func (sbi *StatusBarItem) displayText() string {
+	text := sbi.text
+	if n := len(sbi.messages); n > 0 {
+		text = sbi.messages[n-1].text
+	}
+
+	if sbi.kind == StatusBarItemSpinner && sbi.busy && sbi.sb != nil {
+		frame := statusBarSpinnerFrames[sbi.sb.spinnerFrame%len(statusBarSpinnerFrames)]
+		text = string(frame) + " " + text
+	}
+
+	return text
+}
+
+// Busy returns if a spinner item shows its spinner.
+func (sbi *StatusBarItem) Busy() bool {
+	return sbi.busy
+}
+
+// SetBusy sets if a spinner item shows its spinner.
+func (sbi *StatusBarItem) SetBusy(busy bool) error {
+	if sbi.kind != StatusBarItemSpinner {
+		return errs.NewInvalidArgumentError("not a spinner item")
+	}
+
+	if busy == sbi.busy {
+		return nil
+	}
+
+	sbi.busy = busy
+
+	if sbi.sb != nil {
+		sbi.sb.updateSpinnerTimer()
+	}
+
+	return sbi.maybeTry(sbi.updateText, func() { sbi.busy = !busy })
+}
+
+// ProgressBar returns the ProgressBar of a progress item. It is created when
+// the item is added to a StatusBar, so it is nil before.
+func (sbi *StatusBarItem) ProgressBar() *ProgressBar {
+	return sbi.progressBar
+}
+
+// ProgressRange returns the range of the ProgressBar of a progress item.
+func (sbi *StatusBarItem) ProgressRange() (min, max int) {
+	return sbi.progressMin, sbi.progressMax
+}
+
+// SetProgressRange sets the range of the ProgressBar of a progress item.
+func (sbi *StatusBarItem) SetProgressRange(min, max int) error {
+	if sbi.kind != StatusBarItemProgress {
+		return errs.NewInvalidArgumentError("not a progress item")
+	}
+	if max < min {
+		return errs.NewInvalidArgumentError("max must be >= min")
+	}
+
+	sbi.progressMin, sbi.progressMax = min, max
+
+	if sbi.progressBar != nil {
+		sbi.progressBar.SetRange(min, max)
+	}
+
+	return nil
+}
+
+// ProgressValue returns the value of the ProgressBar of a progress item.
+func (sbi *StatusBarItem) ProgressValue() int {
+	if sbi.progressBar != nil {
+		return sbi.progressBar.Value()
+	}
+
+	return sbi.progressValue
+}
+
+// SetProgressValue sets the value of the ProgressBar of a progress item.
+func (sbi *StatusBarItem) SetProgressValue(value int) error {
+	if sbi.kind != StatusBarItemProgress {
+		return errs.NewInvalidArgumentError("not a progress item")
+	}
+
+	sbi.progressValue = value
+
+	if sbi.progressBar != nil {
+		sbi.progressBar.SetValue(value)
+	}
+
+	return nil
+}
+
+// Widget returns the widget embedded in the StatusBarItem.
+func (sbi *StatusBarItem) Widget() Widget {
+	return sbi.widget
+}
+
+// SetWidget embeds widget, e.g. a ComboBox for the zoom level or a
+// PushButton, in the StatusBarItem, where it covers the text. widget must
+// have been created with a parent; it is moved into the StatusBar and
+// disposed together with it.
+func (sbi *StatusBarItem) SetWidget(widget Widget) error {
+	if widget == sbi.widget {
+		return nil
+	}
+
+	if sbi.kind == StatusBarItemProgress {
+		return errs.NewInvalidArgumentError("progress items cannot embed a widget")
+	}
+
+	old := sbi.widget
+	sbi.widget = widget
+
+	if sbi.sb != nil {
+		succeeded := false
+		defer func() {
+			if !succeeded {
+				sbi.widget = old
+			}
+		}()
+
+		if err := sbi.embed(); err != nil {
+			return err
+		}
+
+		if err := sbi.sb.updateParts(); err != nil {
+			return err
+		}
+
+		succeeded = true
+	}
+
+	if old != nil {
+		old.SetVisible(false)
+	}
+
+	return nil
+}
+
+// embeddedWidget returns the widget shown in the part of the StatusBarItem,
+// if any.
+func (sbi *StatusBarItem) embeddedWidget() Widget {
+	if sbi.widget != nil {
+		return sbi.widget
+	}
+	if sbi.progressBar != nil {
+		return sbi.progressBar
+	}
+
+	return nil
+}
+
+// embed creates the ProgressBar of a progress item and moves the embedded
+// widget into the StatusBar.
+func (sbi *StatusBarItem) embed() error {
+	if sbi.kind == StatusBarItemProgress && sbi.progressBar == nil {
+		parent := sbi.sb.Parent()
+		if parent == nil {
+			return errs.NewError("StatusBar must have a parent")
+		}
+
+		pb, err := NewProgressBar(parent)
+		if err != nil {
+			return err
+		}
+
+		pb.SetRange(sbi.progressMin, sbi.progressMax)
+		pb.SetValue(sbi.progressValue)
+
+		sbi.progressBar = pb
+	}
+
+	if widget := sbi.embeddedWidget(); widget != nil {
+		return sbi.sb.embedWidget(widget)
+	}
+
+	return nil
+}
+
+// unembed hides the embedded widget after the StatusBarItem was removed from
+// its StatusBar.
+func (sbi *StatusBarItem) unembed() {
+	if widget := sbi.embeddedWidget(); widget != nil {
+		widget.SetVisible(false)
+	}
+}
+
 // Width returns the width of the StatusBarItem.
 func (sbi *StatusBarItem) Width() int {
 	return sbi.width
@@ -239,6 +745,112 @@ func (sbi *StatusBarItem) SetWidth(width int) error {
 	return nil
 }
 
+// AutoSize returns if the width of the StatusBarItem fits its content.
+func (sbi *StatusBarItem) AutoSize() bool {
+	return sbi.autoSize
+}
+
+// SetAutoSize sets if the width of the StatusBarItem fits its content, i.e.
+// its icon and text or the ideal width of its embedded widget, instead of
+// Width.
+func (sbi *StatusBarItem) SetAutoSize(autoSize bool) error {
+	if autoSize == sbi.autoSize {
+		return nil
+	}
+
+	sbi.autoSize = autoSize
+
+	if sbi.sb != nil {
+		return sbi.sb.updateParts()
+	}
+
+	return nil
+}
+
+// widthPixels returns the effective width of the StatusBarItem in native
+// pixels.
+func (sbi *StatusBarItem) widthPixels(dpi int) int {
+	if !sbi.autoSize {
+		return IntFrom96DPI(sbi.width, dpi)
+	}
+
+	padding := IntFrom96DPI(statusBarItemPadding96, dpi)
+
+	if widget := sbi.embeddedWidget(); widget != nil {
+		if is, ok := createLayoutItemForWidget(widget).(IdealSizer); ok {
+			return is.IdealSize().Width + padding
+		}
+	}
+
+	width := calculateTextSize(sbi.displayText(), sbi.sb.Font(), dpi, 0, sbi.sb.hWnd).Width + padding
+	if sbi.icon != nil {
+		width += IntFrom96DPI(statusBarIconSize96, dpi) + padding/2
+	}
+
+	return width
+}
+
+// Link returns if the StatusBarItem is shown as a clickable link.
+func (sbi *StatusBarItem) Link() bool {
+	return sbi.link
+}
+
+// SetLink sets if the StatusBarItem is shown as a clickable link, with
+// underlined text and a hand cursor. Clicks are published through Clicked.
+func (sbi *StatusBarItem) SetLink(link bool) error {
+	if link == sbi.link {
+		return nil
+	}
+
+	sbi.link = link
+
+	return sbi.maybeTry(sbi.updateText, func() { sbi.link = !link })
+}
+
+// drawLink draws the owner-drawn part of a link item.
+func (sbi *StatusBarItem) drawLink(dis *user32.DRAWITEMSTRUCT) error {
+	canvas, err := newCanvasFromHDC(dis.HDC)
+	if err != nil {
+		return err
+	}
+	defer canvas.Dispose()
+
+	dpi := sbi.sb.DPI()
+	padding := IntFrom96DPI(statusBarItemPadding96, dpi) / 2
+
+	bounds := rectangleFromRECT(dis.RcItem)
+	bounds.X += padding
+	bounds.Width -= padding
+
+	if sbi.icon != nil {
+		size := IntFrom96DPI(statusBarIconSize96, dpi)
+		if err := sbi.icon.drawStretched(dis.HDC, Rectangle{bounds.X, bounds.Y + (bounds.Height-size)/2, size, size}); err != nil {
+			return err
+		}
+
+		bounds.X += size + padding
+		bounds.Width -= size + padding
+	}
+
+	font := sbi.sb.Font()
+	linkFont, err := NewFont(font.Family(), font.PointSize(), font.Style()|FontUnderline)
+	if err != nil {
+		return err
+	}
+
+	linkBrush, err := NewSystemColorBrush(SysColorHotLight)
+	if err != nil {
+		return err
+	}
+
+	return canvas.DrawTextPixels(
+		sbi.displayText(),
+		linkFont,
+		linkBrush.Color(),
+		bounds,
+		TextLeft|TextVCenter|TextSingleLine|TextEndEllipsis)
+}
+
 func (sbi *StatusBarItem) Clicked() *Event {
 	return sbi.clickedPublisher.Event()
 }
@@ -298,19 +910,34 @@ func (sbi *StatusBarItem) updateIcon(index int) error {
 }
 
 func (sbi *StatusBarItem) updateText(index int) error {
-	utf16, err := syscall.UTF16PtrFromString(sbi.text)
-	if err != nil {
-		return err
+	wParam := uintptr(win.MAKEWORD(byte(index), 0))
+	var lParam uintptr
+
+	if sbi.link {
+		// The text is drawn in response to WM_DRAWITEM.
+		wParam |= commctrl.SBT_OWNERDRAW
+		lParam = uintptr(index)
+	} else {
+		utf16, err := syscall.UTF16PtrFromString(sbi.displayText())
+		if err != nil {
+			return err
+		}
+
+		lParam = uintptr(unsafe.Pointer(utf16))
 	}
 
 	if 0 == sbi.sb.SendMessage(
 		commctrl.SB_SETTEXT,
-		uintptr(win.MAKEWORD(byte(index), 0)),
-		uintptr(unsafe.Pointer(utf16))) {
+		wParam,
+		lParam) {
 
 		return errs.NewError("SB_SETTEXT")
 	}
 
+	if sbi.autoSize {
+		return sbi.sb.updateParts()
+	}
+
 	return nil
 }
 
@@ -349,6 +976,14 @@ func (l *StatusBarItemList) Clear() error {
 	old := l.items
 	l.items = l.items[:0]
 
+	defer func() {
+		if len(l.items) == 0 {
+			for _, item := range old {
+				item.unembed()
+			}
+		}
+	}()
+
 	succeeded := false
 	defer func() {
 		if !succeeded {
@@ -383,7 +1018,7 @@ func (l *StatusBarItemList) Contains(item *StatusBarItem) bool {
 
 func (l *StatusBarItemList) Insert(index int, item *StatusBarItem) error {
 	if item.sb != nil {
-		return errs.NewError("item already contained in a StatusBar")
+		return errs.NewInvalidArgumentError("item already contained in a StatusBar")
 	}
 
 	l.items = append(l.items, nil)
@@ -449,5 +1084,7 @@ func (l *StatusBarItemList) RemoveAt(index int) error {
 
 	succeeded = true
 
+	item.unembed()
+
 	return nil
 }
@@ -0,0 +1,270 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gipcomp/win32/kernel32"
+	"github.com/Gipcomp/winapi/errs"
+)
+
+const (
+	defaultSyncStallTimeout      = 5 * time.Second
+	defaultSyncSlowCallThreshold = 200 * time.Millisecond
+)
+
+// SyncDebugKind classifies a SyncDebugReport.
+type SyncDebugKind int
+
+const (
+	// SyncStalled reports a SynchronizeWait call that waited longer than
+	// the stall timeout. Most likely the UI thread is blocked, e.g. waiting
+	// for the goroutine that called SynchronizeWait.
+	SyncStalled SyncDebugKind = iota
+
+	// SyncSlowCall reports a synchronized function that blocked the UI
+	// thread longer than the slow call threshold.
+	SyncSlowCall
+
+	// SyncReentrant reports synchronized functions run by a nested message
+	// loop, e.g. of a modal dialog opened by a synchronized function.
+	SyncReentrant
+
+	// SyncWaitOnUIThread reports a SynchronizeWait call on the UI thread.
+	// Waiting would deadlock, so the function is called directly instead.
+	SyncWaitOnUIThread
+)
+
+func (k SyncDebugKind) String() string {
+	switch k {
+	case SyncStalled:
+		return "stalled"
+
+	case SyncSlowCall:
+		return "slow call"
+
+	case SyncReentrant:
+		return "re-entrant"
+
+	case SyncWaitOnUIThread:
+		return "wait on UI thread"
+	}
+
+	return "unknown"
+}
+
+// SyncDebugReport describes a problem detected in debug mode.
+type SyncDebugReport struct {
+	Kind     SyncDebugKind
+	ThreadID uint32        // UI thread of the affected WindowGroup
+	Duration time.Duration // Time waited or run, if applicable
+	Stack    []byte        // Stack traces of all goroutines for stalls, else of the reporting one
+}
+
+func (r *SyncDebugReport) String() string {
+	if r.Duration > 0 {
+		return fmt.Sprintf("walk: synchronize %s on thread %d after %v\n%s", r.Kind, r.ThreadID, r.Duration, r.Stack)
+	}
+
+	return fmt.Sprintf("walk: synchronize %s on thread %d\n%s", r.Kind, r.ThreadID, r.Stack)
+}
+
+// SyncDebugOptions configure the debug mode of Synchronize and its variants.
+type SyncDebugOptions struct {
+	// StallTimeout is how long SynchronizeWait waits before reporting a
+	// stall. Zero means 5 seconds.
+	StallTimeout time.Duration
+
+	// SlowCallThreshold is how long a synchronized function may run before
+	// it is reported as slow. Zero means 200 milliseconds.
+	SlowCallThreshold time.Duration
+
+	// Report is called with each report, possibly from a goroutine other
+	// than the UI thread. Nil means the report is logged.
+	Report func(report *SyncDebugReport)
+}
+
+func (o *SyncDebugOptions) stallTimeout() time.Duration {
+	if o.StallTimeout > 0 {
+		return o.StallTimeout
+	}
+
+	return defaultSyncStallTimeout
+}
+
+func (o *SyncDebugOptions) slowCallThreshold() time.Duration {
+	if o.SlowCallThreshold > 0 {
+		return o.SlowCallThreshold
+	}
+
+	return defaultSyncSlowCallThreshold
+}
+
+var syncDebug struct {
+	mutex   sync.RWMutex
+	options *SyncDebugOptions
+}
+
+// SyncDebug returns the options of the debug mode of Synchronize, or nil if
+// the debug mode is disabled.
+func SyncDebug() *SyncDebugOptions {
+	syncDebug.mutex.RLock()
+	defer syncDebug.mutex.RUnlock()
+
+	return syncDebug.options
+}
+
+// SetSyncDebug enables the debug mode of Synchronize with options, or
+// disables it if options is nil.
+//
+// In debug mode, stalled SynchronizeWait calls, slow synchronized functions,
+// synchronized functions run by nested message loops and SynchronizeWait
+// calls on the UI thread are reported.
+func SetSyncDebug(options *SyncDebugOptions) {
+	syncDebug.mutex.Lock()
+	defer syncDebug.mutex.Unlock()
+
+	syncDebug.options = options
+}
+
+// reportSync reports a problem of kind if the debug mode is enabled. The
+// stack traces of all goroutines are included if allStacks is true.
+func (g *WindowGroup) reportSync(kind SyncDebugKind, duration time.Duration, allStacks bool) {
+	options := SyncDebug()
+	if options == nil {
+		return
+	}
+
+	report := &SyncDebugReport{
+		Kind:     kind,
+		ThreadID: g.threadID,
+		Duration: duration,
+		Stack:    stackTrace(allStacks),
+	}
+
+	if options.Report != nil {
+		options.Report(report)
+	} else {
+		log.Print(report)
+	}
+}
+
+func stackTrace(all bool) []byte {
+	buf := make([]byte, 8192)
+
+	for {
+		n := runtime.Stack(buf, all)
+		if n < len(buf) {
+			return buf[:n]
+		}
+
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// onThread returns if the caller runs on the group's thread.
+func (g *WindowGroup) onThread() bool {
+	return kernel32.GetCurrentThreadId() == g.threadID
+}
+
+const (
+	syncCallQueued int32 = iota
+	syncCallRunning
+	syncCallAbandoned
+)
+
+// syncCall is a function queued by SynchronizeWait.
+type syncCall struct {
+	state int32
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func (c *syncCall) run(f func() (interface{}, error)) {
+	if !atomic.CompareAndSwapInt32(&c.state, syncCallQueued, syncCallRunning) {
+		return
+	}
+
+	defer close(c.done)
+
+	defer func() {
+		if x := recover(); x != nil {
+			c.err = errs.NewErrorNoPanic(fmt.Sprintf("synchronized function panicked: %v", x))
+
+			panic(x)
+		}
+	}()
+
+	c.value, c.err = f()
+}
+
+// abandon keeps the function from running if it did not start yet.
+func (c *syncCall) abandon() {
+	atomic.CompareAndSwapInt32(&c.state, syncCallQueued, syncCallAbandoned)
+}
+
+// synchronizeWait queues f, calls post to wake up the group's thread and
+// waits for f to return or ctx to be done.
+func (g *WindowGroup) synchronizeWait(ctx context.Context, post func(), f func() (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if g.onThread() {
+		g.reportSync(SyncWaitOnUIThread, 0, false)
+
+		return f()
+	}
+
+	call := &syncCall{done: make(chan struct{})}
+
+	g.enqueue(nil, func() {
+		call.run(f)
+	})
+	post()
+
+	var stall <-chan time.Time
+	if options := SyncDebug(); options != nil {
+		timer := time.NewTimer(options.stallTimeout())
+		defer timer.Stop()
+
+		stall = timer.C
+	}
+
+	start := time.Now()
+
+	for {
+		select {
+		case <-call.done:
+			return call.value, call.err
+
+		case <-ctx.Done():
+			call.abandon()
+
+			select {
+			case <-call.done:
+				return call.value, call.err
+
+			default:
+				return nil, ctx.Err()
+			}
+
+		case <-stall:
+			stall = nil
+
+			g.reportSync(SyncStalled, time.Since(start), true)
+		}
+	}
+}
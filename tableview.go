@@ -1579,7 +1579,7 @@ func (tv *TableView) StretchLastColumn() error {
 
 	if lp > 0 {
 		if user32.SendMessage(hwnd, commctrl.LVM_SETCOLUMNWIDTH, uintptr(colCount-1), lp) == 0 {
-			return errs.NewError("LVM_SETCOLUMNWIDTH failed")
+			return errs.NewWin32Error("LVM_SETCOLUMNWIDTH")
 		}
 
 		if dpi := tv.DPI(); dpi != tv.dpiOfPrevStretchLastColumn {
@@ -75,7 +75,7 @@ func (l *TableViewColumnList) Contains(item *TableViewColumn) bool {
 // same time.
 func (l *TableViewColumnList) Insert(index int, item *TableViewColumn) error {
 	if item.tv != nil {
-		return errs.NewError("duplicate insert")
+		return errs.NewInvalidArgumentError("duplicate insert")
 	}
 
 	item.tv = l.tv
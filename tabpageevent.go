@@ -0,0 +1,117 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+type tabPageCancelEventHandlerInfo struct {
+	handler TabPageCancelEventHandler
+	once    bool
+}
+
+type TabPageCancelEventHandler func(page *TabPage, canceled *bool)
+
+type TabPageCancelEvent struct {
+	handlers []tabPageCancelEventHandlerInfo
+}
+
+func (e *TabPageCancelEvent) Attach(handler TabPageCancelEventHandler) int {
+	handlerInfo := tabPageCancelEventHandlerInfo{handler, false}
+
+	for i, h := range e.handlers {
+		if h.handler == nil {
+			e.handlers[i] = handlerInfo
+			return i
+		}
+	}
+
+	e.handlers = append(e.handlers, handlerInfo)
+
+	return len(e.handlers) - 1
+}
+
+func (e *TabPageCancelEvent) Detach(handle int) {
+	e.handlers[handle].handler = nil
+}
+
+func (e *TabPageCancelEvent) Once(handler TabPageCancelEventHandler) {
+	i := e.Attach(handler)
+	e.handlers[i].once = true
+}
+
+type TabPageCancelEventPublisher struct {
+	event TabPageCancelEvent
+}
+
+func (p *TabPageCancelEventPublisher) Event() *TabPageCancelEvent {
+	return &p.event
+}
+
+func (p *TabPageCancelEventPublisher) Publish(page *TabPage, canceled *bool) {
+	for i, h := range p.event.handlers {
+		if h.handler != nil {
+			h.handler(page, canceled)
+
+			if h.once {
+				p.event.Detach(i)
+			}
+		}
+	}
+}
+
+type tabPageEventHandlerInfo struct {
+	handler TabPageEventHandler
+	once    bool
+}
+
+type TabPageEventHandler func(page *TabPage)
+
+type TabPageEvent struct {
+	handlers []tabPageEventHandlerInfo
+}
+
+func (e *TabPageEvent) Attach(handler TabPageEventHandler) int {
+	handlerInfo := tabPageEventHandlerInfo{handler, false}
+
+	for i, h := range e.handlers {
+		if h.handler == nil {
+			e.handlers[i] = handlerInfo
+			return i
+		}
+	}
+
+	e.handlers = append(e.handlers, handlerInfo)
+
+	return len(e.handlers) - 1
+}
+
+func (e *TabPageEvent) Detach(handle int) {
+	e.handlers[handle].handler = nil
+}
+
+func (e *TabPageEvent) Once(handler TabPageEventHandler) {
+	i := e.Attach(handler)
+	e.handlers[i].once = true
+}
+
+type TabPageEventPublisher struct {
+	event TabPageEvent
+}
+
+func (p *TabPageEventPublisher) Event() *TabPageEvent {
+	return &p.event
+}
+
+func (p *TabPageEventPublisher) Publish(page *TabPage) {
+	for i, h := range p.event.handlers {
+		if h.handler != nil {
+			h.handler(page)
+
+			if h.once {
+				p.event.Detach(i)
+			}
+		}
+	}
+}
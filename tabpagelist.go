@@ -6,13 +6,17 @@
 
 package winapi
 
-import "github.com/Gipcomp/win32/handle"
+import (
+	"github.com/Gipcomp/win32/handle"
+	"github.com/Gipcomp/winapi/errs"
+)
 
 type tabPageListObserver interface {
 	onInsertingPage(index int, page *TabPage) error
 	onInsertedPage(index int, page *TabPage) error
 	onRemovingPage(index int, page *TabPage) error
 	onRemovedPage(index int, page *TabPage) error
+	onMovedPage(from, to int, page *TabPage) error
 	onClearingPages(pages []*TabPage) error
 	onClearedPages(pages []*TabPage) error
 }
@@ -115,6 +119,37 @@ func (l *TabPageList) Len() int {
 	return len(l.items)
 }
 
+// Move moves item to index, without removing it from the TabWidget.
+func (l *TabPageList) Move(item *TabPage, index int) error {
+	from := l.Index(item)
+	if from == -1 {
+		return errs.NewInvalidArgumentError("item not in list")
+	}
+	if index < 0 || index >= len(l.items) {
+		return errs.NewInvalidArgumentError("invalid index")
+	}
+	if index == from {
+		return nil
+	}
+
+	l.move(from, index)
+
+	if l.observer != nil {
+		if err := l.observer.onMovedPage(from, index, item); err != nil {
+			l.move(index, from)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *TabPageList) move(from, to int) {
+	item := l.items[from]
+	l.items = append(l.items[:from], l.items[from+1:]...)
+	l.insertIntoSlice(to, item)
+}
+
 func (l *TabPageList) Remove(item *TabPage) error {
 	index := l.Index(item)
 	if index == -1 {
@@ -7,7 +7,9 @@
 package winapi
 
 import (
+	"encoding/json"
 	"strconv"
+	"strings"
 	"syscall"
 	"unsafe"
 
@@ -40,10 +42,25 @@ type TabWidget struct {
 	currentIndexChangedPublisher EventPublisher
 	nonClientSizePixels          Size
 	persistent                   bool
+	tabsClosable                 bool
+	tabsMovable                  bool
+	tabsDetachable               bool
+	hotCloseIndex                int
+	pressedCloseIndex            int
+	dragIndex                    int
+	dragStart                    gdi32.POINT
+	dragging                     bool
+	hWndOverflow                 handle.HWND
+	overflowing                  bool
+	detachedFrom                 *TabWidget
+	detachedWindow               *MainWindow
+	pageClosingPublisher         TabPageCancelEventPublisher
+	pageClosedPublisher          TabPageEventPublisher
+	pagesReorderedPublisher      EventPublisher
 }
 
 func NewTabWidget(parent Container) (*TabWidget, error) {
-	tw := &TabWidget{currentIndex: -1}
+	tw := &TabWidget{currentIndex: -1, hotCloseIndex: -1, pressedCloseIndex: -1, dragIndex: -1}
 	tw.pages = newTabPageList(tw)
 
 	if err := InitWidget(
@@ -78,7 +95,11 @@ func NewTabWidget(parent Container) (*TabWidget, error) {
 	user32.SetWindowLongPtr(tw.hWndTab, user32.GWLP_USERDATA, uintptr(unsafe.Pointer(tw)))
 	tw.tabOrigWndProcPtr = user32.SetWindowLongPtr(tw.hWndTab, user32.GWLP_WNDPROC, tabWidgetTabWndProcPtr)
 
-	dpi := int(user32.GetDpiForWindow(tw.hWndTab))
+	if err := tw.createOverflowButton(); err != nil {
+		return nil, err
+	}
+
+	dpi := dpiForHWnd(tw.hWndTab)
 	user32.SendMessage(tw.hWndTab, user32.WM_SETFONT, uintptr(defaultFont.handleForDPI(dpi)), 1)
 
 	tw.applyFont(tw.Font())
@@ -124,6 +145,7 @@ func (tw *TabWidget) applyFont(font *Font) {
 	tw.WidgetBase.applyFont(font)
 
 	SetWindowFont(tw.hWndTab, font)
+	SetWindowFont(tw.hWndOverflow, font)
 
 	// FIXME: won't work with ApplyDPI
 	// applyFontToDescendants(tw, font)
@@ -154,9 +176,7 @@ func (tw *TabWidget) ApplyDPI(dpi int) {
 
 	tw.imageList = iml
 
-	for _, page := range tw.pages.items {
-		tw.onPageChanged(page)
-	}
+	tw.applyTabPadding()
 }
 
 func (tw *TabWidget) CurrentIndex() int {
@@ -169,7 +189,7 @@ func (tw *TabWidget) SetCurrentIndex(index int) error {
 	}
 
 	if index < 0 || index >= tw.pages.Len() {
-		return errs.NewError("invalid index")
+		return errs.NewInvalidArgumentError("invalid index")
 	}
 
 	ret := int(user32.SendMessage(tw.hWndTab, commctrl.TCM_SETCURSEL, uintptr(index), 0))
@@ -200,8 +220,33 @@ func (tw *TabWidget) SetPersistent(value bool) {
 	tw.persistent = value
 }
 
+// tabWidgetState is the state saved by TabWidget.SaveState. Pages are
+// identified by name or, if they have none, by title.
+type tabWidgetState struct {
+	CurrentIndex int      `json:"currentIndex"`
+	Pages        []string `json:"pages"`
+}
+
+func tabPageStateID(page *TabPage) string {
+	if name := page.Name(); name != "" {
+		return name
+	}
+
+	return page.Title()
+}
+
 func (tw *TabWidget) SaveState() error {
-	tw.WriteState(strconv.Itoa(tw.CurrentIndex()))
+	tws := tabWidgetState{CurrentIndex: tw.CurrentIndex()}
+	for _, page := range tw.pages.items {
+		tws.Pages = append(tws.Pages, tabPageStateID(page))
+	}
+
+	state, err := json.Marshal(tws)
+	if err != nil {
+		return err
+	}
+
+	tw.WriteState(string(state))
 
 	for _, page := range tw.pages.items {
 		if err := page.SaveState(); err != nil {
@@ -221,11 +266,19 @@ func (tw *TabWidget) RestoreState() error {
 		return nil
 	}
 
-	index, err := strconv.Atoi(state)
-	if err != nil {
+	var tws tabWidgetState
+	if strings.HasPrefix(state, "{") {
+		if err := json.Unmarshal([]byte(state), &tws); err != nil {
+			return err
+		}
+	} else if tws.CurrentIndex, err = strconv.Atoi(state); err != nil {
+		// The state of older versions holds only the index.
 		return err
 	}
-	if index >= 0 && index < tw.pages.Len() {
+
+	tw.restorePageOrder(tws.Pages)
+
+	if index := tws.CurrentIndex; index >= 0 && index < tw.pages.Len() {
 		if err := tw.SetCurrentIndex(index); err != nil {
 			return err
 		}
@@ -261,7 +314,7 @@ func (tw *TabWidget) pageBounds() Rectangle {
 		Y: r.Top,
 	}
 	if !user32.ScreenToClient(tw.hWnd, &p) {
-		errs.NewError("ScreenToClient failed")
+		errs.NewWin32Error("ScreenToClient")
 		return Rectangle{}
 	}
 
@@ -289,6 +342,8 @@ func (tw *TabWidget) onResize(width, height int32) {
 	}
 
 	tw.resizePages()
+
+	tw.updateOverflow()
 }
 
 func (tw *TabWidget) onSelChange() {
@@ -339,6 +394,12 @@ func (tw *TabWidget) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintpt
 
 			tw.onResize(wp.Cx, wp.Cy)
 
+		case user32.WM_COMMAND:
+			if handle.HWND(lParam) == tw.hWndOverflow && win.HIWORD(uint32(wParam)) == user32.BN_CLICKED {
+				tw.showOverflowMenu()
+				return 0
+			}
+
 		case user32.WM_NOTIFY:
 			nmhdr := (*user32.NMHDR)(unsafe.Pointer(lParam))
 
@@ -357,6 +418,10 @@ var tabWidgetTabWndProcPtr uintptr
 func tabWidgetTabWndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr) uintptr {
 	tw := (*TabWidget)(unsafe.Pointer(user32.GetWindowLongPtr(hwnd, user32.GWLP_USERDATA)))
 
+	if tw.handleTabMouse(hwnd, msg, wParam, lParam) {
+		return 0
+	}
+
 	switch msg {
 	case user32.WM_MOUSEMOVE:
 		user32.InvalidateRect(hwnd, nil, true)
@@ -463,7 +528,7 @@ func tabWidgetTabWndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr) u
 				}
 
 				if page.image != nil {
-					x := rc.Left + adjustment.CX
+					x := rc.Left + tw.tabPaddingPixels(dpi)
 					y := rc.Top
 					s := int32(IntFrom96DPI(16, dpi))
 
@@ -484,7 +549,7 @@ func tabWidgetTabWndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr) u
 					}
 				}
 
-				rc.Left += adjustment.CX
+				rc.Left += tw.tabPaddingPixels(dpi)
 				rc.Top += adjustment.CY
 
 				title, err := syscall.UTF16FromString(page.title)
@@ -513,6 +578,8 @@ func tabWidgetTabWndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr) u
 			}
 		}
 
+		tw.drawCloseButtons(canvas)
+
 		if !gdi32.BitBlt(hdc, 0, 0, int32(cb.Width), int32(cb.Height), canvas.hdc, 0, 0, gdi32.SRCCOPY) {
 			break
 		}
@@ -533,6 +600,8 @@ func (tw *TabWidget) onPageChanged(page *TabPage) (err error) {
 
 	tw.updateNonClientSize()
 
+	tw.updateOverflow()
+
 	return nil
 }
 
@@ -573,6 +642,8 @@ func (tw *TabWidget) onInsertedPage(index int, page *TabPage) (err error) {
 
 	tw.resizePages()
 
+	tw.updateOverflow()
+
 	page.tabWidget = tw
 
 	page.applyFont(tw.Font())
@@ -623,6 +694,8 @@ func (tw *TabWidget) onRemovedPage(index int, page *TabPage) (err error) {
 	}
 	tw.onSelChange()
 
+	tw.updateOverflow()
+
 	return
 
 	// FIXME: Either make use of this unreachable code or remove it.
@@ -658,6 +731,8 @@ func (tw *TabWidget) onClearedPages(pages []*TabPage) (err error) {
 	}
 	tw.currentIndex = -1
 
+	tw.updateOverflow()
+
 	tw.Invalidate()
 
 	return nil
@@ -0,0 +1,663 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/commctrl"
+	"github.com/Gipcomp/win32/gdi32"
+	"github.com/Gipcomp/win32/handle"
+	"github.com/Gipcomp/win32/user32"
+	"github.com/Gipcomp/win32/win"
+	"github.com/Gipcomp/winapi/errs"
+)
+
+// Geometry of tab close buttons at 96 dpi.
+const (
+	tabCloseButtonSize96   = 12
+	tabCloseButtonMargin96 = 4
+	tabCloseButtonGlyph96  = 3
+	tabPadding96           = 6
+	tabPaddingClosable96   = 18
+	tabOverflowWidth96     = 20
+)
+
+// TabsClosable returns if tabs have a close button.
+func (tw *TabWidget) TabsClosable() bool {
+	return tw.tabsClosable
+}
+
+// SetTabsClosable sets if tabs have a close button. Clicking it or
+// clicking a tab with the middle mouse button closes the page, see
+// ClosePage.
+func (tw *TabWidget) SetTabsClosable(value bool) {
+	if value == tw.tabsClosable {
+		return
+	}
+
+	tw.tabsClosable = value
+
+	tw.applyTabPadding()
+}
+
+// TabsMovable returns if tabs can be reordered by dragging them.
+func (tw *TabWidget) TabsMovable() bool {
+	return tw.tabsMovable
+}
+
+// SetTabsMovable sets if tabs can be reordered by dragging them.
+func (tw *TabWidget) SetTabsMovable(value bool) {
+	tw.tabsMovable = value
+}
+
+// TabsDetachable returns if tabs can be dragged out into a new window and
+// between TabWidgets.
+func (tw *TabWidget) TabsDetachable() bool {
+	return tw.tabsDetachable
+}
+
+// SetTabsDetachable sets if tabs can be dragged out into a new window and
+// between TabWidgets. Dropping a tab outside of any TabWidget calls
+// DetachPage. Dropping it onto another TabWidget with detachable tabs
+// moves the page there.
+func (tw *TabWidget) SetTabsDetachable(value bool) {
+	tw.tabsDetachable = value
+}
+
+// PageClosing is published before a page is closed by ClosePage. Setting
+// canceled keeps the page.
+func (tw *TabWidget) PageClosing() *TabPageCancelEvent {
+	return tw.pageClosingPublisher.Event()
+}
+
+// PageClosed is published after a page was removed by ClosePage, right
+// before it is disposed.
+func (tw *TabWidget) PageClosed() *TabPageEvent {
+	return tw.pageClosedPublisher.Event()
+}
+
+// PagesReordered is published after a page was moved to another index.
+func (tw *TabWidget) PagesReordered() *Event {
+	return tw.pagesReorderedPublisher.Event()
+}
+
+// ClosePage publishes PageClosing and, unless canceled, removes and
+// disposes page. It reports whether page was closed.
+func (tw *TabWidget) ClosePage(page *TabPage) (bool, error) {
+	if !tw.pages.Contains(page) {
+		return false, errs.NewInvalidArgumentError("page not in TabWidget")
+	}
+
+	var canceled bool
+	tw.pageClosingPublisher.Publish(page, &canceled)
+	if canceled {
+		return false, nil
+	}
+
+	if err := tw.pages.Remove(page); err != nil {
+		return false, err
+	}
+
+	tw.pageClosedPublisher.Publish(page)
+
+	page.Dispose()
+
+	tw.closeIfDetachedAndEmpty()
+
+	return true, nil
+}
+
+// DetachPage moves page into a TabWidget in a new MainWindow at the mouse
+// cursor. The TabWidget has the same tab settings and publishes
+// PageClosing and PageClosed of tw as well. When the MainWindow is closed,
+// its pages move back to tw.
+func (tw *TabWidget) DetachPage(page *TabPage) (*MainWindow, error) {
+	if !tw.pages.Contains(page) {
+		return nil, errs.NewInvalidArgumentError("page not in TabWidget")
+	}
+
+	origin := tw
+	if tw.detachedFrom != nil {
+		origin = tw.detachedFrom
+	}
+
+	size := tw.ClientBoundsPixels().Size()
+
+	mw, err := NewMainWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			mw.Dispose()
+		}
+	}()
+
+	mw.SetPersistent(false)
+
+	layout := NewVBoxLayout()
+	if err := layout.SetMargins(Margins{}); err != nil {
+		return nil, err
+	}
+	if err := mw.SetLayout(layout); err != nil {
+		return nil, err
+	}
+
+	target, err := NewTabWidget(mw)
+	if err != nil {
+		return nil, err
+	}
+
+	target.SetPersistent(false)
+	target.SetTabsClosable(tw.tabsClosable)
+	target.tabsMovable = tw.tabsMovable
+	target.tabsDetachable = tw.tabsDetachable
+	target.detachedFrom = origin
+	target.detachedWindow = mw
+
+	target.PageClosing().Attach(func(page *TabPage, canceled *bool) {
+		origin.pageClosingPublisher.Publish(page, canceled)
+	})
+	target.PageClosed().Attach(func(page *TabPage) {
+		origin.pageClosedPublisher.Publish(page)
+	})
+	target.CurrentIndexChanged().Attach(func() {
+		if i := target.CurrentIndex(); i > -1 {
+			mw.SetTitle(target.pages.At(i).Title())
+		}
+	})
+	mw.Closing().Attach(func(canceled *bool, reason CloseReason) {
+		target.reattachPages()
+	})
+
+	if err := tw.movePageTo(page, target, 0); err != nil {
+		return nil, err
+	}
+
+	if err := mw.SetClientSizePixels(size); err != nil {
+		return nil, err
+	}
+	tw.moveWindowToCursor(mw)
+
+	mw.Show()
+
+	succeeded = true
+
+	return mw, nil
+}
+
+// moveWindowToCursor moves mw so that the mouse cursor is over its title
+// bar.
+func (tw *TabWidget) moveWindowToCursor(mw *MainWindow) {
+	var p gdi32.POINT
+	if !user32.GetCursorPos(&p) {
+		return
+	}
+
+	bounds := mw.BoundsPixels()
+	bounds.X = int(p.X) - tw.IntFrom96DPI(40)
+	bounds.Y = int(p.Y) - tw.IntFrom96DPI(10)
+
+	mw.SetBoundsPixels(bounds)
+}
+
+// movePageTo moves page from tw to target at index and makes it current.
+func (tw *TabWidget) movePageTo(page *TabPage, target *TabWidget, index int) error {
+	if err := tw.pages.Remove(page); err != nil {
+		return err
+	}
+
+	if index > target.pages.Len() {
+		index = target.pages.Len()
+	}
+
+	if err := target.pages.Insert(index, page); err != nil {
+		return err
+	}
+
+	if err := target.SetCurrentIndex(index); err != nil {
+		return err
+	}
+
+	tw.closeIfDetachedAndEmpty()
+
+	return nil
+}
+
+// reattachPages moves the pages of a detached TabWidget back to the one
+// they were detached from.
+func (tw *TabWidget) reattachPages() {
+	origin := tw.detachedFrom
+	if origin == nil || origin.IsDisposed() {
+		return
+	}
+
+	for tw.pages.Len() > 0 {
+		page := tw.pages.At(0)
+
+		if err := tw.pages.Remove(page); err != nil {
+			return
+		}
+		if err := origin.pages.Add(page); err != nil {
+			return
+		}
+	}
+}
+
+func (tw *TabWidget) closeIfDetachedAndEmpty() {
+	if tw.detachedWindow == nil || tw.pages.Len() > 0 {
+		return
+	}
+
+	mw := tw.detachedWindow
+	tw.detachedFrom = nil
+	tw.detachedWindow = nil
+
+	// Not while handling messages of tw.
+	tw.Synchronize(func() {
+		mw.Close()
+	})
+}
+
+func (tw *TabWidget) onMovedPage(from, to int, page *TabPage) error {
+	user32.SendMessage(tw.hWndTab, commctrl.TCM_DELETEITEM, uintptr(from), 0)
+
+	item := tw.tcitemFromPage(page)
+	if idx := int(user32.SendMessage(tw.hWndTab, commctrl.TCM_INSERTITEM, uintptr(to), uintptr(unsafe.Pointer(item)))); idx == -1 {
+		return errs.NewError("SendMessage(TCM_INSERTITEM) failed")
+	}
+
+	oldIndex := tw.currentIndex
+	switch {
+	case tw.currentIndex == from:
+		tw.currentIndex = to
+
+	case from < tw.currentIndex && tw.currentIndex <= to:
+		tw.currentIndex--
+
+	case to <= tw.currentIndex && tw.currentIndex < from:
+		tw.currentIndex++
+	}
+	user32.SendMessage(tw.hWndTab, commctrl.TCM_SETCURSEL, uintptr(tw.currentIndex), 0)
+
+	tw.Invalidate()
+
+	tw.pagesReorderedPublisher.Publish()
+
+	if tw.currentIndex != oldIndex {
+		tw.currentIndexChangedPublisher.Publish()
+	}
+
+	return nil
+}
+
+// restorePageOrder moves the pages identified by ids, see tabPageStateID,
+// to the front in that order.
+func (tw *TabWidget) restorePageOrder(ids []string) {
+	index := 0
+
+	for _, id := range ids {
+		for i := index; i < tw.pages.Len(); i++ {
+			if page := tw.pages.At(i); tabPageStateID(page) == id {
+				if tw.pages.Move(page, index) == nil {
+					index++
+				}
+				break
+			}
+		}
+	}
+}
+
+func (tw *TabWidget) tabPaddingPixels(dpi int) int32 {
+	if tw.tabsClosable {
+		return int32(IntFrom96DPI(tabPaddingClosable96, dpi))
+	}
+
+	return int32(IntFrom96DPI(tabPadding96, dpi))
+}
+
+// applyTabPadding makes room for close buttons and remeasures all tabs.
+func (tw *TabWidget) applyTabPadding() {
+	dpi := tw.DPI()
+
+	padding := win.MAKELONG(uint16(tw.tabPaddingPixels(dpi)), uint16(IntFrom96DPI(3, dpi)))
+	user32.SendMessage(tw.hWndTab, commctrl.TCM_SETPADDING, 0, uintptr(padding))
+
+	for _, page := range tw.pages.items {
+		tw.onPageChanged(page)
+	}
+
+	tw.resizePages()
+	tw.Invalidate()
+}
+
+// tabHitTest returns the index of the tab at p, in tab control
+// coordinates, or -1.
+func (tw *TabWidget) tabHitTest(p gdi32.POINT) int {
+	info := commctrl.TCHITTESTINFO{Pt: p}
+
+	return int(int32(user32.SendMessage(tw.hWndTab, commctrl.TCM_HITTEST, 0, uintptr(unsafe.Pointer(&info)))))
+}
+
+func (tw *TabWidget) closeButtonRect(index int) (gdi32.RECT, bool) {
+	var rc gdi32.RECT
+	if user32.SendMessage(tw.hWndTab, commctrl.TCM_GETITEMRECT, uintptr(index), uintptr(unsafe.Pointer(&rc))) == 0 {
+		return rc, false
+	}
+
+	dpi := tw.DPI()
+	size := int32(IntFrom96DPI(tabCloseButtonSize96, dpi))
+	margin := int32(IntFrom96DPI(tabCloseButtonMargin96, dpi))
+
+	right := rc.Right - margin
+	top := rc.Top + (rc.Bottom-rc.Top-size)/2
+
+	return gdi32.RECT{Left: right - size, Top: top, Right: right, Bottom: top + size}, true
+}
+
+// closeButtonHitTest returns the index of the tab whose close button is
+// at p, in tab control coordinates, or -1.
+func (tw *TabWidget) closeButtonHitTest(p gdi32.POINT) int {
+	if !tw.tabsClosable {
+		return -1
+	}
+
+	index := tw.tabHitTest(p)
+	if index == -1 {
+		return -1
+	}
+
+	rc, ok := tw.closeButtonRect(index)
+	if !ok || p.X < rc.Left || p.X >= rc.Right || p.Y < rc.Top || p.Y >= rc.Bottom {
+		return -1
+	}
+
+	return index
+}
+
+func (tw *TabWidget) drawCloseButtons(canvas *Canvas) {
+	if !tw.tabsClosable {
+		return
+	}
+
+	pen, err := NewCosmeticPen(PenSolid, RGB(96, 96, 96))
+	if err != nil {
+		return
+	}
+	defer pen.Dispose()
+
+	hotBrush, err := NewSolidColorBrush(RGB(218, 218, 218))
+	if err != nil {
+		return
+	}
+	defer hotBrush.Dispose()
+
+	glyph := int32(tw.IntFrom96DPI(tabCloseButtonGlyph96))
+
+	for i := range tw.pages.items {
+		rc, ok := tw.closeButtonRect(i)
+		if !ok {
+			break
+		}
+
+		if i == tw.hotCloseIndex {
+			canvas.FillRectanglePixels(hotBrush, rectangleFromRECT(rc))
+		}
+
+		l, t, r, b := rc.Left+glyph, rc.Top+glyph, rc.Right-glyph, rc.Bottom-glyph
+		canvas.DrawLinePixels(pen, Point{int(l), int(t)}, Point{int(r), int(b)})
+		canvas.DrawLinePixels(pen, Point{int(r - 1), int(t)}, Point{int(l - 1), int(b)})
+	}
+}
+
+// handleTabMouse implements close buttons, middle-click close and tab
+// dragging for the tab control. It reports whether msg was consumed.
+func (tw *TabWidget) handleTabMouse(hwnd handle.HWND, msg uint32, wParam, lParam uintptr) bool {
+	p := gdi32.POINT{X: user32.GET_X_LPARAM(lParam), Y: user32.GET_Y_LPARAM(lParam)}
+
+	switch msg {
+	case user32.WM_LBUTTONDOWN:
+		if index := tw.closeButtonHitTest(p); index > -1 {
+			tw.pressedCloseIndex = index
+			user32.SetCapture(hwnd)
+			return true
+		}
+
+		if tw.tabsMovable || tw.tabsDetachable {
+			tw.dragIndex = tw.tabHitTest(p)
+			tw.dragStart = p
+		}
+
+	case user32.WM_MOUSEMOVE:
+		tw.hotCloseIndex = tw.closeButtonHitTest(p)
+
+		if tw.dragIndex == -1 || wParam&user32.MK_LBUTTON == 0 {
+			break
+		}
+
+		if !tw.dragging {
+			dx, dy := p.X-tw.dragStart.X, p.Y-tw.dragStart.Y
+			if dx < 0 {
+				dx = -dx
+			}
+			if dy < 0 {
+				dy = -dy
+			}
+
+			if dx > user32.GetSystemMetrics(user32.SM_CXDRAG) || dy > user32.GetSystemMetrics(user32.SM_CYDRAG) {
+				tw.dragging = true
+				user32.SetCapture(hwnd)
+			}
+		}
+
+		if tw.dragging && tw.tabsMovable {
+			if index := tw.tabHitTest(p); index > -1 && index != tw.dragIndex {
+				if tw.pages.Move(tw.pages.At(tw.dragIndex), index) == nil {
+					tw.dragIndex = index
+				}
+			}
+		}
+
+	case user32.WM_LBUTTONUP:
+		if index := tw.pressedCloseIndex; index > -1 {
+			tw.pressedCloseIndex = -1
+			user32.ReleaseCapture()
+
+			if tw.closeButtonHitTest(p) == index {
+				tw.closePageLater(tw.pages.At(index))
+			}
+			return true
+		}
+
+		index, dragging := tw.dragIndex, tw.dragging
+		tw.dragIndex = -1
+		tw.dragging = false
+
+		if dragging {
+			user32.ReleaseCapture()
+			tw.endDrag(tw.pages.At(index), p)
+			return true
+		}
+
+	case user32.WM_MBUTTONUP:
+		if !tw.tabsClosable {
+			break
+		}
+
+		if index := tw.tabHitTest(p); index > -1 {
+			tw.closePageLater(tw.pages.At(index))
+			return true
+		}
+
+	case user32.WM_CAPTURECHANGED:
+		if handle.HWND(lParam) != hwnd {
+			tw.pressedCloseIndex = -1
+			tw.dragIndex = -1
+			tw.dragging = false
+		}
+	}
+
+	return false
+}
+
+// closePageLater calls ClosePage once the current message was handled, as
+// closing may dispose tw.
+func (tw *TabWidget) closePageLater(page *TabPage) {
+	tw.Synchronize(func() {
+		tw.ClosePage(page)
+	})
+}
+
+// endDrag moves page, which was dropped at p in tab control coordinates,
+// to the TabWidget there or into a new window.
+func (tw *TabWidget) endDrag(page *TabPage, p gdi32.POINT) {
+	if !tw.tabsDetachable {
+		return
+	}
+
+	user32.ClientToScreen(tw.hWndTab, &p)
+
+	target := tabWidgetAt(p)
+	switch {
+	case target == tw:
+
+	case target != nil:
+		if !target.tabsDetachable {
+			break
+		}
+
+		targetPoint := p
+		user32.ScreenToClient(target.hWndTab, &targetPoint)
+
+		index := target.tabHitTest(targetPoint)
+		if index == -1 {
+			index = target.pages.Len()
+		}
+
+		tw.Synchronize(func() {
+			tw.movePageTo(page, target, index)
+		})
+
+	case tw.detachedWindow != nil && tw.pages.Len() == 1:
+		// Detaching the only page would just replace the window.
+		tw.moveWindowToCursor(tw.detachedWindow)
+
+	default:
+		tw.Synchronize(func() {
+			tw.DetachPage(page)
+		})
+	}
+}
+
+// tabWidgetAt returns the TabWidget at p in screen coordinates, or nil.
+func tabWidgetAt(p gdi32.POINT) *TabWidget {
+	for hwnd := user32.WindowFromPoint(p); hwnd != 0; hwnd = user32.GetParent(hwnd) {
+		if tw, ok := windowFromHandle(hwnd).(*TabWidget); ok {
+			return tw
+		}
+	}
+
+	return nil
+}
+
+func (tw *TabWidget) createOverflowButton() error {
+	className, err := syscall.UTF16PtrFromString("BUTTON")
+	if err != nil {
+		return err
+	}
+	text, err := syscall.UTF16PtrFromString("»")
+	if err != nil {
+		return err
+	}
+
+	tw.hWndOverflow = user32.CreateWindowEx(
+		0, className, text,
+		user32.WS_CHILD|user32.BS_PUSHBUTTON,
+		0, 0, 0, 0, tw.hWnd, 0, 0, nil)
+	if tw.hWndOverflow == 0 {
+		return errs.LastError("CreateWindowEx")
+	}
+
+	return nil
+}
+
+// updateOverflow shows the overflow button if the tabs do not fit.
+func (tw *TabWidget) updateOverflow() {
+	if tw.hWndOverflow == 0 {
+		return
+	}
+
+	var overflowing bool
+	var first, last gdi32.RECT
+	if n := tw.pages.Len(); n > 0 &&
+		user32.SendMessage(tw.hWndTab, commctrl.TCM_GETITEMRECT, 0, uintptr(unsafe.Pointer(&first))) != 0 &&
+		user32.SendMessage(tw.hWndTab, commctrl.TCM_GETITEMRECT, uintptr(n-1), uintptr(unsafe.Pointer(&last))) != 0 {
+
+		var rc gdi32.RECT
+		user32.GetClientRect(tw.hWndTab, &rc)
+
+		overflowing = last.Right-first.Left > rc.Right
+	}
+
+	tw.overflowing = overflowing
+
+	if !overflowing {
+		user32.ShowWindow(tw.hWndOverflow, user32.SW_HIDE)
+		return
+	}
+
+	var rc gdi32.RECT
+	user32.GetClientRect(tw.hWnd, &rc)
+	width := int32(tw.IntFrom96DPI(tabOverflowWidth96))
+
+	user32.SetWindowPos(tw.hWndOverflow, user32.HWND_TOP, rc.Right-width, 0, width, first.Bottom, user32.SWP_SHOWWINDOW)
+}
+
+// showOverflowMenu shows a menu of all pages below the overflow button.
+func (tw *TabWidget) showOverflowMenu() {
+	menu, err := NewMenu()
+	if err != nil {
+		return
+	}
+	defer menu.Dispose()
+
+	actions := make([]*Action, tw.pages.Len())
+	for i, page := range tw.pages.items {
+		action := NewAction()
+		action.SetText(strings.ReplaceAll(page.Title(), "&", "&&"))
+		action.SetChecked(i == tw.currentIndex)
+
+		if err := menu.Actions().Add(action); err != nil {
+			return
+		}
+
+		actions[i] = action
+	}
+
+	var rc gdi32.RECT
+	user32.GetWindowRect(tw.hWndOverflow, &rc)
+
+	actionId := uint16(user32.TrackPopupMenuEx(
+		menu.hMenu,
+		user32.TPM_NOANIMATION|user32.TPM_RETURNCMD|user32.TPM_RIGHTALIGN,
+		rc.Right,
+		rc.Bottom,
+		tw.hWnd,
+		nil))
+
+	for i, action := range actions {
+		if action.id == actionId {
+			tw.SetCurrentIndex(i)
+			break
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Dispatcher runs f, e.g. by queueing it for the UI thread. The functions
+// passed to a Dispatcher must run in the order they were passed.
+type Dispatcher func(f func())
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+const (
+	// Started events are published when a task starts running.
+	Started EventKind = iota
+
+	// Progressed events are published when a running task reported
+	// progress.
+	Progressed
+
+	// Finished events are published when a task finished.
+	Finished
+)
+
+// Event describes a change of a task.
+type Event struct {
+	Kind     EventKind
+	Task     *Task
+	Progress Progress
+	State    State // for Finished events
+	Err      error // for Finished events
+}
+
+// Scheduler runs tasks on goroutines, optionally limiting how many run at
+// once, and publishes their changes to subscribers.
+type Scheduler struct {
+	dispatcher    Dispatcher
+	maxConcurrent int
+
+	mutex      sync.Mutex
+	pending    []*Task
+	running    []*Task
+	active     sync.WaitGroup
+	nextID     int
+	id2Handler map[int]func(Event)
+}
+
+// NewScheduler returns a Scheduler that delivers events through dispatcher
+// and runs at most maxConcurrent tasks at once. A nil dispatcher calls
+// functions directly on the goroutine of the task, maxConcurrent <= 0 means
+// no limit.
+func NewScheduler(dispatcher Dispatcher, maxConcurrent int) *Scheduler {
+	return &Scheduler{
+		dispatcher:    dispatcher,
+		maxConcurrent: maxConcurrent,
+		id2Handler:    make(map[int]func(Event)),
+	}
+}
+
+// Submit submits a task with name and function fn and returns it.
+func (s *Scheduler) Submit(name string, fn Func) *Task {
+	return s.SubmitContext(context.Background(), name, fn)
+}
+
+// SubmitContext submits a task, whose context derives from ctx, with name and
+// function fn and returns it.
+func (s *Scheduler) SubmitContext(ctx context.Context, name string, fn Func) *Task {
+	t := &Task{
+		scheduler: s,
+		name:      name,
+		fn:        fn,
+		done:      make(chan struct{}),
+	}
+	t.ctx, t.cancel = context.WithCancel(ctx)
+
+	s.mutex.Lock()
+	s.active.Add(1)
+	s.pending = append(s.pending, t)
+	s.mutex.Unlock()
+
+	s.startPending()
+
+	return t
+}
+
+// Subscribe attaches handler, which is called through the Dispatcher for each
+// Event, and returns a function that detaches it again.
+func (s *Scheduler) Subscribe(handler func(Event)) (unsubscribe func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := s.nextID
+	s.nextID++
+	s.id2Handler[id] = handler
+
+	return func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		delete(s.id2Handler, id)
+	}
+}
+
+// Tasks returns the pending and running tasks.
+func (s *Scheduler) Tasks() []*Task {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tasks := make([]*Task, 0, len(s.running)+len(s.pending))
+	tasks = append(tasks, s.running...)
+	tasks = append(tasks, s.pending...)
+
+	return tasks
+}
+
+// Busy returns if there are pending or running tasks.
+func (s *Scheduler) Busy() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.running)+len(s.pending) > 0
+}
+
+// CancelAll cancels all pending and running tasks.
+func (s *Scheduler) CancelAll() {
+	for _, t := range s.Tasks() {
+		t.Cancel()
+	}
+}
+
+// Wait waits until all submitted tasks finished.
+func (s *Scheduler) Wait() {
+	s.active.Wait()
+}
+
+func (s *Scheduler) dispatch(f func()) {
+	if s.dispatcher == nil {
+		f()
+		return
+	}
+
+	s.dispatcher(f)
+}
+
+func (s *Scheduler) publish(e Event) {
+	s.mutex.Lock()
+	ids := make([]int, 0, len(s.id2Handler))
+	for id := range s.id2Handler {
+		ids = append(ids, id)
+	}
+	s.mutex.Unlock()
+
+	// Handlers are called in the order they subscribed.
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		s.mutex.Lock()
+		handler := s.id2Handler[id]
+		s.mutex.Unlock()
+
+		if handler != nil {
+			handler(e)
+		}
+	}
+}
+
+// startPending starts pending tasks while there are free slots.
+func (s *Scheduler) startPending() {
+	for {
+		s.mutex.Lock()
+		if len(s.pending) == 0 || s.maxConcurrent > 0 && len(s.running) >= s.maxConcurrent {
+			s.mutex.Unlock()
+			return
+		}
+
+		t := s.pending[0]
+		s.pending = s.pending[1:]
+		s.running = append(s.running, t)
+		s.mutex.Unlock()
+
+		t.mutex.Lock()
+		t.state = Running
+		t.mutex.Unlock()
+
+		s.dispatch(func() {
+			s.publish(Event{Kind: Started, Task: t, Progress: t.Progress()})
+		})
+
+		go s.run(t)
+	}
+}
+
+func (s *Scheduler) run(t *Task) {
+	var err error
+
+	func() {
+		defer func() {
+			if x := recover(); x != nil {
+				err = fmt.Errorf("task: %s panicked: %v", t.name, x)
+			}
+		}()
+
+		err = t.fn(t.ctx, reporter{t})
+	}()
+
+	s.mutex.Lock()
+	for i, r := range s.running {
+		if r == t {
+			s.running = append(s.running[:i], s.running[i+1:]...)
+			break
+		}
+	}
+	s.mutex.Unlock()
+
+	s.finish(t, t.finishState(err), err)
+
+	s.startPending()
+}
+
+// cancelPending finishes t as canceled, if it did not start yet.
+func (s *Scheduler) cancelPending(t *Task) {
+	s.mutex.Lock()
+	found := false
+	for i, p := range s.pending {
+		if p == t {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			found = true
+			break
+		}
+	}
+	s.mutex.Unlock()
+
+	if found {
+		s.finish(t, Canceled, t.ctx.Err())
+	}
+}
+
+func (s *Scheduler) finish(t *Task, state State, err error) {
+	t.mutex.Lock()
+	t.state = state
+	t.err = err
+	progress := t.progress
+	t.mutex.Unlock()
+
+	t.cancel()
+	close(t.done)
+
+	s.dispatch(func() {
+		s.publish(Event{Kind: Finished, Task: t, Progress: progress, State: state, Err: err})
+	})
+
+	s.active.Done()
+}
@@ -0,0 +1,387 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// queue is a Dispatcher that collects functions until they are flushed, like
+// the message queue of a UI thread.
+type queue struct {
+	mutex sync.Mutex
+	funcs []func()
+}
+
+func (q *queue) dispatch(f func()) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.funcs = append(q.funcs, f)
+}
+
+func (q *queue) flush() {
+	for {
+		q.mutex.Lock()
+		funcs := q.funcs
+		q.funcs = nil
+		q.mutex.Unlock()
+
+		if len(funcs) == 0 {
+			return
+		}
+
+		for _, f := range funcs {
+			f()
+		}
+	}
+}
+
+// recorder collects the events published by a Scheduler.
+type recorder struct {
+	mutex  sync.Mutex
+	events []Event
+}
+
+func (r *recorder) handle(e Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) count(kind EventKind) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var n int
+	for _, e := range r.events {
+		if e.Kind == kind {
+			n++
+		}
+	}
+
+	return n
+}
+
+// blocking returns a Func that signals started and then waits for release or
+// its context.
+func blocking(started chan<- struct{}, release <-chan struct{}) Func {
+	return func(ctx context.Context, _ Reporter) error {
+		started <- struct{}{}
+
+		select {
+		case <-release:
+			return nil
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestSchedulerMaxConcurrent(t *testing.T) {
+	s := NewScheduler(nil, 2)
+
+	var mutex sync.Mutex
+	var running, maxRunning int
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 5)
+
+	tasks := make([]*Task, 5)
+	for i := range tasks {
+		tasks[i] = s.Submit("task", func(context.Context, Reporter) error {
+			mutex.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+
+			started <- struct{}{}
+			<-release
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+
+			return nil
+		})
+	}
+
+	<-started
+	<-started
+
+	var states []string
+	for _, task := range tasks {
+		states = append(states, task.State().String())
+	}
+	if got, want := strings.Join(states, " "), "running running pending pending pending"; got != want {
+		t.Errorf("states = %s; want %s", got, want)
+	}
+	if got := len(s.Tasks()); got != 5 {
+		t.Errorf("len(Tasks()) = %d; want 5", got)
+	}
+
+	close(release)
+	s.Wait()
+
+	if maxRunning != 2 {
+		t.Errorf("%d tasks ran at once; want 2", maxRunning)
+	}
+	if s.Busy() {
+		t.Error("Busy() = true after Wait()")
+	}
+	for i, task := range tasks {
+		if task.State() != Succeeded {
+			t.Errorf("task %d: State() = %s; want succeeded", i, task.State())
+		}
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	s := NewScheduler(nil, 1)
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	running := s.Submit("running", blocking(started, release))
+
+	pendingRan := false
+	pending := s.Submit("pending", func(context.Context, Reporter) error {
+		pendingRan = true
+		return nil
+	})
+
+	<-started
+
+	// Canceling a pending task finishes it right away.
+	pending.Cancel()
+	select {
+	case <-pending.Done():
+	default:
+		t.Fatal("canceled pending task is not done")
+	}
+	if pending.State() != Canceled || !errors.Is(pending.Err(), context.Canceled) {
+		t.Errorf("pending task: %s, %v", pending.State(), pending.Err())
+	}
+
+	// Canceling a running task cancels its context.
+	running.Cancel()
+	if err := running.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() = %v; want context.Canceled", err)
+	}
+	if running.State() != Canceled {
+		t.Errorf("State() = %s; want canceled", running.State())
+	}
+
+	s.Wait()
+
+	if pendingRan {
+		t.Error("canceled pending task ran")
+	}
+}
+
+func TestSchedulerCancelAll(t *testing.T) {
+	s := NewScheduler(nil, 1)
+
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+
+	tasks := make([]*Task, 3)
+	for i := range tasks {
+		tasks[i] = s.Submit("task", blocking(started, release))
+	}
+
+	<-started
+	s.CancelAll()
+	s.Wait()
+
+	for i, task := range tasks {
+		if task.State() != Canceled {
+			t.Errorf("task %d: State() = %s; want canceled", i, task.State())
+		}
+	}
+}
+
+func TestSchedulerPanic(t *testing.T) {
+	s := NewScheduler(nil, 0)
+
+	task := s.Submit("explosive", func(context.Context, Reporter) error {
+		panic("boom")
+	})
+
+	err := task.Wait()
+	if task.State() != Failed {
+		t.Errorf("State() = %s; want failed", task.State())
+	}
+	if err == nil || !strings.Contains(err.Error(), "explosive") || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Err() = %v; want it to name the task and the panic", err)
+	}
+}
+
+func TestSchedulerEvents(t *testing.T) {
+	var q queue
+	s := NewScheduler(q.dispatch, 0)
+
+	var r recorder
+	s.Subscribe(r.handle)
+
+	boom := errors.New("boom")
+	for _, err := range []error{nil, nil, boom} {
+		err := err
+		s.Submit("task", func(context.Context, Reporter) error { return err })
+	}
+
+	canceled := s.SubmitContext(context.Background(), "canceled", func(ctx context.Context, _ Reporter) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	canceled.Cancel()
+
+	s.Wait()
+
+	// Nothing is published before the dispatched functions run.
+	if len(r.events) != 0 {
+		t.Fatalf("%d events published before flushing", len(r.events))
+	}
+
+	q.flush()
+
+	if got := r.count(Started); got < 3 || got > 4 {
+		t.Errorf("%d Started events; want 3 or 4", got)
+	}
+	if got := r.count(Finished); got != 4 {
+		t.Errorf("%d Finished events; want 4", got)
+	}
+
+	state2Count := make(map[State]int)
+	for _, e := range r.events {
+		if e.Kind == Finished {
+			state2Count[e.State]++
+
+			if e.State == Failed && e.Err != boom {
+				t.Errorf("Finished event of the failed task has Err %v", e.Err)
+			}
+		}
+	}
+	if state2Count[Succeeded] != 2 || state2Count[Failed] != 1 || state2Count[Canceled] != 1 {
+		t.Errorf("finished states = %v", state2Count)
+	}
+}
+
+func TestSchedulerSubscribe(t *testing.T) {
+	s := NewScheduler(nil, 0)
+
+	var calls []string
+	s.Subscribe(func(e Event) {
+		if e.Kind == Finished {
+			calls = append(calls, "first")
+		}
+	})
+	unsubscribe := s.Subscribe(func(e Event) {
+		if e.Kind == Finished {
+			calls = append(calls, "second")
+		}
+	})
+	s.Subscribe(func(e Event) {
+		if e.Kind == Finished {
+			calls = append(calls, "third")
+		}
+	})
+
+	s.Submit("task", func(context.Context, Reporter) error { return nil }).Wait()
+	s.Wait()
+
+	unsubscribe()
+
+	s.Submit("task", func(context.Context, Reporter) error { return nil }).Wait()
+	s.Wait()
+
+	if got, want := strings.Join(calls, " "), "first second third first third"; got != want {
+		t.Errorf("handlers called as %s; want %s", got, want)
+	}
+}
+
+func TestSchedulerProgressCoalescing(t *testing.T) {
+	var q queue
+	s := NewScheduler(q.dispatch, 0)
+
+	var r recorder
+	s.Subscribe(r.handle)
+
+	task := s.Submit("task", func(_ context.Context, progress Reporter) error {
+		progress.SetTotal(10)
+		for i := 0; i < 10; i++ {
+			progress.Add(1)
+		}
+		progress.SetMessage("done")
+
+		return nil
+	})
+	task.Wait()
+	s.Wait()
+
+	q.flush()
+
+	if got := r.count(Progressed); got != 1 {
+		t.Fatalf("%d Progressed events; want 1", got)
+	}
+
+	want := Progress{Total: 10, Completed: 10, Message: "done"}
+	for _, e := range r.events {
+		if e.Kind == Progressed && e.Progress != want {
+			t.Errorf("Progressed event reports %+v; want %+v", e.Progress, want)
+		}
+		if e.Kind == Finished && e.Progress != want {
+			t.Errorf("Finished event reports %+v; want %+v", e.Progress, want)
+		}
+	}
+
+	var kinds []EventKind
+	for _, e := range r.events {
+		kinds = append(kinds, e.Kind)
+	}
+	if len(kinds) != 3 || kinds[0] != Started || kinds[1] != Progressed || kinds[2] != Finished {
+		t.Errorf("event kinds = %v; want Started, Progressed, Finished", kinds)
+	}
+}
+
+func TestSchedulerProgressAfterDispatch(t *testing.T) {
+	var q queue
+	s := NewScheduler(q.dispatch, 0)
+
+	var r recorder
+	s.Subscribe(r.handle)
+
+	reported := make(chan struct{})
+	step := make(chan struct{})
+	task := s.Submit("task", func(_ context.Context, progress Reporter) error {
+		progress.SetCompleted(1)
+		close(reported)
+		<-step
+		progress.SetCompleted(2)
+
+		return nil
+	})
+
+	// Deliver the first update, so the next one is dispatched separately.
+	<-reported
+	q.flush()
+
+	close(step)
+	task.Wait()
+	s.Wait()
+	q.flush()
+
+	if got := r.count(Progressed); got != 2 {
+		t.Errorf("%d Progressed events; want 2", got)
+	}
+}
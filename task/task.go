@@ -0,0 +1,227 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package task implements the part of running background tasks that does not
+// depend on a user interface: scheduling task functions on goroutines,
+// collecting their progress, cancellation and reporting state changes.
+//
+// State changes are delivered to subscribers through a Dispatcher, which a
+// user interface integration sets up to run functions on its UI thread. With
+// a Dispatcher that calls functions directly, a Scheduler runs headless, e.g.
+// in tests.
+package task
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Func is the function of a task. It should return soon after ctx is done,
+// with ctx.Err() or an error wrapping it.
+type Func func(ctx context.Context, progress Reporter) error
+
+// Reporter receives the progress of a running task. Its methods may be
+// called from any goroutine.
+type Reporter interface {
+	// SetTotal sets the amount of work of the task. A total <= 0 means the
+	// amount is unknown.
+	SetTotal(total int64)
+
+	// SetCompleted sets the amount of work done.
+	SetCompleted(completed int64)
+
+	// Add adds delta to the amount of work done.
+	Add(delta int64)
+
+	// SetMessage sets a short description of what the task currently does.
+	SetMessage(message string)
+}
+
+// Progress is a snapshot of the progress of a task.
+type Progress struct {
+	Total     int64
+	Completed int64
+	Message   string
+}
+
+// Indeterminate returns if the amount of work is unknown.
+func (p Progress) Indeterminate() bool {
+	return p.Total <= 0
+}
+
+// Fraction returns the fraction of work done in the range [0, 1], or 0 if
+// the amount of work is unknown.
+func (p Progress) Fraction() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+
+	f := float64(p.Completed) / float64(p.Total)
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+
+	return f
+}
+
+// State is the state of a task.
+type State int
+
+const (
+	// Pending tasks wait for a free slot of their Scheduler.
+	Pending State = iota
+
+	// Running tasks run their function.
+	Running
+
+	// Succeeded tasks returned without error.
+	Succeeded
+
+	// Failed tasks returned an error or panicked.
+	Failed
+
+	// Canceled tasks were canceled before or while running.
+	Canceled
+)
+
+var stateNames = [...]string{"pending", "running", "succeeded", "failed", "canceled"}
+
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return "invalid"
+	}
+
+	return stateNames[s]
+}
+
+// Finished returns if s is a final state.
+func (s State) Finished() bool {
+	return s >= Succeeded
+}
+
+// Task is a unit of background work submitted to a Scheduler.
+type Task struct {
+	scheduler *Scheduler
+	name      string
+	fn        Func
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+
+	mutex           sync.Mutex
+	state           State
+	progress        Progress
+	err             error
+	progressPending bool // a progress event is waiting to be dispatched
+}
+
+// Name returns the name the task was submitted with.
+func (t *Task) Name() string {
+	return t.name
+}
+
+// State returns the current state of the task.
+func (t *Task) State() State {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.state
+}
+
+// Progress returns the latest progress of the task.
+func (t *Task) Progress() Progress {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.progress
+}
+
+// Err returns the error of a failed or canceled task.
+func (t *Task) Err() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.err
+}
+
+// Cancel cancels the task. A pending task will not run, the context of a
+// running task is canceled.
+func (t *Task) Cancel() {
+	t.cancel()
+
+	t.scheduler.cancelPending(t)
+}
+
+// Done returns a channel that is closed when the task finished.
+func (t *Task) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait waits for the task to finish and returns its error.
+func (t *Task) Wait() error {
+	<-t.done
+
+	return t.Err()
+}
+
+// finishState returns the final state of a task whose function returned err.
+func (t *Task) finishState(err error) State {
+	switch {
+	case err == nil:
+		return Succeeded
+
+	case errors.Is(err, context.Canceled) || t.ctx.Err() != nil:
+		return Canceled
+	}
+
+	return Failed
+}
+
+// reporter is the Reporter passed to the function of a task.
+type reporter struct {
+	task *Task
+}
+
+func (r reporter) update(f func(p *Progress)) {
+	t := r.task
+
+	t.mutex.Lock()
+	f(&t.progress)
+	pending := t.progressPending
+	t.progressPending = true
+	t.mutex.Unlock()
+
+	// Updates arriving faster than they are dispatched are coalesced, the
+	// dispatched event reports the latest progress.
+	if !pending {
+		t.scheduler.dispatch(func() {
+			t.mutex.Lock()
+			t.progressPending = false
+			progress := t.progress
+			t.mutex.Unlock()
+
+			t.scheduler.publish(Event{Kind: Progressed, Task: t, Progress: progress})
+		})
+	}
+}
+
+func (r reporter) SetTotal(total int64) {
+	r.update(func(p *Progress) { p.Total = total })
+}
+
+func (r reporter) SetCompleted(completed int64) {
+	r.update(func(p *Progress) { p.Completed = completed })
+}
+
+func (r reporter) Add(delta int64) {
+	r.update(func(p *Progress) { p.Completed += delta })
+}
+
+func (r reporter) SetMessage(message string) {
+	r.update(func(p *Progress) { p.Message = message })
+}
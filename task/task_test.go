@@ -0,0 +1,66 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestProgressFraction(t *testing.T) {
+	for _, test := range []struct {
+		progress      Progress
+		fraction      float64
+		indeterminate bool
+	}{
+		{Progress{}, 0, true},
+		{Progress{Total: -1, Completed: 5}, 0, true},
+		{Progress{Total: 4, Completed: 1}, 0.25, false},
+		{Progress{Total: 4, Completed: 9}, 1, false},
+		{Progress{Total: 4, Completed: -2}, 0, false},
+	} {
+		if got := test.progress.Fraction(); got != test.fraction {
+			t.Errorf("%+v.Fraction() = %g; want %g", test.progress, got, test.fraction)
+		}
+		if got := test.progress.Indeterminate(); got != test.indeterminate {
+			t.Errorf("%+v.Indeterminate() = %t; want %t", test.progress, got, test.indeterminate)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	for state, want := range map[State]string{Pending: "pending", Canceled: "canceled", State(42): "invalid"} {
+		if got := state.String(); got != want {
+			t.Errorf("State(%d).String() = %q; want %q", int(state), got, want)
+		}
+	}
+
+	for _, state := range []State{Pending, Running} {
+		if state.Finished() {
+			t.Errorf("%s.Finished() = true", state)
+		}
+	}
+	for _, state := range []State{Succeeded, Failed, Canceled} {
+		if !state.Finished() {
+			t.Errorf("%s.Finished() = false", state)
+		}
+	}
+}
+
+func TestFinishState(t *testing.T) {
+	s := NewScheduler(nil, 0)
+
+	if err := s.Submit("ok", func(context.Context, Reporter) error { return nil }).Wait(); err != nil {
+		t.Errorf("Wait() = %v; want nil", err)
+	}
+
+	boom := errors.New("boom")
+	failed := s.Submit("failed", func(context.Context, Reporter) error { return boom })
+	if err := failed.Wait(); err != boom || failed.State() != Failed {
+		t.Errorf("failed task: %s, %v", failed.State(), err)
+	}
+
+}
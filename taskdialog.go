@@ -0,0 +1,561 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"encoding/binary"
+	"runtime"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/handle"
+	"github.com/Gipcomp/win32/user32"
+	"github.com/Gipcomp/win32/win"
+	"github.com/Gipcomp/winapi/errs"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	libcomctl32            = windows.NewLazySystemDLL("comctl32.dll")
+	procTaskDialogIndirect = libcomctl32.NewProc("TaskDialogIndirect")
+)
+
+type TaskDialogIcon uint16
+
+const (
+	TaskDialogIconNone        TaskDialogIcon = 0
+	TaskDialogIconWarning     TaskDialogIcon = 0xFFFF
+	TaskDialogIconError       TaskDialogIcon = 0xFFFE
+	TaskDialogIconInformation TaskDialogIcon = 0xFFFD
+	TaskDialogIconShield      TaskDialogIcon = 0xFFFC
+)
+
+type TaskDialogCommonButtons uint32
+
+const (
+	TaskDialogOKButton     TaskDialogCommonButtons = 0x0001
+	TaskDialogYesButton    TaskDialogCommonButtons = 0x0002
+	TaskDialogNoButton     TaskDialogCommonButtons = 0x0004
+	TaskDialogCancelButton TaskDialogCommonButtons = 0x0008
+	TaskDialogRetryButton  TaskDialogCommonButtons = 0x0010
+	TaskDialogCloseButton  TaskDialogCommonButtons = 0x0020
+)
+
+// Button ids returned by TaskDialog.Show for the common buttons. Custom
+// buttons and radio buttons should use ids above 100 to avoid clashes.
+const (
+	TaskDialogIDOK     = user32.IDOK
+	TaskDialogIDCancel = user32.IDCANCEL
+	TaskDialogIDRetry  = 4
+	TaskDialogIDYes    = 6
+	TaskDialogIDNo     = 7
+	TaskDialogIDClose  = 8
+)
+
+const (
+	tdfEnableHyperlinks         = 0x0001
+	tdfUseHIconMain             = 0x0002
+	tdfUseHIconFooter           = 0x0004
+	tdfAllowDialogCancellation  = 0x0008
+	tdfUseCommandLinks          = 0x0010
+	tdfUseCommandLinksNoIcon    = 0x0020
+	tdfExpandFooterArea         = 0x0040
+	tdfExpandedByDefault        = 0x0080
+	tdfVerificationFlagChecked  = 0x0100
+	tdfShowProgressBar          = 0x0200
+	tdfShowMarqueeProgressBar   = 0x0400
+	tdfCallbackTimer            = 0x0800
+	tdfPositionRelativeToWindow = 0x1000
+	tdfRTLLayout                = 0x2000
+	tdfNoDefaultRadioButton     = 0x4000
+	tdfCanBeMinimized           = 0x8000
+	tdfSizeToContent            = 0x01000000
+)
+
+const (
+	tdnCreated              = 0
+	tdnButtonClicked        = 2
+	tdnHyperlinkClicked     = 3
+	tdnTimer                = 4
+	tdnDestroyed            = 5
+	tdnRadioButtonClicked   = 6
+	tdnVerificationClicked  = 8
+	tdnExpandoButtonClicked = 10
+)
+
+const (
+	tdmClickButton           = user32.WM_USER + 102
+	tdmSetMarqueeProgressBar = user32.WM_USER + 103
+	tdmSetProgressBarState   = user32.WM_USER + 104
+	tdmSetProgressBarRange   = user32.WM_USER + 105
+	tdmSetProgressBarPos     = user32.WM_USER + 106
+	tdmSetProgressBarMarquee = user32.WM_USER + 107
+	tdmEnableButton          = user32.WM_USER + 111
+	tdmUpdateElementText     = user32.WM_USER + 114
+)
+
+const (
+	tdeContent             = 0
+	tdeExpandedInformation = 1
+	tdeFooter              = 2
+	tdeMainInstruction     = 3
+)
+
+type TaskDialogButton struct {
+	ID   int
+	Text string
+}
+
+// TaskDialog wraps the Vista+ task dialog. Fill in the fields and call Show.
+// After Show returns, RadioButton and VerificationChecked reflect the choices
+// the user made.
+type TaskDialog struct {
+	Title                string
+	MainInstruction      string
+	Content              string
+	ExpandedInformation  string
+	ExpandedControlText  string
+	CollapsedControlText string
+	ExpandedByDefault    bool
+	ExpandFooterArea     bool
+	Footer               string
+	Icon                 TaskDialogIcon
+	CustomIcon           *Icon
+	FooterIcon           TaskDialogIcon
+	CommonButtons        TaskDialogCommonButtons
+	Buttons              []TaskDialogButton
+	UseCommandLinks      bool
+	DefaultButton        int
+	RadioButtons         []TaskDialogButton
+	RadioButton          int
+	VerificationText     string
+	VerificationChecked  bool
+	// VerificationSettingsKey, if not empty, persists the button chosen while
+	// the verification checkbox was checked in App().Settings() under this
+	// key. Subsequent calls to Show return the stored button without
+	// displaying the dialog ("don't ask again").
+	VerificationSettingsKey string
+	ShowProgressBar         bool
+	ProgressMarquee         bool
+	EnableHyperlinks        bool
+	AllowCancel             bool
+	CanBeMinimized          bool
+	RightToLeftLayout       bool
+	// Timeout, if greater than zero, closes the dialog as if TimeoutButton
+	// had been clicked after the specified duration.
+	Timeout       time.Duration
+	TimeoutButton int
+	Width         int
+
+	hwnd                        handle.HWND
+	createdPublisher            EventPublisher
+	buttonClickedPublisher      IntEventPublisher
+	hyperlinkClickedPublisher   StringEventPublisher
+	radioButtonClickedPublisher IntEventPublisher
+	timeoutPublisher            EventPublisher
+}
+
+var (
+	taskDialogCallbackPtr uintptr
+	taskDialogsMutex      sync.Mutex
+	taskDialogs           = make(map[uintptr]*TaskDialog)
+	taskDialogNextID      uintptr
+)
+
+func init() {
+	AppendToWalkInit(func() {
+		taskDialogCallbackPtr = syscall.NewCallback(taskDialogCallback)
+	})
+}
+
+// Created returns the event that is published once the dialog window exists.
+// Progress and text updates are possible from then on.
+func (dlg *TaskDialog) Created() *Event {
+	return dlg.createdPublisher.Event()
+}
+
+// ButtonClicked returns the event that is published when a button is clicked.
+func (dlg *TaskDialog) ButtonClicked() *IntEvent {
+	return dlg.buttonClickedPublisher.Event()
+}
+
+// HyperlinkClicked returns the event that is published with the href of a
+// link clicked in Content, ExpandedInformation or Footer.
+func (dlg *TaskDialog) HyperlinkClicked() *StringEvent {
+	return dlg.hyperlinkClickedPublisher.Event()
+}
+
+// RadioButtonClicked returns the event that is published when a radio button
+// is selected.
+func (dlg *TaskDialog) RadioButtonClicked() *IntEvent {
+	return dlg.radioButtonClickedPublisher.Event()
+}
+
+// TimedOut returns the event that is published right before the dialog is
+// closed because Timeout elapsed.
+func (dlg *TaskDialog) TimedOut() *Event {
+	return dlg.timeoutPublisher.Event()
+}
+
+// Handle returns the window handle of the dialog while it is shown, or 0.
+func (dlg *TaskDialog) Handle() handle.HWND {
+	return dlg.hwnd
+}
+
+// Close closes the dialog as if the specified button had been clicked.
+func (dlg *TaskDialog) Close(button int) {
+	dlg.sendMessage(tdmClickButton, uintptr(button), 0)
+}
+
+// SetButtonEnabled enables or disables a button while the dialog is shown.
+func (dlg *TaskDialog) SetButtonEnabled(button int, enabled bool) {
+	dlg.sendMessage(tdmEnableButton, uintptr(button), uintptr(win.BoolToBOOL(enabled)))
+}
+
+// SetProgressRange sets the range of the progress bar while the dialog is
+// shown.
+func (dlg *TaskDialog) SetProgressRange(min, max int) {
+	dlg.sendMessage(tdmSetProgressBarRange, 0, uintptr(win.MAKELONG(uint16(min), uint16(max))))
+}
+
+// SetProgress sets the position of the progress bar while the dialog is shown.
+func (dlg *TaskDialog) SetProgress(value int) {
+	dlg.sendMessage(tdmSetProgressBarPos, uintptr(value), 0)
+}
+
+// SetProgressState sets the state of the progress bar while the dialog is
+// shown.
+func (dlg *TaskDialog) SetProgressState(state PIState) {
+	var pbst uintptr
+
+	switch state {
+	case PIError:
+		pbst = 2 // PBST_ERROR
+	case PIPaused:
+		pbst = 3 // PBST_PAUSED
+	default:
+		pbst = 1 // PBST_NORMAL
+	}
+
+	dlg.sendMessage(tdmSetProgressBarState, pbst, 0)
+}
+
+// SetProgressMarquee switches the progress bar between marquee and normal
+// mode while the dialog is shown.
+func (dlg *TaskDialog) SetProgressMarquee(marquee bool) {
+	dlg.sendMessage(tdmSetMarqueeProgressBar, uintptr(win.BoolToBOOL(marquee)), 0)
+	dlg.sendMessage(tdmSetProgressBarMarquee, uintptr(win.BoolToBOOL(marquee)), 0)
+}
+
+// SetContent updates the content text while the dialog is shown.
+func (dlg *TaskDialog) SetContent(value string) {
+	dlg.Content = value
+	dlg.updateElementText(tdeContent, value)
+}
+
+// SetMainInstruction updates the main instruction while the dialog is shown.
+func (dlg *TaskDialog) SetMainInstruction(value string) {
+	dlg.MainInstruction = value
+	dlg.updateElementText(tdeMainInstruction, value)
+}
+
+// SetFooter updates the footer text while the dialog is shown.
+func (dlg *TaskDialog) SetFooter(value string) {
+	dlg.Footer = value
+	dlg.updateElementText(tdeFooter, value)
+}
+
+func (dlg *TaskDialog) updateElementText(element uintptr, value string) {
+	if dlg.hwnd == 0 {
+		return
+	}
+
+	p, err := syscall.UTF16PtrFromString(value)
+	if err != nil {
+		errs.NewError(err.Error())
+		return
+	}
+
+	dlg.sendMessage(tdmUpdateElementText, element, uintptr(unsafe.Pointer(p)))
+}
+
+func (dlg *TaskDialog) sendMessage(msg uint32, wParam, lParam uintptr) {
+	if dlg.hwnd != 0 {
+		user32.SendMessage(dlg.hwnd, msg, wParam, lParam)
+	}
+}
+
+// Show displays the dialog modally and returns the id of the button that was
+// used to close it.
+func (dlg *TaskDialog) Show(owner Form) (button int, err error) {
+	if button, ok := dlg.rememberedButton(); ok {
+		return button, nil
+	}
+
+	if err := procTaskDialogIndirect.Find(); err != nil {
+		return 0, errs.NewNotSupportedError("TaskDialogIndirect not available (comctl32.dll version 6 required)")
+	}
+
+	var keepAlive []interface{}
+	str := func(s string) uintptr {
+		if s == "" {
+			return 0
+		}
+		p, err := syscall.UTF16PtrFromString(s)
+		if err != nil {
+			errs.NewError(err.Error())
+			return 0
+		}
+		keepAlive = append(keepAlive, p)
+		return uintptr(unsafe.Pointer(p))
+	}
+	buttons := func(bs []TaskDialogButton) uintptr {
+		if len(bs) == 0 {
+			return 0
+		}
+		// TASKDIALOG_BUTTON is declared inside pshpack1.h, so it has no padding.
+		var w taskDialogPackedWriter
+		for _, b := range bs {
+			w.uint32(uint32(b.ID))
+			w.uintptr(str(b.Text))
+		}
+		keepAlive = append(keepAlive, w.buf)
+		return uintptr(unsafe.Pointer(&w.buf[0]))
+	}
+
+	var flags uint32
+	if dlg.EnableHyperlinks {
+		flags |= tdfEnableHyperlinks
+	}
+	if dlg.AllowCancel {
+		flags |= tdfAllowDialogCancellation
+	}
+	if dlg.UseCommandLinks && len(dlg.Buttons) > 0 {
+		flags |= tdfUseCommandLinks
+	}
+	if dlg.ExpandFooterArea {
+		flags |= tdfExpandFooterArea
+	}
+	if dlg.ExpandedByDefault {
+		flags |= tdfExpandedByDefault
+	}
+	if dlg.VerificationChecked {
+		flags |= tdfVerificationFlagChecked
+	}
+	if dlg.ShowProgressBar {
+		if dlg.ProgressMarquee {
+			flags |= tdfShowMarqueeProgressBar
+		} else {
+			flags |= tdfShowProgressBar
+		}
+	}
+	if dlg.Timeout > 0 {
+		flags |= tdfCallbackTimer
+	}
+	if dlg.CanBeMinimized {
+		flags |= tdfCanBeMinimized
+	}
+	if dlg.RightToLeftLayout {
+		flags |= tdfRTLLayout
+	}
+	if len(dlg.RadioButtons) > 0 && dlg.RadioButton == 0 {
+		flags |= tdfNoDefaultRadioButton
+	}
+
+	var ownerHWnd handle.HWND
+	if owner != nil {
+		ownerHWnd = owner.Handle()
+		flags |= tdfPositionRelativeToWindow
+	}
+
+	var mainIcon uintptr
+	if dlg.CustomIcon != nil {
+		dpi := screenDPI()
+		if owner != nil {
+			dpi = owner.AsFormBase().DPI()
+		}
+		mainIcon = uintptr(dlg.CustomIcon.handleForDPI(dpi))
+		flags |= tdfUseHIconMain
+	} else {
+		mainIcon = uintptr(dlg.Icon)
+	}
+
+	taskDialogsMutex.Lock()
+	taskDialogNextID++
+	id := taskDialogNextID
+	taskDialogs[id] = dlg
+	taskDialogsMutex.Unlock()
+
+	defer func() {
+		taskDialogsMutex.Lock()
+		delete(taskDialogs, id)
+		taskDialogsMutex.Unlock()
+	}()
+
+	// TASKDIALOGCONFIG is declared inside pshpack1.h, so we lay it out by hand.
+	var cfg taskDialogPackedWriter
+	cfg.uint32(0) // cbSize, patched below
+	cfg.uintptr(uintptr(ownerHWnd))
+	cfg.uintptr(0) // hInstance
+	cfg.uint32(flags)
+	cfg.uint32(uint32(dlg.CommonButtons))
+	cfg.uintptr(str(dlg.Title))
+	cfg.uintptr(mainIcon)
+	cfg.uintptr(str(dlg.MainInstruction))
+	cfg.uintptr(str(dlg.Content))
+	cfg.uint32(uint32(len(dlg.Buttons)))
+	cfg.uintptr(buttons(dlg.Buttons))
+	cfg.uint32(uint32(dlg.DefaultButton))
+	cfg.uint32(uint32(len(dlg.RadioButtons)))
+	cfg.uintptr(buttons(dlg.RadioButtons))
+	cfg.uint32(uint32(dlg.RadioButton))
+	cfg.uintptr(str(dlg.VerificationText))
+	cfg.uintptr(str(dlg.ExpandedInformation))
+	cfg.uintptr(str(dlg.ExpandedControlText))
+	cfg.uintptr(str(dlg.CollapsedControlText))
+	cfg.uintptr(uintptr(dlg.FooterIcon))
+	cfg.uintptr(str(dlg.Footer))
+	cfg.uintptr(taskDialogCallbackPtr)
+	cfg.uintptr(id)
+	cfg.uint32(uint32(dlg.Width))
+	binary.LittleEndian.PutUint32(cfg.buf, uint32(len(cfg.buf)))
+
+	var pnButton, pnRadioButton int32
+	var verificationChecked win.BOOL
+
+	hr, _, _ := syscall.Syscall6(procTaskDialogIndirect.Addr(), 4,
+		uintptr(unsafe.Pointer(&cfg.buf[0])),
+		uintptr(unsafe.Pointer(&pnButton)),
+		uintptr(unsafe.Pointer(&pnRadioButton)),
+		uintptr(unsafe.Pointer(&verificationChecked)),
+		0,
+		0)
+
+	runtime.KeepAlive(keepAlive)
+	runtime.KeepAlive(cfg.buf)
+
+	if win.FAILED(win.HRESULT(hr)) {
+		return 0, errs.ErrorFromHRESULT("TaskDialogIndirect", win.HRESULT(hr))
+	}
+
+	dlg.RadioButton = int(pnRadioButton)
+	dlg.VerificationChecked = verificationChecked != 0
+
+	dlg.rememberButton(int(pnButton))
+
+	return int(pnButton), nil
+}
+
+func (dlg *TaskDialog) rememberedButton() (int, bool) {
+	if dlg.VerificationSettingsKey == "" {
+		return 0, false
+	}
+
+	settings := App().Settings()
+	if settings == nil {
+		return 0, false
+	}
+
+	s, ok := settings.Get(dlg.VerificationSettingsKey)
+	if !ok {
+		return 0, false
+	}
+
+	button, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+
+	dlg.VerificationChecked = true
+
+	return button, true
+}
+
+func (dlg *TaskDialog) rememberButton(button int) {
+	if dlg.VerificationSettingsKey == "" || !dlg.VerificationChecked || button == TaskDialogIDCancel {
+		return
+	}
+
+	if settings := App().Settings(); settings != nil {
+		settings.Put(dlg.VerificationSettingsKey, strconv.Itoa(button))
+	}
+}
+
+// taskDialogCallback is the PFTASKDIALOGCALLBACK of all TaskDialogs. The only
+// notification with a non-zero lParam is TDN_HYPERLINK_CLICKED, where it is
+// the URL, so lParam is declared as a string pointer.
+func taskDialogCallback(hwnd handle.HWND, msg uint32, wParam uintptr, lParam *uint16, refData uintptr) uintptr {
+	taskDialogsMutex.Lock()
+	dlg := taskDialogs[refData]
+	taskDialogsMutex.Unlock()
+
+	if dlg == nil {
+		return uintptr(win.S_OK)
+	}
+
+	switch msg {
+	case tdnCreated:
+		dlg.hwnd = hwnd
+		if dlg.ShowProgressBar && dlg.ProgressMarquee {
+			dlg.SetProgressMarquee(true)
+		}
+		dlg.createdPublisher.Publish()
+
+	case tdnButtonClicked:
+		dlg.buttonClickedPublisher.Publish(int(wParam))
+
+	case tdnHyperlinkClicked:
+		dlg.hyperlinkClickedPublisher.Publish(win.UTF16PtrToString(lParam))
+
+	case tdnRadioButtonClicked:
+		dlg.RadioButton = int(wParam)
+		dlg.radioButtonClickedPublisher.Publish(int(wParam))
+
+	case tdnVerificationClicked:
+		dlg.VerificationChecked = wParam != 0
+
+	case tdnTimer:
+		if dlg.Timeout > 0 && time.Duration(wParam)*time.Millisecond >= dlg.Timeout {
+			dlg.timeoutPublisher.Publish()
+
+			button := dlg.TimeoutButton
+			if button == 0 {
+				button = TaskDialogIDCancel
+			}
+			dlg.Close(button)
+		}
+
+	case tdnDestroyed:
+		dlg.hwnd = 0
+	}
+
+	return uintptr(win.S_OK)
+}
+
+// taskDialogPackedWriter serializes structs declared with 1 byte packing.
+type taskDialogPackedWriter struct {
+	buf []byte
+}
+
+func (w *taskDialogPackedWriter) uint32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	w.buf = append(w.buf, b[:]...)
+}
+
+func (w *taskDialogPackedWriter) uintptr(v uintptr) {
+	if unsafe.Sizeof(v) == 8 {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(v))
+		w.buf = append(w.buf, b[:]...)
+	} else {
+		w.uint32(uint32(v))
+	}
+}
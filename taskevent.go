@@ -0,0 +1,66 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"github.com/Gipcomp/winapi/task"
+)
+
+type taskEventHandlerInfo struct {
+	handler TaskEventHandler
+	once    bool
+}
+
+type TaskEventHandler func(t *task.Task)
+
+type TaskEvent struct {
+	handlers []taskEventHandlerInfo
+}
+
+func (e *TaskEvent) Attach(handler TaskEventHandler) int {
+	handlerInfo := taskEventHandlerInfo{handler, false}
+
+	for i, h := range e.handlers {
+		if h.handler == nil {
+			e.handlers[i] = handlerInfo
+			return i
+		}
+	}
+
+	e.handlers = append(e.handlers, handlerInfo)
+
+	return len(e.handlers) - 1
+}
+
+func (e *TaskEvent) Detach(handle int) {
+	e.handlers[handle].handler = nil
+}
+
+func (e *TaskEvent) Once(handler TaskEventHandler) {
+	i := e.Attach(handler)
+	e.handlers[i].once = true
+}
+
+type TaskEventPublisher struct {
+	event TaskEvent
+}
+
+func (p *TaskEventPublisher) Event() *TaskEvent {
+	return &p.event
+}
+
+func (p *TaskEventPublisher) Publish(t *task.Task) {
+	for i, h := range p.event.handlers {
+		if h.handler != nil {
+			h.handler(t)
+
+			if h.once {
+				p.event.Detach(i)
+			}
+		}
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"context"
+
+	"github.com/Gipcomp/winapi/errs"
+	"github.com/Gipcomp/winapi/task"
+)
+
+// taskRunnerProgressMax is the upper bound of the range of bound progress bars.
+const taskRunnerProgressMax = 1000
+
+// TaskRunner runs functions in the background and reflects their progress in
+// bound widgets. All events of a TaskRunner are published on the UI thread of
+// its owner.
+type TaskRunner struct {
+	owner                 Window
+	scheduler             *task.Scheduler
+	unsubscribe           func()
+	runningCondition      *MutableCondition
+	cancelAction          *Action
+	progressBars          []*ProgressBar
+	progressIndicators    []*ProgressIndicator
+	statusBarItems        []*StatusBarItem
+	statusBarItemsPushed  map[*StatusBarItem]*statusBarMessage
+	message               string
+	taskStartedPublisher  TaskEventPublisher
+	taskProgressPublisher TaskEventPublisher
+	taskFinishedPublisher TaskEventPublisher
+}
+
+// NewTaskRunner returns a TaskRunner that marshals progress to the UI thread
+// of owner and runs at most maxConcurrent tasks at once. maxConcurrent <= 0
+// means no limit.
+func NewTaskRunner(owner Window, maxConcurrent int) (*TaskRunner, error) {
+	if owner == nil {
+		return nil, errs.NewInvalidArgumentError("owner cannot be nil")
+	}
+
+	tr := &TaskRunner{
+		owner:                owner,
+		runningCondition:     NewMutableCondition(),
+		statusBarItemsPushed: make(map[*StatusBarItem]*statusBarMessage),
+	}
+
+	tr.scheduler = task.NewScheduler(owner.Synchronize, maxConcurrent)
+	tr.unsubscribe = tr.scheduler.Subscribe(tr.handleEvent)
+
+	return tr, nil
+}
+
+// Dispose cancels all tasks and detaches the TaskRunner from its scheduler.
+// Bound widgets are no longer updated.
+func (tr *TaskRunner) Dispose() {
+	if tr.unsubscribe == nil {
+		return
+	}
+
+	tr.unsubscribe()
+	tr.unsubscribe = nil
+
+	tr.scheduler.CancelAll()
+}
+
+// Scheduler returns the scheduler that runs the tasks.
+func (tr *TaskRunner) Scheduler() *task.Scheduler {
+	return tr.scheduler
+}
+
+// Run submits a task with name and function fn and returns it.
+func (tr *TaskRunner) Run(name string, fn task.Func) *task.Task {
+	return tr.RunContext(context.Background(), name, fn)
+}
+
+// RunContext submits a task, whose context derives from ctx, with name and
+// function fn and returns it.
+func (tr *TaskRunner) RunContext(ctx context.Context, name string, fn task.Func) *task.Task {
+	t := tr.scheduler.SubmitContext(ctx, name, fn)
+
+	// Actions depending on RunningCondition are disabled right away, not
+	// only when the Started event arrives.
+	tr.runningCondition.SetSatisfied(tr.scheduler.Busy())
+
+	return t
+}
+
+// Running returns if there are pending or running tasks.
+func (tr *TaskRunner) Running() bool {
+	return tr.runningCondition.Satisfied()
+}
+
+// RunningCondition returns a Condition that is satisfied while there are
+// pending or running tasks.
+func (tr *TaskRunner) RunningCondition() Condition {
+	return tr.runningCondition
+}
+
+// CancelAll cancels all pending and running tasks.
+func (tr *TaskRunner) CancelAll() {
+	tr.scheduler.CancelAll()
+}
+
+// CancelAction returns an Action that cancels all tasks. It is only enabled
+// while tasks are running.
+func (tr *TaskRunner) CancelAction() *Action {
+	if tr.cancelAction == nil {
+		tr.cancelAction = NewAction()
+		tr.cancelAction.SetText("Cancel")
+		tr.cancelAction.SetEnabledCondition(tr.runningCondition)
+		tr.cancelAction.Triggered().Attach(tr.CancelAll)
+	}
+
+	return tr.cancelAction
+}
+
+// BindProgressBar makes pb show the combined progress of all running tasks.
+// pb switches to marquee mode while the amount of work is unknown.
+func (tr *TaskRunner) BindProgressBar(pb *ProgressBar) {
+	if pb == nil {
+		return
+	}
+
+	pb.SetRange(0, taskRunnerProgressMax)
+
+	tr.progressBars = append(tr.progressBars, pb)
+
+	tr.updateBindings()
+}
+
+// BindProgressIndicator makes pi, e.g. the taskbar button progress of a
+// main window, show the combined progress of all running tasks.
+func (tr *TaskRunner) BindProgressIndicator(pi *ProgressIndicator) {
+	if pi == nil {
+		return
+	}
+
+	pi.SetTotal(taskRunnerProgressMax)
+
+	tr.progressIndicators = append(tr.progressIndicators, pi)
+
+	tr.updateBindings()
+}
+
+// BindStatusBarItem makes sbi show the latest progress message while tasks
+// are running. Spinner items spin and progress items show the combined
+// progress of all running tasks.
+func (tr *TaskRunner) BindStatusBarItem(sbi *StatusBarItem) {
+	if sbi == nil {
+		return
+	}
+
+	if sbi.Kind() == StatusBarItemProgress {
+		sbi.SetProgressRange(0, taskRunnerProgressMax)
+	}
+
+	tr.statusBarItems = append(tr.statusBarItems, sbi)
+
+	tr.updateBindings()
+}
+
+// TaskStarted returns the event that is published when a task starts
+// running.
+func (tr *TaskRunner) TaskStarted() *TaskEvent {
+	return tr.taskStartedPublisher.Event()
+}
+
+// TaskProgressed returns the event that is published when a running task
+// reported progress.
+func (tr *TaskRunner) TaskProgressed() *TaskEvent {
+	return tr.taskProgressPublisher.Event()
+}
+
+// TaskFinished returns the event that is published when a task finished.
+// Use the State and Err methods of the task to tell how.
+func (tr *TaskRunner) TaskFinished() *TaskEvent {
+	return tr.taskFinishedPublisher.Event()
+}
+
+func (tr *TaskRunner) handleEvent(e task.Event) {
+	switch e.Kind {
+	case task.Started:
+		tr.message = e.Task.Name()
+
+	case task.Progressed:
+		if e.Progress.Message != "" {
+			tr.message = e.Progress.Message
+		}
+	}
+
+	tr.runningCondition.SetSatisfied(tr.scheduler.Busy())
+
+	tr.updateBindings()
+
+	switch e.Kind {
+	case task.Started:
+		tr.taskStartedPublisher.Publish(e.Task)
+
+	case task.Progressed:
+		tr.taskProgressPublisher.Publish(e.Task)
+
+	case task.Finished:
+		tr.taskFinishedPublisher.Publish(e.Task)
+	}
+}
+
+// progress returns the combined progress of all running tasks as
+// a value in the range [0, taskRunnerProgressMax], or -1 if the amount of work
+// of any of them is unknown.
+func (tr *TaskRunner) progress() int {
+	var total, completed int64
+
+	for _, t := range tr.scheduler.Tasks() {
+		if t.State() != task.Running {
+			continue
+		}
+
+		p := t.Progress()
+		if p.Indeterminate() {
+			return -1
+		}
+
+		total += p.Total
+		if p.Completed < p.Total {
+			completed += p.Completed
+		} else {
+			completed += p.Total
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return int(completed * taskRunnerProgressMax / total)
+}
+
+func (tr *TaskRunner) updateBindings() {
+	running := tr.scheduler.Busy()
+
+	progress := 0
+	if running {
+		progress = tr.progress()
+	}
+
+	for _, pb := range tr.progressBars {
+		if pb.IsDisposed() {
+			continue
+		}
+
+		pb.SetMarqueeMode(progress < 0)
+		if progress >= 0 {
+			pb.SetValue(progress)
+		}
+	}
+
+	for _, pi := range tr.progressIndicators {
+		switch {
+		case !running:
+			pi.SetState(PINoProgress)
+
+		case progress < 0:
+			pi.SetState(PIIndeterminate)
+
+		default:
+			if pi.State() != PINormal {
+				pi.SetState(PINormal)
+			}
+			pi.SetCompleted(uint32(progress))
+		}
+	}
+
+	for _, sbi := range tr.statusBarItems {
+		tr.updateStatusBarItem(sbi, running, progress)
+	}
+}
+
+func (tr *TaskRunner) updateStatusBarItem(sbi *StatusBarItem, running bool, progress int) {
+	message := ""
+	if running {
+		message = tr.message
+	}
+
+	// Other code may push texts onto sbi too, so only the pushed message
+	// itself is removed.
+	if pushed, ok := tr.statusBarItemsPushed[sbi]; !ok || pushed.text != message {
+		if ok {
+			sbi.removeMessage(pushed)
+			delete(tr.statusBarItemsPushed, sbi)
+		}
+
+		if message != "" {
+			if msg, err := sbi.pushMessage(message); err == nil {
+				tr.statusBarItemsPushed[sbi] = msg
+			}
+		}
+	}
+
+	switch sbi.Kind() {
+	case StatusBarItemSpinner:
+		sbi.SetBusy(running)
+
+	case StatusBarItemProgress:
+		if pb := sbi.ProgressBar(); pb != nil {
+			pb.SetMarqueeMode(progress < 0)
+		}
+
+		if progress >= 0 {
+			sbi.SetProgressValue(progress)
+		}
+	}
+}
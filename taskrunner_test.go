@@ -0,0 +1,41 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import "testing"
+
+func TestTaskRunnerStatusBarItemKeepsOtherTexts(t *testing.T) {
+	tr := &TaskRunner{statusBarItemsPushed: make(map[*StatusBarItem]*statusBarMessage)}
+	sbi := NewStatusBarItem()
+	sbi.SetText("Ready")
+
+	expect := func(step, want string) {
+		t.Helper()
+
+		if got := sbi.displayText(); got != want {
+			t.Errorf("%s: text %q, want %q", step, got, want)
+		}
+	}
+
+	tr.message = "Loading"
+	tr.updateStatusBarItem(sbi, true, -1)
+	expect("started", "Loading")
+
+	// Texts pushed by others stay on top and must survive the updates.
+	sbi.PushText("Saved")
+	expect("pushed", "Saved")
+
+	tr.message = "Parsing"
+	tr.updateStatusBarItem(sbi, true, -1)
+	expect("message changed", "Parsing")
+
+	tr.updateStatusBarItem(sbi, false, -1)
+	expect("stopped", "Saved")
+
+	sbi.PopText()
+	expect("popped", "Ready")
+}
@@ -194,7 +194,7 @@ func (tb *ToolBar) SetDefaultButtonWidth(width int) error {
 	}
 
 	if width < 0 {
-		return errs.NewError("width must be >= 0")
+		return errs.NewInvalidArgumentError("width must be >= 0")
 	}
 
 	old := tb.defaultButtonWidth
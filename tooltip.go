@@ -102,7 +102,7 @@ func (tt *ToolTip) setTitle(title string, icon uintptr) error {
 		return err
 	}
 	if win.FALSE == tt.SendMessage(commctrl.TTM_SETTITLE, icon, uintptr(unsafe.Pointer(strPtr))) {
-		return errs.NewError("TTM_SETTITLE failed")
+		return errs.NewWin32Error("TTM_SETTITLE")
 	}
 
 	return nil
@@ -123,7 +123,7 @@ func (tt *ToolTip) track(tool Widget) error {
 
 	ti := tt.toolInfo(tool.Handle())
 	if ti == nil {
-		return errs.NewError("unknown tool")
+		return errs.NewInvalidArgumentError("unknown tool")
 	}
 
 	tt.SendMessage(commctrl.TTM_TRACKACTIVATE, 1, uintptr(unsafe.Pointer(ti)))
@@ -155,7 +155,7 @@ func (tt *ToolTip) track(tool Widget) error {
 func (tt *ToolTip) untrack(tool Widget) error {
 	ti := tt.toolInfo(tool.Handle())
 	if ti == nil {
-		return errs.NewError("unknown tool")
+		return errs.NewInvalidArgumentError("unknown tool")
 	}
 
 	tt.SendMessage(commctrl.TTM_TRACKACTIVATE, 0, uintptr(unsafe.Pointer(ti)))
@@ -188,7 +188,7 @@ func (tt *ToolTip) addTool(hwnd handle.HWND, track bool) error {
 	ti.UId = uintptr(hwnd)
 
 	if win.FALSE == tt.SendMessage(commctrl.TTM_ADDTOOL, 0, uintptr(unsafe.Pointer(&ti))) {
-		return errs.NewError("TTM_ADDTOOL failed")
+		return errs.NewWin32Error("TTM_ADDTOOL")
 	}
 
 	return nil
@@ -229,7 +229,7 @@ func (tt *ToolTip) SetText(tool Widget, text string) error {
 func (tt *ToolTip) setText(hwnd handle.HWND, text string) error {
 	ti := tt.toolInfo(hwnd)
 	if ti == nil {
-		return errs.NewError("unknown tool")
+		return errs.NewInvalidArgumentError("unknown tool")
 	}
 
 	n := 0
@@ -248,13 +248,13 @@ func (tv *TreeView) EnsureVisible(item TreeItem) error {
 func (tv *TreeView) handleForItem(item TreeItem) (commctrl.HTREEITEM, error) {
 	if item != nil {
 		if info := tv.item2Info[item]; info == nil {
-			return 0, errs.NewError("invalid item")
+			return 0, errs.NewInvalidArgumentError("invalid item")
 		} else {
 			return info.handle, nil
 		}
 	}
 
-	return 0, errs.NewError("invalid item")
+	return 0, errs.NewInvalidArgumentError("invalid item")
 }
 
 // ItemAt determines the location of the specified point in native pixels relative to the client area of a tree-view control.
@@ -388,7 +388,7 @@ func (tv *TreeView) insertItemAfter(item TreeItem, hInsertAfter commctrl.HTREEIT
 	} else {
 		info := tv.item2Info[parent]
 		if info == nil {
-			return 0, errs.NewError("invalid parent")
+			return 0, errs.NewInvalidArgumentError("invalid parent")
 		}
 		tvins.HParent = info.handle
 	}
@@ -397,7 +397,7 @@ func (tv *TreeView) insertItemAfter(item TreeItem, hInsertAfter commctrl.HTREEIT
 
 	hItem := commctrl.HTREEITEM(tv.SendMessage(commctrl.TVM_INSERTITEM, 0, uintptr(unsafe.Pointer(&tvins))))
 	if hItem == 0 {
-		return 0, errs.NewError("TVM_INSERTITEM failed")
+		return 0, errs.NewWin32Error("TVM_INSERTITEM")
 	}
 	tv.item2Info[item] = &treeViewItemInfo{hItem, make(map[TreeItem]commctrl.HTREEITEM)}
 	tv.handle2Item[hItem] = item
@@ -450,7 +450,7 @@ func (tv *TreeView) removeItem(item TreeItem) error {
 
 	info := tv.item2Info[item]
 	if info == nil {
-		return errs.NewError("invalid item")
+		return errs.NewInvalidArgumentError("invalid item")
 	}
 
 	if tv.SendMessage(commctrl.TVM_DELETEITEM, 0, uintptr(info.handle)) == 0 {
@@ -478,7 +478,7 @@ func (tv *TreeView) removeDescendants(parent TreeItem) error {
 
 func (tv *TreeView) ensureItemAndAncestorsInserted(item TreeItem) error {
 	if item == nil {
-		return errs.NewError("invalid item")
+		return errs.NewInvalidArgumentError("invalid item")
 	}
 
 	tv.SetSuspended(true)
@@ -492,7 +492,7 @@ func (tv *TreeView) ensureItemAndAncestorsInserted(item TreeItem) error {
 		if item != nil {
 			hierarchy = append(hierarchy, item)
 		} else {
-			return errs.NewError("invalid item")
+			return errs.NewInvalidArgumentError("invalid item")
 		}
 	}
 
@@ -532,7 +532,7 @@ func (tv *TreeView) SetExpanded(item TreeItem, expanded bool) error {
 
 	info := tv.item2Info[item]
 	if info == nil {
-		return errs.NewError("invalid item")
+		return errs.NewInvalidArgumentError("invalid item")
 	}
 
 	var action uintptr
@@ -0,0 +1,408 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/Gipcomp/winapi/errs"
+)
+
+// TypedSettings provides typed access to the string values of a Settings.
+//
+// If a SettingsSchema is set, its defaults are returned for missing values,
+// values are checked against their descriptors and accessing a key with the
+// wrong type is an error. The GetXxx methods return the default value along
+// with the error if a stored value is invalid.
+type TypedSettings struct {
+	settings Settings
+	schema   *SettingsSchema
+}
+
+// NewTypedSettings returns TypedSettings for settings. schema may be nil.
+func NewTypedSettings(settings Settings, schema *SettingsSchema) *TypedSettings {
+	return &TypedSettings{settings: settings, schema: schema}
+}
+
+func (ts *TypedSettings) Settings() Settings {
+	return ts.settings
+}
+
+func (ts *TypedSettings) Schema() *SettingsSchema {
+	return ts.schema
+}
+
+func (ts *TypedSettings) descriptor(key string, typ SettingType) (*SettingDescriptor, error) {
+	if ts.schema != nil {
+		if sd := ts.schema.Descriptor(key); sd != nil {
+			if sd.Type != typ && !(sd.Type == SettingPath && typ == SettingString) {
+				return nil, errs.NewInvalidArgumentError(fmt.Sprintf("setting %q is of type %s, not %s", key, sd.Type, typ))
+			}
+
+			return sd, nil
+		}
+	}
+
+	return &SettingDescriptor{Key: key, Type: typ}, nil
+}
+
+// get returns the value stored for key, or the default value. ok is false if
+// neither a value nor a declared default exists.
+func (ts *TypedSettings) get(key string, typ SettingType) (value interface{}, ok bool, err error) {
+	sd, err := ts.descriptor(key, typ)
+	if err != nil {
+		return (&SettingDescriptor{Type: typ}).DefaultValue(), false, err
+	}
+
+	s, ok := ts.settings.Get(key)
+	if !ok {
+		return sd.DefaultValue(), sd.Default != nil, nil
+	}
+
+	if value, err = sd.Parse(s); err != nil {
+		return sd.DefaultValue(), true, err
+	}
+
+	return value, true, nil
+}
+
+func (ts *TypedSettings) put(key string, typ SettingType, value interface{}) error {
+	sd, err := ts.descriptor(key, typ)
+	if err != nil {
+		return err
+	}
+
+	s, err := sd.Format(value)
+	if err != nil {
+		return err
+	}
+
+	return ts.settings.Put(key, s)
+}
+
+// Value returns the value stored for key, or its default, as the Go type
+// that corresponds to the Type of the descriptor of key. The schema must
+// declare key.
+func (ts *TypedSettings) Value(key string) (interface{}, error) {
+	if ts.schema == nil || ts.schema.Descriptor(key) == nil {
+		return nil, errs.NewInvalidArgumentError(fmt.Sprintf("setting %q is not declared", key))
+	}
+
+	value, _, err := ts.get(key, ts.schema.Descriptor(key).Type)
+	return value, err
+}
+
+// SetValue stores value for key, which the schema must declare.
+func (ts *TypedSettings) SetValue(key string, value interface{}) error {
+	if ts.schema == nil || ts.schema.Descriptor(key) == nil {
+		return errs.NewInvalidArgumentError(fmt.Sprintf("setting %q is not declared", key))
+	}
+
+	return ts.put(key, ts.schema.Descriptor(key).Type, value)
+}
+
+func (ts *TypedSettings) GetString(key string) (string, error) {
+	value, _, err := ts.get(key, SettingString)
+	return value.(string), err
+}
+
+func (ts *TypedSettings) GetInt(key string) (int, error) {
+	value, _, err := ts.get(key, SettingInt)
+	return value.(int), err
+}
+
+func (ts *TypedSettings) GetFloat(key string) (float64, error) {
+	value, _, err := ts.get(key, SettingFloat)
+	return value.(float64), err
+}
+
+func (ts *TypedSettings) GetBool(key string) (bool, error) {
+	value, _, err := ts.get(key, SettingBool)
+	return value.(bool), err
+}
+
+func (ts *TypedSettings) GetDuration(key string) (time.Duration, error) {
+	value, _, err := ts.get(key, SettingDuration)
+	return value.(time.Duration), err
+}
+
+func (ts *TypedSettings) GetStrings(key string) ([]string, error) {
+	value, _, err := ts.get(key, SettingStrings)
+	return value.([]string), err
+}
+
+func (ts *TypedSettings) GetSize(key string) (Size, error) {
+	value, _, err := ts.get(key, SettingSize)
+	return value.(Size), err
+}
+
+func (ts *TypedSettings) GetPath(key string) (string, error) {
+	value, _, err := ts.get(key, SettingPath)
+	return value.(string), err
+}
+
+func (ts *TypedSettings) GetColor(key string) (Color, error) {
+	value, _, err := ts.get(key, SettingColor)
+	return value.(Color), err
+}
+
+func (ts *TypedSettings) GetFont(key string) (*Font, error) {
+	value, _, err := ts.get(key, SettingFont)
+	return value.(*Font), err
+}
+
+func (ts *TypedSettings) GetShortcut(key string) (Shortcut, error) {
+	value, _, err := ts.get(key, SettingShortcut)
+	return value.(Shortcut), err
+}
+
+func (ts *TypedSettings) PutString(key, value string) error {
+	return ts.put(key, SettingString, value)
+}
+
+func (ts *TypedSettings) PutInt(key string, value int) error {
+	return ts.put(key, SettingInt, value)
+}
+
+func (ts *TypedSettings) PutFloat(key string, value float64) error {
+	return ts.put(key, SettingFloat, value)
+}
+
+func (ts *TypedSettings) PutBool(key string, value bool) error {
+	return ts.put(key, SettingBool, value)
+}
+
+func (ts *TypedSettings) PutDuration(key string, value time.Duration) error {
+	return ts.put(key, SettingDuration, value)
+}
+
+func (ts *TypedSettings) PutStrings(key string, value []string) error {
+	return ts.put(key, SettingStrings, value)
+}
+
+func (ts *TypedSettings) PutSize(key string, value Size) error {
+	return ts.put(key, SettingSize, value)
+}
+
+func (ts *TypedSettings) PutPath(key, value string) error {
+	return ts.put(key, SettingPath, value)
+}
+
+func (ts *TypedSettings) PutColor(key string, value Color) error {
+	return ts.put(key, SettingColor, value)
+}
+
+func (ts *TypedSettings) PutFont(key string, value *Font) error {
+	return ts.put(key, SettingFont, value)
+}
+
+func (ts *TypedSettings) PutShortcut(key string, value Shortcut) error {
+	return ts.put(key, SettingShortcut, value)
+}
+
+var (
+	colorType    = reflect.TypeOf(Color(0))
+	fontType     = reflect.TypeOf((*Font)(nil))
+	shortcutType = reflect.TypeOf(Shortcut{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	sizeType     = reflect.TypeOf(Size{})
+	stringsType  = reflect.TypeOf([]string(nil))
+)
+
+// GetStruct fills the fields of the struct v points to from the settings.
+//
+// The key of a field is taken from its "settings" tag, or is the field name
+// if there is none. Fields tagged with "-" and unexported fields are
+// skipped. Fields of nested struct types are filled recursively, with the
+// key of the struct field and a '/' prepended to their keys. Fields whose
+// key has neither a stored value nor a declared default keep their value.
+//
+// Supported field types are string, signed and unsigned integers, floats,
+// bool, time.Duration, []string, Size, Color, *Font and Shortcut.
+//
+// If some fields cannot be filled, the first error is returned after the
+// remaining fields have been filled.
+func (ts *TypedSettings) GetStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errs.NewInvalidArgumentError("v must be a pointer to a struct")
+	}
+
+	return ts.getStruct(rv.Elem(), "")
+}
+
+func (ts *TypedSettings) getStruct(rv reflect.Value, prefix string) error {
+	var firstErr error
+
+	forEachSettingsField(rv, prefix, func(key string, fv reflect.Value, typ SettingType, nested bool) {
+		var err error
+
+		if nested {
+			err = ts.getStruct(fv, key+"/")
+		} else {
+			var value interface{}
+			var ok bool
+			if value, ok, err = ts.get(key, typ); ok && err == nil {
+				err = setSettingsField(key, fv, value)
+			}
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	})
+
+	return firstErr
+}
+
+// PutStruct stores the fields of the struct v points to, see GetStruct.
+func (ts *TypedSettings) PutStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errs.NewInvalidArgumentError("v must be a struct or a pointer to one")
+	}
+
+	return ts.putStruct(rv, "")
+}
+
+func (ts *TypedSettings) putStruct(rv reflect.Value, prefix string) error {
+	var firstErr error
+
+	forEachSettingsField(rv, prefix, func(key string, fv reflect.Value, typ SettingType, nested bool) {
+		var err error
+
+		if nested {
+			err = ts.putStruct(fv, key+"/")
+		} else {
+			err = ts.put(key, typ, settingsFieldValue(fv, typ))
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	})
+
+	return firstErr
+}
+
+func forEachSettingsField(rv reflect.Value, prefix string, f func(key string, fv reflect.Value, typ SettingType, nested bool)) {
+	t := rv.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key := field.Tag.Get("settings")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+		key = prefix + key
+
+		fv := rv.Field(i)
+
+		switch {
+		case field.Type == durationType:
+			f(key, fv, SettingDuration, false)
+
+		case field.Type == sizeType:
+			f(key, fv, SettingSize, false)
+
+		case field.Type == stringsType:
+			f(key, fv, SettingStrings, false)
+
+		case field.Type == colorType:
+			f(key, fv, SettingColor, false)
+
+		case field.Type == fontType:
+			f(key, fv, SettingFont, false)
+
+		case field.Type == shortcutType:
+			f(key, fv, SettingShortcut, false)
+
+		default:
+			switch field.Type.Kind() {
+			case reflect.String:
+				f(key, fv, SettingString, false)
+
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				f(key, fv, SettingInt, false)
+
+			case reflect.Float32, reflect.Float64:
+				f(key, fv, SettingFloat, false)
+
+			case reflect.Bool:
+				f(key, fv, SettingBool, false)
+
+			case reflect.Struct:
+				f(key, fv, 0, true)
+			}
+		}
+	}
+}
+
+func settingsFieldValue(fv reflect.Value, typ SettingType) interface{} {
+	switch typ {
+	case SettingInt:
+		if fv.Kind() >= reflect.Uint && fv.Kind() <= reflect.Uint64 {
+			return int(fv.Uint())
+		}
+		return int(fv.Int())
+
+	case SettingFloat:
+		return fv.Float()
+
+	case SettingString:
+		return fv.String()
+
+	case SettingBool:
+		return fv.Bool()
+	}
+
+	return fv.Interface()
+}
+
+func setSettingsField(key string, fv reflect.Value, value interface{}) error {
+	switch v := value.(type) {
+	case int:
+		if fv.Kind() >= reflect.Uint && fv.Kind() <= reflect.Uint64 {
+			if v < 0 || fv.OverflowUint(uint64(v)) {
+				return errs.NewInvalidArgumentError(fmt.Sprintf("setting %q: %d overflows %s", key, v, fv.Type()))
+			}
+			fv.SetUint(uint64(v))
+		} else {
+			if fv.OverflowInt(int64(v)) {
+				return errs.NewInvalidArgumentError(fmt.Sprintf("setting %q: %d overflows %s", key, v, fv.Type()))
+			}
+			fv.SetInt(int64(v))
+		}
+
+	case float64:
+		fv.SetFloat(v)
+
+	case string:
+		fv.SetString(v)
+
+	case bool:
+		fv.SetBool(v)
+
+	default:
+		fv.Set(reflect.ValueOf(value))
+	}
+
+	return nil
+}
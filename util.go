@@ -17,6 +17,7 @@ import (
 
 	"github.com/Gipcomp/win32/gdi32"
 	"github.com/Gipcomp/win32/kernel32"
+	"github.com/Gipcomp/win32/shell32"
 	"github.com/Gipcomp/win32/user32"
 )
 
@@ -509,7 +510,7 @@ func less(a, b interface{}, order SortOrder) bool {
 
 func dpiForHDC(hdc gdi32.HDC) int {
 	if hwnd := user32.WindowFromDC(hdc); hwnd != 0 {
-		return int(user32.GetDpiForWindow(hwnd))
+		return dpiForHWnd(hwnd)
 	}
 
 	return int(gdi32.GetDeviceCaps(hdc, gdi32.LOGPIXELSX))
@@ -600,3 +601,17 @@ func scaleSize(value Size, scale float64) Size {
 		Height: scaleInt(value.Height, scale),
 	}
 }
+
+// openInShell opens target, a path or URL, with its associated program.
+func openInShell(target string) bool {
+	verb, err := syscall.UTF16PtrFromString("open")
+	if err != nil {
+		return false
+	}
+	file, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return false
+	}
+
+	return shell32.ShellExecute(0, verb, file, nil, nil, user32.SW_SHOWNORMAL)
+}
@@ -0,0 +1,184 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package visualtest
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// UpdateEnvVar is the environment variable that switches NewBaselines into
+// update mode if set to a value other than "" or "0".
+const UpdateEnvVar = "VISUALTEST_UPDATE"
+
+// Baselines manages the PNG baseline images of a directory.
+type Baselines struct {
+	// Dir is the directory containing the baselines, e.g. "testdata".
+	Dir string
+
+	// FailureDir is the directory to which the actual and diff images of
+	// failed checks are written. If empty, a "failures" directory inside
+	// Dir is used.
+	FailureDir string
+
+	// Update makes Check write the checked images as new baselines instead
+	// of comparing them.
+	Update bool
+
+	// Options are used for comparing images. If nil, DefaultOptions are
+	// used.
+	Options *Options
+}
+
+// NewBaselines returns Baselines for dir, in update mode if the environment
+// variable named by UpdateEnvVar says so.
+func NewBaselines(dir string) *Baselines {
+	update := os.Getenv(UpdateEnvVar)
+
+	return &Baselines{
+		Dir:    dir,
+		Update: update != "" && update != "0",
+	}
+}
+
+// Path returns the path of the baseline called name.
+func (b *Baselines) Path(name string) string {
+	return filepath.Join(b.Dir, name+".png")
+}
+
+func (b *Baselines) failureDir() string {
+	if b.FailureDir != "" {
+		return b.FailureDir
+	}
+
+	return filepath.Join(b.Dir, "failures")
+}
+
+// ActualPath returns the path to which Check writes img if it does not match
+// the baseline called name.
+func (b *Baselines) ActualPath(name string) string {
+	return filepath.Join(b.failureDir(), name+".actual.png")
+}
+
+// DiffPath returns the path to which Check writes the diff image if img does
+// not match the baseline called name.
+func (b *Baselines) DiffPath(name string) string {
+	return filepath.Join(b.failureDir(), name+".diff.png")
+}
+
+func (b *Baselines) options() Options {
+	if b.Options != nil {
+		return *b.Options
+	}
+
+	return DefaultOptions
+}
+
+// Check compares img against the baseline called name.
+//
+// In update mode, img is written as the baseline instead. Otherwise, if the
+// images do not match, the actual and diff images are written to the failure
+// directory and a *MismatchError is returned. Files of earlier failures are
+// removed when the images match.
+func (b *Baselines) Check(name string, img image.Image) error {
+	if b.Update {
+		b.removeFailureFiles(name)
+		return SavePNG(b.Path(name), img)
+	}
+
+	want, err := LoadPNG(b.Path(name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("visualtest: baseline %s does not exist, set %s=1 to create it: %w", b.Path(name), UpdateEnvVar, err)
+		}
+		return err
+	}
+
+	opts := b.options()
+
+	result := Compare(img, want, opts)
+	if result.Matches(opts) {
+		b.removeFailureFiles(name)
+		return nil
+	}
+
+	mismatch := &MismatchError{
+		Name:       name,
+		Result:     result,
+		ActualPath: b.ActualPath(name),
+		DiffPath:   b.DiffPath(name),
+	}
+
+	if err := SavePNG(mismatch.ActualPath, img); err != nil {
+		return err
+	}
+	if err := SavePNG(mismatch.DiffPath, result.Diff); err != nil {
+		return err
+	}
+
+	return mismatch
+}
+
+func (b *Baselines) removeFailureFiles(name string) {
+	os.Remove(b.ActualPath(name))
+	os.Remove(b.DiffPath(name))
+}
+
+// MismatchError is returned by Baselines.Check if an image does not match
+// its baseline.
+type MismatchError struct {
+	Name       string
+	Result     *Result
+	ActualPath string
+	DiffPath   string
+}
+
+func (e *MismatchError) Error() string {
+	if e.Result.SizeMismatch {
+		return fmt.Sprintf("visualtest: %s: size differs from baseline, see %s and %s", e.Name, e.ActualPath, e.DiffPath)
+	}
+
+	return fmt.Sprintf("visualtest: %s: %d pixels differ from baseline, see %s and %s", e.Name, e.Result.DiffPixels, e.ActualPath, e.DiffPath)
+}
+
+// LoadPNG reads the PNG image at filePath.
+func LoadPNG(filePath string) (image.Image, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("visualtest: decoding %s: %w", filePath, err)
+	}
+
+	return img, nil
+}
+
+// SavePNG writes img as PNG image to filePath, creating the directory if
+// necessary.
+func SavePNG(filePath string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
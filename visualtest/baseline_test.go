@@ -0,0 +1,129 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package visualtest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func fileExists(filePath string) bool {
+	_, err := os.Stat(filePath)
+	return err == nil
+}
+
+func TestNewBaselinesUpdate(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "0": false, "1": true, "yes": true} {
+		t.Setenv(UpdateEnvVar, value)
+
+		if b := NewBaselines("testdata"); b.Update != want {
+			t.Errorf("%s=%q: Update = %t; want %t", UpdateEnvVar, value, b.Update, want)
+		}
+	}
+}
+
+func TestBaselinesPaths(t *testing.T) {
+	b := &Baselines{Dir: "testdata"}
+
+	if got, want := b.Path("login"), filepath.Join("testdata", "login.png"); got != want {
+		t.Errorf("Path() = %q; want %q", got, want)
+	}
+	if got, want := b.ActualPath("login"), filepath.Join("testdata", "failures", "login.actual.png"); got != want {
+		t.Errorf("ActualPath() = %q; want %q", got, want)
+	}
+
+	b.FailureDir = "out"
+	if got, want := b.DiffPath("login"), filepath.Join("out", "login.diff.png"); got != want {
+		t.Errorf("DiffPath() = %q; want %q", got, want)
+	}
+}
+
+func TestBaselinesCheckMissing(t *testing.T) {
+	b := &Baselines{Dir: t.TempDir()}
+
+	err := b.Check("missing", newFilledImage(2, 2, white))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Check() error = %v; want one wrapping os.ErrNotExist", err)
+	}
+	if fileExists(b.Path("missing")) {
+		t.Error("Check() created the baseline outside of update mode")
+	}
+}
+
+func TestBaselinesCheck(t *testing.T) {
+	b := &Baselines{Dir: t.TempDir(), Update: true}
+
+	img := newFilledImage(3, 3, white)
+	if err := b.Check("form", img); err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(b.Path("form")) {
+		t.Fatal("update mode did not write the baseline")
+	}
+
+	b.Update = false
+
+	if err := b.Check("form", img); err != nil {
+		t.Fatalf("Check() of the baseline image: %v", err)
+	}
+
+	changed := newFilledImage(3, 3, white)
+	changed.SetRGBA(1, 1, black)
+
+	err := b.Check("form", changed)
+	var mismatch *MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Check() error = %v; want a *MismatchError", err)
+	}
+	if mismatch.Result.DiffPixels != 1 {
+		t.Errorf("DiffPixels = %d; want 1", mismatch.Result.DiffPixels)
+	}
+
+	actual, err := LoadPNG(mismatch.ActualPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := Compare(actual, changed, Options{}); r.DiffPixels != 0 {
+		t.Error("the written actual image differs from the checked one")
+	}
+	if !fileExists(mismatch.DiffPath) {
+		t.Error("no diff image written")
+	}
+
+	if err := b.Check("form", img); err != nil {
+		t.Fatal(err)
+	}
+	if fileExists(mismatch.ActualPath) || fileExists(mismatch.DiffPath) {
+		t.Error("a matching check did not remove the files of the earlier failure")
+	}
+}
+
+func TestBaselinesCheckSizeMismatch(t *testing.T) {
+	b := &Baselines{Dir: t.TempDir()}
+	if err := SavePNG(b.Path("form"), newFilledImage(2, 2, white)); err != nil {
+		t.Fatal(err)
+	}
+
+	var mismatch *MismatchError
+	if err := b.Check("form", newFilledImage(3, 2, white)); !errors.As(err, &mismatch) || !mismatch.Result.SizeMismatch {
+		t.Errorf("Check() error = %v; want a *MismatchError for the size", err)
+	}
+}
+
+func TestBaselinesOptions(t *testing.T) {
+	b := &Baselines{Dir: t.TempDir(), Options: &Options{MaxDiffPixels: 1}}
+	if err := SavePNG(b.Path("form"), newFilledImage(2, 2, white)); err != nil {
+		t.Fatal(err)
+	}
+
+	img := newFilledImage(2, 2, white)
+	img.SetRGBA(0, 0, black)
+
+	if err := b.Check("form", img); err != nil {
+		t.Errorf("Check() with MaxDiffPixels 1: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package visualtest
+
+import (
+	"image"
+
+	"github.com/Gipcomp/winapi"
+)
+
+// Capture renders w at size, in 1/96 inch units, and dpi and returns a
+// screenshot.
+//
+// dpi is the DPI the baselines are made for, 96 if 0. It is applied to the
+// Form of w with SetDPIOverride, so the screenshot does not depend on the
+// monitor the form is on. The override stays in effect after Capture
+// returns.
+//
+// If w is a Form, its client area is resized to size and laid out
+// synchronously. Otherwise the Form of w is laid out first and size is then
+// applied to w. A zero size keeps the current size.
+func Capture(w winapi.Window, size winapi.Size, dpi int) (*image.RGBA, error) {
+	if dpi == 0 {
+		dpi = 96
+	}
+
+	if form := w.Form(); form != nil {
+		form.AsFormBase().SetDPIOverride(dpi)
+	}
+
+	pixels := winapi.SizeFrom96DPI(size, dpi)
+
+	if form, ok := w.(winapi.Form); ok {
+		if size != (winapi.Size{}) {
+			if err := form.SetClientSizePixels(pixels); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := form.AsFormBase().PerformLayout(); err != nil {
+			return nil, err
+		}
+	} else {
+		if form := w.Form(); form != nil {
+			if err := form.AsFormBase().PerformLayout(); err != nil {
+				return nil, err
+			}
+		}
+
+		if size != (winapi.Size{}) {
+			if err := w.SetSizePixels(pixels); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return w.AsWindowBase().Screenshot()
+}
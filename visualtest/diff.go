@@ -0,0 +1,236 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package visualtest implements golden image testing for windows and
+// widgets: screenshots are compared against PNG baselines stored on disk,
+// diff images are written on failure and baselines can be regenerated in
+// update mode.
+//
+// Image comparison and baseline management have no dependencies on the
+// Windows API, so they can be used and tested on any platform. Capturing
+// screenshots, see Capture, is only available on Windows.
+//
+// A test typically looks like this:
+//
+//	func TestLoginForm(t *testing.T) {
+//		img, err := visualtest.Capture(form, winapi.Size{400, 300}, 96)
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		if err := visualtest.NewBaselines("testdata").Check("login", img); err != nil {
+//			t.Error(err)
+//		}
+//	}
+package visualtest
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Options control how images are compared.
+type Options struct {
+	// Tolerance is the largest difference of a single color channel for
+	// which two pixels are still considered equal.
+	Tolerance uint8
+
+	// MaxDiffPixels is the number of differing pixels up to which two images
+	// are still considered to match.
+	MaxDiffPixels int
+
+	// DetectAntiAliasing makes pixels that differ only because of
+	// anti-aliasing not count as differing.
+	DetectAntiAliasing bool
+}
+
+// DefaultOptions are used by Baselines if no Options are set.
+var DefaultOptions = Options{
+	Tolerance:          8,
+	DetectAntiAliasing: true,
+}
+
+// Result describes the outcome of comparing two images.
+type Result struct {
+	// Bounds are the bounds of the compared area, which is the union of the
+	// bounds of both images, translated to the origin.
+	Bounds image.Rectangle
+
+	// SizeMismatch reports whether the images have different sizes. Pixels
+	// outside of either image count as differing.
+	SizeMismatch bool
+
+	// DiffPixels is the number of differing pixels.
+	DiffPixels int
+
+	// AntiAliasedPixels is the number of pixels that differ, but were
+	// recognized as anti-aliasing and are not included in DiffPixels.
+	AntiAliasedPixels int
+
+	// Diff visualizes the comparison: the expected image faded to gray, with
+	// differing pixels in red and anti-aliased pixels in yellow.
+	Diff *image.RGBA
+}
+
+// Matches returns whether the number of differing pixels is within the limit
+// of opts.
+func (r *Result) Matches(opts Options) bool {
+	return !r.SizeMismatch && r.DiffPixels <= opts.MaxDiffPixels
+}
+
+var (
+	diffColor        = color.RGBA{255, 0, 0, 255}
+	antiAliasedColor = color.RGBA{255, 255, 0, 255}
+)
+
+// Compare compares got against want.
+func Compare(got, want image.Image, opts Options) *Result {
+	g, w := toRGBA(got), toRGBA(want)
+
+	bounds := g.Bounds().Union(w.Bounds())
+
+	r := &Result{
+		Bounds:       bounds,
+		SizeMismatch: g.Bounds() != w.Bounds(),
+		Diff:         image.NewRGBA(bounds),
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := image.Pt(x, y)
+			if !p.In(g.Bounds()) || !p.In(w.Bounds()) {
+				r.DiffPixels++
+				r.Diff.SetRGBA(x, y, diffColor)
+				continue
+			}
+
+			gc, wc := g.RGBAAt(x, y), w.RGBAAt(x, y)
+
+			if pixelsEqual(gc, wc, opts.Tolerance) {
+				r.Diff.SetRGBA(x, y, faded(wc))
+				continue
+			}
+
+			if opts.DetectAntiAliasing && (antiAliased(g, w, x, y, opts.Tolerance) || antiAliased(w, g, x, y, opts.Tolerance)) {
+				r.AntiAliasedPixels++
+				r.Diff.SetRGBA(x, y, antiAliasedColor)
+				continue
+			}
+
+			r.DiffPixels++
+			r.Diff.SetRGBA(x, y, diffColor)
+		}
+	}
+
+	return r
+}
+
+// toRGBA returns img as *image.RGBA with bounds starting at the origin.
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+
+	if rgba, ok := img.(*image.RGBA); ok && b.Min == (image.Point{}) {
+		return rgba
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+
+	return rgba
+}
+
+func pixelsEqual(a, b color.RGBA, tolerance uint8) bool {
+	return channelDelta(a.R, b.R) <= tolerance &&
+		channelDelta(a.G, b.G) <= tolerance &&
+		channelDelta(a.B, b.B) <= tolerance &&
+		channelDelta(a.A, b.A) <= tolerance
+}
+
+func channelDelta(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
+
+func brightness(c color.RGBA) int {
+	// ITU-R BT.601 luma, scaled by 1000.
+	return 299*int(c.R) + 587*int(c.G) + 114*int(c.B)
+}
+
+// faded returns c converted to gray and blended with white, so differences
+// stand out in diff images.
+func faded(c color.RGBA) color.RGBA {
+	v := uint8(255 - (255-brightness(c)/1000)/4)
+
+	return color.RGBA{v, v, v, 255}
+}
+
+// antiAliased reports whether the pixel at x, y in img looks like an
+// anti-aliased edge pixel, following the approach of "Anti-aliased Pixel and
+// Intensity Slope Detector" by V. Vysniauskas: the pixel has at most two
+// equal neighbors, both a darker and a brighter neighbor, and one of these
+// lies within an area of equal pixels in both images.
+func antiAliased(img, other *image.RGBA, x, y int, tolerance uint8) bool {
+	c := img.RGBAAt(x, y)
+	b := brightness(c)
+
+	equal := 0
+	var minDelta, maxDelta int
+	var minX, minY, maxX, maxY int
+
+	forEachNeighbor(img.Bounds(), x, y, func(nx, ny int) {
+		nc := img.RGBAAt(nx, ny)
+		if pixelsEqual(c, nc, tolerance) {
+			equal++
+			return
+		}
+
+		delta := brightness(nc) - b
+		if delta < minDelta {
+			minDelta, minX, minY = delta, nx, ny
+		} else if delta > maxDelta {
+			maxDelta, maxX, maxY = delta, nx, ny
+		}
+	})
+
+	if equal > 2 || minDelta == 0 || maxDelta == 0 {
+		return false
+	}
+
+	return hasManySiblings(img, minX, minY, tolerance) && hasManySiblings(other, minX, minY, tolerance) ||
+		hasManySiblings(img, maxX, maxY, tolerance) && hasManySiblings(other, maxX, maxY, tolerance)
+}
+
+// hasManySiblings reports whether the pixel at x, y has more than two equal
+// neighbors.
+func hasManySiblings(img *image.RGBA, x, y int, tolerance uint8) bool {
+	if !image.Pt(x, y).In(img.Bounds()) {
+		return false
+	}
+
+	c := img.RGBAAt(x, y)
+
+	equal := 0
+	forEachNeighbor(img.Bounds(), x, y, func(nx, ny int) {
+		if pixelsEqual(c, img.RGBAAt(nx, ny), tolerance) {
+			equal++
+		}
+	})
+
+	return equal > 2
+}
+
+func forEachNeighbor(bounds image.Rectangle, x, y int, f func(nx, ny int)) {
+	for ny := y - 1; ny <= y+1; ny++ {
+		for nx := x - 1; nx <= x+1; nx++ {
+			if nx == x && ny == y || !image.Pt(nx, ny).In(bounds) {
+				continue
+			}
+
+			f(nx, ny)
+		}
+	}
+}
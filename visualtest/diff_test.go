@@ -0,0 +1,134 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package visualtest
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var (
+	white = color.RGBA{255, 255, 255, 255}
+	black = color.RGBA{0, 0, 0, 255}
+)
+
+func newFilledImage(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestCompareEqual(t *testing.T) {
+	r := Compare(newFilledImage(4, 3, white), newFilledImage(4, 3, white), Options{})
+
+	if r.DiffPixels != 0 || r.SizeMismatch {
+		t.Errorf("DiffPixels = %d, SizeMismatch = %t; want 0, false", r.DiffPixels, r.SizeMismatch)
+	}
+	if !r.Matches(Options{}) {
+		t.Error("equal images do not match")
+	}
+	if r.Diff.Bounds() != image.Rect(0, 0, 4, 3) {
+		t.Errorf("Diff.Bounds() = %v", r.Diff.Bounds())
+	}
+}
+
+func TestCompareTolerance(t *testing.T) {
+	got := newFilledImage(2, 2, white)
+	got.SetRGBA(0, 0, color.RGBA{250, 255, 255, 255})
+
+	if r := Compare(got, newFilledImage(2, 2, white), Options{Tolerance: 5}); r.DiffPixels != 0 {
+		t.Errorf("DiffPixels = %d within tolerance; want 0", r.DiffPixels)
+	}
+
+	r := Compare(got, newFilledImage(2, 2, white), Options{Tolerance: 4})
+	if r.DiffPixels != 1 {
+		t.Errorf("DiffPixels = %d beyond tolerance; want 1", r.DiffPixels)
+	}
+	if c := r.Diff.RGBAAt(0, 0); c != diffColor {
+		t.Errorf("Diff at differing pixel = %v; want %v", c, diffColor)
+	}
+}
+
+func TestCompareMaxDiffPixels(t *testing.T) {
+	got := newFilledImage(3, 3, white)
+	got.SetRGBA(0, 0, black)
+	got.SetRGBA(2, 2, black)
+
+	r := Compare(got, newFilledImage(3, 3, white), Options{})
+	if r.DiffPixels != 2 {
+		t.Fatalf("DiffPixels = %d; want 2", r.DiffPixels)
+	}
+	if r.Matches(Options{MaxDiffPixels: 1}) {
+		t.Error("matches with MaxDiffPixels 1")
+	}
+	if !r.Matches(Options{MaxDiffPixels: 2}) {
+		t.Error("does not match with MaxDiffPixels 2")
+	}
+}
+
+func TestCompareSizeMismatch(t *testing.T) {
+	r := Compare(newFilledImage(3, 2, white), newFilledImage(2, 3, white), Options{})
+
+	if !r.SizeMismatch {
+		t.Error("SizeMismatch = false")
+	}
+	if r.Bounds != image.Rect(0, 0, 3, 3) {
+		t.Errorf("Bounds = %v; want the union of both images", r.Bounds)
+	}
+	// Pixels outside of either image: (2, 0), (2, 1), (0, 2), (1, 2) and (2, 2).
+	if r.DiffPixels != 5 {
+		t.Errorf("DiffPixels = %d; want 5", r.DiffPixels)
+	}
+	if r.Matches(Options{MaxDiffPixels: 100}) {
+		t.Error("images of different sizes match")
+	}
+}
+
+func TestCompareTranslatesBounds(t *testing.T) {
+	sub := newFilledImage(4, 4, white).SubImage(image.Rect(1, 1, 3, 3))
+
+	r := Compare(sub, newFilledImage(2, 2, white), Options{})
+	if r.SizeMismatch || r.DiffPixels != 0 {
+		t.Errorf("SizeMismatch = %t, DiffPixels = %d; want false, 0", r.SizeMismatch, r.DiffPixels)
+	}
+}
+
+func TestCompareAntiAliasing(t *testing.T) {
+	// A vertical edge between a black and a white area, with a gray pixel in
+	// between in one of the images, as produced by anti-aliasing.
+	want := image.NewRGBA(image.Rect(0, 0, 6, 5))
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 6; x++ {
+			if x < 3 {
+				want.SetRGBA(x, y, black)
+			} else {
+				want.SetRGBA(x, y, white)
+			}
+		}
+	}
+
+	got := image.NewRGBA(want.Bounds())
+	copy(got.Pix, want.Pix)
+	got.SetRGBA(3, 2, color.RGBA{128, 128, 128, 255})
+
+	r := Compare(got, want, Options{DetectAntiAliasing: true})
+	if r.DiffPixels != 0 || r.AntiAliasedPixels != 1 {
+		t.Errorf("DiffPixels = %d, AntiAliasedPixels = %d; want 0, 1", r.DiffPixels, r.AntiAliasedPixels)
+	}
+	if c := r.Diff.RGBAAt(3, 2); c != antiAliasedColor {
+		t.Errorf("Diff at anti-aliased pixel = %v; want %v", c, antiAliasedColor)
+	}
+
+	r = Compare(got, want, Options{})
+	if r.DiffPixels != 1 || r.AntiAliasedPixels != 0 {
+		t.Errorf("without detection: DiffPixels = %d, AntiAliasedPixels = %d; want 1, 0", r.DiffPixels, r.AntiAliasedPixels)
+	}
+}
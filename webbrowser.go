@@ -0,0 +1,83 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/Gipcomp/winapi/jsbridge"
+	"github.com/Gipcomp/winapi/webserve"
+)
+
+// WebBrowser is the part of the web browser widgets WebView2 and WebView
+// that does not depend on the engine.
+type WebBrowser interface {
+	Widget
+
+	URL() (string, error)
+	SetURL(url string) error
+	URLChanged() *Event
+	Refresh() error
+	GoBack() error
+	GoForward() error
+	Stop() error
+	CanGoBack() bool
+	CanGoBackChanged() *Event
+	CanGoForward() bool
+	CanGoForwardChanged() *Event
+	Navigating() *WebViewNavigatingEvent
+	Navigated() *StringEvent
+	NavigatedError() *WebViewNavigatedErrorEvent
+	DocumentCompleted() *StringEvent
+	DocumentTitle() string
+	DocumentTitleChanged() *Event
+	ProgressValue() int32
+	ProgressMax() int32
+	ProgressChanged() *Event
+	ShortcutsEnabled() bool
+	SetShortcutsEnabled(value bool)
+	ShortcutsEnabledChanged() *Event
+	NativeContextMenuEnabled() bool
+	SetNativeContextMenuEnabled(value bool)
+	NativeContextMenuEnabledChanged() *Event
+
+	Bind(name string, fn interface{}) error
+	Unbind(name string)
+	Bridge() *jsbridge.Bridge
+	Eval(script string) (interface{}, error)
+
+	ContentFS() fs.FS
+	SetContentFS(fsys fs.FS) error
+	ContentURL(name string) (string, error)
+	ContentName(url string) (string, bool)
+	NavigateContent(name string) error
+	SetContentInterceptor(interceptor webserve.Interceptor) error
+	SetHTML(html string) error
+}
+
+var (
+	_ WebBrowser = (*WebView)(nil)
+	_ WebBrowser = (*WebView2)(nil)
+)
+
+// NewWebBrowser returns a new WebView2, or a WebView if the WebView2
+// runtime is not installed.
+//
+// Note that bound functions return a Promise in a WebView2 only, see
+// WebView2.Bind.
+func NewWebBrowser(parent Container) (WebBrowser, error) {
+	wv2, err := NewWebView2(parent)
+	if err == nil {
+		return wv2, nil
+	}
+	if !errors.Is(err, ErrWebView2Unavailable) {
+		return nil, err
+	}
+
+	return NewWebView(parent)
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webserve
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ext2ContentType holds the types of common web files. These take
+// precedence over package mime, which on Windows reads the registry, where
+// other applications may have registered e.g. .js as text/plain.
+var ext2ContentType = map[string]string{
+	".css":   "text/css; charset=utf-8",
+	".csv":   "text/csv; charset=utf-8",
+	".gif":   "image/gif",
+	".htm":   "text/html; charset=utf-8",
+	".html":  "text/html; charset=utf-8",
+	".ico":   "image/x-icon",
+	".jpeg":  "image/jpeg",
+	".jpg":   "image/jpeg",
+	".js":    "application/javascript; charset=utf-8",
+	".json":  "application/json; charset=utf-8",
+	".map":   "application/json; charset=utf-8",
+	".md":    "text/markdown; charset=utf-8",
+	".mjs":   "application/javascript; charset=utf-8",
+	".mp4":   "video/mp4",
+	".otf":   "font/otf",
+	".pdf":   "application/pdf",
+	".png":   "image/png",
+	".svg":   "image/svg+xml",
+	".ttf":   "font/ttf",
+	".txt":   "text/plain; charset=utf-8",
+	".wasm":  "application/wasm",
+	".webm":  "video/webm",
+	".webp":  "image/webp",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".xml":   "application/xml; charset=utf-8",
+}
+
+// ContentType returns the MIME type of the file name with contents data,
+// derived from the extension of name or else by sniffing data.
+func ContentType(name string, data []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+
+	if t, ok := ext2ContentType[ext]; ok {
+		return t
+	}
+
+	if ext != "" {
+		if t := mime.TypeByExtension(ext); t != "" {
+			return t
+		}
+	}
+
+	return http.DetectContentType(data)
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webserve
+
+import "testing"
+
+func TestContentType(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data string
+		want string
+	}{
+		{"index.html", "", "text/html; charset=utf-8"},
+		{"INDEX.HTM", "", "text/html; charset=utf-8"},
+		{"app.js", "", "application/javascript; charset=utf-8"},
+		{"module.mjs", "", "application/javascript; charset=utf-8"},
+		{"styles/site.css", "", "text/css; charset=utf-8"},
+		{"logo.svg", "", "image/svg+xml"},
+		{"fonts/a.woff2", "", "font/woff2"},
+		{"main.wasm", "", "application/wasm"},
+
+		// Without a known extension, the data is sniffed.
+		{"README", "<!DOCTYPE html><p>", "text/html; charset=utf-8"},
+		{"data", "\x89PNG\r\n\x1a\n", "image/png"},
+		{"notes", "plain text", "text/plain; charset=utf-8"},
+	} {
+		if got := ContentType(test.name, []byte(test.data)); got != test.want {
+			t.Errorf("ContentType(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
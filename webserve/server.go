@@ -0,0 +1,287 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package webserve serves the files of an fs.FS, e.g. an embed.FS, and
+// content generated by Go to an embedded browser through an in-process
+// HTTP server on the loopback interface.
+//
+// All URLs share a random prefix, so other processes cannot guess them,
+// and map to names in the file system like fs.FS expects them, so relative
+// references between files resolve as usual.
+package webserve
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Content is a response generated by Go.
+type Content struct {
+	// Type is the MIME type. If empty, it is derived from the name of the
+	// request or the data.
+	Type string
+
+	Data []byte
+}
+
+// HTML returns Content of type text/html holding html.
+func HTML(html string) *Content {
+	return &Content{Type: "text/html; charset=utf-8", Data: []byte(html)}
+}
+
+// Interceptor is called for each request before the pages and the file
+// system are consulted, with name being the requested name in the file
+// system. If it returns nil Content and no error, the request is served
+// as usual.
+type Interceptor func(name string, r *http.Request) (*Content, error)
+
+// Server serves an fs.FS, pages set with SetPage and an Interceptor.
+type Server struct {
+	mutex       sync.RWMutex
+	fsys        fs.FS
+	pages       map[string]*Content
+	interceptor Interceptor
+	listener    net.Listener
+	server      *http.Server
+	prefix      string
+	baseURL     string
+}
+
+// Start starts a Server for fsys, which may be nil, listening on a random
+// loopback port.
+func Start(fsys fs.FS) (*Server, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("webserve: %s", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("webserve: %s", err)
+	}
+
+	s := &Server{
+		fsys:     fsys,
+		pages:    make(map[string]*Content),
+		listener: listener,
+		prefix:   "/" + hex.EncodeToString(token) + "/",
+	}
+	s.baseURL = "http://" + listener.Addr().String() + s.prefix
+	s.server = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go s.server.Serve(listener)
+
+	return s, nil
+}
+
+// Close stops the Server.
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// BaseURL returns the URL of the root of the file system, ending in '/'.
+func (s *Server) BaseURL() string {
+	return s.baseURL
+}
+
+// URL returns the URL of name in the file system, which may also be the
+// name of a page or directory.
+func (s *Server) URL(name string) string {
+	name = strings.TrimPrefix(name, "/")
+
+	u := url.URL{Path: name}
+
+	return s.baseURL + u.EscapedPath()
+}
+
+// Name returns the name in the file system rawURL refers to and whether
+// it is served by s.
+func (s *Server) Name(rawURL string) (string, bool) {
+	if rawURL == strings.TrimSuffix(s.baseURL, "/") {
+		return "", true
+	}
+	if !strings.HasPrefix(rawURL, s.baseURL) {
+		return "", false
+	}
+
+	rest := rawURL[len(s.baseURL):]
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	name, err := url.PathUnescape(rest)
+	if err != nil {
+		return "", false
+	}
+
+	return name, true
+}
+
+// FS returns the file system served.
+func (s *Server) FS() fs.FS {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.fsys
+}
+
+// SetFS replaces the file system served.
+func (s *Server) SetFS(fsys fs.FS) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.fsys = fsys
+}
+
+// SetInterceptor sets the Interceptor called for each request.
+func (s *Server) SetInterceptor(interceptor Interceptor) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.interceptor = interceptor
+}
+
+// SetPage serves content as name, taking precedence over the file system.
+// Passing nil content removes the page.
+func (s *Server) SetPage(name string, content *Content) {
+	name = strings.TrimPrefix(name, "/")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if content == nil {
+		delete(s.pages, name)
+	} else {
+		s.pages[name] = content
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, s.prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, s.prefix)
+
+	s.mutex.RLock()
+	fsys, page, interceptor := s.fsys, s.pages[name], s.interceptor
+	s.mutex.RUnlock()
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if interceptor != nil {
+		content, err := interceptor(name, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if content != nil {
+			serveContent(w, r, name, content)
+			return
+		}
+	}
+
+	if page != nil {
+		serveContent(w, r, name, page)
+		return
+	}
+
+	if fsys == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.serveFile(w, r, fsys, name)
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) {
+	fsName := strings.TrimSuffix(name, "/")
+	if fsName == "" {
+		fsName = "."
+	}
+	if !fs.ValidPath(fsName) {
+		http.NotFound(w, r)
+		return
+	}
+
+	info, err := fs.Stat(fsys, fsName)
+	if err != nil {
+		serveError(w, r, err)
+		return
+	}
+
+	if info.IsDir() {
+		// Relative references only resolve against the directory if the
+		// URL ends in '/'.
+		if name != "" && !strings.HasSuffix(name, "/") {
+			u := url.URL{Path: s.prefix + name + "/"}
+			http.Redirect(w, r, u.EscapedPath(), http.StatusMovedPermanently)
+			return
+		}
+
+		fsName = path.Join(fsName, "index.html")
+		if info, err = fs.Stat(fsys, fsName); err != nil {
+			serveError(w, r, err)
+			return
+		}
+	}
+
+	data, err := fs.ReadFile(fsys, fsName)
+	if err != nil {
+		serveError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType(fsName, data))
+
+	http.ServeContent(w, r, fsName, info.ModTime(), bytes.NewReader(data))
+}
+
+func serveContent(w http.ResponseWriter, r *http.Request, name string, content *Content) {
+	contentType := content.Type
+	if contentType == "" {
+		contentType = ContentType(name, content.Data)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(content.Data)))
+
+	if r.Method != http.MethodHead {
+		io.Copy(w, bytes.NewReader(content.Data))
+	}
+}
+
+func serveError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.NotFound(w, r)
+
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, "forbidden", http.StatusForbidden)
+
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webserve
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func startTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	s, err := Start(fstest.MapFS{
+		"index.html":            {Data: []byte("<p>root</p>")},
+		"app.js":                {Data: []byte("let x = 1")},
+		"docs/index.html":       {Data: []byte("<p>docs</p>")},
+		"docs/page.md":          {Data: []byte("# Page")},
+		"empty/readme.txt":      {Data: []byte("no index")},
+		"a b?c#d/index.html":    {Data: []byte("<p>odd</p>")},
+		"spaces in name.txt":    {Data: []byte("spaces")},
+		"noext":                 {Data: []byte("<!DOCTYPE html><p>sniffed</p>")},
+		"images/logo.svg":       {Data: []byte("<svg/>")},
+		"images/unknown.zzznot": {Data: []byte("plain text")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+// get requests rawURL from s without following redirects.
+func get(t *testing.T, s *Server, method, rawURL string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(method, rawURL, nil))
+
+	return rec
+}
+
+func TestServerFiles(t *testing.T) {
+	s := startTestServer(t)
+
+	for _, test := range []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"", http.StatusOK, "text/html; charset=utf-8", "<p>root</p>"},
+		{"app.js", http.StatusOK, "application/javascript; charset=utf-8", "let x = 1"},
+		{"docs/", http.StatusOK, "text/html; charset=utf-8", "<p>docs</p>"},
+		{"docs/page.md", http.StatusOK, "text/markdown; charset=utf-8", "# Page"},
+		{"spaces in name.txt", http.StatusOK, "text/plain; charset=utf-8", "spaces"},
+		{"a b?c#d/", http.StatusOK, "text/html; charset=utf-8", "<p>odd</p>"},
+		{"noext", http.StatusOK, "text/html; charset=utf-8", "<!DOCTYPE html><p>sniffed</p>"},
+		{"images/logo.svg", http.StatusOK, "image/svg+xml", "<svg/>"},
+		{"missing.html", http.StatusNotFound, "", ""},
+		{"empty/", http.StatusNotFound, "", ""},
+		{"docs/../app.js", http.StatusNotFound, "", ""},
+	} {
+		rec := get(t, s, http.MethodGet, s.URL(test.name))
+
+		if rec.Code != test.status {
+			t.Errorf("GET %q: status %d, want %d", test.name, rec.Code, test.status)
+			continue
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); got != test.contentType {
+			t.Errorf("GET %q: Content-Type %q, want %q", test.name, got, test.contentType)
+		}
+		if got := rec.Body.String(); got != test.body {
+			t.Errorf("GET %q: body %q, want %q", test.name, got, test.body)
+		}
+		if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+			t.Errorf("GET %q: Cache-Control %q, want no-store", test.name, got)
+		}
+	}
+}
+
+func TestServerDirectoryRedirect(t *testing.T) {
+	s := startTestServer(t)
+
+	for _, name := range []string{"docs", "a b?c#d"} {
+		rec := get(t, s, http.MethodGet, s.URL(name))
+
+		if rec.Code != http.StatusMovedPermanently {
+			t.Errorf("GET %q: status %d, want %d", name, rec.Code, http.StatusMovedPermanently)
+			continue
+		}
+
+		// The redirect must lead to the directory, with '/' appended.
+		location := rec.Header().Get("Location")
+		if got, ok := s.Name(strings.TrimSuffix(s.BaseURL(), s.prefix) + location); !ok || got != name+"/" {
+			t.Errorf("GET %q: redirected to %q, which is %q, %t", name, location, got, ok)
+		}
+
+		if rec := get(t, s, http.MethodGet, location); rec.Code != http.StatusOK {
+			t.Errorf("GET %q: status %d after the redirect", location, rec.Code)
+		}
+	}
+}
+
+func TestServerRejects(t *testing.T) {
+	s := startTestServer(t)
+
+	if rec := get(t, s, http.MethodGet, "/other/index.html"); rec.Code != http.StatusNotFound {
+		t.Errorf("request outside the prefix: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := get(t, s, http.MethodDelete, s.URL("app.js")); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE: status %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+
+	s.SetFS(nil)
+	if rec := get(t, s, http.MethodGet, s.URL("app.js")); rec.Code != http.StatusNotFound {
+		t.Errorf("GET without a file system: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestServerPages(t *testing.T) {
+	s := startTestServer(t)
+
+	s.SetPage("/generated.html", HTML("<p>generated</p>"))
+	s.SetPage("app.js", &Content{Data: []byte("let y = 2")})
+
+	for name, want := range map[string]string{
+		"generated.html": "<p>generated</p>",
+		"app.js":         "let y = 2",
+	} {
+		rec := get(t, s, http.MethodGet, s.URL(name))
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("GET %q: %d %q, want %q", name, rec.Code, rec.Body.String(), want)
+		}
+	}
+
+	// The type of pages without one is derived from the name.
+	if got := get(t, s, http.MethodGet, s.URL("app.js")).Header().Get("Content-Type"); got != "application/javascript; charset=utf-8" {
+		t.Errorf("Content-Type of page app.js = %q", got)
+	}
+
+	if rec := get(t, s, http.MethodHead, s.URL("generated.html")); rec.Body.Len() != 0 || rec.Header().Get("Content-Length") != "16" {
+		t.Errorf("HEAD: body %q, Content-Length %q", rec.Body.String(), rec.Header().Get("Content-Length"))
+	}
+
+	// Removing a page uncovers the file.
+	s.SetPage("app.js", nil)
+	if rec := get(t, s, http.MethodGet, s.URL("app.js")); rec.Body.String() != "let x = 1" {
+		t.Errorf("GET app.js after removing the page: %q", rec.Body.String())
+	}
+}
+
+func TestServerInterceptor(t *testing.T) {
+	s := startTestServer(t)
+	s.SetPage("page.html", HTML("<p>page</p>"))
+
+	var names []string
+	s.SetInterceptor(func(name string, r *http.Request) (*Content, error) {
+		names = append(names, name)
+
+		switch name {
+		case "api/data":
+			return &Content{Type: "application/json", Data: []byte(`{"q":"` + r.URL.Query().Get("q") + `"}`)}, nil
+
+		case "page.html":
+			return HTML("<p>intercepted</p>"), nil
+
+		case "broken":
+			return nil, errors.New("broken")
+		}
+
+		return nil, nil
+	})
+
+	for _, test := range []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{s.URL("api/data") + "?q=x", http.StatusOK, `{"q":"x"}`},
+		{s.URL("page.html"), http.StatusOK, "<p>intercepted</p>"},
+		{s.URL("app.js"), http.StatusOK, "let x = 1"},
+		{s.URL("broken"), http.StatusInternalServerError, "broken\n"},
+	} {
+		rec := get(t, s, http.MethodGet, test.url)
+		if rec.Code != test.status || rec.Body.String() != test.body {
+			t.Errorf("GET %s: %d %q, want %d %q", test.url, rec.Code, rec.Body.String(), test.status, test.body)
+		}
+	}
+
+	if got, want := strings.Join(names, " "), "api/data page.html app.js broken"; got != want {
+		t.Errorf("interceptor called for %q, want %q", got, want)
+	}
+}
+
+func TestServerNameURL(t *testing.T) {
+	s := startTestServer(t)
+
+	if !strings.HasPrefix(s.BaseURL(), "http://127.0.0.1:") || !strings.HasSuffix(s.BaseURL(), "/") {
+		t.Errorf("BaseURL() = %q", s.BaseURL())
+	}
+
+	for _, name := range []string{"", "index.html", "docs/", "docs/page.md", "spaces in name.txt", "a b?c#d/index.html", "ü/ß.html", "100%.txt"} {
+		u := s.URL(name)
+		if strings.ContainsAny(strings.TrimPrefix(u, "http://"), " ?#") {
+			t.Errorf("URL(%q) = %q is not escaped", name, u)
+		}
+
+		if got, ok := s.Name(u); !ok || got != name {
+			t.Errorf("Name(URL(%q)) = %q, %t", name, got, ok)
+		}
+	}
+
+	if got := s.URL("/docs/page.md"); got != s.URL("docs/page.md") {
+		t.Errorf("URL() with a leading '/' = %q", got)
+	}
+
+	for rawURL, want := range map[string]string{
+		strings.TrimSuffix(s.BaseURL(), "/"): "",
+		s.BaseURL() + "docs/page.md?x=1":     "docs/page.md",
+		s.BaseURL() + "docs/page.md#top":     "docs/page.md",
+	} {
+		if got, ok := s.Name(rawURL); !ok || got != want {
+			t.Errorf("Name(%q) = %q, %t, want %q, true", rawURL, got, ok, want)
+		}
+	}
+
+	for _, rawURL := range []string{"https://example.com/", "http://127.0.0.1:1/other/", s.BaseURL() + "%zz"} {
+		if name, ok := s.Name(rawURL); ok {
+			t.Errorf("Name(%q) = %q, true, want false", rawURL, name)
+		}
+	}
+}
+
+func TestServerHTTP(t *testing.T) {
+	s := startTestServer(t)
+
+	resp, err := http.Get(s.URL("docs/page.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != "# Page" {
+		t.Errorf("GET over HTTP: %d %q", resp.StatusCode, body)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/Gipcomp/win32/user32"
 	"github.com/Gipcomp/win32/win"
 	"github.com/Gipcomp/winapi/errs"
+	"github.com/Gipcomp/winapi/jsbridge"
 )
 
 const webViewWindowClass = `\o/ Walk_WebView_Class \o/`
@@ -68,6 +69,9 @@ type WebView struct {
 	statusTextChangedPublisher               EventPublisher
 	documentTitle                            string
 	documentTitleChangedPublisher            EventPublisher
+	bridge                                   *jsbridge.Bridge
+	external                                 *webViewExternal
+	webContent
 }
 
 func NewWebView(parent Container) (*WebView, error) {
@@ -103,7 +107,10 @@ func NewWebView(parent Container) (*WebView, error) {
 		},
 		shortcutsEnabled:         false,
 		nativeContextMenuEnabled: false,
+		bridge:                   jsbridge.NewBridge(),
 	}
+	wv.external = newWebViewExternal(wv)
+	wv.webContent.navigate = wv.SetURL
 
 	if err := InitWidget(
 		wv,
@@ -217,6 +224,8 @@ func NewWebView(parent Container) (*WebView, error) {
 }
 
 func (wv *WebView) Dispose() {
+	wv.webContent.close()
+
 	if wv.browserObject != nil {
 		wv.browserObject.Close(ole32.OLECLOSE_NOSAVE)
 		wv.browserObject.Release()
@@ -419,6 +428,34 @@ func (wv *WebView) Refresh() error {
 	})
 }
 
+// GoBack navigates to the previous page in the history.
+func (wv *WebView) GoBack() error {
+	return wv.callWebBrowser2("IWebBrowser2.GoBack", func(vtbl *shdocvw.IWebBrowser2Vtbl) uintptr { return vtbl.GoBack })
+}
+
+// GoForward navigates to the next page in the history.
+func (wv *WebView) GoForward() error {
+	return wv.callWebBrowser2("IWebBrowser2.GoForward", func(vtbl *shdocvw.IWebBrowser2Vtbl) uintptr { return vtbl.GoForward })
+}
+
+// Stop stops loading the current page.
+func (wv *WebView) Stop() error {
+	return wv.callWebBrowser2("IWebBrowser2.Stop", func(vtbl *shdocvw.IWebBrowser2Vtbl) uintptr { return vtbl.Stop })
+}
+
+// callWebBrowser2 calls the IWebBrowser2 method without arguments method
+// returns.
+func (wv *WebView) callWebBrowser2(name string, method func(vtbl *shdocvw.IWebBrowser2Vtbl) uintptr) error {
+	return wv.withWebBrowser2(func(webBrowser2 *shdocvw.IWebBrowser2) error {
+		hr, _, _ := syscall.Syscall(method(webBrowser2.LpVtbl), 1, uintptr(unsafe.Pointer(webBrowser2)), 0, 0)
+		if win.FAILED(win.HRESULT(hr)) {
+			return errs.ErrorFromHRESULT(name, win.HRESULT(hr))
+		}
+
+		return nil
+	})
+}
+
 func (wv *WebView) withWebBrowser2(f func(webBrowser2 *shdocvw.IWebBrowser2) error) error {
 	var webBrowser2Ptr unsafe.Pointer
 	if hr := wv.browserObject.QueryInterface(&shdocvw.IID_IWebBrowser2, &webBrowser2Ptr); win.FAILED(hr) {
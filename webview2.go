@@ -0,0 +1,756 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/gdi32"
+	"github.com/Gipcomp/win32/handle"
+	"github.com/Gipcomp/win32/ole32"
+	"github.com/Gipcomp/win32/user32"
+	"github.com/Gipcomp/win32/win"
+	"github.com/Gipcomp/winapi/errs"
+	"github.com/Gipcomp/winapi/jsbridge"
+)
+
+const webView2WindowClass = `\o/ Walk_WebView2_Class \o/`
+
+func init() {
+	AppendToWalkInit(func() {
+		MustRegisterWindowClass(webView2WindowClass)
+	})
+}
+
+// ErrWebView2Unavailable is returned by NewWebView2 if the WebView2 runtime
+// or WebView2Loader.dll cannot be found. It matches errs.ErrNotSupported.
+var ErrWebView2Unavailable = fmt.Errorf("WebView2 runtime not available: %w", errs.ErrNotSupported)
+
+// Page script of the bridge, see jsbridge.AsyncScript.
+const (
+	webView2BridgePost      = "function(m) { window.chrome.webview.postMessage(m); }"
+	webView2BridgeSubscribe = "function(cb) { window.chrome.webview.addEventListener('message', function(e) { cb(e.data); }); }"
+)
+
+// WebView2Options configures the browser of a WebView2.
+type WebView2Options struct {
+	// BrowserExecutableFolder is the folder of a fixed version runtime. If
+	// empty, the installed evergreen runtime is used.
+	BrowserExecutableFolder string
+
+	// UserDataFolder holds cookies, cache and the like. If empty, a folder
+	// named after the executable in the local application data folder is
+	// used.
+	UserDataFolder string
+}
+
+// WebView2 is a web browser widget based on Microsoft Edge WebView2. It
+// provides the events and methods of WebView that do not depend on the
+// IE engine. See NewWebBrowser for a fallback to WebView.
+type WebView2 struct {
+	WidgetBase
+	controller                               *iCoreWebView2Controller
+	webView                                  *iCoreWebView2
+	oleInitialized                           bool
+	url                                      string
+	urlChangedPublisher                      EventPublisher
+	shortcutsEnabled                         bool
+	shortcutsEnabledChangedPublisher         EventPublisher
+	nativeContextMenuEnabled                 bool
+	nativeContextMenuEnabledChangedPublisher EventPublisher
+	navigatingPublisher                      WebViewNavigatingEventPublisher
+	navigatedPublisher                       StringEventPublisher
+	navigatedErrorPublisher                  WebViewNavigatedErrorEventPublisher
+	documentCompletedPublisher               StringEventPublisher
+	canGoBack                                bool
+	canGoBackChangedPublisher                EventPublisher
+	canGoForward                             bool
+	canGoForwardChangedPublisher             EventPublisher
+	progressValue                            int32
+	progressMax                              int32
+	progressChangedPublisher                 EventPublisher
+	documentTitle                            string
+	documentTitleChangedPublisher            EventPublisher
+	bridge                                   *jsbridge.Bridge
+	webContent
+}
+
+// NewWebView2 returns a new WebView2 with default WebView2Options.
+func NewWebView2(parent Container) (*WebView2, error) {
+	return NewWebView2WithOptions(parent, WebView2Options{})
+}
+
+// NewWebView2WithOptions returns a new WebView2 configured by opts.
+//
+// ErrWebView2Unavailable is returned if the runtime is not installed.
+// Creating the browser runs a nested message loop until it is ready.
+func NewWebView2WithOptions(parent Container, opts WebView2Options) (*WebView2, error) {
+	browserFolder, err := webView2UTF16PtrOrNil(opts.BrowserExecutableFolder)
+	if err != nil {
+		return nil, errs.NewInvalidArgumentError(err.Error())
+	}
+
+	if !webView2Available(browserFolder) {
+		return nil, ErrWebView2Unavailable
+	}
+
+	userDataFolder := opts.UserDataFolder
+	if userDataFolder == "" {
+		userDataFolder = webView2DefaultUserDataFolder()
+	}
+	userDataFolderPtr, err := webView2UTF16PtrOrNil(userDataFolder)
+	if err != nil {
+		return nil, errs.NewInvalidArgumentError(err.Error())
+	}
+
+	if hr := ole32.OleInitialize(); hr != win.S_OK && hr != win.S_FALSE {
+		return nil, errs.NewError(fmt.Sprint("OleInitialize Error: ", hr))
+	}
+
+	wv := &WebView2{
+		progressMax: 100,
+		bridge:      jsbridge.NewBridge(),
+	}
+	wv.webContent.navigate = wv.SetURL
+
+	if err := InitWidget(
+		wv,
+		parent,
+		webView2WindowClass,
+		user32.WS_CLIPCHILDREN|user32.WS_VISIBLE,
+		0); err != nil {
+		ole32.OleUninitialize()
+		return nil, err
+	}
+	wv.oleInitialized = true
+
+	succeeded := false
+
+	defer func() {
+		if !succeeded {
+			wv.Dispose()
+		}
+	}()
+
+	if err := wv.createController(browserFolder, userDataFolderPtr); err != nil {
+		return nil, err
+	}
+
+	if err := wv.initWebView(); err != nil {
+		return nil, err
+	}
+
+	wv.SizeChanged().Attach(wv.onResize)
+
+	wv.MustRegisterProperty("URL", NewProperty(
+		func() interface{} {
+			return wv.url
+		},
+		func(v interface{}) error {
+			return wv.SetURL(assertStringOr(v, ""))
+		},
+		wv.urlChangedPublisher.Event()))
+
+	wv.MustRegisterProperty("ShortcutsEnabled", NewProperty(
+		func() interface{} {
+			return wv.ShortcutsEnabled()
+		},
+		func(v interface{}) error {
+			wv.SetShortcutsEnabled(v.(bool))
+			return nil
+		},
+		wv.shortcutsEnabledChangedPublisher.Event()))
+
+	wv.MustRegisterProperty("NativeContextMenuEnabled", NewProperty(
+		func() interface{} {
+			return wv.NativeContextMenuEnabled()
+		},
+		func(v interface{}) error {
+			wv.SetNativeContextMenuEnabled(v.(bool))
+			return nil
+		},
+		wv.nativeContextMenuEnabledChangedPublisher.Event()))
+
+	succeeded = true
+
+	return wv, nil
+}
+
+func webView2UTF16PtrOrNil(s string) (*uint16, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	return syscall.UTF16PtrFromString(s)
+}
+
+func webView2Available(browserFolder *uint16) bool {
+	if err := procGetAvailableCoreWebView2BrowserVersionString.Find(); err != nil {
+		return false
+	}
+
+	var version *uint16
+	hr, _, _ := syscall.Syscall(procGetAvailableCoreWebView2BrowserVersionString.Addr(), 2,
+		uintptr(unsafe.Pointer(browserFolder)),
+		uintptr(unsafe.Pointer(&version)),
+		0)
+
+	return !win.FAILED(win.HRESULT(hr)) && coTaskMemString(version) != ""
+}
+
+func webView2DefaultUserDataFolder() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	name := strings.TrimSuffix(filepath.Base(exe), filepath.Ext(exe))
+
+	return filepath.Join(dir, name, "WebView2")
+}
+
+// createController creates the environment and the controller, pumping
+// messages until both completion handlers ran.
+func (wv *WebView2) createController(browserFolder, userDataFolder *uint16) error {
+	var done bool
+	var err error
+
+	controllerCompleted := newWebView2Handler(func(hr uintptr, controller unsafe.Pointer) uintptr {
+		done = true
+
+		if win.FAILED(win.HRESULT(hr)) {
+			err = errs.ErrorFromHRESULT("ICoreWebView2Environment.CreateCoreWebView2Controller", win.HRESULT(hr))
+			return 0
+		}
+
+		wv.controller = (*iCoreWebView2Controller)(controller)
+		wv.controller.AddRef()
+
+		return 0
+	})
+	defer controllerCompleted.Release()
+
+	environmentCompleted := newWebView2Handler(func(hr uintptr, environment unsafe.Pointer) uintptr {
+		if win.FAILED(win.HRESULT(hr)) {
+			done = true
+			err = errs.ErrorFromHRESULT("CreateCoreWebView2EnvironmentWithOptions", win.HRESULT(hr))
+			return 0
+		}
+
+		env := (*iCoreWebView2Environment)(environment)
+		if hr := env.CreateCoreWebView2Controller(wv.hWnd, controllerCompleted); win.FAILED(hr) {
+			done = true
+			err = errs.ErrorFromHRESULT("ICoreWebView2Environment.CreateCoreWebView2Controller", hr)
+		}
+
+		return 0
+	})
+	defer environmentCompleted.Release()
+
+	hr, _, _ := syscall.Syscall6(procCreateCoreWebView2EnvironmentWithOptions.Addr(), 4,
+		uintptr(unsafe.Pointer(browserFolder)),
+		uintptr(unsafe.Pointer(userDataFolder)),
+		0,
+		uintptr(unsafe.Pointer(environmentCompleted)),
+		0,
+		0)
+	if win.FAILED(win.HRESULT(hr)) {
+		return errs.ErrorFromHRESULT("CreateCoreWebView2EnvironmentWithOptions", win.HRESULT(hr))
+	}
+
+	if err := webView2PumpMessages(&done); err != nil {
+		return err
+	}
+
+	return err
+}
+
+// webView2PumpMessages dispatches messages until *done is true. The
+// asynchronous WebView2 API completes through messages to the UI thread.
+func webView2PumpMessages(done *bool) error {
+	var msg user32.MSG
+
+	for !*done {
+		switch user32.GetMessage(&msg, 0, 0, 0) {
+		case 0:
+			// Leave WM_QUIT to the main loop.
+			user32.PostQuitMessage(int32(msg.WParam))
+			return errs.NewError("WM_QUIT while waiting for WebView2")
+
+		case -1:
+			return errs.LastError("GetMessage")
+		}
+
+		user32.TranslateMessage(&msg)
+		user32.DispatchMessage(&msg)
+	}
+
+	return nil
+}
+
+func (wv *WebView2) initWebView() error {
+	webView, hr := wv.controller.GetCoreWebView2()
+	if win.FAILED(hr) {
+		return errs.ErrorFromHRESULT("ICoreWebView2Controller.get_CoreWebView2", hr)
+	}
+	wv.webView = webView
+
+	wv.onResize()
+
+	settings, hr := webView.GetSettings()
+	if win.FAILED(hr) {
+		return errs.ErrorFromHRESULT("ICoreWebView2.get_Settings", hr)
+	}
+	settings.PutIsWebMessageEnabled(true)
+	settings.PutAreDefaultContextMenusEnabled(wv.nativeContextMenuEnabled)
+	settings.Release()
+
+	vtbl := webView.vtbl
+
+	for _, h := range []struct {
+		name   string
+		add    uintptr
+		invoke func(sender uintptr, args unsafe.Pointer) uintptr
+	}{
+		{"add_NavigationStarting", vtbl.AddNavigationStarting, wv.onNavigationStarting},
+		{"add_ContentLoading", vtbl.AddContentLoading, wv.onContentLoading},
+		{"add_SourceChanged", vtbl.AddSourceChanged, wv.onSourceChanged},
+		{"add_HistoryChanged", vtbl.AddHistoryChanged, wv.onHistoryChanged},
+		{"add_NavigationCompleted", vtbl.AddNavigationCompleted, wv.onNavigationCompleted},
+		{"add_DocumentTitleChanged", vtbl.AddDocumentTitleChanged, wv.onDocumentTitleChanged},
+		{"add_WebMessageReceived", vtbl.AddWebMessageReceived, wv.onWebMessageReceived},
+	} {
+		handler := newWebView2Handler(h.invoke)
+		hr := webView.addHandler(h.add, handler)
+		handler.Release()
+		if win.FAILED(hr) {
+			return errs.ErrorFromHRESULT("ICoreWebView2."+h.name, hr)
+		}
+	}
+
+	acceleratorKeyPressed := newWebView2Handler(wv.onAcceleratorKeyPressed)
+	defer acceleratorKeyPressed.Release()
+	if hr := wv.controller.AddAcceleratorKeyPressed(acceleratorKeyPressed); win.FAILED(hr) {
+		return errs.ErrorFromHRESULT("ICoreWebView2Controller.add_AcceleratorKeyPressed", hr)
+	}
+
+	script := jsbridge.AsyncScript(jsbridge.DefaultNamespace, webView2BridgePost, webView2BridgeSubscribe)
+	ignore := newWebView2Handler(func(hr uintptr, id unsafe.Pointer) uintptr { return 0 })
+	defer ignore.Release()
+	if hr := webView.AddScriptToExecuteOnDocumentCreated(script, ignore); win.FAILED(hr) {
+		return errs.ErrorFromHRESULT("ICoreWebView2.AddScriptToExecuteOnDocumentCreated", hr)
+	}
+
+	return nil
+}
+
+func (wv *WebView2) Dispose() {
+	wv.webContent.close()
+
+	if wv.controller != nil {
+		if wv.webView != nil {
+			wv.webView.Release()
+			wv.webView = nil
+		}
+
+		wv.controller.Close()
+		wv.controller.Release()
+		wv.controller = nil
+	}
+
+	if wv.oleInitialized {
+		ole32.OleUninitialize()
+		wv.oleInitialized = false
+	}
+
+	wv.WidgetBase.Dispose()
+}
+
+func (wv *WebView2) onNavigationStarting(sender uintptr, args unsafe.Pointer) uintptr {
+	navArgs := (*iCoreWebView2NavigationStartingEventArgs)(args)
+
+	eventData := &WebViewNavigatingEventData{
+		webView2Args: navArgs,
+		webView2URL:  navArgs.GetUri(),
+	}
+
+	if eventData.webView2URL != wv.webContent.generatedURL {
+		wv.navigatingPublisher.Publish(eventData)
+	}
+
+	if eventData.html != nil {
+		url, html := eventData.webView2URL, *eventData.html
+
+		// Navigating from within NavigationStarting is not allowed.
+		wv.Synchronize(func() {
+			if err := wv.respondHTML(url, html); err != nil {
+				log.Printf("*WebView2 - failed to respond to %s: %s", url, err)
+			}
+		})
+	}
+
+	wv.setProgress(0)
+
+	return 0
+}
+
+func (wv *WebView2) onContentLoading(sender uintptr, args unsafe.Pointer) uintptr {
+	wv.setProgress(50)
+
+	wv.navigatedPublisher.Publish(wv.url)
+
+	return 0
+}
+
+func (wv *WebView2) onSourceChanged(sender uintptr, args unsafe.Pointer) uintptr {
+	url, hr := wv.webView.GetSource()
+	if win.FAILED(hr) {
+		return 0
+	}
+
+	if url != wv.url {
+		wv.url = url
+		wv.urlChangedPublisher.Publish()
+	}
+
+	return 0
+}
+
+func (wv *WebView2) onHistoryChanged(sender uintptr, args unsafe.Pointer) uintptr {
+	if canGoBack := wv.webView.GetCanGoBack(); canGoBack != wv.canGoBack {
+		wv.canGoBack = canGoBack
+		wv.canGoBackChangedPublisher.Publish()
+	}
+
+	if canGoForward := wv.webView.GetCanGoForward(); canGoForward != wv.canGoForward {
+		wv.canGoForward = canGoForward
+		wv.canGoForwardChangedPublisher.Publish()
+	}
+
+	return 0
+}
+
+func (wv *WebView2) onNavigationCompleted(sender uintptr, args unsafe.Pointer) uintptr {
+	wv.setProgress(100)
+
+	navArgs := (*iCoreWebView2NavigationCompletedEventArgs)(args)
+	if !navArgs.GetIsSuccess() {
+		wv.navigatedErrorPublisher.Publish(&WebViewNavigatedErrorEventData{
+			webView2:            true,
+			webView2URL:         wv.url,
+			webView2ErrorStatus: navArgs.GetWebErrorStatus(),
+		})
+		return 0
+	}
+
+	wv.documentCompletedPublisher.Publish(wv.url)
+
+	return 0
+}
+
+func (wv *WebView2) onDocumentTitleChanged(sender uintptr, args unsafe.Pointer) uintptr {
+	if title := wv.webView.GetDocumentTitle(); title != wv.documentTitle {
+		wv.documentTitle = title
+		wv.documentTitleChangedPublisher.Publish()
+	}
+
+	return 0
+}
+
+func (wv *WebView2) onWebMessageReceived(sender uintptr, args unsafe.Pointer) uintptr {
+	message, ok := (*iCoreWebView2WebMessageReceivedEventArgs)(args).TryGetWebMessageAsString()
+	if !ok {
+		return 0
+	}
+
+	if response := wv.bridge.Dispatch([]byte(message)); response != nil {
+		wv.webView.PostWebMessageAsString(string(response))
+	}
+
+	return 0
+}
+
+func (wv *WebView2) onAcceleratorKeyPressed(sender uintptr, args unsafe.Pointer) uintptr {
+	if wv.shortcutsEnabled {
+		return 0
+	}
+
+	keyArgs := (*iCoreWebView2AcceleratorKeyPressedEventArgs)(args)
+
+	switch keyArgs.GetKeyEventKind() {
+	case webView2KeyEventKindKeyDown, webView2KeyEventKindSystemKeyDown:
+		// Keys like arrows also count as accelerators, only suppress the
+		// browser shortcuts, which involve Ctrl or Alt.
+		if user32.GetKeyState(user32.VK_CONTROL) < 0 || user32.GetKeyState(user32.VK_MENU) < 0 {
+			keyArgs.PutHandled(true)
+		}
+	}
+
+	return 0
+}
+
+func (wv *WebView2) setProgress(value int32) {
+	if value != wv.progressValue {
+		wv.progressValue = value
+		wv.progressChangedPublisher.Publish()
+	}
+}
+
+func (wv *WebView2) URL() (url string, err error) {
+	return wv.url, nil
+}
+
+func (wv *WebView2) SetURL(url string) error {
+	if hr := wv.webView.Navigate(url); win.FAILED(hr) {
+		return errs.ErrorFromHRESULT("ICoreWebView2.Navigate", hr)
+	}
+
+	return nil
+}
+
+func (wv *WebView2) URLChanged() *Event {
+	return wv.urlChangedPublisher.Event()
+}
+
+func (wv *WebView2) ShortcutsEnabled() bool {
+	return wv.shortcutsEnabled
+}
+
+func (wv *WebView2) SetShortcutsEnabled(value bool) {
+	wv.shortcutsEnabled = value
+	wv.shortcutsEnabledChangedPublisher.Publish()
+}
+
+func (wv *WebView2) ShortcutsEnabledChanged() *Event {
+	return wv.shortcutsEnabledChangedPublisher.Event()
+}
+
+func (wv *WebView2) NativeContextMenuEnabled() bool {
+	return wv.nativeContextMenuEnabled
+}
+
+func (wv *WebView2) SetNativeContextMenuEnabled(value bool) {
+	wv.nativeContextMenuEnabled = value
+
+	if settings, hr := wv.webView.GetSettings(); !win.FAILED(hr) {
+		settings.PutAreDefaultContextMenusEnabled(value)
+		settings.Release()
+	}
+
+	wv.nativeContextMenuEnabledChangedPublisher.Publish()
+}
+
+func (wv *WebView2) NativeContextMenuEnabledChanged() *Event {
+	return wv.nativeContextMenuEnabledChangedPublisher.Event()
+}
+
+// Navigating is published before navigating to a URL. Only
+// WebViewNavigatingEventData.Url, Canceled, SetCanceled and RespondHTML are
+// supported by WebView2.
+func (wv *WebView2) Navigating() *WebViewNavigatingEvent {
+	return wv.navigatingPublisher.Event()
+}
+
+// Navigated is published when the content of the new page starts loading.
+func (wv *WebView2) Navigated() *StringEvent {
+	return wv.navigatedPublisher.Event()
+}
+
+// NavigatedError is published instead of DocumentCompleted if navigating
+// failed. The navigation cannot be canceled anymore at that point.
+func (wv *WebView2) NavigatedError() *WebViewNavigatedErrorEvent {
+	return wv.navigatedErrorPublisher.Event()
+}
+
+// DocumentCompleted is published when the page finished loading.
+func (wv *WebView2) DocumentCompleted() *StringEvent {
+	return wv.documentCompletedPublisher.Event()
+}
+
+func (wv *WebView2) CanGoBack() bool {
+	return wv.canGoBack
+}
+
+func (wv *WebView2) CanGoBackChanged() *Event {
+	return wv.canGoBackChangedPublisher.Event()
+}
+
+func (wv *WebView2) CanGoForward() bool {
+	return wv.canGoForward
+}
+
+func (wv *WebView2) CanGoForwardChanged() *Event {
+	return wv.canGoForwardChangedPublisher.Event()
+}
+
+// ProgressValue returns the loading progress. WebView2 does not report
+// progress, so it only distinguishes started, loading content and
+// completed.
+func (wv *WebView2) ProgressValue() int32 {
+	return wv.progressValue
+}
+
+func (wv *WebView2) ProgressMax() int32 {
+	return wv.progressMax
+}
+
+func (wv *WebView2) ProgressChanged() *Event {
+	return wv.progressChangedPublisher.Event()
+}
+
+func (wv *WebView2) DocumentTitle() string {
+	return wv.documentTitle
+}
+
+func (wv *WebView2) DocumentTitleChanged() *Event {
+	return wv.documentTitleChangedPublisher.Event()
+}
+
+func (wv *WebView2) Refresh() error {
+	if hr := wv.webView.Reload(); win.FAILED(hr) {
+		return errs.ErrorFromHRESULT("ICoreWebView2.Reload", hr)
+	}
+
+	return nil
+}
+
+// GoBack navigates to the previous page in the history.
+func (wv *WebView2) GoBack() error {
+	if hr := wv.webView.GoBack(); win.FAILED(hr) {
+		return errs.ErrorFromHRESULT("ICoreWebView2.GoBack", hr)
+	}
+
+	return nil
+}
+
+// GoForward navigates to the next page in the history.
+func (wv *WebView2) GoForward() error {
+	if hr := wv.webView.GoForward(); win.FAILED(hr) {
+		return errs.ErrorFromHRESULT("ICoreWebView2.GoForward", hr)
+	}
+
+	return nil
+}
+
+// Stop stops loading the current page.
+func (wv *WebView2) Stop() error {
+	if hr := wv.webView.Stop(); win.FAILED(hr) {
+		return errs.ErrorFromHRESULT("ICoreWebView2.Stop", hr)
+	}
+
+	return nil
+}
+
+// Bind makes fn callable from page script as window.go.<name>, returning a
+// Promise. See WebView.Bind for the conversion of arguments and results.
+//
+// Functions can be bound at any time, page script looks them up when they
+// are called.
+func (wv *WebView2) Bind(name string, fn interface{}) error {
+	if err := wv.bridge.Bind(name, fn); err != nil {
+		return errs.NewInvalidArgumentError(err.Error())
+	}
+
+	return nil
+}
+
+// Unbind removes the func bound as name.
+func (wv *WebView2) Unbind(name string) {
+	wv.bridge.Unbind(name)
+}
+
+// Bridge returns the jsbridge.Bridge that dispatches the calls page script
+// makes.
+func (wv *WebView2) Bridge() *jsbridge.Bridge {
+	return wv.bridge
+}
+
+// Eval evaluates script in the current document and returns its value,
+// converted through JSON like json.Unmarshal does into an interface{}.
+// A *jsbridge.EvalError is returned if script throws.
+//
+// WebView2 evaluates script asynchronously, Eval runs a nested message
+// loop until it is done.
+func (wv *WebView2) Eval(script string) (interface{}, error) {
+	var done bool
+	var result string
+	var err error
+
+	// The browser may still hold the handler after completion, it lives
+	// until the browser releases it too.
+	handler := newWebView2Handler(func(hr uintptr, resultJSON unsafe.Pointer) uintptr {
+		done = true
+
+		if win.FAILED(win.HRESULT(hr)) {
+			err = errs.ErrorFromHRESULT("ICoreWebView2.ExecuteScript", win.HRESULT(hr))
+			return 0
+		}
+
+		// The result is the JSON encoding of the string EvalScript yields.
+		if e := json.Unmarshal([]byte(win.UTF16PtrToString((*uint16)(resultJSON))), &result); e != nil {
+			err = errs.NewError(fmt.Sprintf("invalid ExecuteScript result: %s", e))
+		}
+
+		return 0
+	})
+	defer handler.Release()
+
+	if hr := wv.webView.ExecuteScript(jsbridge.EvalScript(script), handler); win.FAILED(hr) {
+		return nil, errs.ErrorFromHRESULT("ICoreWebView2.ExecuteScript", hr)
+	}
+
+	if err := webView2PumpMessages(&done); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return jsbridge.DecodeEvalResult(result)
+}
+
+func (wv *WebView2) onResize() {
+	if wv.controller == nil {
+		return
+	}
+
+	bounds := wv.ClientBoundsPixels()
+
+	wv.controller.PutBounds(gdi32.RECT{
+		Right:  int32(bounds.Width),
+		Bottom: int32(bounds.Height),
+	})
+}
+
+func (wv *WebView2) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr) uintptr {
+	switch msg {
+	case user32.WM_SETFOCUS:
+		if wv.controller != nil {
+			wv.controller.MoveFocus(webView2MoveFocusReasonProgrammatic)
+		}
+
+	case user32.WM_MOUSEACTIVATE:
+		wv.invalidateBorderInParent()
+	}
+
+	return wv.WidgetBase.WndProc(hwnd, msg, wParam, lParam)
+}
+
+func (wv *WebView2) CreateLayoutItem(ctx *LayoutContext) LayoutItem {
+	return NewGreedyLayoutItem()
+}
@@ -0,0 +1,561 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/gdi32"
+	"github.com/Gipcomp/win32/handle"
+	"github.com/Gipcomp/win32/ole32"
+	"github.com/Gipcomp/win32/win"
+	"golang.org/x/sys/windows"
+)
+
+// The WebView2 loader is not part of Windows, applications using WebView2
+// ship WebView2Loader.dll next to their executable.
+var (
+	libwebView2Loader                                = windows.NewLazyDLL("WebView2Loader.dll")
+	procCreateCoreWebView2EnvironmentWithOptions     = libwebView2Loader.NewProc("CreateCoreWebView2EnvironmentWithOptions")
+	procGetAvailableCoreWebView2BrowserVersionString = libwebView2Loader.NewProc("GetAvailableCoreWebView2BrowserVersionString")
+)
+
+// webView2EInvalidArg is E_INVALIDARG, which overflows win.HRESULT as
+// untyped constant.
+const webView2EInvalidArg win.HRESULT = -0x7ff8ffa9
+
+const (
+	webView2MoveFocusReasonProgrammatic = 0
+
+	webView2KeyEventKindKeyDown       = 0
+	webView2KeyEventKindSystemKeyDown = 2
+)
+
+type webView2EventRegistrationToken struct {
+	value int64
+}
+
+// comCall calls the COM method fn with args, the first being the object.
+func comCall(fn uintptr, args ...uintptr) win.HRESULT {
+	var a [9]uintptr
+	copy(a[:], args)
+
+	var hr uintptr
+	switch {
+	case len(args) <= 3:
+		hr, _, _ = syscall.Syscall(fn, uintptr(len(args)), a[0], a[1], a[2])
+
+	case len(args) <= 6:
+		hr, _, _ = syscall.Syscall6(fn, uintptr(len(args)), a[0], a[1], a[2], a[3], a[4], a[5])
+
+	default:
+		hr, _, _ = syscall.Syscall9(fn, uintptr(len(args)), a[0], a[1], a[2], a[3], a[4], a[5], a[6], a[7], a[8])
+	}
+
+	return win.HRESULT(hr)
+}
+
+// coTaskMemString returns the string s points to and frees it.
+func coTaskMemString(s *uint16) string {
+	if s == nil {
+		return ""
+	}
+	defer ole32.CoTaskMemFree(uintptr(unsafe.Pointer(s)))
+
+	return win.UTF16PtrToString(s)
+}
+
+var webView2HandlerVtblInstance *webView2HandlerVtbl
+
+func init() {
+	AppendToWalkInit(func() {
+		webView2HandlerVtblInstance = &webView2HandlerVtbl{
+			QueryInterface: syscall.NewCallback(webView2Handler_QueryInterface),
+			AddRef:         syscall.NewCallback(webView2Handler_AddRef),
+			Release:        syscall.NewCallback(webView2Handler_Release),
+			Invoke:         syscall.NewCallback(webView2Handler_Invoke),
+		}
+	})
+}
+
+type webView2HandlerVtbl struct {
+	QueryInterface uintptr
+	AddRef         uintptr
+	Release        uintptr
+	Invoke         uintptr
+}
+
+// webView2Handler implements all WebView2 completed and event handler
+// interfaces, which only differ in the types of the two arguments of
+// Invoke. The second one is a pointer in all of them.
+type webView2Handler struct {
+	vtbl   *webView2HandlerVtbl
+	invoke func(arg1 uintptr, arg2 unsafe.Pointer) uintptr
+	refs   int32
+}
+
+// webView2LiveHandlers keeps handlers reachable while the browser holds
+// references to them.
+var (
+	webView2LiveHandlersMutex sync.Mutex
+	webView2LiveHandlers      = make(map[*webView2Handler]struct{})
+)
+
+// newWebView2Handler returns a handler calling invoke with a reference
+// count of 1, which the caller must Release once it passed the handler on.
+func newWebView2Handler(invoke func(arg1 uintptr, arg2 unsafe.Pointer) uintptr) *webView2Handler {
+	handler := &webView2Handler{vtbl: webView2HandlerVtblInstance, invoke: invoke, refs: 1}
+
+	webView2LiveHandlersMutex.Lock()
+	webView2LiveHandlers[handler] = struct{}{}
+	webView2LiveHandlersMutex.Unlock()
+
+	return handler
+}
+
+func (h *webView2Handler) AddRef() uint32 {
+	return uint32(atomic.AddInt32(&h.refs, 1))
+}
+
+// Release decrements the reference count and drops the handler on the
+// final release.
+func (h *webView2Handler) Release() uint32 {
+	refs := atomic.AddInt32(&h.refs, -1)
+	if refs == 0 {
+		webView2LiveHandlersMutex.Lock()
+		delete(webView2LiveHandlers, h)
+		webView2LiveHandlersMutex.Unlock()
+	}
+
+	return uint32(refs)
+}
+
+func webView2Handler_QueryInterface(handler *webView2Handler, riid ole32.REFIID, ppvObject *unsafe.Pointer) uintptr {
+	*ppvObject = unsafe.Pointer(handler)
+	handler.AddRef()
+
+	return win.S_OK
+}
+
+func webView2Handler_AddRef(handler *webView2Handler) uintptr {
+	return uintptr(handler.AddRef())
+}
+
+func webView2Handler_Release(handler *webView2Handler) uintptr {
+	return uintptr(handler.Release())
+}
+
+func webView2Handler_Invoke(handler *webView2Handler, arg1 uintptr, arg2 unsafe.Pointer) uintptr {
+	return handler.invoke(arg1, arg2)
+}
+
+type iUnknownVtbl struct {
+	QueryInterface uintptr
+	AddRef         uintptr
+	Release        uintptr
+}
+
+type iCoreWebView2EnvironmentVtbl struct {
+	iUnknownVtbl
+	CreateCoreWebView2Controller     uintptr
+	CreateWebResourceResponse        uintptr
+	GetBrowserVersionString          uintptr
+	AddNewBrowserVersionAvailable    uintptr
+	RemoveNewBrowserVersionAvailable uintptr
+}
+
+type iCoreWebView2Environment struct {
+	vtbl *iCoreWebView2EnvironmentVtbl
+}
+
+func (e *iCoreWebView2Environment) AddRef() {
+	comCall(e.vtbl.AddRef, uintptr(unsafe.Pointer(e)))
+}
+
+func (e *iCoreWebView2Environment) Release() {
+	comCall(e.vtbl.Release, uintptr(unsafe.Pointer(e)))
+}
+
+func (e *iCoreWebView2Environment) CreateCoreWebView2Controller(hWnd handle.HWND, handler *webView2Handler) win.HRESULT {
+	return comCall(e.vtbl.CreateCoreWebView2Controller, uintptr(unsafe.Pointer(e)), uintptr(hWnd), uintptr(unsafe.Pointer(handler)))
+}
+
+type iCoreWebView2ControllerVtbl struct {
+	iUnknownVtbl
+	GetIsVisible                      uintptr
+	PutIsVisible                      uintptr
+	GetBounds                         uintptr
+	PutBounds                         uintptr
+	GetZoomFactor                     uintptr
+	PutZoomFactor                     uintptr
+	AddZoomFactorChanged              uintptr
+	RemoveZoomFactorChanged           uintptr
+	SetBoundsAndZoomFactor            uintptr
+	MoveFocus                         uintptr
+	AddMoveFocusRequested             uintptr
+	RemoveMoveFocusRequested          uintptr
+	AddGotFocus                       uintptr
+	RemoveGotFocus                    uintptr
+	AddLostFocus                      uintptr
+	RemoveLostFocus                   uintptr
+	AddAcceleratorKeyPressed          uintptr
+	RemoveAcceleratorKeyPressed       uintptr
+	GetParentWindow                   uintptr
+	PutParentWindow                   uintptr
+	NotifyParentWindowPositionChanged uintptr
+	Close                             uintptr
+	GetCoreWebView2                   uintptr
+}
+
+type iCoreWebView2Controller struct {
+	vtbl *iCoreWebView2ControllerVtbl
+}
+
+func (c *iCoreWebView2Controller) AddRef() {
+	comCall(c.vtbl.AddRef, uintptr(unsafe.Pointer(c)))
+}
+
+func (c *iCoreWebView2Controller) Release() {
+	comCall(c.vtbl.Release, uintptr(unsafe.Pointer(c)))
+}
+
+func (c *iCoreWebView2Controller) PutBounds(bounds gdi32.RECT) win.HRESULT {
+	this := uintptr(unsafe.Pointer(c))
+
+	// RECT is passed by value, which each ABI does differently.
+	switch runtime.GOARCH {
+	case "386", "arm":
+		return comCall(c.vtbl.PutBounds, this, uintptr(bounds.Left), uintptr(bounds.Top), uintptr(bounds.Right), uintptr(bounds.Bottom))
+
+	case "arm64":
+		return comCall(c.vtbl.PutBounds, this,
+			uintptr(uint32(bounds.Left))|uintptr(uint32(bounds.Top))<<32,
+			uintptr(uint32(bounds.Right))|uintptr(uint32(bounds.Bottom))<<32)
+	}
+
+	return comCall(c.vtbl.PutBounds, this, uintptr(unsafe.Pointer(&bounds)))
+}
+
+func (c *iCoreWebView2Controller) MoveFocus(reason uintptr) win.HRESULT {
+	return comCall(c.vtbl.MoveFocus, uintptr(unsafe.Pointer(c)), reason)
+}
+
+func (c *iCoreWebView2Controller) AddAcceleratorKeyPressed(handler *webView2Handler) win.HRESULT {
+	var token webView2EventRegistrationToken
+	return comCall(c.vtbl.AddAcceleratorKeyPressed, uintptr(unsafe.Pointer(c)), uintptr(unsafe.Pointer(handler)), uintptr(unsafe.Pointer(&token)))
+}
+
+func (c *iCoreWebView2Controller) Close() win.HRESULT {
+	return comCall(c.vtbl.Close, uintptr(unsafe.Pointer(c)))
+}
+
+func (c *iCoreWebView2Controller) GetCoreWebView2() (*iCoreWebView2, win.HRESULT) {
+	var webView *iCoreWebView2
+	hr := comCall(c.vtbl.GetCoreWebView2, uintptr(unsafe.Pointer(c)), uintptr(unsafe.Pointer(&webView)))
+	return webView, hr
+}
+
+type iCoreWebView2Vtbl struct {
+	iUnknownVtbl
+	GetSettings                            uintptr
+	GetSource                              uintptr
+	Navigate                               uintptr
+	NavigateToString                       uintptr
+	AddNavigationStarting                  uintptr
+	RemoveNavigationStarting               uintptr
+	AddContentLoading                      uintptr
+	RemoveContentLoading                   uintptr
+	AddSourceChanged                       uintptr
+	RemoveSourceChanged                    uintptr
+	AddHistoryChanged                      uintptr
+	RemoveHistoryChanged                   uintptr
+	AddNavigationCompleted                 uintptr
+	RemoveNavigationCompleted              uintptr
+	AddFrameNavigationStarting             uintptr
+	RemoveFrameNavigationStarting          uintptr
+	AddFrameNavigationCompleted            uintptr
+	RemoveFrameNavigationCompleted         uintptr
+	AddScriptDialogOpening                 uintptr
+	RemoveScriptDialogOpening              uintptr
+	AddPermissionRequested                 uintptr
+	RemovePermissionRequested              uintptr
+	AddProcessFailed                       uintptr
+	RemoveProcessFailed                    uintptr
+	AddScriptToExecuteOnDocumentCreated    uintptr
+	RemoveScriptToExecuteOnDocumentCreated uintptr
+	ExecuteScript                          uintptr
+	CapturePreview                         uintptr
+	Reload                                 uintptr
+	PostWebMessageAsJson                   uintptr
+	PostWebMessageAsString                 uintptr
+	AddWebMessageReceived                  uintptr
+	RemoveWebMessageReceived               uintptr
+	CallDevToolsProtocolMethod             uintptr
+	GetBrowserProcessId                    uintptr
+	GetCanGoBack                           uintptr
+	GetCanGoForward                        uintptr
+	GoBack                                 uintptr
+	GoForward                              uintptr
+	GetDevToolsProtocolEventReceiver       uintptr
+	Stop                                   uintptr
+	AddNewWindowRequested                  uintptr
+	RemoveNewWindowRequested               uintptr
+	AddDocumentTitleChanged                uintptr
+	RemoveDocumentTitleChanged             uintptr
+	GetDocumentTitle                       uintptr
+	AddHostObjectToScript                  uintptr
+	RemoveHostObjectFromScript             uintptr
+	OpenDevToolsWindow                     uintptr
+	AddContainsFullScreenElementChanged    uintptr
+	RemoveContainsFullScreenElementChanged uintptr
+	GetContainsFullScreenElement           uintptr
+	AddWebResourceRequested                uintptr
+	RemoveWebResourceRequested             uintptr
+	AddWebResourceRequestedFilter          uintptr
+	RemoveWebResourceRequestedFilter       uintptr
+	AddWindowCloseRequested                uintptr
+	RemoveWindowCloseRequested             uintptr
+}
+
+type iCoreWebView2 struct {
+	vtbl *iCoreWebView2Vtbl
+}
+
+func (wv *iCoreWebView2) this() uintptr {
+	return uintptr(unsafe.Pointer(wv))
+}
+
+func (wv *iCoreWebView2) Release() {
+	comCall(wv.vtbl.Release, wv.this())
+}
+
+func (wv *iCoreWebView2) GetSettings() (*iCoreWebView2Settings, win.HRESULT) {
+	var settings *iCoreWebView2Settings
+	hr := comCall(wv.vtbl.GetSettings, wv.this(), uintptr(unsafe.Pointer(&settings)))
+	return settings, hr
+}
+
+func (wv *iCoreWebView2) GetSource() (string, win.HRESULT) {
+	var uri *uint16
+	hr := comCall(wv.vtbl.GetSource, wv.this(), uintptr(unsafe.Pointer(&uri)))
+	return coTaskMemString(uri), hr
+}
+
+func (wv *iCoreWebView2) Navigate(url string) win.HRESULT {
+	return wv.callWithString(wv.vtbl.Navigate, url)
+}
+
+func (wv *iCoreWebView2) callWithString(fn uintptr, s string) win.HRESULT {
+	p, err := syscall.UTF16PtrFromString(s)
+	if err != nil {
+		return webView2EInvalidArg
+	}
+
+	return comCall(fn, wv.this(), uintptr(unsafe.Pointer(p)))
+}
+
+// addHandler registers handler with the add_* method fn.
+func (wv *iCoreWebView2) addHandler(fn uintptr, handler *webView2Handler) win.HRESULT {
+	var token webView2EventRegistrationToken
+	return comCall(fn, wv.this(), uintptr(unsafe.Pointer(handler)), uintptr(unsafe.Pointer(&token)))
+}
+
+func (wv *iCoreWebView2) AddScriptToExecuteOnDocumentCreated(script string, handler *webView2Handler) win.HRESULT {
+	p, err := syscall.UTF16PtrFromString(script)
+	if err != nil {
+		return webView2EInvalidArg
+	}
+
+	return comCall(wv.vtbl.AddScriptToExecuteOnDocumentCreated, wv.this(), uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(handler)))
+}
+
+func (wv *iCoreWebView2) ExecuteScript(script string, handler *webView2Handler) win.HRESULT {
+	p, err := syscall.UTF16PtrFromString(script)
+	if err != nil {
+		return webView2EInvalidArg
+	}
+
+	return comCall(wv.vtbl.ExecuteScript, wv.this(), uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(handler)))
+}
+
+func (wv *iCoreWebView2) Reload() win.HRESULT {
+	return comCall(wv.vtbl.Reload, wv.this())
+}
+
+func (wv *iCoreWebView2) PostWebMessageAsString(message string) win.HRESULT {
+	return wv.callWithString(wv.vtbl.PostWebMessageAsString, message)
+}
+
+func (wv *iCoreWebView2) GetCanGoBack() bool {
+	var value win.BOOL
+	comCall(wv.vtbl.GetCanGoBack, wv.this(), uintptr(unsafe.Pointer(&value)))
+	return value != 0
+}
+
+func (wv *iCoreWebView2) GetCanGoForward() bool {
+	var value win.BOOL
+	comCall(wv.vtbl.GetCanGoForward, wv.this(), uintptr(unsafe.Pointer(&value)))
+	return value != 0
+}
+
+func (wv *iCoreWebView2) GoBack() win.HRESULT {
+	return comCall(wv.vtbl.GoBack, wv.this())
+}
+
+func (wv *iCoreWebView2) GoForward() win.HRESULT {
+	return comCall(wv.vtbl.GoForward, wv.this())
+}
+
+func (wv *iCoreWebView2) Stop() win.HRESULT {
+	return comCall(wv.vtbl.Stop, wv.this())
+}
+
+func (wv *iCoreWebView2) GetDocumentTitle() string {
+	var title *uint16
+	comCall(wv.vtbl.GetDocumentTitle, wv.this(), uintptr(unsafe.Pointer(&title)))
+	return coTaskMemString(title)
+}
+
+type iCoreWebView2SettingsVtbl struct {
+	iUnknownVtbl
+	GetIsScriptEnabled                uintptr
+	PutIsScriptEnabled                uintptr
+	GetIsWebMessageEnabled            uintptr
+	PutIsWebMessageEnabled            uintptr
+	GetAreDefaultScriptDialogsEnabled uintptr
+	PutAreDefaultScriptDialogsEnabled uintptr
+	GetIsStatusBarEnabled             uintptr
+	PutIsStatusBarEnabled             uintptr
+	GetAreDevToolsEnabled             uintptr
+	PutAreDevToolsEnabled             uintptr
+	GetAreDefaultContextMenusEnabled  uintptr
+	PutAreDefaultContextMenusEnabled  uintptr
+	GetAreHostObjectsAllowed          uintptr
+	PutAreHostObjectsAllowed          uintptr
+	GetIsZoomControlEnabled           uintptr
+	PutIsZoomControlEnabled           uintptr
+	GetIsBuiltInErrorPageEnabled      uintptr
+	PutIsBuiltInErrorPageEnabled      uintptr
+}
+
+type iCoreWebView2Settings struct {
+	vtbl *iCoreWebView2SettingsVtbl
+}
+
+func (s *iCoreWebView2Settings) Release() {
+	comCall(s.vtbl.Release, uintptr(unsafe.Pointer(s)))
+}
+
+func (s *iCoreWebView2Settings) PutIsWebMessageEnabled(enabled bool) win.HRESULT {
+	return comCall(s.vtbl.PutIsWebMessageEnabled, uintptr(unsafe.Pointer(s)), uintptr(win.BoolToBOOL(enabled)))
+}
+
+func (s *iCoreWebView2Settings) PutAreDefaultContextMenusEnabled(enabled bool) win.HRESULT {
+	return comCall(s.vtbl.PutAreDefaultContextMenusEnabled, uintptr(unsafe.Pointer(s)), uintptr(win.BoolToBOOL(enabled)))
+}
+
+type iCoreWebView2NavigationStartingEventArgsVtbl struct {
+	iUnknownVtbl
+	GetUri             uintptr
+	GetIsUserInitiated uintptr
+	GetIsRedirected    uintptr
+	GetRequestHeaders  uintptr
+	GetCancel          uintptr
+	PutCancel          uintptr
+	GetNavigationId    uintptr
+}
+
+type iCoreWebView2NavigationStartingEventArgs struct {
+	vtbl *iCoreWebView2NavigationStartingEventArgsVtbl
+}
+
+func (args *iCoreWebView2NavigationStartingEventArgs) GetUri() string {
+	var uri *uint16
+	comCall(args.vtbl.GetUri, uintptr(unsafe.Pointer(args)), uintptr(unsafe.Pointer(&uri)))
+	return coTaskMemString(uri)
+}
+
+func (args *iCoreWebView2NavigationStartingEventArgs) GetCancel() bool {
+	var cancel win.BOOL
+	comCall(args.vtbl.GetCancel, uintptr(unsafe.Pointer(args)), uintptr(unsafe.Pointer(&cancel)))
+	return cancel != 0
+}
+
+func (args *iCoreWebView2NavigationStartingEventArgs) PutCancel(cancel bool) {
+	comCall(args.vtbl.PutCancel, uintptr(unsafe.Pointer(args)), uintptr(win.BoolToBOOL(cancel)))
+}
+
+type iCoreWebView2NavigationCompletedEventArgsVtbl struct {
+	iUnknownVtbl
+	GetIsSuccess      uintptr
+	GetWebErrorStatus uintptr
+	GetNavigationId   uintptr
+}
+
+type iCoreWebView2NavigationCompletedEventArgs struct {
+	vtbl *iCoreWebView2NavigationCompletedEventArgsVtbl
+}
+
+func (args *iCoreWebView2NavigationCompletedEventArgs) GetIsSuccess() bool {
+	var success win.BOOL
+	comCall(args.vtbl.GetIsSuccess, uintptr(unsafe.Pointer(args)), uintptr(unsafe.Pointer(&success)))
+	return success != 0
+}
+
+func (args *iCoreWebView2NavigationCompletedEventArgs) GetWebErrorStatus() int32 {
+	var status int32
+	comCall(args.vtbl.GetWebErrorStatus, uintptr(unsafe.Pointer(args)), uintptr(unsafe.Pointer(&status)))
+	return status
+}
+
+type iCoreWebView2WebMessageReceivedEventArgsVtbl struct {
+	iUnknownVtbl
+	GetSource                uintptr
+	GetWebMessageAsJson      uintptr
+	TryGetWebMessageAsString uintptr
+}
+
+type iCoreWebView2WebMessageReceivedEventArgs struct {
+	vtbl *iCoreWebView2WebMessageReceivedEventArgsVtbl
+}
+
+func (args *iCoreWebView2WebMessageReceivedEventArgs) TryGetWebMessageAsString() (string, bool) {
+	var message *uint16
+	if hr := comCall(args.vtbl.TryGetWebMessageAsString, uintptr(unsafe.Pointer(args)), uintptr(unsafe.Pointer(&message))); win.FAILED(hr) {
+		return "", false
+	}
+
+	return coTaskMemString(message), true
+}
+
+type iCoreWebView2AcceleratorKeyPressedEventArgsVtbl struct {
+	iUnknownVtbl
+	GetKeyEventKind      uintptr
+	GetVirtualKey        uintptr
+	GetKeyEventLParam    uintptr
+	GetPhysicalKeyStatus uintptr
+	GetHandled           uintptr
+	PutHandled           uintptr
+}
+
+type iCoreWebView2AcceleratorKeyPressedEventArgs struct {
+	vtbl *iCoreWebView2AcceleratorKeyPressedEventArgsVtbl
+}
+
+func (args *iCoreWebView2AcceleratorKeyPressedEventArgs) GetKeyEventKind() int32 {
+	var kind int32
+	comCall(args.vtbl.GetKeyEventKind, uintptr(unsafe.Pointer(args)), uintptr(unsafe.Pointer(&kind)))
+	return kind
+}
+
+func (args *iCoreWebView2AcceleratorKeyPressedEventArgs) PutHandled(handled bool) {
+	comCall(args.vtbl.PutHandled, uintptr(unsafe.Pointer(args)), uintptr(win.BoolToBOOL(handled)))
+}
@@ -0,0 +1,371 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/Gipcomp/win32/ole32"
+	"github.com/Gipcomp/win32/oleaut32"
+	"github.com/Gipcomp/win32/shdocvw"
+	"github.com/Gipcomp/win32/win"
+	"github.com/Gipcomp/winapi/errs"
+	"github.com/Gipcomp/winapi/jsbridge"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	liboleaut32      = windows.NewLazySystemDLL("oleaut32.dll")
+	procVariantClear = liboleaut32.NewProc("VariantClear")
+)
+
+const (
+	dispatchMethod      = 0x1
+	dispatchPropertyGet = 0x2
+
+	dispidExternalInvoke oleaut32.DISPID = 1
+
+	dispEUnknownName   = 0x80020006
+	dispEBadParamCount = 0x8002000E
+	dispETypeMismatch  = 0x80020005
+	dispEException     = 0x80020009
+)
+
+// webViewBridgeTransport is the page script expression the bridge script
+// uses to pass messages to Go.
+const webViewBridgeTransport = "function(message) { return window.external.invoke(message); }"
+
+type excepInfo struct {
+	wCode             uint16
+	wReserved         uint16
+	bstrSource        *uint16
+	bstrDescription   *uint16
+	bstrHelpFile      *uint16
+	dwHelpContext     uint32
+	pvReserved        uintptr
+	pfnDeferredFillIn uintptr
+	scode             int32
+}
+
+var webViewExternalVtbl *oleaut32.IDispatchVtbl
+
+func init() {
+	AppendToWalkInit(func() {
+		webViewExternalVtbl = &oleaut32.IDispatchVtbl{
+			QueryInterface:   syscall.NewCallback(webView_External_QueryInterface),
+			AddRef:           syscall.NewCallback(webView_External_AddRef),
+			Release:          syscall.NewCallback(webView_External_Release),
+			GetTypeInfoCount: syscall.NewCallback(webView_External_GetTypeInfoCount),
+			GetTypeInfo:      syscall.NewCallback(webView_External_GetTypeInfo),
+			GetIDsOfNames:    syscall.NewCallback(webView_External_GetIDsOfNames),
+			Invoke:           syscall.NewCallback(webView_External_Invoke),
+		}
+	})
+}
+
+// webViewExternal is the object page script sees as window.external. Its
+// only method invoke takes a JSON-RPC message and returns the response.
+type webViewExternal struct {
+	oleaut32.IDispatch
+	webView *WebView
+}
+
+func newWebViewExternal(wv *WebView) *webViewExternal {
+	return &webViewExternal{
+		IDispatch: oleaut32.IDispatch{LpVtbl: webViewExternalVtbl},
+		webView:   wv,
+	}
+}
+
+func webView_External_QueryInterface(external *webViewExternal, riid ole32.REFIID, ppvObject *unsafe.Pointer) uintptr {
+	if ole32.EqualREFIID(riid, &ole32.IID_IUnknown) || ole32.EqualREFIID(riid, &oleaut32.IID_IDispatch) {
+		*ppvObject = unsafe.Pointer(external)
+		return win.S_OK
+	}
+
+	*ppvObject = nil
+
+	return win.E_NOINTERFACE
+}
+
+func webView_External_AddRef(external *webViewExternal) uintptr {
+	return 1
+}
+
+func webView_External_Release(external *webViewExternal) uintptr {
+	return 1
+}
+
+func webView_External_GetTypeInfoCount(external *webViewExternal, pctinfo *uint32) uintptr {
+	*pctinfo = 0
+
+	return win.S_OK
+}
+
+func webView_External_GetTypeInfo(external *webViewExternal, iTInfo, lcid uintptr, ppTInfo *uintptr) uintptr {
+	*ppTInfo = 0
+
+	return win.E_NOTIMPL
+}
+
+func webView_External_GetIDsOfNames(external *webViewExternal, riid uintptr, rgszNames **uint16, cNames uintptr, lcid uintptr, rgDispId *oleaut32.DISPID) uintptr {
+	names := (*[1 << 16]*uint16)(unsafe.Pointer(rgszNames))[:cNames:cNames]
+	dispIDs := (*[1 << 16]oleaut32.DISPID)(unsafe.Pointer(rgDispId))[:cNames:cNames]
+
+	var hr uintptr = win.S_OK
+	for i, name := range names {
+		if i == 0 && win.UTF16PtrToString(name) == "invoke" {
+			dispIDs[i] = dispidExternalInvoke
+		} else {
+			dispIDs[i] = -1
+			hr = dispEUnknownName
+		}
+	}
+
+	return hr
+}
+
+func webView_External_Invoke(
+	external *webViewExternal,
+	arg1 uintptr,
+	riid uintptr,
+	lcid uintptr,
+	arg4 uintptr,
+	pDispParams *oleaut32.DISPPARAMS,
+	pVarResult *oleaut32.VARIANT,
+	pExcepInfo *excepInfo,
+	puArgErr *uint32) uintptr {
+
+	dispIdMember := *(*oleaut32.DISPID)(unsafe.Pointer(&arg1))
+	wFlags := *(*uint16)(unsafe.Pointer(&arg4))
+
+	if dispIdMember != dispidExternalInvoke || wFlags&dispatchMethod == 0 {
+		return oleaut32.DISP_E_MEMBERNOTFOUND
+	}
+	if pDispParams.CArgs != 1 {
+		return dispEBadParamCount
+	}
+
+	arg := &(*[1]oleaut32.VARIANTARG)(unsafe.Pointer(pDispParams.Rgvarg))[0]
+	if arg.Vt != oleaut32.VT_BSTR {
+		return dispETypeMismatch
+	}
+
+	response := external.webView.bridge.Dispatch([]byte(oleaut32.BSTRToString(arg.MustBSTR())))
+
+	if pVarResult != nil {
+		if response == nil {
+			pVarResult.Vt = oleaut32.VT_NULL
+		} else {
+			// The caller frees the BSTR.
+			pVarResult.SetBSTR(oleaut32.SysAllocString(string(response)))
+		}
+	}
+
+	return win.S_OK
+}
+
+// Bind makes fn callable from page script as window.go.name, returning
+// what fn returns or throwing an Error with what it failed with. Arguments
+// and results are passed as JSON. See jsbridge.Bridge.Bind for the funcs
+// allowed.
+//
+// Page script can also pass JSON-RPC 2.0 messages to
+// window.external.invoke directly. window.go is available once a document
+// completed loading and requires a document mode supporting JSON, e.g.
+// through <meta http-equiv="X-UA-Compatible" content="IE=edge">.
+func (wv *WebView) Bind(name string, fn interface{}) error {
+	if err := wv.bridge.Bind(name, fn); err != nil {
+		return errs.NewInvalidArgumentError(err.Error())
+	}
+
+	wv.injectBridgeScript()
+
+	return nil
+}
+
+// Unbind removes the func bound as name.
+func (wv *WebView) Unbind(name string) {
+	wv.bridge.Unbind(name)
+}
+
+// Bridge returns the jsbridge.Bridge that dispatches the calls page script
+// makes.
+func (wv *WebView) Bridge() *jsbridge.Bridge {
+	return wv.bridge
+}
+
+// injectBridgeScript adds the bound functions to window.go of the current
+// document, if there is one.
+func (wv *WebView) injectBridgeScript() {
+	names := wv.bridge.Names()
+	if len(names) == 0 {
+		return
+	}
+
+	wv.evalString(jsbridge.Script(jsbridge.DefaultNamespace, webViewBridgeTransport, names))
+}
+
+// Eval evaluates script in the current document and returns its value,
+// converted through JSON like json.Unmarshal does into an interface{}.
+// A *jsbridge.EvalError is returned if script throws.
+func (wv *WebView) Eval(script string) (interface{}, error) {
+	result, err := wv.evalString(jsbridge.EvalScript(script))
+	if err != nil {
+		return nil, err
+	}
+
+	return jsbridge.DecodeEvalResult(result)
+}
+
+// evalString evaluates script, which must yield a string, in the current
+// document.
+func (wv *WebView) evalString(script string) (result string, err error) {
+	err = wv.withScriptDispatch(func(window *oleaut32.IDispatch) error {
+		dispID, err := dispatchGetIDOfName(window, "eval")
+		if err != nil {
+			return err
+		}
+
+		var arg oleaut32.VARIANTARG
+		arg.SetBSTR(oleaut32.SysAllocString(script))
+		defer variantClear(&arg.VARIANT)
+
+		var value oleaut32.VARIANT
+		if err := dispatchInvoke(window, dispID, dispatchMethod, []oleaut32.VARIANTARG{arg}, &value); err != nil {
+			return err
+		}
+		defer variantClear(&value)
+
+		bstr, err := value.BSTR()
+		if err != nil {
+			return errs.NewError(fmt.Sprintf("eval returned variant type %d instead of string", value.Vt))
+		}
+
+		result = oleaut32.BSTRToString(bstr)
+
+		return nil
+	})
+
+	return
+}
+
+// withScriptDispatch calls f with the script object, i.e. the window, of
+// the current document.
+func (wv *WebView) withScriptDispatch(f func(window *oleaut32.IDispatch) error) error {
+	return wv.withWebBrowser2(func(webBrowser2 *shdocvw.IWebBrowser2) error {
+		var document *oleaut32.IDispatch
+		if hr, _, _ := syscall.Syscall(webBrowser2.LpVtbl.Get_Document, 2,
+			uintptr(unsafe.Pointer(webBrowser2)),
+			uintptr(unsafe.Pointer(&document)),
+			0); win.FAILED(win.HRESULT(hr)) {
+			return errs.ErrorFromHRESULT("IWebBrowser2.Get_Document", win.HRESULT(hr))
+		}
+		if document == nil {
+			return errs.NewError("no document loaded")
+		}
+		defer dispatchRelease(document)
+
+		dispID, err := dispatchGetIDOfName(document, "Script")
+		if err != nil {
+			return err
+		}
+
+		var script oleaut32.VARIANT
+		if err := dispatchInvoke(document, dispID, dispatchPropertyGet, nil, &script); err != nil {
+			return err
+		}
+		defer variantClear(&script)
+
+		window, err := script.PDispatch()
+		if err != nil || window == nil {
+			return errs.NewError("document has no script object")
+		}
+
+		return f(window)
+	})
+}
+
+func dispatchGetIDOfName(disp *oleaut32.IDispatch, name string) (oleaut32.DISPID, error) {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	var iidNull ole32.IID
+	var dispID oleaut32.DISPID
+
+	if hr, _, _ := syscall.Syscall6(disp.LpVtbl.GetIDsOfNames, 6,
+		uintptr(unsafe.Pointer(disp)),
+		uintptr(unsafe.Pointer(&iidNull)),
+		uintptr(unsafe.Pointer(&namePtr)),
+		1,
+		0,
+		uintptr(unsafe.Pointer(&dispID))); win.FAILED(win.HRESULT(hr)) {
+		return 0, errs.ErrorFromHRESULT(fmt.Sprintf("IDispatch.GetIDsOfNames(%s)", name), win.HRESULT(hr))
+	}
+
+	return dispID, nil
+}
+
+// dispatchInvoke calls the member dispID of disp. args are passed in
+// reverse order, as IDispatch expects them.
+func dispatchInvoke(disp *oleaut32.IDispatch, dispID oleaut32.DISPID, flags uint16, args []oleaut32.VARIANTARG, result *oleaut32.VARIANT) error {
+	var params oleaut32.DISPPARAMS
+
+	if len(args) > 0 {
+		reversed := make([]oleaut32.VARIANTARG, len(args))
+		for i, arg := range args {
+			reversed[len(args)-1-i] = arg
+		}
+
+		params.Rgvarg = &reversed[0]
+		params.CArgs = int32(len(args))
+	}
+
+	var exception excepInfo
+	var argErr uint32
+
+	hr, _, _ := syscall.Syscall9(disp.LpVtbl.Invoke, 9,
+		uintptr(unsafe.Pointer(disp)),
+		uintptr(dispID),
+		uintptr(unsafe.Pointer(new(ole32.IID))),
+		0,
+		uintptr(flags),
+		uintptr(unsafe.Pointer(&params)),
+		uintptr(unsafe.Pointer(result)),
+		uintptr(unsafe.Pointer(&exception)),
+		uintptr(unsafe.Pointer(&argErr)))
+
+	if uint32(hr) == dispEException {
+		description := "unknown"
+		if exception.bstrDescription != nil {
+			description = oleaut32.BSTRToString(exception.bstrDescription)
+		}
+
+		oleaut32.SysFreeString(exception.bstrSource)
+		oleaut32.SysFreeString(exception.bstrDescription)
+		oleaut32.SysFreeString(exception.bstrHelpFile)
+
+		return errs.NewError("script exception: " + description)
+	}
+	if win.FAILED(win.HRESULT(hr)) {
+		return errs.ErrorFromHRESULT("IDispatch.Invoke", win.HRESULT(hr))
+	}
+
+	return nil
+}
+
+func dispatchRelease(disp *oleaut32.IDispatch) {
+	syscall.Syscall(disp.LpVtbl.Release, 1, uintptr(unsafe.Pointer(disp)), 0, 0)
+}
+
+func variantClear(v *oleaut32.VARIANT) {
+	procVariantClear.Call(uintptr(unsafe.Pointer(v)))
+}
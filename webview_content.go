@@ -0,0 +1,173 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/Gipcomp/winapi/webserve"
+)
+
+// webContent serves the content file system and generated pages of a web
+// browser widget. Embedded in WebView and WebView2, it provides their
+// content methods, navigating through navigate.
+type webContent struct {
+	server       *webserve.Server
+	generatedURL string
+	navigate     func(url string) error
+}
+
+var generatedPageCounter int
+
+func (wc *webContent) ensureServer() (*webserve.Server, error) {
+	if wc.server == nil {
+		server, err := webserve.Start(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		wc.server = server
+	}
+
+	return wc.server, nil
+}
+
+func (wc *webContent) close() {
+	if wc.server != nil {
+		wc.server.Close()
+		wc.server = nil
+	}
+}
+
+// generatedPage serves html as a new page in dir, which relative
+// references in html resolve against, and returns its URL. Only the most
+// recent page is kept.
+func (wc *webContent) generatedPage(dir, html string) (string, error) {
+	server, err := wc.ensureServer()
+	if err != nil {
+		return "", err
+	}
+
+	if name, ok := server.Name(wc.generatedURL); ok && wc.generatedURL != "" {
+		server.SetPage(name, nil)
+	}
+
+	generatedPageCounter++
+	name := fmt.Sprintf("~generated-%d.html", generatedPageCounter)
+	if dir != "" && dir != "." {
+		name = dir + "/" + name
+	}
+
+	server.SetPage(name, webserve.HTML(html))
+
+	wc.generatedURL = server.URL(name)
+
+	return wc.generatedURL, nil
+}
+
+// ContentFS returns the file system served to the browser, or nil.
+func (wc *webContent) ContentFS() fs.FS {
+	if wc.server == nil {
+		return nil
+	}
+
+	return wc.server.FS()
+}
+
+// SetContentFS makes the browser serve the files of fsys, e.g. an embed.FS,
+// at the URLs returned by ContentURL. The files are served by an in-process
+// HTTP server on the loopback interface, which is started on first use.
+func (wc *webContent) SetContentFS(fsys fs.FS) error {
+	server, err := wc.ensureServer()
+	if err != nil {
+		return err
+	}
+
+	server.SetFS(fsys)
+
+	return nil
+}
+
+// ContentURL returns the URL of name in the content file system, like
+// "index.html" or "help/".
+func (wc *webContent) ContentURL(name string) (string, error) {
+	server, err := wc.ensureServer()
+	if err != nil {
+		return "", err
+	}
+
+	return server.URL(name), nil
+}
+
+// ContentName returns the name in the content file system url refers to
+// and whether it refers to the content file system at all.
+func (wc *webContent) ContentName(url string) (string, bool) {
+	if wc.server == nil {
+		return "", false
+	}
+
+	return wc.server.Name(url)
+}
+
+// NavigateContent navigates to name in the content file system.
+func (wc *webContent) NavigateContent(name string) error {
+	url, err := wc.ContentURL(name)
+	if err != nil {
+		return err
+	}
+
+	return wc.navigate(url)
+}
+
+// SetContentInterceptor sets a func that is asked first for each request
+// to the content file system and may return generated content, e.g. HTML.
+//
+// The interceptor is called on a goroutine of the HTTP server, so it must
+// use Synchronize to access widgets.
+func (wc *webContent) SetContentInterceptor(interceptor webserve.Interceptor) error {
+	server, err := wc.ensureServer()
+	if err != nil {
+		return err
+	}
+
+	server.SetInterceptor(interceptor)
+
+	return nil
+}
+
+// SetHTML shows html, served from the root of the content file system, so
+// relative references in html resolve against it.
+//
+// Only the page most recently set is kept, so navigating back to an older
+// one fails.
+func (wc *webContent) SetHTML(html string) error {
+	url, err := wc.generatedPage("", html)
+	if err != nil {
+		return err
+	}
+
+	return wc.navigate(url)
+}
+
+// respondHTML shows html in place of url for
+// WebViewNavigatingEventData.RespondHTML. Relative references in html
+// resolve against url if it refers to the content file system.
+func (wc *webContent) respondHTML(url, html string) error {
+	var dir string
+	if name, ok := wc.ContentName(url); ok {
+		dir = path.Dir(name)
+	}
+
+	pageURL, err := wc.generatedPage(dir, html)
+	if err != nil {
+		return err
+	}
+
+	return wc.navigate(pageURL)
+}
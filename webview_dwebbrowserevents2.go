@@ -7,6 +7,7 @@
 package winapi
 
 import (
+	"log"
 	"syscall"
 	"time"
 	"unsafe"
@@ -153,7 +154,18 @@ func webView_DWebBrowserEvents2_Invoke(
 			headers:         (*rgvargPtr)[1].MustPVariant(),
 			cancel:          (*rgvargPtr)[0].MustPBool(),
 		}
-		wv.navigatingPublisher.Publish(eventData)
+		if url := eventData.Url(); url == "" || url != wv.webContent.generatedURL {
+			wv.navigatingPublisher.Publish(eventData)
+		}
+
+		if eventData.html != nil {
+			url, html := eventData.Url(), *eventData.html
+			wv.Synchronize(func() {
+				if err := wv.respondHTML(url, html); err != nil {
+					log.Printf("*WebView - failed to respond to %s: %s", url, err)
+				}
+			})
+		}
 
 	case oleaut32.DISPID_NAVIGATECOMPLETE2:
 		rgvargPtr := (*[2]oleaut32.VARIANTARG)(unsafe.Pointer(pDispParams.Rgvarg))
@@ -191,6 +203,8 @@ func webView_DWebBrowserEvents2_Invoke(
 			})
 		})
 
+		wv.injectBridgeScript()
+
 		wv.documentCompletedPublisher.Publish(urlStr)
 
 	case oleaut32.DISPID_NAVIGATEERROR:
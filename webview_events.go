@@ -20,9 +20,16 @@ type WebViewNavigatingEventData struct {
 	postData        *oleaut32.VARIANT
 	headers         *oleaut32.VARIANT
 	cancel          *oleaut32.VARIANT_BOOL
+	html            *string
+	webView2Args    *iCoreWebView2NavigationStartingEventArgs
+	webView2URL     string
 }
 
 func (eventData *WebViewNavigatingEventData) Url() string {
+	if eventData.webView2Args != nil {
+		return eventData.webView2URL
+	}
+
 	url := eventData.url
 	if url != nil && url.MustBSTR() != nil {
 		return oleaut32.BSTRToString(url.MustBSTR())
@@ -72,6 +79,10 @@ func (eventData *WebViewNavigatingEventData) TargetFrameName() string {
 }
 
 func (eventData *WebViewNavigatingEventData) Canceled() bool {
+	if eventData.webView2Args != nil {
+		return eventData.webView2Args.GetCancel()
+	}
+
 	cancel := eventData.cancel
 	if cancel != nil {
 		if *cancel != oleaut32.VARIANT_FALSE {
@@ -84,6 +95,11 @@ func (eventData *WebViewNavigatingEventData) Canceled() bool {
 }
 
 func (eventData *WebViewNavigatingEventData) SetCanceled(value bool) {
+	if eventData.webView2Args != nil {
+		eventData.webView2Args.PutCancel(value)
+		return
+	}
+
 	cancel := eventData.cancel
 	if cancel != nil {
 		if value {
@@ -94,6 +110,15 @@ func (eventData *WebViewNavigatingEventData) SetCanceled(value bool) {
 	}
 }
 
+// RespondHTML cancels the navigation and shows html instead, served like
+// by WebView.SetHTML. Relative references in html resolve against the URL
+// navigated to if it refers to the content file system of the WebView and
+// against its root otherwise.
+func (eventData *WebViewNavigatingEventData) RespondHTML(html string) {
+	eventData.SetCanceled(true)
+	eventData.html = &html
+}
+
 type WebViewNavigatingEventHandler func(eventData *WebViewNavigatingEventData)
 
 type WebViewNavigatingEvent struct {
@@ -133,14 +158,21 @@ func (p *WebViewNavigatingEventPublisher) Publish(eventData *WebViewNavigatingEv
 }
 
 type WebViewNavigatedErrorEventData struct {
-	pDisp           *oleaut32.IDispatch
-	url             *oleaut32.VARIANT
-	targetFrameName *oleaut32.VARIANT
-	statusCode      *oleaut32.VARIANT
-	cancel          *oleaut32.VARIANT_BOOL
+	pDisp               *oleaut32.IDispatch
+	url                 *oleaut32.VARIANT
+	targetFrameName     *oleaut32.VARIANT
+	statusCode          *oleaut32.VARIANT
+	cancel              *oleaut32.VARIANT_BOOL
+	webView2            bool
+	webView2URL         string
+	webView2ErrorStatus int32
 }
 
 func (eventData *WebViewNavigatedErrorEventData) Url() string {
+	if eventData.webView2 {
+		return eventData.webView2URL
+	}
+
 	url := eventData.url
 	if url != nil && url.MustBSTR() != nil {
 		return oleaut32.BSTRToString(url.MustBSTR())
@@ -156,7 +188,13 @@ func (eventData *WebViewNavigatedErrorEventData) TargetFrameName() string {
 	return ""
 }
 
+// StatusCode returns the error status of the navigation. For a WebView2,
+// it is a COREWEBVIEW2_WEB_ERROR_STATUS value.
 func (eventData *WebViewNavigatedErrorEventData) StatusCode() int32 {
+	if eventData.webView2 {
+		return eventData.webView2ErrorStatus
+	}
+
 	statusCode := eventData.statusCode
 	if statusCode != nil {
 		return statusCode.MustLong()
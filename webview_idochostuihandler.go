@@ -137,9 +137,13 @@ func webView_IDocHostUIHandler_GetDropTarget(docHostUIHandler *webViewIDocHostUI
 }
 
 func webView_IDocHostUIHandler_GetExternal(docHostUIHandler *webViewIDocHostUIHandler, ppDispatch *uintptr) uintptr {
-	*ppDispatch = 0
+	offset := unsafe.Offsetof(WebView{}.clientSite) + unsafe.Offsetof(webViewIOleClientSite{}.docHostUIHandler)
+	webView := (*WebView)(unsafe.Add(unsafe.Pointer(docHostUIHandler), -int(offset)))
 
-	return win.S_FALSE
+	// Page script reaches Go through window.external.
+	*ppDispatch = uintptr(unsafe.Pointer(webView.external))
+
+	return win.S_OK
 }
 
 func webView_IDocHostUIHandler_TranslateUrl(docHostUIHandler *webViewIDocHostUIHandler, dwTranslate uint32, pchURLIn *uint16, ppchURLOut **uint16) uintptr {
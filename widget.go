@@ -107,7 +107,7 @@ type WidgetBase struct {
 // InitWidget initializes a Widget.
 func InitWidget(widget Widget, parent Window, className string, style, exStyle uint32) error {
 	if parent == nil {
-		return errs.NewError("parent cannot be nil")
+		return errs.NewInvalidArgumentError("parent cannot be nil")
 	}
 
 	if err := InitWindow(widget, parent, className, style|user32.WS_CHILD, exStyle); err != nil {
@@ -195,7 +195,7 @@ func (wb *WidgetBase) BoundsPixels() Rectangle {
 	if wb.parent != nil {
 		p := b.Location().toPOINT()
 		if !user32.ScreenToClient(wb.parent.Handle(), &p) {
-			errs.NewError("ScreenToClient failed")
+			errs.NewWin32Error("ScreenToClient")
 			return Rectangle{}
 		}
 		b.X = int(p.X)
@@ -253,7 +253,7 @@ func (wb *WidgetBase) Alignment() Alignment2D {
 func (wb *WidgetBase) SetAlignment(alignment Alignment2D) error {
 	if alignment != wb.alignment {
 		if alignment < AlignHVDefault || alignment > AlignHFarVFar {
-			return errs.NewError("invalid Alignment value")
+			return errs.NewInvalidArgumentError("invalid Alignment value")
 		}
 
 		wb.alignment = alignment
@@ -0,0 +1,62 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+type widgetEventHandlerInfo struct {
+	handler WidgetEventHandler
+	once    bool
+}
+
+type WidgetEventHandler func(widget Widget)
+
+type WidgetEvent struct {
+	handlers []widgetEventHandlerInfo
+}
+
+func (e *WidgetEvent) Attach(handler WidgetEventHandler) int {
+	handlerInfo := widgetEventHandlerInfo{handler, false}
+
+	for i, h := range e.handlers {
+		if h.handler == nil {
+			e.handlers[i] = handlerInfo
+			return i
+		}
+	}
+
+	e.handlers = append(e.handlers, handlerInfo)
+
+	return len(e.handlers) - 1
+}
+
+func (e *WidgetEvent) Detach(handle int) {
+	e.handlers[handle].handler = nil
+}
+
+func (e *WidgetEvent) Once(handler WidgetEventHandler) {
+	i := e.Attach(handler)
+	e.handlers[i].once = true
+}
+
+type WidgetEventPublisher struct {
+	event WidgetEvent
+}
+
+func (p *WidgetEventPublisher) Event() *WidgetEvent {
+	return &p.event
+}
+
+func (p *WidgetEventPublisher) Publish(widget Widget) {
+	for i, h := range p.event.handlers {
+		if h.handler != nil {
+			h.handler(widget)
+
+			if h.once {
+				p.event.Detach(i)
+			}
+		}
+	}
+}
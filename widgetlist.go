@@ -101,7 +101,7 @@ func (l *WidgetList) insertIntoSlice(index int, item Widget) {
 
 func (l *WidgetList) Insert(index int, item Widget) error {
 	if l.Contains(item) {
-		return errs.NewError("cannot insert same widget multiple times")
+		return errs.NewInvalidArgumentError("cannot insert same widget multiple times")
 	}
 
 	observer := l.observer
@@ -0,0 +1,551 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Gipcomp/winapi/errs"
+)
+
+// PropertyInfo describes a Property registered with a Window.
+type PropertyInfo struct {
+	Name     string
+	Type     reflect.Type // The type of Value, nil if Value is nil.
+	ReadOnly bool
+	Value    interface{}
+}
+
+// PropertyNames returns the sorted names of the properties registered with
+// MustRegisterProperty.
+func (wb *WindowBase) PropertyNames() []string {
+	names := make([]string, 0, len(wb.name2Property))
+	for name := range wb.name2Property {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Properties returns information about all registered properties, sorted by
+// name, including their current values.
+func (wb *WindowBase) Properties() []PropertyInfo {
+	names := wb.PropertyNames()
+	infos := make([]PropertyInfo, len(names))
+
+	for i, name := range names {
+		p := wb.name2Property[name]
+		value := p.Get()
+
+		infos[i] = PropertyInfo{
+			Name:     name,
+			Type:     reflect.TypeOf(value),
+			ReadOnly: p.ReadOnly(),
+			Value:    value,
+		}
+	}
+
+	return infos
+}
+
+// WindowTypeName returns the name of the type of w without package, e.g.
+// "LineEdit" for a *LineEdit. Selectors use it to match types.
+func WindowTypeName(w Window) string {
+	t := reflect.TypeOf(w)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Name()
+}
+
+// WidgetNamePath returns the names of w and its named ancestors, separated
+// by '/', as accepted by FindWidget for the Form of w.
+func WidgetNamePath(w Widget) string {
+	var names []string
+
+	for wnd := Window(w); wnd != nil; {
+		if _, ok := wnd.(Form); ok {
+			break
+		}
+
+		if name := wnd.Name(); name != "" {
+			names = append(names, name)
+		}
+
+		widget, ok := wnd.(Widget)
+		if !ok {
+			break
+		}
+
+		parent := widget.Parent()
+		if parent == nil {
+			break
+		}
+		wnd = parent
+	}
+
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+
+	return strings.Join(names, "/")
+}
+
+// windowChildren returns the widgets directly contained in w.
+func windowChildren(w Window) []Widget {
+	var widgets []Widget
+
+	switch w := w.(type) {
+	case *TabWidget:
+		for _, p := range w.Pages().items {
+			widgets = append(widgets, p)
+		}
+
+	case Container:
+		if children := w.Children(); children != nil {
+			for _, wb := range children.items {
+				if widget, ok := wb.window.(Widget); ok {
+					widgets = append(widgets, widget)
+				}
+			}
+		}
+	}
+
+	return widgets
+}
+
+// walkDescendants calls f for every descendant of root in tree order, with
+// the chain of its ancestors starting at root. Returning false from f stops
+// the walk.
+func walkWidgetTree(root Window, f func(w Widget, ancestors []Window) bool) bool {
+	var walk func(chain []Window) bool
+	walk = func(chain []Window) bool {
+		for _, child := range windowChildren(chain[len(chain)-1]) {
+			if !f(child, chain) {
+				return false
+			}
+
+			if !walk(append(chain, child)) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return walk([]Window{root})
+}
+
+// FindWidget returns the widget identified by path below root, or nil. path
+// is a list of widget names separated by '/', where each name is searched
+// among all descendants of the widget found for the previous one, so unnamed
+// containers in between need not be mentioned, e.g. "settingsGroup/userName".
+func FindWidget(root Window, path string) Widget {
+	var found Widget
+
+	for _, name := range strings.Split(path, "/") {
+		found = nil
+
+		walkWidgetTree(root, func(w Widget, _ []Window) bool {
+			if w.Name() == name {
+				found = w
+				return false
+			}
+
+			return true
+		})
+
+		if found == nil {
+			return nil
+		}
+
+		root = found
+	}
+
+	return found
+}
+
+// QueryWidgets returns all descendants of root that match selector, in tree
+// order.
+//
+// The selector syntax is modelled after CSS. A compound selector consists of
+// a type name like "LineEdit", or "*" for any type, optionally followed by
+// any of "#name", "[Property]", "[Property=value]" (also "!=", "^=", "$=" and
+// "*=" for not equal, prefix, suffix and substring), ":visible", ":hidden",
+// ":enabled", ":disabled" and ":focused". Property values are compared in
+// their fmt.Sprint form and may be quoted with '"'. Compound selectors are
+// combined with " " for descendants and ">" for children, and several
+// selectors may be separated by ",".
+//
+// Example: `GroupBox#settings > LineEdit:enabled, PushButton[Text="OK"]`
+func QueryWidgets(root Window, selector string) ([]Widget, error) {
+	sels, err := parseSelectors(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var widgets []Widget
+
+	walkWidgetTree(root, func(w Widget, ancestors []Window) bool {
+		for _, sel := range sels {
+			if sel.matches(w, ancestors) {
+				widgets = append(widgets, w)
+				break
+			}
+		}
+
+		return true
+	})
+
+	return widgets, nil
+}
+
+// QueryWidget returns the first descendant of root that matches selector, or
+// nil. See QueryWidgets for the syntax.
+func QueryWidget(root Window, selector string) (Widget, error) {
+	sels, err := parseSelectors(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var found Widget
+
+	walkWidgetTree(root, func(w Widget, ancestors []Window) bool {
+		for _, sel := range sels {
+			if sel.matches(w, ancestors) {
+				found = w
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return found, nil
+}
+
+// Find returns the descendant identified by path, see FindWidget.
+func (cb *ContainerBase) Find(path string) Widget {
+	return FindWidget(cb.window, path)
+}
+
+// Query returns the first descendant matching selector, see QueryWidgets.
+func (cb *ContainerBase) Query(selector string) (Widget, error) {
+	return QueryWidget(cb.window, selector)
+}
+
+// QueryAll returns all descendants matching selector, see QueryWidgets.
+func (cb *ContainerBase) QueryAll(selector string) ([]Widget, error) {
+	return QueryWidgets(cb.window, selector)
+}
+
+// Find returns the descendant identified by path, see FindWidget.
+func (fb *FormBase) Find(path string) Widget {
+	return FindWidget(fb.window, path)
+}
+
+// Query returns the first descendant matching selector, see QueryWidgets.
+func (fb *FormBase) Query(selector string) (Widget, error) {
+	return QueryWidget(fb.window, selector)
+}
+
+// QueryAll returns all descendants matching selector, see QueryWidgets.
+func (fb *FormBase) QueryAll(selector string) ([]Widget, error) {
+	return QueryWidgets(fb.window, selector)
+}
+
+type selectorAttr struct {
+	name  string
+	op    string // empty if only presence is tested
+	value string
+}
+
+type compoundSelector struct {
+	typeName string // empty for any type
+	name     string
+	attrs    []selectorAttr
+	pseudos  []string
+	child    bool // whether it must match the parent of the next compound
+}
+
+type selector []*compoundSelector
+
+func (cs *compoundSelector) matches(w Window) bool {
+	if cs.typeName != "" && cs.typeName != WindowTypeName(w) {
+		return false
+	}
+	if cs.name != "" && cs.name != w.Name() {
+		return false
+	}
+
+	for _, attr := range cs.attrs {
+		p := w.AsWindowBase().Property(attr.name)
+		if p == nil {
+			return false
+		}
+		if attr.op == "" {
+			continue
+		}
+
+		value := fmt.Sprint(p.Get())
+
+		var ok bool
+		switch attr.op {
+		case "=":
+			ok = value == attr.value
+		case "!=":
+			ok = value != attr.value
+		case "^=":
+			ok = strings.HasPrefix(value, attr.value)
+		case "$=":
+			ok = strings.HasSuffix(value, attr.value)
+		case "*=":
+			ok = strings.Contains(value, attr.value)
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	for _, pseudo := range cs.pseudos {
+		var ok bool
+		switch pseudo {
+		case "visible":
+			ok = w.Visible()
+		case "hidden":
+			ok = !w.Visible()
+		case "enabled":
+			ok = w.Enabled()
+		case "disabled":
+			ok = !w.Enabled()
+		case "focused":
+			ok = w.Focused()
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (sel selector) matches(w Widget, ancestors []Window) bool {
+	last := len(sel) - 1
+	if !sel[last].matches(w) {
+		return false
+	}
+
+	return sel.matchAncestors(last-1, ancestors)
+}
+
+// matchAncestors reports whether sel[:ci+1] matches ancestors, where
+// sel[ci+1] matched the window following the last of ancestors.
+func (sel selector) matchAncestors(ci int, ancestors []Window) bool {
+	if ci < 0 {
+		return true
+	}
+
+	if sel[ci].child {
+		n := len(ancestors) - 1
+		return n >= 0 && sel[ci].matches(ancestors[n]) && sel.matchAncestors(ci-1, ancestors[:n])
+	}
+
+	for n := len(ancestors) - 1; n >= 0; n-- {
+		if sel[ci].matches(ancestors[n]) && sel.matchAncestors(ci-1, ancestors[:n]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseSelectors(s string) ([]selector, error) {
+	var sels []selector
+
+	for _, part := range splitSelectorList(s) {
+		sel, err := parseSelector(part)
+		if err != nil {
+			return nil, err
+		}
+
+		sels = append(sels, sel)
+	}
+
+	if len(sels) == 0 {
+		return nil, errs.NewInvalidArgumentError("empty selector")
+	}
+
+	return sels, nil
+}
+
+// splitSelectorList splits s at commas outside of quotes and brackets.
+func splitSelectorList(s string) []string {
+	var parts []string
+	var inQuotes bool
+	var depth int
+	start := 0
+
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func parseSelector(s string) (selector, error) {
+	var sel selector
+	invalid := func(msg string) (selector, error) {
+		return nil, errs.NewInvalidArgumentError(fmt.Sprintf("invalid selector %q: %s", s, msg))
+	}
+
+	rs := []rune(s)
+	i := 0
+
+	skipSpace := func() bool {
+		start := i
+		for i < len(rs) && unicode.IsSpace(rs[i]) {
+			i++
+		}
+		return i > start
+	}
+	ident := func() string {
+		start := i
+		for i < len(rs) && (unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i]) || rs[i] == '_' || rs[i] == '-') {
+			i++
+		}
+		return string(rs[start:i])
+	}
+
+	skipSpace()
+
+	for i < len(rs) {
+		cs := new(compoundSelector)
+
+		if rs[i] == '*' {
+			i++
+		} else {
+			cs.typeName = ident()
+		}
+
+	simple:
+		for i < len(rs) {
+			switch rs[i] {
+			case '#':
+				i++
+				if cs.name = ident(); cs.name == "" {
+					return invalid("missing name after '#'")
+				}
+
+			case ':':
+				i++
+				pseudo := ident()
+				switch pseudo {
+				case "visible", "hidden", "enabled", "disabled", "focused":
+					cs.pseudos = append(cs.pseudos, pseudo)
+				default:
+					return invalid("unknown pseudo class " + pseudo)
+				}
+
+			case '[':
+				i++
+				skipSpace()
+
+				var attr selectorAttr
+				if attr.name = ident(); attr.name == "" {
+					return invalid("missing property name")
+				}
+				skipSpace()
+
+				if i < len(rs) && rs[i] != ']' {
+					for _, op := range []string{"!=", "^=", "$=", "*=", "="} {
+						if strings.HasPrefix(string(rs[i:]), op) {
+							attr.op = op
+							i += len(op)
+							break
+						}
+					}
+					if attr.op == "" {
+						return invalid("invalid operator")
+					}
+					skipSpace()
+
+					if i < len(rs) && rs[i] == '"' {
+						i++
+						start := i
+						for i < len(rs) && rs[i] != '"' {
+							i++
+						}
+						if i == len(rs) {
+							return invalid("unterminated string")
+						}
+						attr.value = string(rs[start:i])
+						i++
+					} else {
+						start := i
+						for i < len(rs) && rs[i] != ']' {
+							i++
+						}
+						attr.value = strings.TrimSpace(string(rs[start:i]))
+					}
+					skipSpace()
+				}
+
+				if i == len(rs) || rs[i] != ']' {
+					return invalid("missing ']'")
+				}
+				i++
+
+				cs.attrs = append(cs.attrs, attr)
+
+			default:
+				break simple
+			}
+		}
+
+		if cs.typeName == "" && cs.name == "" && len(cs.attrs) == 0 && len(cs.pseudos) == 0 && (i == 0 || rs[i-1] != '*') {
+			return invalid(fmt.Sprintf("unexpected %q", rs[i]))
+		}
+
+		sel = append(sel, cs)
+
+		hadSpace := skipSpace()
+		if i < len(rs) && rs[i] == '>' {
+			i++
+			cs.child = true
+			skipSpace()
+			if i == len(rs) {
+				return invalid("missing selector after '>'")
+			}
+		} else if i < len(rs) && !hadSpace {
+			return invalid(fmt.Sprintf("unexpected %q", rs[i]))
+		}
+	}
+
+	if len(sel) == 0 {
+		return invalid("empty selector")
+	}
+
+	return sel, nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package winapi
+
+import (
+	"strings"
+	"testing"
+)
+
+// describeSelector returns the canonical form of sel, e.g.
+// `GroupBox#a > *[Text="x"]:enabled`.
+func describeSelector(sel selector) string {
+	var sb strings.Builder
+
+	for i, cs := range sel {
+		if i > 0 {
+			if sel[i-1].child {
+				sb.WriteString(" > ")
+			} else {
+				sb.WriteString(" ")
+			}
+		}
+
+		if cs.typeName == "" {
+			sb.WriteString("*")
+		} else {
+			sb.WriteString(cs.typeName)
+		}
+		if cs.name != "" {
+			sb.WriteString("#" + cs.name)
+		}
+		for _, attr := range cs.attrs {
+			sb.WriteString("[" + attr.name)
+			if attr.op != "" {
+				sb.WriteString(attr.op + `"` + attr.value + `"`)
+			}
+			sb.WriteString("]")
+		}
+		for _, pseudo := range cs.pseudos {
+			sb.WriteString(":" + pseudo)
+		}
+	}
+
+	return sb.String()
+}
+
+func TestParseSelectors(t *testing.T) {
+	for _, test := range []struct {
+		selector string
+		want     string // selectors separated by ", "
+	}{
+		{"LineEdit", "LineEdit"},
+		{"*", "*"},
+		{"  #userName  ", "*#userName"},
+		{"LineEdit#userName:enabled:visible", "LineEdit#userName:enabled:visible"},
+		{"GroupBox LineEdit", "GroupBox LineEdit"},
+		{"GroupBox>LineEdit", "GroupBox > LineEdit"},
+		{"Dialog  GroupBox  >  * #name", "Dialog GroupBox > * *#name"},
+		{"[ReadOnly]", "*[ReadOnly]"},
+		{`PushButton[Text="OK"]`, `PushButton[Text="OK"]`},
+		{`PushButton[ Text = OK ]`, `PushButton[Text="OK"]`},
+		{`[Text!=a][Text^=b][Text$=c][Text*=d]`, `*[Text!="a"][Text^="b"][Text$="c"][Text*="d"]`},
+		{`[Text="a, b]"]`, `*[Text="a, b]"]`},
+		{`LineEdit, PushButton[Text="Yes, please"]:focused`, `LineEdit, PushButton[Text="Yes, please"]:focused`},
+		{"Composite:hidden > CheckBox:disabled", "Composite:hidden > CheckBox:disabled"},
+		{"tab_page-1", "tab_page-1"},
+	} {
+		sels, err := parseSelectors(test.selector)
+		if err != nil {
+			t.Errorf("parseSelectors(%q): %v", test.selector, err)
+			continue
+		}
+
+		got := make([]string, len(sels))
+		for i, sel := range sels {
+			got[i] = describeSelector(sel)
+		}
+
+		if strings.Join(got, ", ") != test.want {
+			t.Errorf("parseSelectors(%q) = %s, want %s", test.selector, strings.Join(got, ", "), test.want)
+		}
+	}
+}
+
+func TestParseSelectorsInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"   ",
+		"LineEdit,",
+		",LineEdit",
+		"LineEdit >",
+		"> LineEdit",
+		"#",
+		"LineEdit#",
+		"LineEdit:checked",
+		"LineEdit:",
+		"[",
+		"[]",
+		"[Text",
+		"[Text=a",
+		`[Text="a]`,
+		"[Text~=a]",
+		"[Text=a]b",
+		"LineEdit.class",
+		"LineEdit!",
+	} {
+		if sels, err := parseSelectors(s); err == nil {
+			t.Errorf("parseSelectors(%q) = %d selectors, want an error", s, len(sels))
+		}
+	}
+}
+
+// queryTestWidget implements the parts of Widget selectors use. Calling any
+// other method panics.
+type queryTestWidget struct {
+	Widget
+	wb      WindowBase
+	name    string
+	visible bool
+	enabled bool
+	focused bool
+}
+
+func newQueryTestWidget(name string, properties map[string]interface{}) *queryTestWidget {
+	w := &queryTestWidget{name: name, visible: true, enabled: true}
+	w.wb.name2Property = make(map[string]Property)
+
+	for name, value := range properties {
+		value := value
+		w.wb.MustRegisterProperty(name, NewReadOnlyProperty(func() interface{} { return value }, nil))
+	}
+
+	return w
+}
+
+func (w *queryTestWidget) AsWindowBase() *WindowBase { return &w.wb }
+func (w *queryTestWidget) Name() string              { return w.name }
+func (w *queryTestWidget) Visible() bool             { return w.visible }
+func (w *queryTestWidget) Enabled() bool             { return w.enabled }
+func (w *queryTestWidget) Focused() bool             { return w.focused }
+
+// Selectors match the type name, so each kind of test widget needs a type.
+type (
+	testGroup  struct{ *queryTestWidget }
+	testEdit   struct{ *queryTestWidget }
+	testButton struct{ *queryTestWidget }
+)
+
+func TestSelectorMatches(t *testing.T) {
+	form := &testGroup{newQueryTestWidget("form", nil)}
+	settings := &testGroup{newQueryTestWidget("settings", nil)}
+	inner := &testGroup{newQueryTestWidget("", nil)}
+	user := &testEdit{newQueryTestWidget("user", map[string]interface{}{"Text": "Alice Smith", "ReadOnly": false})}
+	pass := &testEdit{newQueryTestWidget("pass", map[string]interface{}{"Text": ""})}
+	ok := &testButton{newQueryTestWidget("ok", map[string]interface{}{"Text": "OK"})}
+
+	pass.enabled = false
+	ok.focused = true
+	inner.visible = false
+
+	// Each widget with its ancestors, starting at the root of the query.
+	widgets := []struct {
+		w         Widget
+		ancestors []Window
+	}{
+		{settings, []Window{form}},
+		{user, []Window{form, settings}},
+		{inner, []Window{form, settings}},
+		{pass, []Window{form, settings, inner}},
+		{ok, []Window{form}},
+	}
+
+	for _, test := range []struct {
+		selector string
+		want     string
+	}{
+		{"testEdit", "user pass"},
+		{"*", "settings user  pass ok"},
+		{"#ok", "ok"},
+		{"testGroup#settings testEdit", "user pass"},
+		{"testGroup#settings > testEdit", "user"},
+		{"testGroup > testGroup > testEdit", "user pass"},
+		{"testGroup > testGroup:hidden > testEdit", "pass"},
+		{"testGroup:hidden testEdit", "pass"},
+		{"testGroup:visible > testEdit", "user"},
+		{"testEdit:disabled, testButton:focused", "pass ok"},
+		{"testEdit:enabled:visible", "user"},
+		{"[Text]", "user pass ok"},
+		{"[ReadOnly]", "user"},
+		{"[ReadOnly=false]", "user"},
+		{`[Text="OK"]`, "ok"},
+		{"[Text!=OK]", "user pass"},
+		{"[Text^=Alice]", "user"},
+		{"[Text$=Smith]", "user"},
+		{"[Text*=ce Sm]", "user"},
+		{"[Missing]", ""},
+		{"testButton testEdit", ""},
+		{"testGroup#settings > testGroup > testEdit#pass", "pass"},
+		{"testGroup#form > testEdit", ""},
+	} {
+		sels, err := parseSelectors(test.selector)
+		if err != nil {
+			t.Errorf("parseSelectors(%q): %v", test.selector, err)
+			continue
+		}
+
+		var names []string
+		for _, w := range widgets {
+			for _, sel := range sels {
+				if sel.matches(w.w, w.ancestors) {
+					names = append(names, w.w.Name())
+					break
+				}
+			}
+		}
+
+		if got := strings.Join(names, " "); got != test.want {
+			t.Errorf("%q matches %q, want %q", test.selector, got, test.want)
+		}
+	}
+}
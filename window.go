@@ -9,7 +9,7 @@ package winapi
 
 import (
 	"bytes"
-	"fmt"
+	"context"
 	"image"
 	"runtime"
 	"strings"
@@ -1042,9 +1042,23 @@ func (wb *WindowBase) ApplySysColors() {
 	wb.Invalidate()
 }
 
-// DPI returns the current DPI value of the WindowBase.
+// DPI returns the current DPI value of the WindowBase. It is the DPI of the
+// monitor the window is on, unless its form has a DPI override, see
+// FormBase.SetDPIOverride.
 func (wb *WindowBase) DPI() int {
-	return int(user32.GetDpiForWindow(wb.hWnd))
+	return dpiForHWnd(wb.hWnd)
+}
+
+// dpiForHWnd returns the DPI of the window hwnd, taking the DPI override of
+// its form into account.
+func dpiForHWnd(hwnd handle.HWND) int {
+	if len(formHWnd2DPIOverride) > 0 {
+		if dpi, ok := formHWnd2DPIOverride[user32.GetAncestor(hwnd, user32.GA_ROOT)]; ok {
+			return dpi
+		}
+	}
+
+	return int(user32.GetDpiForWindow(hwnd))
 }
 
 type ApplyDPIer interface {
@@ -1180,7 +1194,7 @@ func (wb *WindowBase) applyFont(font *Font) {
 }
 
 func SetWindowFont(hwnd handle.HWND, font *Font) {
-	dpi := int(user32.GetDpiForWindow(hwnd))
+	dpi := dpiForHWnd(hwnd)
 	setWindowFont(hwnd, font.handleForDPI(dpi))
 }
 
@@ -1234,7 +1248,7 @@ func (wb *WindowBase) SetSuspended(suspend bool) {
 // Invalidate schedules a full repaint of the *WindowBase.
 func (wb *WindowBase) Invalidate() error {
 	if !user32.InvalidateRect(wb.hWnd, nil, true) {
-		return errs.NewError("InvalidateRect failed")
+		return errs.NewWin32Error("InvalidateRect")
 	}
 
 	return nil
@@ -1266,7 +1280,7 @@ func windowText(hwnd handle.HWND) string {
 // 		return err
 // 	}
 // 	if win.TRUE != user32.SendMessage(hwnd, user32.WM_SETTEXT, 0, uintptr(unsafe.Pointer(strPtr))) {
-// 		return errs.NewError("WM_SETTEXT failed")
+// 		return errs.NewWin32Error("WM_SETTEXT")
 // 	}
 
 // 	return nil
@@ -1516,11 +1530,11 @@ func (wb *WindowBase) MaxSizePixels() Size {
 // Use walk.Size{} to make the respective limit be ignored.
 func (wb *WindowBase) SetMinMaxSize(min, max Size) error {
 	if min.Width < 0 || min.Height < 0 {
-		return errs.NewError("min must be positive")
+		return errs.NewInvalidArgumentError("min must be positive")
 	}
 	if max.Width > 0 && max.Width < min.Width ||
 		max.Height > 0 && max.Height < min.Height {
-		return errs.NewError("max must be greater as or equal to min")
+		return errs.NewInvalidArgumentError("max must be greater as or equal to min")
 	}
 	wb.minSize96dpi = min
 	wb.maxSize96dpi = max
@@ -1571,7 +1585,7 @@ func (wb *WindowBase) dialogBaseUnits() Size {
 
 	var tm gdi32.TEXTMETRIC
 	if !gdi32.GetTextMetrics(hdc, &tm) {
-		errs.NewError("GetTextMetrics failed")
+		errs.NewWin32Error("GetTextMetrics")
 	}
 
 	var size gdi32.SIZE
@@ -1580,7 +1594,7 @@ func (wb *WindowBase) dialogBaseUnits() Size {
 		dialogBaseUnitsUTF16StringPtr,
 		52,
 		&size) {
-		errs.NewError("GetTextExtentPoint32 failed")
+		errs.NewWin32Error("GetTextExtentPoint32")
 	}
 
 	s := Size{int((size.CX/26 + 1) / 2), int(tm.TmHeight)}
@@ -1652,7 +1666,7 @@ func (wb *WindowBase) calculateTextSizeForWidth(width int) Size {
 func calculateTextSize(text string, font *Font, dpi int, width int, hwnd handle.HWND) Size {
 	hdc := user32.GetDC(hwnd)
 	if hdc == 0 {
-		errs.NewError("GetDC failed")
+		errs.NewWin32Error("GetDC")
 		return Size{}
 	}
 	defer user32.ReleaseDC(hwnd, hdc)
@@ -1685,7 +1699,7 @@ func calculateTextSize(text string, font *Font, dpi int, width int, hwnd handle.
 			}
 
 			if !gdi32.GetTextExtentPoint32(hdc, &str[0], int32(len(str)-1), &s) {
-				errs.NewError("GetTextExtentPoint32 failed")
+				errs.NewWin32Error("GetTextExtentPoint32")
 				return Size{}
 			}
 
@@ -2119,6 +2133,38 @@ func (wb *WindowBase) Synchronize(f func()) {
 	user32.PostMessage(wb.hWnd, syncMsgId, 0, 0)
 }
 
+// SynchronizeKeyed is like Synchronize, but if a function enqueued with the
+// same key has not been called yet, f replaces it instead. Use it for
+// frequent updates where only the latest one matters. A nil key disables
+// coalescing.
+func (wb *WindowBase) SynchronizeKeyed(key interface{}, f func()) {
+	if !wb.group.enqueue(key, f) {
+		user32.PostMessage(wb.hWnd, syncMsgId, 0, 0)
+	}
+}
+
+// SynchronizeWait enqueues func f like Synchronize and waits until it
+// returned, then returns its results.
+//
+// If ctx is done first, SynchronizeWait returns ctx.Err() and f is not
+// called anymore, unless it already started. Called from the UI thread,
+// SynchronizeWait calls f directly, because waiting would deadlock.
+func (wb *WindowBase) SynchronizeWait(ctx context.Context, f func() (interface{}, error)) (interface{}, error) {
+	if wb.hWnd == 0 {
+		return nil, errs.NewDisposedError("window has been disposed")
+	}
+
+	return wb.group.synchronizeWait(ctx, func() {
+		user32.PostMessage(wb.hWnd, syncMsgId, 0, 0)
+	}, f)
+}
+
+// SyncStats returns statistics of the queue of functions enqueued by
+// Synchronize and its variants for the UI thread of the *WindowBase.
+func (wb *WindowBase) SyncStats() SyncStats {
+	return wb.group.SyncStats()
+}
+
 // synchronizeLayout causes the given layout computations to be applied
 // later by the message loop running on the group's thread.
 //
@@ -2167,19 +2213,9 @@ func windowFromHandle(hwnd handle.HWND) Window {
 
 func defaultWndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintptr) (result uintptr) {
 	defer func() {
-		// FIXME: Rework the panicking publisher so that we don't have to
-		// access a private member here.
-		if len(App().panickingPublisher.event.handlers) > 0 {
-			var err error
+		if App().recoversPanics() {
 			if x := recover(); x != nil {
-				if e, ok := x.(error); ok {
-					err = errs.WrapErrorNoPanic(e)
-				} else {
-					err = errs.NewErrorNoPanic(fmt.Sprint(x))
-				}
-			}
-			if err != nil {
-				App().panickingPublisher.Publish(err)
+				App().handlePanic(x)
 			}
 		}
 	}()
@@ -2441,6 +2477,10 @@ func (wb *WindowBase) WndProc(hwnd handle.HWND, msg uint32, wParam, lParam uintp
 			if wb.Form() == wb.group.ActiveForm() {
 				wnd.AsWidgetBase().invalidateBorderInParent()
 			}
+
+			if msg == user32.WM_SETFOCUS {
+				scrollFocusedIntoView(wnd)
+			}
 		}
 
 		wb.focusedChangedPublisher.Publish()
@@ -8,6 +8,7 @@ package winapi
 
 import (
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/Gipcomp/win32/handle"
@@ -102,10 +103,30 @@ type WindowGroup struct {
 	accPropServices *oleacc.IAccPropServices
 
 	syncMutex           sync.Mutex
-	syncFuncs           []func()                   // Functions queued to run on the group's thread
+	syncFuncs           []syncEntry                // Functions queued to run on the group's thread
+	syncKeys            map[interface{}]int        // Index into syncFuncs of the queued function of each coalescing key
+	syncStats           SyncStats                  // Statistics of the function queue, guarded by syncMutex
+	syncDepth           int                        // Nesting level of RunSynchronized calls, only used by the group's thread
 	layoutResultsByForm map[Form]*formLayoutResult // Layout computations queued for application on the group's thread
 }
 
+// syncEntry is a function queued by Synchronize.
+type syncEntry struct {
+	f      func()
+	queued time.Time
+}
+
+// SyncStats describes the function queue of a WindowGroup.
+type SyncStats struct {
+	Pending    int           // Number of functions currently queued
+	MaxPending int           // Highest number of functions queued at once
+	Queued     uint64        // Number of functions queued in total
+	Coalesced  uint64        // Number of functions replaced by a later one with the same key
+	Run        uint64        // Number of functions run in total
+	MaxLatency time.Duration // Longest time a function waited in the queue
+	MaxRunTime time.Duration // Longest time a function blocked the group's thread
+}
+
 // newWindowGroup returns a new window group for the given thread ID.
 //
 // The completion function will be called when the group is disposed of.
@@ -197,9 +218,86 @@ func (g *WindowGroup) Done() {
 //
 // Synchronize can be called from any thread.
 func (g *WindowGroup) Synchronize(f func()) {
+	g.enqueue(nil, f)
+}
+
+// SynchronizeKeyed is like Synchronize, but if a function queued with the
+// same key has not been run yet, f replaces it instead of being queued
+// after it. This avoids redundant work for frequent updates, e.g. progress
+// reported by a background goroutine.
+//
+// A nil key disables coalescing. SynchronizeKeyed can be called from any
+// thread.
+func (g *WindowGroup) SynchronizeKeyed(key interface{}, f func()) {
+	g.enqueue(key, f)
+}
+
+// enqueue adds f to the group's function queue and returns if it replaced a
+// queued function with the same non-nil key.
+func (g *WindowGroup) enqueue(key interface{}, f func()) (coalesced bool) {
+	g.syncMutex.Lock()
+	defer g.syncMutex.Unlock()
+
+	if key != nil {
+		if i, ok := g.syncKeys[key]; ok {
+			// The replacement keeps the position and queue time of the
+			// original, so coalescing does not starve updates.
+			g.syncFuncs[i].f = f
+			g.syncStats.Coalesced++
+			return true
+		}
+
+		if g.syncKeys == nil {
+			g.syncKeys = make(map[interface{}]int)
+		}
+		g.syncKeys[key] = len(g.syncFuncs)
+	}
+
+	g.syncFuncs = append(g.syncFuncs, syncEntry{f, time.Now()})
+
+	g.syncStats.Queued++
+	g.syncStats.Pending = len(g.syncFuncs)
+	if g.syncStats.Pending > g.syncStats.MaxPending {
+		g.syncStats.MaxPending = g.syncStats.Pending
+	}
+
+	return false
+}
+
+// SyncStats returns statistics of the group's function queue.
+//
+// SyncStats can be called from any thread.
+func (g *WindowGroup) SyncStats() SyncStats {
+	g.syncMutex.Lock()
+	defer g.syncMutex.Unlock()
+
+	return g.syncStats
+}
+
+// ResetSyncStats resets the totals and maximums of the group's queue
+// statistics.
+//
+// ResetSyncStats can be called from any thread.
+func (g *WindowGroup) ResetSyncStats() {
+	g.syncMutex.Lock()
+	defer g.syncMutex.Unlock()
+
+	g.syncStats = SyncStats{Pending: len(g.syncFuncs), MaxPending: len(g.syncFuncs)}
+}
+
+// recordSyncRun updates the queue statistics for a function that waited
+// latency in the queue and then ran for runTime.
+func (g *WindowGroup) recordSyncRun(latency, runTime time.Duration) {
 	g.syncMutex.Lock()
 	defer g.syncMutex.Unlock()
-	g.syncFuncs = append(g.syncFuncs, f)
+
+	g.syncStats.Run++
+	if latency > g.syncStats.MaxLatency {
+		g.syncStats.MaxLatency = latency
+	}
+	if runTime > g.syncStats.MaxRunTime {
+		g.syncStats.MaxRunTime = runTime
+	}
 }
 
 // synchronizeLayout causes the given layout computations to be applied
@@ -230,16 +328,52 @@ func (g *WindowGroup) RunSynchronized() {
 		delete(g.layoutResultsByForm, result.form)
 	}
 	g.syncFuncs = nil
+	g.syncKeys = nil
+	g.syncStats.Pending = 0
 	g.syncMutex.Unlock()
 
+	// A synchronized function running a modal dialog runs nested message
+	// loops, which call RunSynchronized again.
+	g.syncDepth++
+	defer func() {
+		g.syncDepth--
+	}()
+
+	if g.syncDepth > 1 && len(funcs) > 0 {
+		g.reportSync(SyncReentrant, 0, false)
+	}
+
 	for _, result := range results {
 		applyLayoutResults(result.results, result.stopwatch)
 	}
-	for _, f := range funcs {
-		f()
+	for _, e := range funcs {
+		start := time.Now()
+
+		runSynchronizedFunc(e.f)
+
+		runTime := time.Since(start)
+		g.recordSyncRun(start.Sub(e.queued), runTime)
+
+		if options := SyncDebug(); options != nil && runTime > options.slowCallThreshold() {
+			g.reportSync(SyncSlowCall, runTime, false)
+		}
 	}
 }
 
+// runSynchronizedFunc calls f, recovering from panics if the application
+// handles them, so one failing callback does not take down the message loop.
+func runSynchronizedFunc(f func()) {
+	defer func() {
+		if App().recoversPanics() {
+			if x := recover(); x != nil {
+				App().handlePanic(x)
+			}
+		}
+	}()
+
+	f()
+}
+
 // ToolTip returns the tool tip control for the group, if one exists.
 func (g *WindowGroup) ToolTip() *ToolTip {
 	return g.toolTip